@@ -0,0 +1,180 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+	"sort"
+
+	"github.com/alwindoss/cloudy/pkg/cloudy/azure"
+	"github.com/alwindoss/cloudy/pkg/cloudy/cloudflare"
+	"github.com/alwindoss/cloudy/pkg/cloudy/gcp"
+	"github.com/alwindoss/cloudy/pkg/cloudy/oci"
+	"github.com/alwindoss/cloudy/pkg/cloudy/openstack"
+	"github.com/gin-gonic/gin"
+)
+
+// viewAccounts groups a response's resources by account, then region,
+// then type, rather than listing them region by region.
+const viewAccounts = "accounts"
+
+// accountAttributes name the attribute other providers' resources carry
+// their account in, in place of an AWS account: the subscription,
+// project or compartment they belong to.
+var accountAttributes = map[string]string{
+	azure.ProviderName:      "subscription_id",
+	gcp.ProviderName:        "project_id",
+	oci.ProviderName:        "compartment_id",
+	cloudflare.ProviderName: "account_id",
+	openstack.ProviderName:  "project_id",
+}
+
+// labelAccounts sets the account of provider's resources from their
+// account attribute, for providers the library leaves it to.
+func labelAccounts(provider string, resources []Resource) {
+	attribute, ok := accountAttributes[provider]
+	if !ok {
+		return
+	}
+	for i := range resources {
+		if resources[i].AccountID == "" {
+			resources[i].AccountID = resources[i].Attributes[attribute]
+		}
+	}
+}
+
+// validateView rejects views other than the default and accounts, and
+// what the accounts view can't be combined with.
+func validateView(req RegionsRequest, format string) error {
+	switch req.View {
+	case "":
+		return nil
+	case viewAccounts:
+	default:
+		return errors.New("view must be accounts")
+	}
+	if format != formatJSON && format != formatYAML {
+		return errors.New("view accounts is only available as json or yaml")
+	}
+	if req.Query != "" || req.Limit != 0 || req.NextToken != "" {
+		return errors.New("query, limit and next_token are not supported with view accounts")
+	}
+	return nil
+}
+
+// AccountsResponse is the accounts view of a scan: its resources grouped
+// by account, region and type, each with its count. Errors are every
+// scanned region's.
+type AccountsResponse struct {
+	Accounts   []AccountResources `json:"accounts" yaml:"accounts"`
+	TotalCount int                `json:"total_count" yaml:"total_count"`
+	Errors     []ServiceError     `json:"errors,omitempty" yaml:"errors,omitempty"`
+}
+
+type AccountResources struct {
+	AccountID   string          `json:"account_id" yaml:"account_id"`
+	AccountName string          `json:"account_name,omitempty" yaml:"account_name,omitempty"`
+	Count       int             `json:"count" yaml:"count"`
+	Regions     []AccountRegion `json:"regions" yaml:"regions"`
+}
+
+type AccountRegion struct {
+	Region string          `json:"region" yaml:"region"`
+	Count  int             `json:"count" yaml:"count"`
+	Types  []TypeResources `json:"types" yaml:"types"`
+}
+
+type TypeResources struct {
+	Type      string `json:"type" yaml:"type"`
+	Count     int    `json:"count" yaml:"count"`
+	Resources []any  `json:"resources" yaml:"resources"`
+}
+
+// groupByAccount builds the accounts view of regionData, keeping each
+// type's resources in the order they were scanned or sorted in. Accounts,
+// regions and types are sorted by name.
+func groupByAccount(regionData []RegionResources, p *projection) AccountsResponse {
+	var response AccountsResponse
+	accounts := make(map[string]*AccountResources)
+	regions := make(map[[2]string]*AccountRegion)
+	types := make(map[[3]string]*TypeResources)
+
+	for _, rd := range regionData {
+		response.Errors = append(response.Errors, rd.Errors...)
+		for _, resource := range rd.Resources {
+			response.TotalCount++
+
+			account, ok := accounts[resource.AccountID]
+			if !ok {
+				account = &AccountResources{AccountID: resource.AccountID}
+				accounts[resource.AccountID] = account
+			}
+			if account.AccountName == "" {
+				account.AccountName = resource.AccountName
+			}
+			account.Count++
+
+			regionKey := [2]string{resource.AccountID, rd.Region}
+			region, ok := regions[regionKey]
+			if !ok {
+				region = &AccountRegion{Region: rd.Region}
+				regions[regionKey] = region
+			}
+			region.Count++
+
+			typeKey := [3]string{resource.AccountID, rd.Region, resource.Type}
+			group, ok := types[typeKey]
+			if !ok {
+				group = &TypeResources{Type: resource.Type}
+				types[typeKey] = group
+			}
+			group.Count++
+			group.Resources = append(group.Resources, p.value(resource))
+		}
+	}
+
+	// Assemble the tree in name order now that every group is complete
+	typeKeys := make([][3]string, 0, len(types))
+	for key := range types {
+		typeKeys = append(typeKeys, key)
+	}
+	sort.Slice(typeKeys, func(i, j int) bool {
+		a, b := typeKeys[i], typeKeys[j]
+		if a[0] != b[0] {
+			return a[0] < b[0]
+		}
+		if a[1] != b[1] {
+			return a[1] < b[1]
+		}
+		return a[2] < b[2]
+	})
+	response.Accounts = []AccountResources{}
+	for _, key := range typeKeys {
+		if n := len(response.Accounts); n == 0 || response.Accounts[n-1].AccountID != key[0] {
+			account := *accounts[key[0]]
+			account.Regions = nil
+			response.Accounts = append(response.Accounts, account)
+		}
+		account := &response.Accounts[len(response.Accounts)-1]
+		if n := len(account.Regions); n == 0 || account.Regions[n-1].Region != key[1] {
+			region := *regions[[2]string{key[0], key[1]}]
+			account.Regions = append(account.Regions, region)
+		}
+		region := &account.Regions[len(account.Regions)-1]
+		region.Types = append(region.Types, *types[key])
+	}
+	return response
+}
+
+// writeAccountsView writes the accounts view of regionData as JSON or
+// YAML.
+func writeAccountsView(c *gin.Context, format string, regionData []RegionResources, p *projection) {
+	response := groupByAccount(regionData, p)
+	if notModified(c, jsonETag(gin.H{"format": format, "accounts": response})) {
+		return
+	}
+	if format == formatYAML {
+		c.YAML(http.StatusOK, response)
+		return
+	}
+	c.JSON(http.StatusOK, response)
+}
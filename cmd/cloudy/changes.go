@@ -0,0 +1,200 @@
+package main
+
+import (
+	"maps"
+	"sync"
+	"time"
+)
+
+// ResourceChanges are the resources a scan found new, gone or changed
+// since the previous full scan of the same regions.
+type ResourceChanges struct {
+	Added   []Resource        `json:"added"`
+	Removed []Resource        `json:"removed"`
+	Changed []ChangedResource `json:"changed"`
+}
+
+// ChangedResource is a resource as the previous scan and the latest one
+// listed it.
+type ChangedResource struct {
+	Before Resource `json:"before"`
+	After  Resource `json:"after"`
+}
+
+// diffResources compares two listings of one region, keeping the order
+// resources were listed in.
+func diffResources(before, after []Resource) ResourceChanges {
+	var changes ResourceChanges
+	previous := make(map[string]Resource, len(before))
+	for _, resource := range before {
+		previous[resource.ID] = resource
+	}
+	current := make(map[string]bool, len(after))
+	for _, resource := range after {
+		current[resource.ID] = true
+		old, ok := previous[resource.ID]
+		switch {
+		case !ok:
+			changes.Added = append(changes.Added, resource)
+		case len(changedFields(old, resource)) > 0:
+			changes.Changed = append(changes.Changed, ChangedResource{Before: old, After: resource})
+		}
+	}
+	for _, resource := range before {
+		if !current[resource.ID] {
+			changes.Removed = append(changes.Removed, resource)
+		}
+	}
+	return changes
+}
+
+// changedFields names what differs between two listings of a resource.
+func changedFields(before, after Resource) []string {
+	var fields []string
+	if before.Name != after.Name {
+		fields = append(fields, "name")
+	}
+	if before.State != after.State {
+		fields = append(fields, "state")
+	}
+	if !maps.Equal(before.Tags, after.Tags) {
+		fields = append(fields, "tags")
+	}
+	if !maps.Equal(before.Attributes, after.Attributes) {
+		fields = append(fields, "attributes")
+	}
+	return fields
+}
+
+// add appends other's changes to c's.
+func (c *ResourceChanges) add(other ResourceChanges) {
+	c.Added = append(c.Added, other.Added...)
+	c.Removed = append(c.Removed, other.Removed...)
+	c.Changed = append(c.Changed, other.Changed...)
+}
+
+// filter returns the changes to resources keep accepts. A changed
+// resource is kept if keep accepts it before or after the change, so one
+// that stops matching, say by losing a tag, is still reported.
+func (c ResourceChanges) filter(keep func(Resource) bool) ResourceChanges {
+	var kept ResourceChanges
+	for _, resource := range c.Added {
+		if keep(resource) {
+			kept.Added = append(kept.Added, resource)
+		}
+	}
+	for _, resource := range c.Removed {
+		if keep(resource) {
+			kept.Removed = append(kept.Removed, resource)
+		}
+	}
+	for _, changed := range c.Changed {
+		if keep(changed.Before) || keep(changed.After) {
+			kept.Changed = append(kept.Changed, changed)
+		}
+	}
+	return kept
+}
+
+// empty reports whether nothing changed.
+func (c ResourceChanges) empty() bool {
+	return len(c.Added) == 0 && len(c.Removed) == 0 && len(c.Changed) == 0
+}
+
+// driftSample counts the changes of one kind to one type of resource in
+// one region that a scheduled scan found.
+type driftSample struct {
+	At     time.Time
+	Region string
+	Type   string
+	Change string
+	Count  int
+}
+
+// driftStore keeps the changes scheduled scans found over time, for
+// charting drift. Samples are kept as long as trends are, in memory only.
+type driftStore struct {
+	mu      sync.Mutex
+	samples []driftSample
+}
+
+var resourceDrift = &driftStore{}
+
+// Record adds the counts of changes found at at.
+func (d *driftStore) Record(at time.Time, changes ResourceChanges) {
+	type key struct{ region, resourceType, change string }
+	counts := make(map[key]int)
+	for _, resource := range changes.Added {
+		counts[key{resource.Region, resource.Type, changeCreated}]++
+	}
+	for _, changed := range changes.Changed {
+		counts[key{changed.After.Region, changed.After.Type, changeUpdated}]++
+	}
+	for _, resource := range changes.Removed {
+		counts[key{resource.Region, resource.Type, changeDeleted}]++
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	cutoff := at.Add(-trendRetention)
+	kept := d.samples[:0]
+	for _, s := range d.samples {
+		if s.At.After(cutoff) {
+			kept = append(kept, s)
+		}
+	}
+	d.samples = kept
+	for k, n := range counts {
+		d.samples = append(d.samples, driftSample{At: at, Region: k.region, Type: k.resourceType, Change: k.change, Count: n})
+	}
+}
+
+// Series returns how many changes matching keep were found per interval,
+// from the interval from is in until to, with zero for intervals without
+// any.
+func (d *driftStore) Series(keep func(driftSample) bool, from, to time.Time, interval time.Duration) []TrendPoint {
+	counts := make(map[time.Time]int)
+	d.mu.Lock()
+	for _, s := range d.samples {
+		if keep(s) {
+			counts[s.At.Truncate(interval)] += s.Count
+		}
+	}
+	d.mu.Unlock()
+
+	points := []TrendPoint{}
+	for bucket := from.Truncate(interval); !bucket.After(to); bucket = bucket.Add(interval) {
+		points = append(points, TrendPoint{Time: bucket, Count: counts[bucket]})
+	}
+	return points
+}
+
+// Samples returns the samples recorded after from and up to to.
+func (d *driftStore) Samples(from, to time.Time) []driftSample {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	var samples []driftSample
+	for _, s := range d.samples {
+		if s.At.After(from) && !s.At.After(to) {
+			samples = append(samples, s)
+		}
+	}
+	return samples
+}
+
+// values returns the distinct values of a dimension of the samples keep
+// accepts, in order.
+func (d *driftStore) values(keep func(driftSample) bool, value func(driftSample) string) []string {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	seen := make(map[string]bool)
+	for _, s := range d.samples {
+		if keep(s) {
+			seen[value(s)] = true
+		}
+	}
+	return sortedKeys(seen)
+}
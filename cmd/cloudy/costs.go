@@ -0,0 +1,382 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"maps"
+	"math"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/alwindoss/cloudy/pkg/cloudy"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/pricing"
+	pricingtypes "github.com/aws/aws-sdk-go-v2/service/pricing/types"
+)
+
+// costAttribute is the attribute a resource's estimated monthly cost, in
+// US dollars, is set in.
+const costAttribute = "monthly_cost_usd"
+
+// hoursPerMonth is the hours an hourly price is multiplied by for a
+// month's cost, as AWS's own calculator does.
+const hoursPerMonth = 730
+
+// pricingRegion is the region the Pricing API is served from, for every
+// region's prices.
+const pricingRegion = "us-east-1"
+
+// Prices are looked up again after priceTTL, or after priceRetry if the
+// lookup failed; until then the last price found is used. Each lookup is
+// bounded by pricingTimeout, and at most pricingConcurrency run at a time.
+// A region's resources wait estimateTimeout for the prices they need; the
+// lookups still running then carry on for the regions that come next.
+const (
+	priceTTL           = 24 * time.Hour
+	priceRetry         = 10 * time.Minute
+	pricingTimeout     = 30 * time.Second
+	pricingConcurrency = 4
+	estimateTimeout    = 3 * time.Second
+)
+
+// rdsEngines are the Pricing API's names of the RDS engines costs are
+// estimated for. Engines that need a license or an edition to be priced,
+// such as Oracle and SQL Server, aren't.
+var rdsEngines = map[string]string{
+	"mysql":             "MySQL",
+	"postgres":          "PostgreSQL",
+	"mariadb":           "MariaDB",
+	"aurora-mysql":      "Aurora MySQL",
+	"aurora-postgresql": "Aurora PostgreSQL",
+}
+
+// costs estimates the monthly cost of AWS resources as they're listed,
+// when CLOUDY_COSTS is set.
+var costs *costEstimator
+
+// priceQuery finds one on-demand price: that of the products of Service
+// matching every filter, per Unit.
+type priceQuery struct {
+	Service string
+	Filters [][2]string
+	Unit    string
+}
+
+func (q priceQuery) key() string {
+	parts := []string{q.Service, q.Unit}
+	for _, filter := range q.Filters {
+		parts = append(parts, filter[0]+"="+filter[1])
+	}
+	return strings.Join(parts, "|")
+}
+
+// priceEntry is a price as last looked up. ready is closed once the first
+// lookup is done.
+type priceEntry struct {
+	ready      chan struct{}
+	price      float64
+	found      bool
+	expires    time.Time
+	refreshing bool
+}
+
+// costEstimator prices resources from the Pricing API, keeping the prices
+// it looks up so each is only asked for once a day.
+type costEstimator struct {
+	client  *pricing.Client
+	lookups chan struct{}
+	mu      sync.Mutex
+	prices  map[string]*priceEntry
+}
+
+// newCostEstimator prices resources with cfg's credentials, or returns nil
+// if they aren't the commercial partition's, which alone can call the
+// Pricing API.
+func newCostEstimator(cfg aws.Config) *costEstimator {
+	if partition := cloudy.Partition(cfg.Region); partition != cloudy.PartitionAWS {
+		log.Printf("Costs aren't estimated: the Pricing API needs credentials of the aws partition, not %s", partition)
+		return nil
+	}
+	cfg = cfg.Copy()
+	cfg.Region = pricingRegion
+	return &costEstimator{
+		client:  pricing.NewFromConfig(cfg),
+		lookups: make(chan struct{}, pricingConcurrency),
+		prices:  make(map[string]*priceEntry),
+	}
+}
+
+// costQuery returns the price that makes up resource's monthly cost and
+// how many of its unit a month takes, or false if it isn't priced:
+// running EC2 instances by type, RDS instances that aren't stopped by
+// class, engine and deployment, EBS volumes by type and size, and
+// available NAT gateways by the hour. Instances are priced as Linux on
+// shared tenancy; volumes by their storage alone, without provisioned
+// IOPS or throughput, and gateways without the data they process. Only
+// the commercial partition's regions are priced.
+func costQuery(resource Resource) (priceQuery, float64, bool) {
+	// The Pricing API is a commercial partition service: the China regions
+	// aren't in it, and GovCloud prices would need commercial credentials
+	// the server's GovCloud ones can't stand in for
+	if cloudy.Partition(resource.Region) != cloudy.PartitionAWS {
+		return priceQuery{}, 0, false
+	}
+	region := [2]string{"regionCode", resource.Region}
+	switch resource.Type {
+	case "EC2 Instance":
+		instanceType := resource.Attributes["instance_type"]
+		if resource.State != "running" || instanceType == "" {
+			return priceQuery{}, 0, false
+		}
+		return priceQuery{Service: "AmazonEC2", Unit: "Hrs", Filters: [][2]string{
+			region,
+			{"instanceType", instanceType},
+			{"operatingSystem", "Linux"},
+			{"tenancy", "Shared"},
+			{"preInstalledSw", "NA"},
+			{"capacitystatus", "Used"},
+			{"operation", "RunInstances"},
+		}}, hoursPerMonth, true
+	case "RDS Instance":
+		engine, ok := rdsEngines[resource.Attributes["engine"]]
+		class := resource.Attributes["instance_class"]
+		if !ok || class == "" || resource.State == "stopped" {
+			return priceQuery{}, 0, false
+		}
+		deployment := "Single-AZ"
+		if resource.Attributes["multi_az"] == "true" {
+			deployment = "Multi-AZ"
+		}
+		return priceQuery{Service: "AmazonRDS", Unit: "Hrs", Filters: [][2]string{
+			region,
+			{"instanceType", class},
+			{"databaseEngine", engine},
+			{"deploymentOption", deployment},
+			// The engines priced are open source, but the products list
+			// other license models too
+			{"licenseModel", "No license required"},
+		}}, hoursPerMonth, true
+	case "EBS Volume":
+		size, err := strconv.ParseFloat(resource.Attributes["size_gb"], 64)
+		volumeType := resource.Attributes["volume_type"]
+		if err != nil || volumeType == "" {
+			return priceQuery{}, 0, false
+		}
+		return priceQuery{Service: "AmazonEC2", Unit: "GB-Mo", Filters: [][2]string{
+			region,
+			{"productFamily", "Storage"},
+			{"volumeApiName", volumeType},
+		}}, size, true
+	case "NAT Gateway":
+		if resource.State != "available" {
+			return priceQuery{}, 0, false
+		}
+		return priceQuery{Service: "AmazonEC2", Unit: "Hrs", Filters: [][2]string{
+			region,
+			{"productFamily", "NAT Gateway"},
+		}}, hoursPerMonth, true
+	}
+	return priceQuery{}, 0, false
+}
+
+// Estimate sets the estimated monthly cost of the resources it can price
+// in their costAttribute. Their attributes are copied first, as listings
+// are shared through the result cache. It waits at most estimateTimeout
+// for prices not yet known, and returns an error if any weren't in time;
+// the resources needing them are left without an estimate.
+func (e *costEstimator) Estimate(ctx context.Context, resources []Resource) error {
+	queries := make(map[string]priceQuery)
+	for _, resource := range resources {
+		if q, _, ok := costQuery(resource); ok {
+			queries[q.key()] = q
+		}
+	}
+	if len(queries) == 0 {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, estimateTimeout)
+	defer cancel()
+	var (
+		wg     sync.WaitGroup
+		mu     sync.Mutex
+		prices = make(map[string]float64)
+	)
+	for key, q := range queries {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if price, found := e.price(ctx, q); found {
+				mu.Lock()
+				prices[key] = price
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+
+	for i, resource := range resources {
+		q, quantity, ok := costQuery(resource)
+		if !ok {
+			continue
+		}
+		price, found := prices[q.key()]
+		if !found {
+			continue
+		}
+		attributes := maps.Clone(resource.Attributes)
+		if attributes == nil {
+			attributes = make(map[string]string)
+		}
+		attributes[costAttribute] = strconv.FormatFloat(price*quantity, 'f', 2, 64)
+		resources[i].Attributes = attributes
+	}
+	if err := ctx.Err(); err != nil {
+		return fmt.Errorf("prices weren't looked up in time, so some resources have no estimate: %w", err)
+	}
+	return nil
+}
+
+// price returns q's price, or false if it has none or ctx is done before
+// its first lookup is. Lookups run in the background, so one that's due
+// leaves the old price in use until it's done, and one that ctx stops
+// waiting for still finishes for whoever needs the price next.
+func (e *costEstimator) price(ctx context.Context, q priceQuery) (float64, bool) {
+	key := q.key()
+	e.mu.Lock()
+	entry, known := e.prices[key]
+	if !known {
+		entry = &priceEntry{ready: make(chan struct{})}
+		e.prices[key] = entry
+	}
+	due := known && !entry.refreshing && time.Now().After(entry.expires)
+	if due {
+		entry.refreshing = true
+	}
+	e.mu.Unlock()
+
+	if !known || due {
+		go e.refresh(q, entry, !known)
+	}
+	select {
+	case <-entry.ready:
+	case <-ctx.Done():
+		return 0, false
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return entry.price, entry.found
+}
+
+// refresh looks up q's price into entry, closing its ready channel if
+// this is its first lookup.
+func (e *costEstimator) refresh(q priceQuery, entry *priceEntry, first bool) {
+	e.lookups <- struct{}{}
+	ctx, cancel := context.WithTimeout(context.Background(), pricingTimeout)
+	price, found, err := e.lookup(ctx, q)
+	cancel()
+	<-e.lookups
+
+	e.mu.Lock()
+	if err != nil {
+		log.Printf("Failed to look up the price of %s: %v", q.key(), err)
+		entry.expires = time.Now().Add(priceRetry)
+	} else {
+		entry.price, entry.found = price, found
+		entry.expires = time.Now().Add(priceTTL)
+	}
+	entry.refreshing = false
+	e.mu.Unlock()
+	if first {
+		close(entry.ready)
+	}
+}
+
+// lookup asks the Pricing API for the products q matches and returns the
+// lowest on-demand price per unit among them, such as that of standard
+// over I/O-optimized Aurora storage. Found is false if none matched.
+func (e *costEstimator) lookup(ctx context.Context, q priceQuery) (price float64, found bool, err error) {
+	input := &pricing.GetProductsInput{ServiceCode: aws.String(q.Service), MaxResults: aws.Int32(100)}
+	for _, filter := range q.Filters {
+		input.Filters = append(input.Filters, pricingtypes.Filter{
+			Field: aws.String(filter[0]),
+			Type:  pricingtypes.FilterTypeTermMatch,
+			Value: aws.String(filter[1]),
+		})
+	}
+
+	paginator := pricing.NewGetProductsPaginator(e.client, input)
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return 0, false, err
+		}
+		for _, item := range page.PriceList {
+			for _, p := range onDemandPrices(item, q.Unit) {
+				// Free tiers and placeholders are listed at zero
+				if p > 0 && (!found || p < price) {
+					price, found = p, true
+				}
+			}
+		}
+	}
+	return price, found, nil
+}
+
+// onDemandPrices reads the on-demand US dollar prices per unit of a
+// Pricing API price list item.
+func onDemandPrices(item, unit string) []float64 {
+	var product struct {
+		Terms struct {
+			OnDemand map[string]struct {
+				PriceDimensions map[string]struct {
+					Unit         string            `json:"unit"`
+					PricePerUnit map[string]string `json:"pricePerUnit"`
+				} `json:"priceDimensions"`
+			} `json:"OnDemand"`
+		} `json:"terms"`
+	}
+	if err := json.Unmarshal([]byte(item), &product); err != nil {
+		return nil
+	}
+	var prices []float64
+	for _, term := range product.Terms.OnDemand {
+		for _, dimension := range term.PriceDimensions {
+			if dimension.Unit != unit {
+				continue
+			}
+			if p, err := strconv.ParseFloat(dimension.PricePerUnit["USD"], 64); err == nil {
+				prices = append(prices, p)
+			}
+		}
+	}
+	return prices
+}
+
+// resourceCost returns the cents resource's estimated monthly cost comes
+// to, or false if it has none.
+func resourceCost(resource Resource) (int64, bool) {
+	cost, err := strconv.ParseFloat(resource.Attributes[costAttribute], 64)
+	if err != nil {
+		return 0, false
+	}
+	return int64(math.Round(cost * 100)), true
+}
+
+// costTotals are cost totals by a field's value, added up in cents so
+// they aren't off by rounding errors.
+type costTotals map[string]int64
+
+// dollars converts the totals to dollars.
+func (t costTotals) dollars() map[string]float64 {
+	totals := make(map[string]float64, len(t))
+	for key, cents := range t {
+		totals[key] = float64(cents) / 100
+	}
+	return totals
+}
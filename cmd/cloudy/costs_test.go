@@ -0,0 +1,53 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestEstimateStopsWaitingForPrices(t *testing.T) {
+	instance := Resource{ID: "i-1", Type: "EC2 Instance", Region: "us-east-1", State: "running", Attributes: map[string]string{"instance_type": "t3.micro"}}
+	gateway := Resource{ID: "nat-1", Type: "NAT Gateway", Region: "us-east-1", State: "available"}
+	instanceQuery, _, _ := costQuery(instance)
+	gatewayQuery, _, _ := costQuery(gateway)
+
+	// The instance's price is known; the gateway's first lookup never ends
+	priced := &priceEntry{ready: make(chan struct{}), price: 0.01, found: true, expires: time.Now().Add(time.Hour)}
+	close(priced.ready)
+	e := &costEstimator{prices: map[string]*priceEntry{
+		instanceQuery.key(): priced,
+		gatewayQuery.key():  {ready: make(chan struct{}), refreshing: true},
+	}}
+
+	resources := []Resource{instance, gateway}
+	started := time.Now()
+	err := e.Estimate(context.Background(), resources)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Estimate = %v, want a deadline error", err)
+	}
+	if elapsed := time.Since(started); elapsed > estimateTimeout+time.Second {
+		t.Errorf("Estimate took %s, want about %s", elapsed, estimateTimeout)
+	}
+	if got := resources[0].Attributes[costAttribute]; got != "7.30" {
+		t.Errorf("instance cost = %q, want 7.30", got)
+	}
+	if got, ok := resources[1].Attributes[costAttribute]; ok {
+		t.Errorf("gateway cost = %q, want none", got)
+	}
+	if _, ok := instance.Attributes[costAttribute]; ok {
+		t.Error("Estimate changed the listed resource's attributes in place")
+	}
+}
+
+func TestEstimateWithoutPricedResources(t *testing.T) {
+	e := &costEstimator{prices: make(map[string]*priceEntry)}
+	resources := []Resource{{ID: "bucket", Type: "S3 Bucket", Region: "us-east-1"}}
+	if err := e.Estimate(context.Background(), resources); err != nil {
+		t.Errorf("Estimate = %v, want nil", err)
+	}
+	if resources[0].Attributes != nil {
+		t.Error("an unpriced resource got attributes")
+	}
+}
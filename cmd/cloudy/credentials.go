@@ -0,0 +1,360 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/netip"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	"github.com/Azure/azure-sdk-for-go/sdk/azidentity"
+	"github.com/alwindoss/cloudy/pkg/cloudy"
+	"github.com/alwindoss/cloudy/pkg/cloudy/azure"
+	"github.com/alwindoss/cloudy/pkg/cloudy/gcp"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/credentials"
+	"google.golang.org/api/option"
+)
+
+// Credentials pick, for one request, which of the credentials available
+// to the server a provider is scanned with, in place of those it was
+// started with, or give AWS credentials of the caller's own. Only the
+// fields of the requested provider may be set.
+type Credentials struct {
+	// AWSProfile is a profile in the shared AWS config and credentials
+	// files, one of those CLOUDY_AWS_PROFILES allows.
+	AWSProfile string `json:"aws_profile,omitempty"`
+	// AWSAccessKeyID, AWSSecretAccessKey and AWSSessionToken are
+	// temporary credentials from STS, such as a CI job's, to scan as.
+	// Unlike the other fields they are secrets, so they are only taken
+	// from request bodies sent over TLS, and nothing built with them
+	// outlives the request.
+	AWSAccessKeyID     string `json:"aws_access_key_id,omitempty"`
+	AWSSecretAccessKey string `json:"aws_secret_access_key,omitempty"`
+	AWSSessionToken    string `json:"aws_session_token,omitempty"`
+	// AzureSubscriptionID is the subscription to scan, and AzureClientID
+	// the client ID of the user-assigned managed identity to scan it as.
+	AzureSubscriptionID string `json:"azure_subscription_id,omitempty"`
+	AzureClientID       string `json:"azure_client_id,omitempty"`
+	// GCPProject is the project to scan, and GCPKeyRef the service
+	// account key to scan it with: the file <GCPKeyRef>.json in
+	// CLOUDY_GCP_KEY_DIR.
+	GCPProject string `json:"gcp_project,omitempty"`
+	GCPKeyRef  string `json:"gcp_key_ref,omitempty"`
+}
+
+// requestCredentials allows requests to carry Azure and GCP Credentials,
+// from CLOUDY_REQUEST_CREDENTIALS, and awsProfiles are the AWS profiles
+// they may name, from CLOUDY_AWS_PROFILES. gcpKeyDir holds the keys
+// GCPKeyRef names, from CLOUDY_GCP_KEY_DIR.
+var (
+	requestCredentials bool
+	awsProfiles        []string
+	gcpKeyDir          string
+)
+
+// keyRefPattern keeps key refs to plain file names within gcpKeyDir.
+var keyRefPattern = regexp.MustCompile(`^[A-Za-z0-9_-][A-Za-z0-9._-]*$`)
+
+// credentialListers are the listers built for requests' credentials, kept
+// so later requests with the same credentials reuse their clients and
+// cached regions. Requests can name any Azure subscription or GCP project,
+// so only the maxCredentialListers used most recently are kept.
+var credentialListers = struct {
+	mu      sync.Mutex
+	listers map[credentialsKey]*credentialLister
+}{listers: make(map[credentialsKey]*credentialLister)}
+
+// maxCredentialListers bounds credentialListers.
+const maxCredentialListers = 64
+
+// credentialLister is a lister in credentialListers, with when a request
+// last used it.
+type credentialLister struct {
+	lister   *ResourceLister
+	lastUsed time.Time
+}
+
+type credentialsKey struct {
+	provider    string
+	credentials Credentials
+}
+
+// providerOf returns the provider creds apply to, or "" if none of its
+// fields is set.
+func (creds Credentials) providerOf() (string, error) {
+	var providers []string
+	if creds.AWSProfile != "" || creds.hasSession() {
+		providers = append(providers, cloudy.ProviderAWS)
+	}
+	if creds.AzureSubscriptionID != "" || creds.AzureClientID != "" {
+		providers = append(providers, azure.ProviderName)
+	}
+	if creds.GCPProject != "" || creds.GCPKeyRef != "" {
+		providers = append(providers, gcp.ProviderName)
+	}
+	if len(providers) > 1 {
+		return "", fmt.Errorf("credentials are for one provider, got %s", strings.Join(providers, " and "))
+	}
+	if len(providers) == 0 {
+		return "", nil
+	}
+	return providers[0], nil
+}
+
+// hasSession reports whether creds carry temporary AWS credentials.
+func (creds Credentials) hasSession() bool {
+	return creds.AWSAccessKeyID != "" || creds.AWSSecretAccessKey != "" || creds.AWSSessionToken != ""
+}
+
+// allowed rejects creds the server's configuration doesn't let requests
+// use. Temporary credentials are the caller's own, so they need no
+// allowing, but they must be complete.
+func (creds Credentials) allowed() error {
+	if creds.hasSession() {
+		if creds.AWSProfile != "" {
+			return errors.New("aws_profile can't be combined with temporary credentials")
+		}
+		if creds.AWSAccessKeyID == "" || creds.AWSSecretAccessKey == "" || creds.AWSSessionToken == "" {
+			return errors.New("temporary credentials need aws_access_key_id, aws_secret_access_key and aws_session_token")
+		}
+		return nil
+	}
+	if creds.AWSProfile != "" && !slices.Contains(awsProfiles, creds.AWSProfile) {
+		if len(awsProfiles) == 0 {
+			return errors.New("aws_profile is disabled; set CLOUDY_AWS_PROFILES to the profiles requests may use")
+		}
+		return fmt.Errorf("aws_profile %q isn't allowed; expected one of %s", creds.AWSProfile, strings.Join(awsProfiles, ", "))
+	}
+	if creds.AWSProfile == "" && !requestCredentials {
+		return errors.New("per-request credentials are disabled; set CLOUDY_REQUEST_CREDENTIALS=true to allow them")
+	}
+	return nil
+}
+
+// credentialsLister returns the lister for provider with creds, building
+// it on first use.
+func credentialsLister(provider string, creds Credentials) (*ResourceLister, error) {
+	credsProvider, err := creds.providerOf()
+	if err != nil {
+		return nil, err
+	}
+	if credsProvider != provider {
+		return nil, fmt.Errorf("credentials are for provider %s, not %s", credsProvider, provider)
+	}
+	if err := creds.allowed(); err != nil {
+		return nil, err
+	}
+	if creds.hasSession() {
+		// Kept for this request only, rather than holding on to the
+		// secrets
+		lister, err := newAWSSessionResourceLister(creds)
+		if err != nil {
+			return nil, fmt.Errorf("credentials: %w", err)
+		}
+		lister.perRequest = true
+		return lister, nil
+	}
+
+	key := credentialsKey{provider: provider, credentials: creds}
+	if lister, ok := cachedCredentialLister(key); ok {
+		return lister, nil
+	}
+
+	// Built without holding credentialListers.mu, as building one may load
+	// credentials over the network and would hold up every other request
+	// with credentials meanwhile
+	var lister *ResourceLister
+	switch provider {
+	case cloudy.ProviderAWS:
+		lister, err = newAWSProfileResourceLister(creds.AWSProfile)
+	case azure.ProviderName:
+		lister, err = newAzureCredentialsResourceLister(nonEmpty(creds.AzureSubscriptionID), creds.AzureClientID)
+	case gcp.ProviderName:
+		lister, err = newGCPCredentialsResourceLister(nonEmpty(creds.GCPProject), creds.GCPKeyRef)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("credentials: %w", err)
+	}
+	// The server's latest scan and trends are of its own credentials
+	lister.perRequest = true
+
+	credentialListers.mu.Lock()
+	defer credentialListers.mu.Unlock()
+	// A request with the same credentials may have built one first
+	if cached, ok := credentialListers.listers[key]; ok {
+		cached.lastUsed = time.Now()
+		return cached.lister, nil
+	}
+	if len(credentialListers.listers) >= maxCredentialListers {
+		evictCredentialLister()
+	}
+	credentialListers.listers[key] = &credentialLister{lister: lister, lastUsed: time.Now()}
+	return lister, nil
+}
+
+// cachedCredentialLister returns the lister kept for key, if any, marking
+// it used.
+func cachedCredentialLister(key credentialsKey) (*ResourceLister, bool) {
+	credentialListers.mu.Lock()
+	defer credentialListers.mu.Unlock()
+	cached, ok := credentialListers.listers[key]
+	if !ok {
+		return nil, false
+	}
+	cached.lastUsed = time.Now()
+	return cached.lister, true
+}
+
+// evictCredentialLister drops the least recently used of
+// credentialListers. The caller must hold credentialListers.mu.
+func evictCredentialLister() {
+	var oldest credentialsKey
+	var oldestUsed time.Time
+	for key, cached := range credentialListers.listers {
+		if oldestUsed.IsZero() || cached.lastUsed.Before(oldestUsed) {
+			oldest, oldestUsed = key, cached.lastUsed
+		}
+	}
+	delete(credentialListers.listers, oldest)
+}
+
+// newAWSProfileResourceLister scans with the credentials of profile.
+func newAWSProfileResourceLister(profile string) (*ResourceLister, error) {
+	cfg, err := loadAWSConfig(config.WithSharedConfigProfile(profile))
+	if err != nil {
+		return nil, err
+	}
+	return newResourceLister(cloudy.NewScannerFromConfig(cfg)), nil
+}
+
+// newAWSSessionResourceLister scans with the temporary credentials in
+// creds. Its scans don't share the result cache, which holds what the
+// server's credentials could list; they show what the caller's can.
+func newAWSSessionResourceLister(creds Credentials) (*ResourceLister, error) {
+	provider := credentials.NewStaticCredentialsProvider(creds.AWSAccessKeyID, creds.AWSSecretAccessKey, creds.AWSSessionToken)
+	cfg, err := config.LoadDefaultConfig(context.TODO(), config.WithCredentialsProvider(provider))
+	if err != nil {
+		return nil, err
+	}
+	lister := newResourceLister(cloudy.NewScannerFromConfig(cfg))
+	lister.SetCache(nil)
+	return lister, nil
+}
+
+// trustedProxies are the proxies in front of the server, from
+// CLOUDY_TRUSTED_PROXIES, whose X-Forwarded-Proto and X-Forwarded-For
+// headers it believes. Anyone else can set them.
+var trustedProxies []netip.Prefix
+
+// parseTrustedProxies parses proxies, each an IP address or a CIDR range.
+func parseTrustedProxies(proxies []string) ([]netip.Prefix, error) {
+	prefixes := make([]netip.Prefix, 0, len(proxies))
+	for _, proxy := range proxies {
+		if addr, err := netip.ParseAddr(proxy); err == nil {
+			prefixes = append(prefixes, netip.PrefixFrom(addr, addr.BitLen()))
+			continue
+		}
+		prefix, err := netip.ParsePrefix(proxy)
+		if err != nil {
+			return nil, fmt.Errorf("invalid trusted proxy %q; expected an IP address or CIDR range", proxy)
+		}
+		prefixes = append(prefixes, prefix.Masked())
+	}
+	return prefixes, nil
+}
+
+// fromTrustedProxy reports whether r came from one of trustedProxies.
+func fromTrustedProxy(r *http.Request) bool {
+	addrPort, err := netip.ParseAddrPort(r.RemoteAddr)
+	if err != nil {
+		return false
+	}
+	addr := addrPort.Addr().Unmap()
+	for _, prefix := range trustedProxies {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+// validateCredentialsTransport rejects temporary credentials that came
+// over plain HTTP: r must have come over TLS, to the server or to one of
+// trustedProxies that says so with X-Forwarded-Proto.
+func validateCredentialsTransport(r *http.Request, creds *Credentials) error {
+	if creds == nil || !creds.hasSession() || r.TLS != nil {
+		return nil
+	}
+	if r.Header.Get("X-Forwarded-Proto") != "https" || !fromTrustedProxy(r) {
+		return errors.New("temporary credentials must be sent over HTTPS")
+	}
+	return nil
+}
+
+// nonEmpty returns a list of value, or nil if it is "".
+func nonEmpty(value string) []string {
+	if value == "" {
+		return nil
+	}
+	return []string{value}
+}
+
+// newAzureCredentialsResourceLister scans subscriptions, or the server's
+// subscriptions if there are none, as the managed identity clientID, or
+// with the server's credentials if clientID is "".
+func newAzureCredentialsResourceLister(subscriptions []string, clientID string) (*ResourceLister, error) {
+	if len(subscriptions) == 0 {
+		subscriptions = envList("AZURE_SUBSCRIPTION_ID")
+	}
+	if len(subscriptions) == 0 {
+		return nil, errors.New("azure_subscription_id is required when AZURE_SUBSCRIPTION_ID isn't set")
+	}
+
+	var cred azcore.TokenCredential
+	var err error
+	if clientID != "" {
+		cred, err = azidentity.NewManagedIdentityCredential(&azidentity.ManagedIdentityCredentialOptions{ID: azidentity.ClientID(clientID)})
+	} else {
+		cred, err = azidentity.NewDefaultAzureCredential(nil)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return newResourceLister(cloudy.NewProviderScanner(azure.NewProvider(cred, subscriptions, nil))), nil
+}
+
+// newGCPCredentialsResourceLister scans projects, or the server's projects
+// if there are none, with the key keyRef names, or with Application
+// Default Credentials if keyRef is "".
+func newGCPCredentialsResourceLister(projects []string, keyRef string) (*ResourceLister, error) {
+	if len(projects) == 0 {
+		projects = envList("GOOGLE_CLOUD_PROJECT")
+	}
+	if len(projects) == 0 {
+		return nil, errors.New("gcp_project is required when GOOGLE_CLOUD_PROJECT isn't set")
+	}
+
+	var opts []option.ClientOption
+	if keyRef != "" {
+		if gcpKeyDir == "" {
+			return nil, errors.New("gcp_key_ref needs CLOUDY_GCP_KEY_DIR to be set")
+		}
+		if !keyRefPattern.MatchString(keyRef) {
+			return nil, fmt.Errorf("invalid gcp_key_ref %q; expected a key file name without .json", keyRef)
+		}
+		opts = append(opts, option.WithCredentialsFile(filepath.Join(gcpKeyDir, keyRef+".json")))
+	}
+	provider, err := gcp.NewProvider(context.TODO(), projects, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return newResourceLister(cloudy.NewProviderScanner(provider)), nil
+}
@@ -0,0 +1,279 @@
+package main
+
+import (
+	"context"
+	"crypto/tls"
+	"net/http/httptest"
+	"net/netip"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// withCredentialsConfig sets the server's credential settings for the
+// length of a test.
+func withCredentialsConfig(t *testing.T, profiles []string, gate bool) {
+	t.Helper()
+	oldProfiles, oldGate := awsProfiles, requestCredentials
+	awsProfiles, requestCredentials = profiles, gate
+	t.Cleanup(func() {
+		awsProfiles, requestCredentials = oldProfiles, oldGate
+		credentialListers.mu.Lock()
+		clear(credentialListers.listers)
+		credentialListers.mu.Unlock()
+	})
+}
+
+func TestCredentialsAllowed(t *testing.T) {
+	tests := []struct {
+		name     string
+		profiles []string
+		gate     bool
+		creds    Credentials
+		wantErr  string
+	}{
+		{name: "allowed profile", profiles: []string{"audit", "prod"}, creds: Credentials{AWSProfile: "prod"}},
+		{name: "allowed profile with the gate on", profiles: []string{"prod"}, gate: true, creds: Credentials{AWSProfile: "prod"}},
+		{name: "disallowed profile", profiles: []string{"audit"}, creds: Credentials{AWSProfile: "prod"}, wantErr: `aws_profile "prod" isn't allowed`},
+		{name: "profiles disabled", creds: Credentials{AWSProfile: "prod"}, wantErr: "aws_profile is disabled"},
+		{name: "profiles disabled with the gate on", gate: true, creds: Credentials{AWSProfile: "prod"}, wantErr: "aws_profile is disabled"},
+		{name: "azure with the gate off", profiles: []string{"prod"}, creds: Credentials{AzureSubscriptionID: "sub"}, wantErr: "per-request credentials are disabled"},
+		{name: "gcp with the gate off", creds: Credentials{GCPProject: "proj", GCPKeyRef: "key"}, wantErr: "per-request credentials are disabled"},
+		{name: "azure with the gate on", gate: true, creds: Credentials{AzureSubscriptionID: "sub", AzureClientID: "id"}},
+		{name: "gcp with the gate on", gate: true, creds: Credentials{GCPProject: "proj"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			withCredentialsConfig(t, tt.profiles, tt.gate)
+			err := tt.creds.allowed()
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("allowed() = %v, want nil", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("allowed() = %v, want an error containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestListerForCredentials(t *testing.T) {
+	// An allowed profile is loaded from the shared config, not the network
+	configFile := filepath.Join(t.TempDir(), "config")
+	if err := os.WriteFile(configFile, []byte("[profile prod]\nregion = us-east-1\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("AWS_CONFIG_FILE", configFile)
+	t.Setenv("AWS_SHARED_CREDENTIALS_FILE", filepath.Join(t.TempDir(), "credentials"))
+	providerListers["aws"] = &ResourceLister{}
+	t.Cleanup(func() { delete(providerListers, "aws") })
+	tenantLister := &ResourceLister{}
+	tenantCtx := withTenant(context.Background(), &tenant{
+		name:         "acme",
+		listers:      map[string]*ResourceLister{"aws": tenantLister},
+		accountNames: newAccountNames(),
+	})
+	session := Credentials{AWSAccessKeyID: "AKIAEXAMPLE", AWSSecretAccessKey: "secret", AWSSessionToken: "token"}
+
+	tests := []struct {
+		name     string
+		tenant   bool
+		provider string
+		creds    *Credentials
+		want     *ResourceLister
+		wantErr  string
+	}{
+		{name: "server credentials", provider: "aws", want: providerListers["aws"]},
+		{name: "empty credentials", provider: "aws", creds: &Credentials{}, want: providerListers["aws"]},
+		{name: "allowed profile", provider: "AWS", creds: &Credentials{AWSProfile: "prod"}},
+		{name: "disallowed profile", provider: "aws", creds: &Credentials{AWSProfile: "dev"}, wantErr: "isn't allowed"},
+		{name: "credentials for another provider", provider: "aws", creds: &Credentials{GCPProject: "proj"}, wantErr: "credentials are for provider gcp, not aws"},
+		{name: "two providers", provider: "aws", creds: &Credentials{AWSProfile: "prod", AzureClientID: "id"}, wantErr: "credentials are for one provider"},
+		{name: "azure with the gate off", provider: "azure", creds: &Credentials{AzureSubscriptionID: "sub"}, wantErr: "per-request credentials are disabled"},
+		{name: "unknown provider", provider: "oracle", wantErr: `unknown provider "oracle"`},
+		{name: "session credentials", provider: "aws", creds: &session},
+		{name: "incomplete session credentials", provider: "aws", creds: &Credentials{AWSAccessKeyID: "AKIAEXAMPLE"}, wantErr: "temporary credentials need"},
+		{name: "tenant", tenant: true, provider: "aws", want: tenantLister},
+		{name: "tenant without the provider", tenant: true, provider: "gcp", wantErr: `unknown provider "gcp"`},
+		{name: "tenant session credentials", tenant: true, provider: "aws", creds: &session},
+		{name: "tenant profile", tenant: true, provider: "aws", creds: &Credentials{AWSProfile: "prod"}, wantErr: "tenants can only send temporary AWS credentials"},
+		{name: "tenant profile with session credentials", tenant: true, provider: "aws", creds: &Credentials{AWSProfile: "prod", AWSAccessKeyID: "AKIAEXAMPLE", AWSSecretAccessKey: "secret", AWSSessionToken: "token"}, wantErr: "tenants can only send temporary AWS credentials"},
+		{name: "tenant incomplete session credentials", tenant: true, provider: "aws", creds: &Credentials{AWSAccessKeyID: "AKIAEXAMPLE", AWSSessionToken: "token"}, wantErr: "temporary credentials need"},
+		{name: "tenant azure credentials", tenant: true, provider: "azure", creds: &Credentials{AzureSubscriptionID: "sub"}, wantErr: "tenants can only send temporary AWS credentials"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			withCredentialsConfig(t, []string{"prod"}, false)
+			ctx := context.Background()
+			if tt.tenant {
+				ctx = tenantCtx
+			}
+			lister, err := listerFor(ctx, tt.provider, tt.creds)
+			if tt.wantErr == "" {
+				if err != nil || lister == nil {
+					t.Errorf("listerFor = %v, %v; want a lister", lister, err)
+				} else if tt.want != nil && lister != tt.want {
+					t.Errorf("listerFor returned another lister than %s's", tt.provider)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("listerFor = %v, want an error containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestListerForReusesCredentialListers(t *testing.T) {
+	configFile := filepath.Join(t.TempDir(), "config")
+	if err := os.WriteFile(configFile, []byte("[profile prod]\nregion = us-east-1\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("AWS_CONFIG_FILE", configFile)
+	withCredentialsConfig(t, []string{"prod"}, false)
+
+	first, err := listerFor(context.Background(), "aws", &Credentials{AWSProfile: "prod"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := listerFor(context.Background(), "aws", &Credentials{AWSProfile: "prod"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if first != second {
+		t.Error("a second request with the same profile built a new lister")
+	}
+	if !first.perRequest {
+		t.Error("a profile's lister isn't marked per request")
+	}
+}
+
+func TestListerForConcurrentRequestsShareLister(t *testing.T) {
+	configFile := filepath.Join(t.TempDir(), "config")
+	if err := os.WriteFile(configFile, []byte("[profile prod]\nregion = us-east-1\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("AWS_CONFIG_FILE", configFile)
+	withCredentialsConfig(t, []string{"prod"}, false)
+
+	// Requests racing to build the profile's lister all end up with the
+	// one kept
+	listers := make([]*ResourceLister, 8)
+	var wg sync.WaitGroup
+	for i := range listers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			lister, err := listerFor(context.Background(), "aws", &Credentials{AWSProfile: "prod"})
+			if err != nil {
+				t.Error(err)
+			}
+			listers[i] = lister
+		}()
+	}
+	wg.Wait()
+
+	kept, ok := cachedCredentialLister(credentialsKey{provider: "aws", credentials: Credentials{AWSProfile: "prod"}})
+	if !ok {
+		t.Fatal("the profile's lister wasn't kept")
+	}
+	for i, lister := range listers {
+		if lister != kept {
+			t.Errorf("request %d got a lister other than the one kept", i)
+		}
+	}
+	if n := len(credentialListers.listers); n != 1 {
+		t.Errorf("%d listers kept, want 1", n)
+	}
+}
+
+func TestValidateCredentialsTransport(t *testing.T) {
+	proxies, err := parseTrustedProxies([]string{"10.0.0.0/8", "192.0.2.7", "2001:db8::/32"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func(previous []netip.Prefix) { trustedProxies = previous }(trustedProxies)
+	trustedProxies = proxies
+
+	session := &Credentials{AWSAccessKeyID: "AKIA", AWSSecretAccessKey: "secret", AWSSessionToken: "token"}
+	tests := []struct {
+		name       string
+		creds      *Credentials
+		remoteAddr string
+		tls        bool
+		proto      string
+		wantErr    bool
+	}{
+		{name: "no credentials", creds: nil, remoteAddr: "203.0.113.1:1234"},
+		{name: "profile over HTTP", creds: &Credentials{AWSProfile: "dev"}, remoteAddr: "203.0.113.1:1234"},
+		{name: "TLS", creds: session, remoteAddr: "203.0.113.1:1234", tls: true},
+		{name: "plain HTTP", creds: session, remoteAddr: "203.0.113.1:1234", wantErr: true},
+		{name: "trusted proxy range", creds: session, remoteAddr: "10.1.2.3:1234", proto: "https"},
+		{name: "trusted proxy address", creds: session, remoteAddr: "192.0.2.7:1234", proto: "https"},
+		{name: "trusted IPv6 proxy", creds: session, remoteAddr: "[2001:db8::1]:1234", proto: "https"},
+		{name: "IPv4-mapped trusted proxy", creds: session, remoteAddr: "[::ffff:10.1.2.3]:1234", proto: "https"},
+		{name: "trusted proxy over HTTP", creds: session, remoteAddr: "10.1.2.3:1234", proto: "http", wantErr: true},
+		{name: "untrusted client claiming HTTPS", creds: session, remoteAddr: "203.0.113.1:1234", proto: "https", wantErr: true},
+		{name: "address next to a trusted one", creds: session, remoteAddr: "192.0.2.8:1234", proto: "https", wantErr: true},
+		{name: "unparsable address", creds: session, remoteAddr: "pipe", proto: "https", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest("POST", "/api/v1/resources", nil)
+			r.RemoteAddr = tt.remoteAddr
+			r.TLS = nil
+			if tt.tls {
+				r.TLS = &tls.ConnectionState{}
+			}
+			if tt.proto != "" {
+				r.Header.Set("X-Forwarded-Proto", tt.proto)
+			}
+			err := validateCredentialsTransport(r, tt.creds)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("validateCredentialsTransport() = %v, want error %t", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestParseTrustedProxies(t *testing.T) {
+	tests := []struct {
+		proxies []string
+		want    []string
+		wantErr bool
+	}{
+		{proxies: nil, want: []string{}},
+		{proxies: []string{"10.0.0.1"}, want: []string{"10.0.0.1/32"}},
+		{proxies: []string{"10.1.2.3/8"}, want: []string{"10.0.0.0/8"}},
+		{proxies: []string{"::1"}, want: []string{"::1/128"}},
+		{proxies: []string{"10.0.0.0/8", "proxy.internal"}, wantErr: true},
+		{proxies: []string{"10.0.0.0/33"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := parseTrustedProxies(tt.proxies)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("parseTrustedProxies(%q) succeeded, want an error", tt.proxies)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("parseTrustedProxies(%q): %v", tt.proxies, err)
+			continue
+		}
+		if len(got) != len(tt.want) {
+			t.Errorf("parseTrustedProxies(%q) = %v, want %v", tt.proxies, got, tt.want)
+			continue
+		}
+		for i := range got {
+			if got[i].String() != tt.want[i] {
+				t.Errorf("parseTrustedProxies(%q) = %v, want %v", tt.proxies, got, tt.want)
+				break
+			}
+		}
+	}
+}
@@ -0,0 +1,194 @@
+package main
+
+import (
+	"fmt"
+	"net"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// datadogEventLines bounds how many changes a drift event lists, keeping
+// it within a DogStatsD datagram.
+const datadogEventLines = 20
+
+// datadog sends metrics and events to a Datadog agent over DogStatsD, from
+// CLOUDY_DATADOG_ADDR, or is nil if they aren't sent. Its methods do
+// nothing on a nil datadog.
+var datadog *dogStatsD
+
+// dogStatsD writes the DogStatsD datagram format. Writes aren't checked:
+// over UDP a missing agent only loses metrics.
+type dogStatsD struct {
+	conn net.Conn
+	// tags are added to every metric and event, from CLOUDY_DATADOG_TAGS.
+	tags []string
+}
+
+// newDogStatsD connects to the agent at addr, host:port for UDP or
+// unix:///path for its Unix socket.
+func newDogStatsD(addr string, tags []string) (*dogStatsD, error) {
+	network := "udp"
+	if path, ok := strings.CutPrefix(addr, "unix://"); ok {
+		network, addr = "unixgram", path
+	}
+	conn, err := net.Dial(network, addr)
+	if err != nil {
+		return nil, err
+	}
+	return &dogStatsD{conn: conn, tags: tags}, nil
+}
+
+func (d *dogStatsD) gauge(name string, value float64, tags ...string) {
+	d.metric(name, strconv.FormatFloat(value, 'f', -1, 64), "g", tags)
+}
+
+func (d *dogStatsD) count(name string, value int, tags ...string) {
+	d.metric(name, strconv.Itoa(value), "c", tags)
+}
+
+func (d *dogStatsD) timing(name string, duration time.Duration, tags ...string) {
+	d.metric(name, strconv.FormatInt(duration.Milliseconds(), 10), "ms", tags)
+}
+
+func (d *dogStatsD) metric(name, value, metricType string, tags []string) {
+	if d == nil {
+		return
+	}
+	d.conn.Write([]byte(name + ":" + value + "|" + metricType + d.tagSuffix(tags)))
+}
+
+// event sends an event, whose text is rendered as Markdown.
+func (d *dogStatsD) event(title, text, alertType string, tags ...string) {
+	if d == nil {
+		return
+	}
+	title = strings.ReplaceAll(title, "\n", " ")
+	text = strings.ReplaceAll("%%% \n"+text+"\n %%%", "\n", `\n`)
+	d.conn.Write([]byte(fmt.Sprintf("_e{%d,%d}:%s|%s|t:%s|s:cloudy", len(title), len(text), title, text, alertType) + d.tagSuffix(tags)))
+}
+
+func (d *dogStatsD) tagSuffix(tags []string) string {
+	all := append(append([]string(nil), d.tags...), tags...)
+	if len(all) == 0 {
+		return ""
+	}
+	return "|#" + strings.Join(all, ",")
+}
+
+// datadogTag is name:value, with the characters DogStatsD separates
+// fields and tags with replaced.
+func datadogTag(name, value string) string {
+	return name + ":" + strings.Map(func(r rune) rune {
+		switch r {
+		case ',', '|', '#', '\n':
+			return '_'
+		}
+		return r
+	}, value)
+}
+
+// datadogTenantTags tags t's metrics with its name, if it isn't the
+// server's own.
+func datadogTenantTags(t *tenant, tags ...string) []string {
+	if t != serverTenant {
+		tags = append(tags, datadogTag("tenant", t.name))
+	}
+	return tags
+}
+
+// reportScan sends how long a scan of regions took.
+func reportScan(t *tenant, provider, mode string, regions int, took time.Duration) {
+	datadog.timing("cloudy.scan.duration", took, datadogTenantTags(t, datadogTag("provider", provider), datadogTag("mode", mode), datadogTag("regions", strconv.Itoa(regions)))...)
+}
+
+// reportRegionScan sends how long a region took to list, and for a full
+// listing how many resources of each type each account has in it.
+func reportRegionScan(t *tenant, provider, mode string, rd RegionResources, took time.Duration, full bool) {
+	if datadog == nil {
+		return
+	}
+	status := "ok"
+	if rd.Error != "" {
+		status = "error"
+	}
+	// Clipped, so each metric's tags are appended to a copy
+	tags := slices.Clip(datadogTenantTags(t, datadogTag("provider", provider), datadogTag("region", rd.Region)))
+	datadog.timing("cloudy.scan.region.duration", took, append(tags, datadogTag("mode", mode), datadogTag("status", status))...)
+	if !full || rd.Error != "" {
+		return
+	}
+
+	type key struct{ account, accountName, resourceType string }
+	counts := make(map[key]int)
+	for _, resource := range rd.Resources {
+		counts[key{resource.AccountID, resource.AccountName, resource.Type}]++
+	}
+	for k, n := range counts {
+		resourceTags := append(tags, datadogTag("resource_type", k.resourceType))
+		if k.account != "" {
+			resourceTags = append(resourceTags, datadogTag("account_id", k.account))
+		}
+		if k.accountName != "" {
+			resourceTags = append(resourceTags, datadogTag("account_name", k.accountName))
+		}
+		datadog.gauge("cloudy.resources", float64(n), resourceTags...)
+	}
+}
+
+// reportDrift counts scan's changes by kind of change, account, region
+// and type, and sends an event listing them.
+func reportDrift(scan scheduledScan) {
+	if datadog == nil || scan.changes.empty() {
+		return
+	}
+	type key struct{ change, account, region, resourceType string }
+	counts := make(map[key]int)
+	var lines []string
+	note := func(change string, resource Resource, detail string) {
+		counts[key{change, resource.AccountID, resource.Region, resource.Type}]++
+		lines = append(lines, fmt.Sprintf("- %s %s %s (%s)%s", change, resource.Type, resourceLabel(resource), resource.Region, detail))
+	}
+	for _, resource := range scan.changes.Added {
+		note(changeCreated, resource, "")
+	}
+	for _, resource := range scan.changes.Removed {
+		note(changeDeleted, resource, "")
+	}
+	for _, changed := range scan.changes.Changed {
+		note(changeUpdated, changed.After, ": "+strings.Join(changedFields(changed.Before, changed.After), ", "))
+	}
+
+	for k, n := range counts {
+		tags := datadogTenantTags(scan.tenant, datadogTag("change", k.change), datadogTag("region", k.region), datadogTag("resource_type", k.resourceType))
+		if k.account != "" {
+			tags = append(tags, datadogTag("account_id", k.account))
+		}
+		datadog.count("cloudy.resources.changes", n, tags...)
+	}
+
+	if len(lines) > datadogEventLines {
+		lines = append(lines[:datadogEventLines], fmt.Sprintf("- and %d more", len(lines)-datadogEventLines))
+	}
+	accounts := make(map[string]bool)
+	for k := range counts {
+		if k.account != "" {
+			accounts[k.account] = true
+		}
+	}
+	tags := datadogTenantTags(scan.tenant)
+	for _, account := range sortedKeys(accounts) {
+		tags = append(tags, datadogTag("account_id", account))
+	}
+	title := fmt.Sprintf("Cloudy found %d new, %d deleted and %d changed resources", len(scan.changes.Added), len(scan.changes.Removed), len(scan.changes.Changed))
+	datadog.event(title, strings.Join(lines, "\n"), "info", tags...)
+}
+
+// resourceLabel names resource by its name, or its ID if it has none.
+func resourceLabel(resource Resource) string {
+	if resource.Name != "" {
+		return resource.Name
+	}
+	return resource.ID
+}
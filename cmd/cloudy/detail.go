@@ -0,0 +1,267 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/alwindoss/cloudy/pkg/cloudy"
+	"github.com/aws/aws-sdk-go-v2/aws/arn"
+	"github.com/gin-gonic/gin"
+)
+
+// arnServices maps the service part of an ARN to the v2 services whose
+// listers produce that kind of resource.
+var arnServices = map[string][]string{
+	"ec2":               {"ec2"},
+	"s3":                {"s3"},
+	"rds":               {"rds", "neptune", "docdb"},
+	"lambda":            {"lambda"},
+	"ecs":               {"ecs"},
+	"iam":               {"iam"},
+	"events":            {"eventbridge"},
+	"scheduler":         {"eventbridge"},
+	"globalaccelerator": {"globalaccelerator"},
+	"apprunner":         {"apprunner"},
+	"amplify":           {"amplify"},
+	"elasticmapreduce":  {"emr"},
+	"dms":               {"dms"},
+	"workspaces":        {"workspaces"},
+	"guardduty":         {"guardduty"},
+	"config":            {"config"},
+	"cloudtrail":        {"cloudtrail"},
+}
+
+type ResourceDetailResponse struct {
+	Resource  Resource  `json:"resource"`
+	Source    string    `json:"source"`
+	ScannedAt time.Time `json:"scanned_at"`
+}
+
+// maxLookupARNs caps how many ARNs one bulk lookup may ask for.
+const maxLookupARNs = 1000
+
+// lookupConcurrency bounds the live listings one bulk lookup runs at once.
+const lookupConcurrency = 8
+
+// getResource returns a single resource by ID or ARN. It is looked up in the
+// latest scan first; an ARN that isn't there is looked up live by listing
+// just that service in the ARN's region.
+func getResource(c *gin.Context) {
+	id := strings.TrimPrefix(c.Param("id"), "/")
+	if id == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "a resource ID or ARN must be specified"})
+		return
+	}
+
+	regionData, scannedAt := tenantFrom(c.Request.Context()).latestScan.Snapshot()
+	if resource, ok := findResource(regionData, id); ok {
+		c.JSON(http.StatusOK, ResourceDetailResponse{Resource: resource, Source: "cache", ScannedAt: scannedAt})
+		return
+	}
+
+	parsed, err := arn.Parse(id)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "resource not found in the latest scan; use its ARN to look it up live"})
+		return
+	}
+	region, types, ok := liveLookupScope(parsed)
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "resources of service " + parsed.Service + " aren't listed by cloudy"})
+		return
+	}
+
+	lister, err := listerFor(c.Request.Context(), cloudy.ProviderAWS, nil)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	release, ok := limitScan(c)
+	if !ok {
+		return
+	}
+	defer release()
+
+	// Bypass the result cache, which is no newer than the latest scan
+	resources, listErr := lister.ListResourcesInRegion(cloudy.WithRefresh(c.Request.Context()), region, types, nil)
+	if resource, ok := findResource([]RegionResources{{Region: region, Resources: resources}}, id); ok {
+		c.JSON(http.StatusOK, ResourceDetailResponse{Resource: resource, Source: "live", ScannedAt: time.Now().UTC()})
+		return
+	}
+
+	if listErr != nil {
+		c.JSON(http.StatusBadGateway, gin.H{"error": listErr.Error()})
+		return
+	}
+	c.JSON(http.StatusNotFound, gin.H{"error": "resource not found"})
+}
+
+type LookupRequest struct {
+	ARNs []string `json:"arns" binding:"required"`
+}
+
+type LookupResult struct {
+	ARN       string     `json:"arn"`
+	Found     bool       `json:"found"`
+	Resource  *Resource  `json:"resource,omitempty"`
+	Source    string     `json:"source,omitempty"`
+	ScannedAt *time.Time `json:"scanned_at,omitempty"`
+	Error     string     `json:"error,omitempty"`
+}
+
+type LookupResponse struct {
+	Results    []LookupResult `json:"results"`
+	FoundCount int            `json:"found_count"`
+}
+
+// lookupResources resolves many ARNs (or IDs) at once. Those in the latest
+// scan are answered from it; the rest are grouped by region and service so
+// each group costs a single targeted listing.
+func lookupResources(c *gin.Context) {
+	var req LookupRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if len(req.ARNs) > maxLookupARNs {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("at most %d ARNs can be looked up at once", maxLookupARNs)})
+		return
+	}
+
+	results := make([]LookupResult, len(req.ARNs))
+	regionData, scannedAt := tenantFrom(c.Request.Context()).latestScan.Snapshot()
+
+	// Group what isn't cached by the listing that would find it
+	type scope struct {
+		region  string
+		service string
+	}
+	pending := make(map[scope][]int)
+	scopeTypes := make(map[scope][]string)
+	for i, id := range req.ARNs {
+		results[i].ARN = id
+		if resource, ok := findResource(regionData, id); ok {
+			results[i].Found = true
+			results[i].Resource = &resource
+			results[i].Source = "cache"
+			results[i].ScannedAt = &scannedAt
+			continue
+		}
+
+		parsed, err := arn.Parse(id)
+		if err != nil {
+			results[i].Error = "not in the latest scan, and not an ARN that could be looked up live"
+			continue
+		}
+		region, types, ok := liveLookupScope(parsed)
+		if !ok {
+			results[i].Error = "resources of service " + parsed.Service + " aren't listed by cloudy"
+			continue
+		}
+		key := scope{region: region, service: parsed.Service}
+		pending[key] = append(pending[key], i)
+		scopeTypes[key] = types
+	}
+
+	if len(pending) > 0 {
+		lister, err := listerFor(c.Request.Context(), cloudy.ProviderAWS, nil)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		release, ok := limitScan(c)
+		if !ok {
+			return
+		}
+		defer release()
+
+		var wg sync.WaitGroup
+		sem := make(chan struct{}, lookupConcurrency)
+		for key, indexes := range pending {
+			wg.Add(1)
+			sem <- struct{}{}
+			go func(key scope, indexes []int) {
+				defer wg.Done()
+				defer func() { <-sem }()
+
+				resources, listErr := lister.ListResourcesInRegion(cloudy.WithRefresh(c.Request.Context()), key.region, scopeTypes[key], nil)
+				listed := []RegionResources{{Region: key.region, Resources: resources}}
+				now := time.Now().UTC()
+				// Each index belongs to exactly one scope, so the writes
+				// don't overlap
+				for _, i := range indexes {
+					if resource, ok := findResource(listed, req.ARNs[i]); ok {
+						results[i].Found = true
+						results[i].Resource = &resource
+						results[i].Source = "live"
+						results[i].ScannedAt = &now
+					} else if listErr != nil {
+						results[i].Error = listErr.Error()
+					}
+				}
+			}(key, indexes)
+		}
+		wg.Wait()
+	}
+
+	response := LookupResponse{Results: results}
+	for _, result := range results {
+		if result.Found {
+			response.FoundCount++
+		}
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// findResource looks id up by resource ID or, if it is an ARN, by the ARN's
+// last part too.
+func findResource(regionData []RegionResources, id string) (Resource, bool) {
+	parsed, err := arn.Parse(id)
+	isARN := err == nil
+	for _, rd := range regionData {
+		for _, resource := range rd.Resources {
+			if resource.ID == id || (isARN && matchesARN(resource, parsed)) {
+				return resource, true
+			}
+		}
+	}
+	return Resource{}, false
+}
+
+// liveLookupScope returns the region and resource types to list to find
+// the resource an ARN names, or false if no lister covers its service.
+func liveLookupScope(parsed arn.ARN) (string, []string, bool) {
+	services, ok := arnServices[parsed.Service]
+	if !ok {
+		return "", nil, false
+	}
+	types, _ := serviceTypes(services)
+
+	region := parsed.Region
+	if region == "" {
+		// Global services are listed under their partition's global region
+		region = cloudy.GlobalRegion(parsed.Partition)
+	}
+	return region, types, true
+}
+
+// matchesARN reports whether resource is the one a parsed ARN names, for
+// resources whose ID is the last part of the ARN rather than the ARN itself
+// (EC2 instances, S3 buckets, RDS instances, ...).
+func matchesARN(resource Resource, parsed arn.ARN) bool {
+	if resource.ID == parsed.String() {
+		return true
+	}
+	if parsed.Region != "" && resource.Region != parsed.Region {
+		return false
+	}
+	name := parsed.Resource
+	if i := strings.LastIndexAny(name, "/:"); i >= 0 {
+		name = name[i+1:]
+	}
+	return name != "" && resource.ID == name
+}
@@ -0,0 +1,58 @@
+package main
+
+import (
+	_ "embed"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	swaggerFiles "github.com/swaggo/files"
+)
+
+//go:embed openapi.json
+var openAPISpec []byte
+
+// swaggerUIPage loads the Swagger UI assets bundled by swaggo/files and
+// points them at /openapi.json.
+const swaggerUIPage = `<!DOCTYPE html>
+<html lang="en">
+<head>
+  <meta charset="UTF-8">
+  <title>Cloudy API</title>
+  <link rel="stylesheet" type="text/css" href="swagger-ui.css">
+  <link rel="icon" type="image/png" href="favicon-32x32.png" sizes="32x32">
+</head>
+<body>
+  <div id="swagger-ui"></div>
+  <script src="swagger-ui-bundle.js"></script>
+  <script src="swagger-ui-standalone-preset.js"></script>
+  <script>
+    window.onload = function() {
+      window.ui = SwaggerUIBundle({
+        url: "/openapi.json",
+        dom_id: "#swagger-ui",
+        deepLinking: true,
+        presets: [SwaggerUIBundle.presets.apis, SwaggerUIStandalonePreset],
+        layout: "StandaloneLayout"
+      });
+    };
+  </script>
+</body>
+</html>
+`
+
+func openAPIHandler(c *gin.Context) {
+	c.Data(http.StatusOK, gin.MIMEJSON, openAPISpec)
+}
+
+// swaggerUIHandler serves Swagger UI under /docs/.
+func swaggerUIHandler() gin.HandlerFunc {
+	assets := http.StripPrefix("/docs", http.FileServer(swaggerFiles.HTTP))
+	return func(c *gin.Context) {
+		switch c.Param("file") {
+		case "/", "/index.html":
+			c.Data(http.StatusOK, "text/html; charset=utf-8", []byte(swaggerUIPage))
+		default:
+			assets.ServeHTTP(c.Writer, c.Request)
+		}
+	}
+}
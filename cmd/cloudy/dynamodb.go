@@ -0,0 +1,317 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// dynamoBatchSize is the most items one BatchWriteItem call takes.
+const dynamoBatchSize = 25
+
+// dynamoRetries bounds how often a batch's unprocessed items are sent
+// again, waiting twice as long each time from dynamoBackoff.
+const (
+	dynamoRetries = 8
+	dynamoBackoff = 50 * time.Millisecond
+)
+
+// inventoryQueue is how many region writes can wait for the table before
+// recording scans waits for them.
+const inventoryQueue = 64
+
+// inventory is the DynamoDB table the latest scans are kept in, from
+// CLOUDY_DYNAMODB_TABLE, or nil if they are only kept in memory.
+var inventory *inventoryTable
+
+// inventoryTable keeps every scanStore's resources in a DynamoDB table,
+// one item per resource, so they survive restarts and other services can
+// query them. Items are keyed by pk, the resource's account and region as
+// account#region, prefixed with tenant# for a tenant's, and sk, its ID
+// (its ARN where it has one). Writes are made in the background, in the
+// order scans were recorded.
+type inventoryTable struct {
+	client *dynamodb.Client
+	name   string
+	writes chan inventoryWrite
+	done   chan struct{}
+
+	// mu guards closed, so scans recorded during shutdown are dropped
+	// rather than sent on a closed channel.
+	mu     sync.Mutex
+	closed bool
+}
+
+// inventoryWrite replaces the items of a region's previous scan with
+// those of its latest.
+type inventoryWrite struct {
+	tenant   string
+	region   string
+	previous []Resource
+	stored   storedRegion
+}
+
+// newInventoryTable starts writing to the table name.
+func newInventoryTable(client *dynamodb.Client, name string) *inventoryTable {
+	t := &inventoryTable{
+		client: client,
+		name:   name,
+		writes: make(chan inventoryWrite, inventoryQueue),
+		done:   make(chan struct{}),
+	}
+	go t.run()
+	return t
+}
+
+func (t *inventoryTable) run() {
+	defer close(t.done)
+	for w := range t.writes {
+		if err := t.write(context.Background(), w); err != nil {
+			log.Printf("Failed to save %s to DynamoDB table %s: %v", w.region, t.name, err)
+		}
+	}
+}
+
+// Close waits for the queued writes to finish, or for ctx to be done.
+func (t *inventoryTable) Close(ctx context.Context) error {
+	t.mu.Lock()
+	t.closed = true
+	close(t.writes)
+	t.mu.Unlock()
+
+	select {
+	case <-t.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (t *inventoryTable) enqueue(w inventoryWrite) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if !t.closed {
+		t.writes <- w
+	}
+}
+
+// write puts the stored resources and deletes the previous ones the
+// latest scan no longer lists.
+func (t *inventoryTable) write(ctx context.Context, w inventoryWrite) error {
+	var requests []types.WriteRequest
+	current := make(map[string]bool, len(w.stored.Resources))
+	for _, resource := range w.stored.Resources {
+		pk := inventoryPK(w.tenant, resource, w.region)
+		current[pk+"\n"+resource.ID] = true
+		requests = append(requests, types.WriteRequest{PutRequest: &types.PutRequest{Item: inventoryItem(w.tenant, pk, resource, w.stored)}})
+	}
+	for _, resource := range w.previous {
+		pk := inventoryPK(w.tenant, resource, w.region)
+		if !current[pk+"\n"+resource.ID] {
+			current[pk+"\n"+resource.ID] = true
+			requests = append(requests, types.WriteRequest{DeleteRequest: &types.DeleteRequest{Key: map[string]types.AttributeValue{
+				"pk": &types.AttributeValueMemberS{Value: pk},
+				"sk": &types.AttributeValueMemberS{Value: resource.ID},
+			}}})
+		}
+	}
+
+	for start := 0; start < len(requests); start += dynamoBatchSize {
+		if err := t.writeBatch(ctx, requests[start:min(start+dynamoBatchSize, len(requests))]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// writeBatch sends requests, and whichever DynamoDB leaves unprocessed
+// when throttled, until all are made.
+func (t *inventoryTable) writeBatch(ctx context.Context, requests []types.WriteRequest) error {
+	backoff := dynamoBackoff
+	for attempt := 0; ; attempt++ {
+		out, err := t.client.BatchWriteItem(ctx, &dynamodb.BatchWriteItemInput{
+			RequestItems: map[string][]types.WriteRequest{t.name: requests},
+		})
+		if err != nil {
+			return err
+		}
+		requests = out.UnprocessedItems[t.name]
+		if len(requests) == 0 {
+			return nil
+		}
+		if attempt == dynamoRetries {
+			return fmt.Errorf("%d items left unprocessed", len(requests))
+		}
+		time.Sleep(backoff)
+		backoff *= 2
+	}
+}
+
+// Load reads every item in the table into the stores of the tenants they
+// belong to, keyed by tenant name, so scans recorded before a restart are
+// searched and used as incremental baselines again. Items of tenants that
+// no longer exist are skipped.
+func (t *inventoryTable) Load(ctx context.Context, stores map[string]*scanStore) error {
+	paginator := dynamodb.NewScanPaginator(t.client, &dynamodb.ScanInput{TableName: aws.String(t.name)})
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return err
+		}
+		for _, item := range page.Items {
+			store, ok := stores[itemString(item, "tenant")]
+			if !ok {
+				continue
+			}
+			// The region scanned, which for global resources isn't
+			// always theirs, ends the partition key
+			pk := itemString(item, "pk")
+			region := pk[strings.LastIndex(pk, "#")+1:]
+			resource, scannedAt, complete := itemResource(item)
+			key := regionKey{resource.Provider, region}
+			stored, seen := store.regions[key]
+			stored.Resources = append(stored.Resources, resource)
+			if !seen || scannedAt.Before(stored.ScannedAt) {
+				stored.ScannedAt = scannedAt
+			}
+			stored.Complete = complete && (!seen || stored.Complete)
+			store.regions[key] = stored
+		}
+	}
+	return nil
+}
+
+// inventoryPK is the partition key of resource, listed in region.
+func inventoryPK(tenant string, resource Resource, region string) string {
+	account := resource.AccountID
+	if account == "" {
+		account = resource.Provider
+	}
+	pk := account + "#" + region
+	if tenant != "" {
+		pk = tenant + "#" + pk
+	}
+	return pk
+}
+
+func inventoryItem(tenant, pk string, resource Resource, stored storedRegion) map[string]types.AttributeValue {
+	item := map[string]types.AttributeValue{
+		"pk":         &types.AttributeValueMemberS{Value: pk},
+		"sk":         &types.AttributeValueMemberS{Value: resource.ID},
+		"id":         &types.AttributeValueMemberS{Value: resource.ID},
+		"scanned_at": &types.AttributeValueMemberS{Value: stored.ScannedAt.Format(time.RFC3339)},
+		"complete":   &types.AttributeValueMemberBOOL{Value: stored.Complete},
+	}
+	for name, value := range map[string]string{
+		"tenant":       tenant,
+		"name":         resource.Name,
+		"type":         resource.Type,
+		"kind":         resource.Kind,
+		"state":        resource.State,
+		"region":       resource.Region,
+		"provider":     resource.Provider,
+		"partition":    resource.Partition,
+		"account_id":   resource.AccountID,
+		"account_name": resource.AccountName,
+	} {
+		if value != "" {
+			item[name] = &types.AttributeValueMemberS{Value: value}
+		}
+	}
+	if len(resource.Tags) > 0 {
+		item["tags"] = stringMapAttribute(resource.Tags)
+	}
+	if len(resource.Attributes) > 0 {
+		item["attributes"] = stringMapAttribute(resource.Attributes)
+	}
+	return item
+}
+
+func itemResource(item map[string]types.AttributeValue) (resource Resource, scannedAt time.Time, complete bool) {
+	resource = Resource{
+		ID:          itemString(item, "id"),
+		Name:        itemString(item, "name"),
+		Type:        itemString(item, "type"),
+		Kind:        itemString(item, "kind"),
+		State:       itemString(item, "state"),
+		Region:      itemString(item, "region"),
+		Provider:    itemString(item, "provider"),
+		Partition:   itemString(item, "partition"),
+		AccountID:   itemString(item, "account_id"),
+		AccountName: itemString(item, "account_name"),
+		Tags:        itemStringMap(item, "tags"),
+		Attributes:  itemStringMap(item, "attributes"),
+	}
+	scannedAt, _ = time.Parse(time.RFC3339, itemString(item, "scanned_at"))
+	if value, ok := item["complete"].(*types.AttributeValueMemberBOOL); ok {
+		complete = value.Value
+	}
+	return resource, scannedAt, complete
+}
+
+func stringMapAttribute(values map[string]string) types.AttributeValue {
+	m := make(map[string]types.AttributeValue, len(values))
+	for key, value := range values {
+		m[key] = &types.AttributeValueMemberS{Value: value}
+	}
+	return &types.AttributeValueMemberM{Value: m}
+}
+
+func itemString(item map[string]types.AttributeValue, name string) string {
+	if value, ok := item[name].(*types.AttributeValueMemberS); ok {
+		return value.Value
+	}
+	return ""
+}
+
+func itemStringMap(item map[string]types.AttributeValue, name string) map[string]string {
+	value, ok := item[name].(*types.AttributeValueMemberM)
+	if !ok {
+		return nil
+	}
+	values := make(map[string]string, len(value.Value))
+	for key, v := range value.Value {
+		if s, ok := v.(*types.AttributeValueMemberS); ok {
+			values[key] = s.Value
+		}
+	}
+	return values
+}
+
+// openInventory loads the latest scans of the server and every tenant from
+// the table name, and saves theirs to it from then on.
+func openInventory(ctx context.Context, name, region string) error {
+	cfg, err := loadAWSConfig()
+	if err != nil {
+		return err
+	}
+	if region != "" {
+		cfg.Region = region
+	}
+	table := newInventoryTable(dynamodb.NewFromConfig(cfg), name)
+
+	stores := make(map[string]*scanStore)
+	for _, t := range scheduledTenants() {
+		stores[t.name] = t.latestScan
+	}
+	if err := table.Load(ctx, stores); err != nil {
+		var notFound *types.ResourceNotFoundException
+		if errors.As(err, &notFound) {
+			return fmt.Errorf("table %s doesn't exist; create it with partition key pk and sort key sk, both strings", name)
+		}
+		return err
+	}
+	for tenantName, store := range stores {
+		store.persist(table, tenantName)
+	}
+	inventory = table
+	return nil
+}
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// resourcesETag derives an ETag from the JSON encoding of the response and
+// variant, which names the output format and anything else that changes
+// the output. It is weak because gzip may change the bytes on the
+// wire without changing the content.
+func resourcesETag(variant string, response ListResourcesResponse, p *projection) string {
+	return encodedETag(variant, func(w io.Writer) error {
+		return encodeResourcesJSON(w, response, p)
+	})
+}
+
+// encodedETag is resourcesETag for a response written by encode.
+func encodedETag(variant string, encode func(w io.Writer) error) string {
+	h := sha256.New()
+	h.Write([]byte(variant + "\n"))
+	encode(h)
+	return `W/"` + hex.EncodeToString(h.Sum(nil)[:16]) + `"`
+}
+
+func jsonETag(v any) string {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return ""
+	}
+	sum := sha256.Sum256(data)
+	return `W/"` + hex.EncodeToString(sum[:16]) + `"`
+}
+
+// notModified sets the ETag header and, if the request is a GET or HEAD
+// whose If-None-Match already names etag, answers 304 and reports true.
+// Other methods always get the full response.
+func notModified(c *gin.Context, etag string) bool {
+	if etag == "" {
+		return false
+	}
+	c.Header("ETag", etag)
+
+	if method := c.Request.Method; method != http.MethodGet && method != http.MethodHead {
+		return false
+	}
+
+	for _, candidate := range strings.Split(c.GetHeader("If-None-Match"), ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || strings.TrimPrefix(candidate, "W/") == strings.TrimPrefix(etag, "W/") {
+			c.Status(http.StatusNotModified)
+			return true
+		}
+	}
+	return false
+}
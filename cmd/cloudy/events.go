@@ -0,0 +1,273 @@
+package main
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/aws/arn"
+	"github.com/aws/aws-sdk-go-v2/service/eventbridge"
+	eventbridgetypes "github.com/aws/aws-sdk-go-v2/service/eventbridge/types"
+	"github.com/aws/aws-sdk-go-v2/service/sns"
+	snstypes "github.com/aws/aws-sdk-go-v2/service/sns/types"
+	"github.com/aws/aws-sdk-go-v2/service/sqs"
+	sqstypes "github.com/aws/aws-sdk-go-v2/service/sqs/types"
+)
+
+const (
+	changeCreated = "created"
+	changeUpdated = "updated"
+	changeDeleted = "deleted"
+)
+
+// changeEventSource is the source of the events put on EventBridge.
+const changeEventSource = "cloudy"
+
+// changeBatchSize is the most entries SNS, SQS and EventBridge take in
+// one batch call.
+const changeBatchSize = 10
+
+// ChangeEvent is one resource's change between two full scans of its
+// region, as published to SNS, SQS and EventBridge.
+type ChangeEvent struct {
+	ID         string    `json:"id"`
+	Type       string    `json:"type"`
+	Tenant     string    `json:"tenant,omitempty"`
+	DetectedAt time.Time `json:"detected_at"`
+	// Resource is the resource as the latest scan listed it, or as the
+	// previous one did if it was deleted.
+	Resource Resource `json:"resource"`
+	// Before and ChangedFields are set for updates.
+	Before        *Resource `json:"before,omitempty"`
+	ChangedFields []string  `json:"changed_fields,omitempty"`
+}
+
+// changePublisher sends change events to one destination.
+type changePublisher interface {
+	publish(ctx context.Context, events []ChangeEvent) error
+	String() string
+}
+
+// changePublishers are sent the changes scheduled scans find, from
+// CLOUDY_EVENTS_SNS_TOPIC, CLOUDY_EVENTS_SQS_QUEUE and CLOUDY_EVENTS_BUS.
+var changePublishers []changePublisher
+
+// newChangeEvents turns t's changes into one event each.
+func newChangeEvents(t *tenant, changes ResourceChanges, detectedAt time.Time) []ChangeEvent {
+	var events []ChangeEvent
+	event := func(eventType string, resource Resource) ChangeEvent {
+		// An ID that can't be made only stops consumers telling repeats apart
+		id, _ := newScanID()
+		return ChangeEvent{ID: id, Type: eventType, Tenant: t.name, DetectedAt: detectedAt, Resource: resource}
+	}
+	for _, resource := range changes.Added {
+		events = append(events, event(changeCreated, resource))
+	}
+	for _, changed := range changes.Changed {
+		e := event(changeUpdated, changed.After)
+		before := changed.Before
+		e.Before = &before
+		e.ChangedFields = changedFields(changed.Before, changed.After)
+		events = append(events, e)
+	}
+	for _, resource := range changes.Removed {
+		events = append(events, event(changeDeleted, resource))
+	}
+	return events
+}
+
+// publishChanges sends an event for each of scan's changes to every
+// destination. Failures are logged; the next scan doesn't send them
+// again.
+func publishChanges(ctx context.Context, scan scheduledScan) {
+	if len(changePublishers) == 0 || scan.changes.empty() {
+		return
+	}
+	events := newChangeEvents(scan.tenant, scan.changes, scan.finishedAt)
+	for _, publisher := range changePublishers {
+		for start := 0; start < len(events); start += changeBatchSize {
+			if err := publisher.publish(ctx, events[start:min(start+changeBatchSize, len(events))]); err != nil {
+				log.Printf("Failed to publish change events%s to %s: %v", tenantSuffix(scan.tenant), publisher, err)
+			}
+		}
+	}
+}
+
+// newChangePublishers sets up the destinations configured, each with the
+// server's AWS credentials in the destination's own region.
+func newChangePublishers(topicARN, queueURL, bus string) ([]changePublisher, error) {
+	var publishers []changePublisher
+	if topicARN != "" {
+		parsed, err := arn.Parse(topicARN)
+		if err != nil {
+			return nil, fmt.Errorf("CLOUDY_EVENTS_SNS_TOPIC must be a topic ARN: %w", err)
+		}
+		cfg, err := loadAWSConfig()
+		if err != nil {
+			return nil, err
+		}
+		cfg.Region = parsed.Region
+		publishers = append(publishers, snsPublisher{client: sns.NewFromConfig(cfg), topic: topicARN})
+	}
+	if queueURL != "" {
+		parsed, err := url.Parse(queueURL)
+		if err != nil || parsed.Host == "" {
+			return nil, fmt.Errorf("CLOUDY_EVENTS_SQS_QUEUE must be a queue URL, got %q", queueURL)
+		}
+		cfg, err := loadAWSConfig()
+		if err != nil {
+			return nil, err
+		}
+		// sqs.<region>.amazonaws.com
+		if parts := strings.Split(parsed.Host, "."); len(parts) > 2 && parts[0] == "sqs" {
+			cfg.Region = parts[1]
+		}
+		publishers = append(publishers, sqsPublisher{client: sqs.NewFromConfig(cfg), queue: queueURL})
+	}
+	if bus != "" {
+		cfg, err := loadAWSConfig()
+		if err != nil {
+			return nil, err
+		}
+		if parsed, err := arn.Parse(bus); err == nil {
+			cfg.Region = parsed.Region
+		}
+		publishers = append(publishers, eventBridgePublisher{client: eventbridge.NewFromConfig(cfg), bus: bus})
+	}
+	return publishers, nil
+}
+
+// changeDetailTypes are the EventBridge detail types of each change.
+var changeDetailTypes = map[string]string{
+	changeCreated: "Resource Created",
+	changeUpdated: "Resource Updated",
+	changeDeleted: "Resource Deleted",
+}
+
+type snsPublisher struct {
+	client *sns.Client
+	topic  string
+}
+
+func (p snsPublisher) String() string { return p.topic }
+
+// publish sends each event as a message with event_type and
+// resource_type attributes, for subscription filter policies.
+func (p snsPublisher) publish(ctx context.Context, events []ChangeEvent) error {
+	entries := make([]snstypes.PublishBatchRequestEntry, len(events))
+	for i, event := range events {
+		body, err := json.Marshal(event)
+		if err != nil {
+			return err
+		}
+		entries[i] = snstypes.PublishBatchRequestEntry{
+			Id:      aws.String(strconv.Itoa(i)),
+			Message: aws.String(string(body)),
+			MessageAttributes: map[string]snstypes.MessageAttributeValue{
+				"event_type":    {DataType: aws.String("String"), StringValue: aws.String(event.Type)},
+				"resource_type": {DataType: aws.String("String"), StringValue: aws.String(event.Resource.Type)},
+			},
+		}
+	}
+	out, err := p.client.PublishBatch(ctx, &sns.PublishBatchInput{TopicArn: aws.String(p.topic), PublishBatchRequestEntries: entries})
+	if err != nil {
+		return err
+	}
+	if len(out.Failed) > 0 {
+		return fmt.Errorf("%d of %d events failed: %s", len(out.Failed), len(events), aws.ToString(out.Failed[0].Message))
+	}
+	return nil
+}
+
+type sqsPublisher struct {
+	client *sqs.Client
+	queue  string
+}
+
+func (p sqsPublisher) String() string { return p.queue }
+
+// publish sends each event as a message with event_type and
+// resource_type attributes.
+func (p sqsPublisher) publish(ctx context.Context, events []ChangeEvent) error {
+	entries := make([]sqstypes.SendMessageBatchRequestEntry, len(events))
+	for i, event := range events {
+		body, err := json.Marshal(event)
+		if err != nil {
+			return err
+		}
+		entries[i] = sqstypes.SendMessageBatchRequestEntry{
+			Id:          aws.String(strconv.Itoa(i)),
+			MessageBody: aws.String(string(body)),
+			MessageAttributes: map[string]sqstypes.MessageAttributeValue{
+				"event_type":    {DataType: aws.String("String"), StringValue: aws.String(event.Type)},
+				"resource_type": {DataType: aws.String("String"), StringValue: aws.String(event.Resource.Type)},
+			},
+		}
+		// FIFO queues order each resource's events in a group of its own,
+		// named by a hash as IDs can be longer than groups allow, and
+		// deduplicate retries by event ID
+		if strings.HasSuffix(p.queue, ".fifo") {
+			group := sha256.Sum256([]byte(event.Resource.ID))
+			entries[i].MessageGroupId = aws.String(hex.EncodeToString(group[:]))
+			entries[i].MessageDeduplicationId = aws.String(event.ID)
+		}
+	}
+	out, err := p.client.SendMessageBatch(ctx, &sqs.SendMessageBatchInput{QueueUrl: aws.String(p.queue), Entries: entries})
+	if err != nil {
+		return err
+	}
+	if len(out.Failed) > 0 {
+		return fmt.Errorf("%d of %d events failed: %s", len(out.Failed), len(events), aws.ToString(out.Failed[0].Message))
+	}
+	return nil
+}
+
+type eventBridgePublisher struct {
+	client *eventbridge.Client
+	bus    string
+}
+
+func (p eventBridgePublisher) String() string { return p.bus }
+
+// publish puts each event on the bus with source cloudy and a detail
+// type per change, naming the resource's ARN where it has one.
+func (p eventBridgePublisher) publish(ctx context.Context, events []ChangeEvent) error {
+	entries := make([]eventbridgetypes.PutEventsRequestEntry, len(events))
+	for i, event := range events {
+		detail, err := json.Marshal(event)
+		if err != nil {
+			return err
+		}
+		entries[i] = eventbridgetypes.PutEventsRequestEntry{
+			EventBusName: aws.String(p.bus),
+			Source:       aws.String(changeEventSource),
+			DetailType:   aws.String(changeDetailTypes[event.Type]),
+			Detail:       aws.String(string(detail)),
+			Time:         aws.Time(event.DetectedAt),
+		}
+		if arn.IsARN(event.Resource.ID) {
+			entries[i].Resources = []string{event.Resource.ID}
+		}
+	}
+	out, err := p.client.PutEvents(ctx, &eventbridge.PutEventsInput{Entries: entries})
+	if err != nil {
+		return err
+	}
+	if out.FailedEntryCount > 0 {
+		for _, entry := range out.Entries {
+			if entry.ErrorCode != nil {
+				return fmt.Errorf("%d of %d events failed: %s", out.FailedEntryCount, len(events), aws.ToString(entry.ErrorMessage))
+			}
+		}
+		return fmt.Errorf("%d of %d events failed", out.FailedEntryCount, len(events))
+	}
+	return nil
+}
@@ -0,0 +1,130 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+)
+
+// exportFormats are the formats snapshots can be exported in, each with
+// the file extension and content type of its objects.
+var exportFormats = map[string]struct {
+	extension   string
+	contentType string
+	encode      func(out io.Writer, response ListResourcesResponse, scannedAt time.Time) error
+}{
+	formatJSON: {"json", "application/json", func(out io.Writer, response ListResourcesResponse, _ time.Time) error {
+		return encodeResourcesJSON(out, response, nil)
+	}},
+	formatNDJSON: {"ndjson", mimeNDJSON, encodeSnapshotNDJSON},
+	formatParquet: {"parquet", mimeParquet, func(out io.Writer, response ListResourcesResponse, scannedAt time.Time) error {
+		return encodeResourcesParquet(out, response, nil, scannedAt)
+	}},
+}
+
+// snapshotExport writes scheduled scans' snapshots to an S3 bucket, from
+// CLOUDY_EXPORT_BUCKET, CLOUDY_EXPORT_PREFIX and CLOUDY_EXPORT_FORMATS.
+// Exports are off while client is nil.
+var snapshotExport struct {
+	client  *s3.Client
+	bucket  string
+	prefix  string
+	formats []string
+}
+
+// newSnapshotExport sets up exports to bucket with the server's AWS
+// credentials, in region, or the configured one if it is "".
+func newSnapshotExport(bucket, prefix, region string, formats []string) error {
+	for _, format := range formats {
+		if _, ok := exportFormats[format]; !ok {
+			return fmt.Errorf("unknown export format %q; expected json, ndjson or parquet", format)
+		}
+	}
+	cfg, err := loadAWSConfig()
+	if err != nil {
+		return err
+	}
+	if region != "" {
+		cfg.Region = region
+	}
+	snapshotExport.client = s3.NewFromConfig(cfg)
+	snapshotExport.bucket = bucket
+	snapshotExport.prefix = strings.Trim(prefix, "/")
+	snapshotExport.formats = formats
+	return nil
+}
+
+// exportSnapshot writes scan's snapshot to the export bucket in every
+// export format. Regions listed with errors are written with what was
+// listed, as they are returned by the API.
+func exportSnapshot(ctx context.Context, scan scheduledScan) {
+	if snapshotExport.client == nil {
+		return
+	}
+	response := ListResourcesResponse{RegionData: scan.regionData}
+	for _, rd := range scan.regionData {
+		response.TotalCount += len(rd.Resources)
+	}
+
+	for _, format := range snapshotExport.formats {
+		exporter := exportFormats[format]
+		var buf bytes.Buffer
+		if err := exporter.encode(&buf, response, scan.finishedAt); err != nil {
+			log.Printf("Failed to encode %s snapshot%s: %v", format, tenantSuffix(scan.tenant), err)
+			continue
+		}
+		key := snapshotKey(snapshotExport.prefix, format, scan.tenant, scan.finishedAt, exporter.extension)
+		_, err := snapshotExport.client.PutObject(ctx, &s3.PutObjectInput{
+			Bucket:      aws.String(snapshotExport.bucket),
+			Key:         aws.String(key),
+			Body:        bytes.NewReader(buf.Bytes()),
+			ContentType: aws.String(exporter.contentType),
+		})
+		if err != nil {
+			log.Printf("Failed to export snapshot%s to s3://%s/%s: %v", tenantSuffix(scan.tenant), snapshotExport.bucket, key, err)
+		}
+	}
+}
+
+// snapshotKey returns the key of the snapshot of t taken at at, such as
+// cloudy/ndjson/tenant=payments/dt=2024-01-01/snapshot-20240101T130000Z.ndjson.
+// Each format has its own prefix, for one Athena table each, and tenant
+// and dt are Hive-style partitions.
+func snapshotKey(prefix, format string, t *tenant, at time.Time, extension string) string {
+	parts := []string{format}
+	if prefix != "" {
+		parts = append([]string{prefix}, parts...)
+	}
+	if t != serverTenant {
+		parts = append(parts, "tenant="+t.name)
+	}
+	at = at.UTC()
+	parts = append(parts, "dt="+at.Format(time.DateOnly), "snapshot-"+at.Format("20060102T150405Z")+"."+extension)
+	return strings.Join(parts, "/")
+}
+
+// encodeSnapshotNDJSON writes one resource per line, each with the
+// snapshot's scanned_at as Parquet rows have.
+func encodeSnapshotNDJSON(out io.Writer, response ListResourcesResponse, scannedAt time.Time) error {
+	encoder := json.NewEncoder(out)
+	for _, rd := range response.RegionData {
+		for _, resource := range rd.Resources {
+			line := struct {
+				Resource
+				ScannedAt time.Time `json:"scanned_at"`
+			}{resource, scannedAt}
+			if err := encoder.Encode(line); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
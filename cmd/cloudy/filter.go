@@ -0,0 +1,156 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/alwindoss/cloudy/pkg/cloudy"
+)
+
+// matchesTagFilters reports whether a resource carries every tag in
+// filters. A filter value of "*" only requires the tag key to be present.
+func matchesTagFilters(resource Resource, filters map[string]string) bool {
+	for key, want := range filters {
+		value, ok := resource.Tags[key]
+		if !ok {
+			return false
+		}
+		if want != "*" && value != want {
+			return false
+		}
+	}
+	return true
+}
+
+// matchesAny reports whether value is one of values, treating an empty
+// list as matching everything.
+func matchesAny(value string, values []string) bool {
+	if len(values) == 0 {
+		return true
+	}
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// matchesState reports whether a resource's state is one of states,
+// ignoring case since services differ ("running", "available", "ACTIVE").
+// Resources without a state never match a non-empty list.
+func matchesState(state string, states []string) bool {
+	if len(states) == 0 {
+		return true
+	}
+	for _, s := range states {
+		if strings.EqualFold(s, state) {
+			return true
+		}
+	}
+	return false
+}
+
+// globRegexp translates a glob such as "payments-*" into an anchored
+// regular expression: * matches any run of characters (including "/"),
+// ? matches one, and everything else is literal.
+func globRegexp(glob string) string {
+	var b strings.Builder
+	b.WriteString("^")
+	for _, r := range glob {
+		switch r {
+		case '*':
+			b.WriteString(".*")
+		case '?':
+			b.WriteString(".")
+		default:
+			b.WriteString(regexp.QuoteMeta(string(r)))
+		}
+	}
+	b.WriteString("$")
+	return b.String()
+}
+
+// nameMatcher compiles the request's name_pattern and name_regex into one
+// predicate. It returns nil when neither is set.
+func nameMatcher(req RegionsRequest) (func(string) bool, error) {
+	var patterns []*regexp.Regexp
+	if req.NamePattern != "" {
+		patterns = append(patterns, regexp.MustCompile(globRegexp(req.NamePattern)))
+	}
+	if req.NameRegex != "" {
+		re, err := regexp.Compile(req.NameRegex)
+		if err != nil {
+			return nil, fmt.Errorf("invalid name_regex: %w", err)
+		}
+		patterns = append(patterns, re)
+	}
+	if len(patterns) == 0 {
+		return nil, nil
+	}
+
+	return func(name string) bool {
+		for _, re := range patterns {
+			if !re.MatchString(name) {
+				return false
+			}
+		}
+		return true
+	}, nil
+}
+
+// matchesKind reports whether kind is one of kinds, or in one of their
+// categories, treating an empty list as matching everything.
+func matchesKind(kind string, kinds []string) bool {
+	if len(kinds) == 0 {
+		return true
+	}
+	for _, want := range kinds {
+		if cloudy.MatchesKind(kind, want) {
+			return true
+		}
+	}
+	return false
+}
+
+// validateKinds rejects kinds, or categories, no resource type has.
+func validateKinds(kinds []string) error {
+	for _, kind := range kinds {
+		if len(cloudy.KindTypes(kind)) == 0 {
+			return fmt.Errorf("unknown kind %q; expected one of %s, or a category like compute", kind, strings.Join(cloudy.Kinds(), ", "))
+		}
+	}
+	return nil
+}
+
+// scanTypes returns the types to have the Scanner list for req: its types,
+// or those of its kinds when it only gives kinds.
+func scanTypes(req RegionsRequest) []string {
+	if len(req.Types) == 0 && len(req.Kinds) > 0 {
+		return cloudy.KindTypes(req.Kinds...)
+	}
+	return req.Types
+}
+
+// filterResources returns the resources that pass the request's filters,
+// with matchesName the request's nameMatcher, compiled once for all its
+// regions. Some listers produce several types (e.g. Lambda functions and
+// aliases), so the type filter is applied here as well as when choosing
+// listers.
+func filterResources(resources []Resource, req RegionsRequest, matchesName func(string) bool) []Resource {
+	if len(req.TagFilters) == 0 && len(req.Types) == 0 && len(req.Kinds) == 0 && len(req.States) == 0 && matchesName == nil {
+		return resources
+	}
+
+	filtered := make([]Resource, 0, len(resources))
+	for _, resource := range resources {
+		if matchesName != nil && !matchesName(resource.Name) {
+			continue
+		}
+		if matchesAny(resource.Type, req.Types) && matchesKind(resource.Kind, req.Kinds) && matchesState(resource.State, req.States) && matchesTagFilters(resource, req.TagFilters) {
+			filtered = append(filtered, resource)
+		}
+	}
+	return filtered
+}
@@ -0,0 +1,144 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestNameMatcher(t *testing.T) {
+	tests := []struct {
+		name    string
+		req     RegionsRequest
+		match   []string
+		noMatch []string
+	}{
+		{
+			name:    "star matches any run, slashes included",
+			req:     RegionsRequest{NamePattern: "payments-*"},
+			match:   []string{"payments-", "payments-api", "payments-api/v2"},
+			noMatch: []string{"payments", "old-payments-api"},
+		},
+		{
+			name:    "question mark matches one character",
+			req:     RegionsRequest{NamePattern: "web-?"},
+			match:   []string{"web-1", "web-a"},
+			noMatch: []string{"web-", "web-10"},
+		},
+		{
+			name:    "dot is literal",
+			req:     RegionsRequest{NamePattern: "logs.example.com"},
+			match:   []string{"logs.example.com"},
+			noMatch: []string{"logsXexampleXcom"},
+		},
+		{
+			name:    "brackets are literal",
+			req:     RegionsRequest{NamePattern: "db[1]-*"},
+			match:   []string{"db[1]-primary"},
+			noMatch: []string{"db1-primary"},
+		},
+		{
+			name:    "regex is unanchored",
+			req:     RegionsRequest{NameRegex: "prod|staging"},
+			match:   []string{"api-prod", "staging-db"},
+			noMatch: []string{"dev"},
+		},
+		{
+			name:    "both filters must match",
+			req:     RegionsRequest{NamePattern: "api-*", NameRegex: "-v[0-9]+$"},
+			match:   []string{"api-orders-v2"},
+			noMatch: []string{"api-orders", "web-orders-v2"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			matches, err := nameMatcher(tt.req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, name := range tt.match {
+				if !matches(name) {
+					t.Errorf("%q didn't match", name)
+				}
+			}
+			for _, name := range tt.noMatch {
+				if matches(name) {
+					t.Errorf("%q matched", name)
+				}
+			}
+		})
+	}
+}
+
+func TestNameMatcherUnset(t *testing.T) {
+	matches, err := nameMatcher(RegionsRequest{})
+	if err != nil || matches != nil {
+		t.Errorf("nameMatcher without filters returned a matcher or %v, want neither", err)
+	}
+}
+
+func TestNameMatcherInvalidRegex(t *testing.T) {
+	if _, err := nameMatcher(RegionsRequest{NameRegex: "("}); err == nil {
+		t.Error("nameMatcher accepted an invalid name_regex")
+	}
+}
+
+func TestFilterResourcesByName(t *testing.T) {
+	resources := []Resource{
+		{ID: "1", Type: "EC2 Instance", Name: "api-prod"},
+		{ID: "2", Type: "EC2 Instance", Name: "api-dev"},
+		{ID: "3", Type: "S3 Bucket", Name: "api-prod-logs"},
+		{ID: "4", Type: "EC2 Instance", Name: "web-prod"},
+	}
+	req := RegionsRequest{Types: []string{"EC2 Instance"}, NamePattern: "api-*", NameRegex: "prod"}
+	matchesName, err := nameMatcher(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var got []string
+	for _, resource := range filterResources(resources, req, matchesName) {
+		got = append(got, resource.ID)
+	}
+	if want := []string{"1"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("filtered = %v, want %v", got, want)
+	}
+}
+
+func TestInvalidNameRegexRejected(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	handlers := map[string]gin.HandlerFunc{
+		"resources": listResourcesQuery,
+		"summary":   summarizeResources,
+	}
+	for name, handler := range handlers {
+		t.Run(name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Request = httptest.NewRequest(http.MethodGet, "/?regions=us-east-1&name_regex=(", nil)
+
+			handler(c)
+			if w.Code != http.StatusBadRequest {
+				t.Errorf("status = %d, want %d", w.Code, http.StatusBadRequest)
+			}
+		})
+	}
+}
+
+func TestStreamRegionsInvalidNameRegex(t *testing.T) {
+	req := RegionsRequest{Regions: []string{"us-east-1", "eu-west-1"}, NameRegex: "("}
+	var regions []string
+	for rd := range (&ResourceLister{}).streamRegions(context.Background(), req) {
+		if rd.Error == "" || len(rd.Resources) != 0 {
+			t.Errorf("%s = %d resources, error %q; want none and the regex's error", rd.Region, len(rd.Resources), rd.Error)
+		}
+		regions = append(regions, rd.Region)
+	}
+	if !reflect.DeepEqual(regions, req.Regions) {
+		t.Errorf("regions = %v, want %v", regions, req.Regions)
+	}
+}
@@ -0,0 +1,298 @@
+package main
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+)
+
+const (
+	formatJSON    = "json"
+	formatCSV     = "csv"
+	formatXLSX    = "xlsx"
+	formatNDJSON  = "ndjson"
+	formatParquet = "parquet"
+	formatYAML    = "yaml"
+	formatDOT     = "dot"
+	formatCypher  = "cypher"
+
+	mimeXLSX   = "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+	mimeNDJSON = "application/x-ndjson"
+)
+
+// responseFormat picks the output format of the resources endpoint from the
+// format query parameter, falling back to the Accept header.
+func responseFormat(c *gin.Context) (string, error) {
+	if format := c.Query("format"); format != "" {
+		switch format {
+		case formatJSON, formatCSV, formatXLSX, formatNDJSON, formatParquet, formatYAML, formatDOT, formatCypher:
+			return format, nil
+		}
+		return "", fmt.Errorf("unsupported format %q", format)
+	}
+
+	switch c.NegotiateFormat(gin.MIMEJSON, "text/csv", mimeXLSX, mimeNDJSON, mimeParquet, gin.MIMEYAML, binding.MIMEYAML2, mimeDOT) {
+	case "text/csv":
+		return formatCSV, nil
+	case mimeXLSX:
+		return formatXLSX, nil
+	case mimeNDJSON:
+		return formatNDJSON, nil
+	case mimeParquet:
+		return formatParquet, nil
+	case gin.MIMEYAML, binding.MIMEYAML2:
+		return formatYAML, nil
+	case mimeDOT:
+		return formatDOT, nil
+	}
+	return formatJSON, nil
+}
+
+// writeResourcesCSV writes one row per resource, with a tag:<key> and an
+// attr:<key> column for every tag and attribute key present in the result.
+// The total count and next page token go in X-Total-Count and X-Next-Token.
+func writeResourcesCSV(c *gin.Context, response ListResourcesResponse, p *projection) {
+	var resources []Resource
+	for _, rd := range response.RegionData {
+		resources = append(resources, rd.Resources...)
+	}
+	columns := newResourceColumns(resources, p, true)
+
+	setExportHeaders(c, response, "resources.csv")
+	c.Status(http.StatusOK)
+	c.Writer.Header().Set("Content-Type", "text/csv; charset=utf-8")
+
+	w := csv.NewWriter(c.Writer)
+	w.Write(columns.header())
+	for _, resource := range resources {
+		w.Write(columns.row(resource))
+	}
+	w.Flush()
+}
+
+// writeResourcesJSON encodes the response one resource at a time rather
+// than marshaling it whole, so a large inventory isn't held in memory a
+// second time as encoded JSON. The output is the same as json.Marshal's.
+func writeResourcesJSON(c *gin.Context, response ListResourcesResponse, p *projection) {
+	c.Header("Content-Type", "application/json; charset=utf-8")
+	c.Status(http.StatusOK)
+	encodeResourcesJSON(c.Writer, response, p)
+}
+
+func encodeResourcesJSON(out io.Writer, response ListResourcesResponse, p *projection) error {
+	w := bufio.NewWriterSize(out, 64*1024)
+
+	w.WriteString(`{"region_data":`)
+	if response.RegionData == nil {
+		w.WriteString("null")
+	} else {
+		w.WriteByte('[')
+		for i, rd := range response.RegionData {
+			if i > 0 {
+				w.WriteByte(',')
+			}
+			if err := writeRegionJSON(w, rd, p); err != nil {
+				return err
+			}
+		}
+		w.WriteByte(']')
+	}
+	fmt.Fprintf(w, `,"total_count":%d`, response.TotalCount)
+	if response.NextToken != "" {
+		w.WriteString(`,"next_token":`)
+		writeJSONValue(w, response.NextToken)
+	}
+	w.WriteByte('}')
+	return w.Flush()
+}
+
+func writeRegionJSON(w *bufio.Writer, rd RegionResources, p *projection) error {
+	return writeRegionFieldsJSON(w, rd, rd.Resources == nil, func() error {
+		for i, resource := range rd.Resources {
+			if i > 0 {
+				w.WriteByte(',')
+			}
+			if err := writeJSONValue(w, p.value(resource)); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// writeRegionFieldsJSON writes rd with writeResources filling in its
+// resources array, which is null instead if nilResources.
+func writeRegionFieldsJSON(w *bufio.Writer, rd RegionResources, nilResources bool, writeResources func() error) error {
+	w.WriteString(`{"region":`)
+	writeJSONValue(w, rd.Region)
+	w.WriteString(`,"resources":`)
+	if nilResources {
+		w.WriteString("null")
+	} else {
+		w.WriteByte('[')
+		if err := writeResources(); err != nil {
+			return err
+		}
+		w.WriteByte(']')
+	}
+	if rd.Error != "" {
+		w.WriteString(`,"error":`)
+		writeJSONValue(w, rd.Error)
+	}
+	if len(rd.Errors) > 0 {
+		w.WriteString(`,"errors":`)
+		if err := writeJSONValue(w, rd.Errors); err != nil {
+			return err
+		}
+	}
+	if rd.Throttles != 0 {
+		fmt.Fprintf(w, `,"throttles":%d`, rd.Throttles)
+	}
+	if rd.CostError != "" {
+		w.WriteString(`,"cost_error":`)
+		writeJSONValue(w, rd.CostError)
+	}
+	w.WriteByte('}')
+	return nil
+}
+
+func writeJSONValue(w *bufio.Writer, v any) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(data)
+	return err
+}
+
+// streamResourcesNDJSON writes one JSON resource per line, flushing each
+// region's resources as soon as that region has been scanned. Regions that
+// failed are listed in the X-Scan-Errors trailer as region=error pairs.
+func streamResourcesNDJSON(c *gin.Context, lister *ResourceLister, req RegionsRequest, p *projection) {
+	c.Header("Trailer", "X-Scan-Errors")
+	c.Header("Content-Type", mimeNDJSON)
+	c.Status(http.StatusOK)
+
+	var scanErrors []string
+	encoder := json.NewEncoder(c.Writer)
+	for rd := range lister.streamRegions(c.Request.Context(), req) {
+		if rd.Error != "" {
+			scanErrors = append(scanErrors, rd.Region+"="+rd.Error)
+		}
+		for _, resource := range rd.Resources {
+			if err := encoder.Encode(p.value(resource)); err != nil {
+				return
+			}
+		}
+		c.Writer.Flush()
+	}
+
+	sort.Strings(scanErrors)
+	c.Writer.Header().Set("X-Scan-Errors", strings.Join(scanErrors, "; "))
+}
+
+func setExportHeaders(c *gin.Context, response ListResourcesResponse, filename string) {
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+	c.Header("X-Total-Count", strconv.Itoa(response.TotalCount))
+	if response.NextToken != "" {
+		c.Header("X-Next-Token", response.NextToken)
+	}
+}
+
+// resourceColumns flattens resources into rows for tabular formats.
+type resourceColumns struct {
+	projection *projection
+	base       []string
+	tags       []string
+	attributes []string
+}
+
+// newResourceColumns picks the columns for resources: the selected
+// top-level fields (type only if withType), then one column per tag and
+// attribute key present.
+func newResourceColumns(resources []Resource, p *projection, withType bool) resourceColumns {
+	var base []string
+	for _, field := range []string{"id", "name", "type", "kind", "state", "region", "provider", "partition", "account_id", "account_name"} {
+		if (field != "type" || withType) && p.keeps(field) {
+			base = append(base, field)
+		}
+	}
+
+	tagKeys := map[string]bool{}
+	attributeKeys := map[string]bool{}
+	for _, resource := range resources {
+		resource = p.apply(resource)
+		for key := range resource.Tags {
+			tagKeys[key] = true
+		}
+		for key := range resource.Attributes {
+			attributeKeys[key] = true
+		}
+	}
+	return resourceColumns{projection: p, base: base, tags: sortedKeys(tagKeys), attributes: sortedKeys(attributeKeys)}
+}
+
+func (cols resourceColumns) header() []string {
+	header := append([]string(nil), cols.base...)
+	for _, key := range cols.tags {
+		header = append(header, "tag:"+key)
+	}
+	for _, key := range cols.attributes {
+		header = append(header, "attr:"+key)
+	}
+	return header
+}
+
+func (cols resourceColumns) row(resource Resource) []string {
+	resource = cols.projection.apply(resource)
+	row := make([]string, 0, len(cols.base)+len(cols.tags)+len(cols.attributes))
+	for _, field := range cols.base {
+		switch field {
+		case "id":
+			row = append(row, resource.ID)
+		case "name":
+			row = append(row, resource.Name)
+		case "type":
+			row = append(row, resource.Type)
+		case "kind":
+			row = append(row, resource.Kind)
+		case "state":
+			row = append(row, resource.State)
+		case "region":
+			row = append(row, resource.Region)
+		case "provider":
+			row = append(row, resource.Provider)
+		case "partition":
+			row = append(row, resource.Partition)
+		case "account_id":
+			row = append(row, resource.AccountID)
+		case "account_name":
+			row = append(row, resource.AccountName)
+		}
+	}
+	for _, key := range cols.tags {
+		row = append(row, resource.Tags[key])
+	}
+	for _, key := range cols.attributes {
+		row = append(row, resource.Attributes[key])
+	}
+	return row
+}
+
+func sortedKeys(set map[string]bool) []string {
+	keys := make([]string, 0, len(set))
+	for key := range set {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,264 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// grafanaTargets are the series Grafana's JSON datasources can chart:
+// resource counts, from the trends full scans keep, and the changes
+// scheduled scans find. "by" splits a series into one per value.
+var grafanaTargets = []string{
+	"resources",
+	"resources by type",
+	"resources by region",
+	"drift",
+	"drift by change",
+	"drift by type",
+	"drift by region",
+}
+
+// GrafanaQueryRequest is the body the Simple JSON and Infinity
+// datasources post to /query.
+type GrafanaQueryRequest struct {
+	Range struct {
+		From time.Time `json:"from"`
+		To   time.Time `json:"to"`
+	} `json:"range"`
+	IntervalMs int64           `json:"intervalMs"`
+	Targets    []GrafanaTarget `json:"targets"`
+}
+
+// GrafanaTarget is one query of a panel.
+type GrafanaTarget struct {
+	Target string `json:"target"`
+	RefID  string `json:"refId,omitempty"`
+	// Type is timeserie, the default, or table.
+	Type string `json:"type,omitempty"`
+	Hide bool   `json:"hide,omitempty"`
+	// Payload narrows the series, as {"type", "region", "change"}. Simple
+	// JSON sends it as data, and newer versions as payload, sometimes
+	// encoded as a string.
+	Payload json.RawMessage `json:"payload,omitempty"`
+	Data    json.RawMessage `json:"data,omitempty"`
+}
+
+// GrafanaFilter narrows a target's series.
+type GrafanaFilter struct {
+	Type   string `json:"type,omitempty"`
+	Region string `json:"region,omitempty"`
+	Change string `json:"change,omitempty"`
+}
+
+// GrafanaSeries is a time series, each point [value, unix milliseconds].
+type GrafanaSeries struct {
+	Target     string       `json:"target"`
+	Datapoints [][2]float64 `json:"datapoints"`
+}
+
+// GrafanaTable is a target answered as a table.
+type GrafanaTable struct {
+	Type    string          `json:"type"`
+	Columns []GrafanaColumn `json:"columns"`
+	Rows    [][]any         `json:"rows"`
+}
+
+type GrafanaColumn struct {
+	Text string `json:"text"`
+	Type string `json:"type"`
+}
+
+// grafanaSearch lists the targets whose name contains the text searched
+// for, or all of them.
+func grafanaSearch(c *gin.Context) {
+	var req struct {
+		Target string `json:"target"`
+	}
+	// Older versions post no body
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+	targets := []string{}
+	for _, target := range grafanaTargets {
+		if strings.Contains(target, strings.ToLower(req.Target)) {
+			targets = append(targets, target)
+		}
+	}
+	c.JSON(http.StatusOK, targets)
+}
+
+// grafanaQuery answers each target with its series over the requested
+// range, or with a table of each series' latest count for resources and
+// total for drift.
+func grafanaQuery(c *gin.Context) {
+	var req GrafanaQueryRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if req.Range.To.IsZero() {
+		req.Range.To = time.Now().UTC()
+	}
+	if req.Range.From.IsZero() || req.Range.From.After(req.Range.To) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "range.from must be set and no later than range.to"})
+		return
+	}
+	// Trends don't go finer than an hour
+	interval := max(time.Duration(req.IntervalMs)*time.Millisecond, trendResolution)
+
+	t := tenantFrom(c.Request.Context())
+	results := []any{}
+	for _, target := range req.Targets {
+		if target.Hide {
+			continue
+		}
+		filter, err := target.filter()
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		series, by, err := grafanaSeries(t, target.Target, filter, req.Range.From, req.Range.To, interval)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if target.Type != "table" {
+			for _, s := range series {
+				results = append(results, s)
+			}
+			continue
+		}
+
+		column := by
+		if column == "" {
+			column = "series"
+		}
+		table := GrafanaTable{
+			Type:    "table",
+			Columns: []GrafanaColumn{{Text: column, Type: "string"}, {Text: "count", Type: "number"}},
+			Rows:    [][]any{},
+		}
+		drift := strings.HasPrefix(target.Target, "drift")
+		for _, s := range series {
+			count := 0.0
+			for _, point := range s.Datapoints {
+				if drift {
+					count += point[0]
+				} else {
+					count = point[0]
+				}
+			}
+			table.Rows = append(table.Rows, []any{s.Target, count})
+		}
+		results = append(results, table)
+	}
+	c.JSON(http.StatusOK, results)
+}
+
+// filter decodes the target's payload, or its data.
+func (t GrafanaTarget) filter() (GrafanaFilter, error) {
+	raw := t.Payload
+	if len(raw) == 0 || string(raw) == "null" {
+		raw = t.Data
+	}
+	var filter GrafanaFilter
+	if len(raw) == 0 || string(raw) == "null" {
+		return filter, nil
+	}
+	var encoded string
+	if err := json.Unmarshal(raw, &encoded); err == nil {
+		if strings.TrimSpace(encoded) == "" {
+			return filter, nil
+		}
+		raw = json.RawMessage(encoded)
+	}
+	if err := json.Unmarshal(raw, &filter); err != nil {
+		return filter, fmt.Errorf("target %s: payload must be an object of type, region and change: %w", t.Target, err)
+	}
+	return filter, nil
+}
+
+// grafanaSeries returns target's series between from and to, and the
+// dimension it is split by, if any.
+func grafanaSeries(t *tenant, target string, filter GrafanaFilter, from, to time.Time, interval time.Duration) ([]GrafanaSeries, string, error) {
+	metric, by, _ := strings.Cut(target, " by ")
+	switch metric {
+	case "resources":
+		if filter.Change != "" {
+			return nil, "", fmt.Errorf("target %s: resources can't be filtered by change", target)
+		}
+		series := func(name, resourceType, region string) GrafanaSeries {
+			return grafanaPoints(name, t.trends.Series(resourceType, region, interval), from, to, interval)
+		}
+		types, regions := t.trends.dimensions()
+		switch by {
+		case "":
+			return []GrafanaSeries{series(metric, filter.Type, filter.Region)}, "", nil
+		case "type":
+			var all []GrafanaSeries
+			for _, resourceType := range types {
+				if filter.Type == "" || resourceType == filter.Type {
+					all = append(all, series(resourceType, resourceType, filter.Region))
+				}
+			}
+			return all, by, nil
+		case "region":
+			var all []GrafanaSeries
+			for _, region := range regions {
+				if filter.Region == "" || region == filter.Region {
+					all = append(all, series(region, filter.Type, region))
+				}
+			}
+			return all, by, nil
+		}
+	case "drift":
+		dimensions := map[string]func(driftSample) string{
+			"":       nil,
+			"change": func(s driftSample) string { return s.Change },
+			"type":   func(s driftSample) string { return s.Type },
+			"region": func(s driftSample) string { return s.Region },
+		}
+		value, ok := dimensions[by]
+		if !ok {
+			break
+		}
+		matches := func(s driftSample) bool {
+			return (filter.Type == "" || s.Type == filter.Type) &&
+				(filter.Region == "" || s.Region == filter.Region) &&
+				(filter.Change == "" || s.Change == filter.Change)
+		}
+		if value == nil {
+			return []GrafanaSeries{grafanaPoints(metric, t.drift.Series(matches, from, to, interval), from, to, interval)}, "", nil
+		}
+		var all []GrafanaSeries
+		for _, name := range t.drift.values(matches, value) {
+			points := t.drift.Series(func(s driftSample) bool { return matches(s) && value(s) == name }, from, to, interval)
+			all = append(all, grafanaPoints(name, points, from, to, interval))
+		}
+		return all, by, nil
+	}
+	return nil, "", fmt.Errorf("unknown target %q; expected one of %s", target, strings.Join(grafanaTargets, ", "))
+}
+
+// grafanaPoints converts the points in the intervals between from and to,
+// and the last one before them, so a count that hasn't changed since
+// still charts as a line.
+func grafanaPoints(name string, points []TrendPoint, from, to time.Time, interval time.Duration) GrafanaSeries {
+	series := GrafanaSeries{Target: name, Datapoints: [][2]float64{}}
+	for i, point := range points {
+		inRange := point.Time.Add(interval).After(from)
+		lastBefore := !inRange && (i+1 == len(points) || points[i+1].Time.Add(interval).After(from))
+		if (inRange || lastBefore) && !point.Time.After(to) {
+			series.Datapoints = append(series.Datapoints, [2]float64{float64(point.Count), float64(point.Time.UnixMilli())})
+		}
+	}
+	return series
+}
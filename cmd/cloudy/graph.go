@@ -0,0 +1,232 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+const mimeDOT = "text/vnd.graphviz"
+
+// graphRelation links resources of Types to the resource whose ID is in
+// their Attribute. With From, the link starts at the resource whose ID is
+// in that attribute instead, for relations the listers only record on
+// what depends on both, such as a subnet's VPC on its instances; the
+// From resource must be the target of an earlier relation. Targets that
+// weren't listed are still drawn, as stubs of type Stub.
+type graphRelation struct {
+	Types     []string
+	From      string
+	Attribute string
+	Relation  string
+	Stub      string
+}
+
+// graphRelations are the relations between resources graph exports draw.
+var graphRelations = []graphRelation{
+	{Types: []string{"EC2 Instance"}, Attribute: "subnet_id", Relation: "IN_SUBNET", Stub: "Subnet"},
+	{Types: []string{"EC2 Instance"}, From: "subnet_id", Attribute: "vpc_id", Relation: "IN_VPC", Stub: "VPC"},
+	{Types: []string{"NAT Gateway"}, Attribute: "subnet_id", Relation: "IN_SUBNET", Stub: "Subnet"},
+	{Types: []string{"NAT Gateway"}, From: "subnet_id", Attribute: "vpc_id", Relation: "IN_VPC", Stub: "VPC"},
+	{Types: []string{"EBS Volume"}, Attribute: "instance_id", Relation: "ATTACHED_TO", Stub: "EC2 Instance"},
+	{Types: []string{"Droplet", "DOKS Cluster", "DigitalOcean Load Balancer", "DigitalOcean Database"}, Attribute: "vpc_id", Relation: "IN_VPC", Stub: "VPC"},
+	{Types: []string{"Config Recorder"}, Attribute: "role_arn", Relation: "ASSUMES", Stub: "IAM Role"},
+	{Types: []string{"Lambda Event Source Mapping"}, Attribute: "event_source_arn", Relation: "READS_FROM", Stub: "Resource"},
+	{Types: []string{"Lambda Event Source Mapping"}, Attribute: "function_arn", Relation: "TRIGGERS", Stub: "Lambda Function"},
+	{Types: []string{"EventBridge Schedule"}, Attribute: "target_arn", Relation: "TARGETS", Stub: "Resource"},
+	{Types: []string{"Global Accelerator Listener"}, Attribute: "accelerator_arn", Relation: "BELONGS_TO", Stub: "Global Accelerator"},
+	{Types: []string{"CloudTrail Trail"}, Attribute: "s3_bucket", Relation: "LOGS_TO", Stub: "S3 Bucket"},
+	{Types: []string{"CloudTrail Trail"}, Attribute: "kms_key_id", Relation: "ENCRYPTED_BY", Stub: "KMS Key"},
+}
+
+// graphNode is a listed resource, or a stub standing in for one that
+// wasn't listed but is related to one that was.
+type graphNode struct {
+	Resource Resource
+	Stub     bool
+}
+
+type graphEdge struct {
+	From     string
+	To       string
+	Relation string
+}
+
+// resourceGraph is the resources of a scan and the relations between them.
+type resourceGraph struct {
+	Nodes []graphNode
+	Edges []graphEdge
+}
+
+// newResourceGraph links resources by graphRelations. Nodes are the
+// resources in order, then stubs by ID; edges are in the order found,
+// each once.
+func newResourceGraph(resources []Resource) resourceGraph {
+	var graph resourceGraph
+	listed := make(map[string]bool, len(resources))
+	for _, resource := range resources {
+		if !listed[resource.ID] {
+			listed[resource.ID] = true
+			graph.Nodes = append(graph.Nodes, graphNode{Resource: resource})
+		}
+	}
+
+	stubs := make(map[string]string)
+	edges := make(map[graphEdge]bool)
+	for _, resource := range resources {
+		for _, relation := range graphRelations {
+			if !matchesAny(resource.Type, relation.Types) {
+				continue
+			}
+			from, to := resource.ID, resource.Attributes[relation.Attribute]
+			if relation.From != "" {
+				from = resource.Attributes[relation.From]
+			}
+			if from == "" || to == "" || from == to {
+				continue
+			}
+			edge := graphEdge{From: from, To: to, Relation: relation.Relation}
+			if edges[edge] {
+				continue
+			}
+			edges[edge] = true
+			graph.Edges = append(graph.Edges, edge)
+			if !listed[to] {
+				stubs[to] = relation.Stub
+			}
+		}
+	}
+
+	ids := make([]string, 0, len(stubs))
+	for id := range stubs {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	for _, id := range ids {
+		graph.Nodes = append(graph.Nodes, graphNode{Resource: Resource{ID: id, Type: stubs[id]}, Stub: true})
+	}
+	return graph
+}
+
+// writeResourcesGraph writes the resources and their relations as a
+// Graphviz digraph or as Cypher statements for Neo4j.
+func writeResourcesGraph(c *gin.Context, format string, response ListResourcesResponse) {
+	var resources []Resource
+	for _, rd := range response.RegionData {
+		resources = append(resources, rd.Resources...)
+	}
+	graph := newResourceGraph(resources)
+
+	if format == formatDOT {
+		setExportHeaders(c, response, "resources.dot")
+		c.Header("Content-Type", mimeDOT+"; charset=utf-8")
+		c.Status(http.StatusOK)
+		graph.writeDOT(c.Writer)
+		return
+	}
+	setExportHeaders(c, response, "resources.cypher")
+	c.Header("Content-Type", "text/plain; charset=utf-8")
+	c.Status(http.StatusOK)
+	graph.writeCypher(c.Writer)
+}
+
+// writeDOT draws each node as a box labeled with its name and type, stubs
+// dashed, and each edge labeled with its relation.
+func (g resourceGraph) writeDOT(out io.Writer) error {
+	w := bufio.NewWriterSize(out, 64*1024)
+	w.WriteString("digraph cloudy {\n\trankdir=LR;\n\tnode [shape=box, fontname=\"Helvetica\"];\n\tedge [fontname=\"Helvetica\", fontsize=10];\n")
+	for _, node := range g.Nodes {
+		label := dotEscape(resourceLabel(node.Resource)) + `\n` + dotEscape(node.Resource.Type)
+		if node.Resource.Region != "" {
+			label += `\n` + dotEscape(node.Resource.Region)
+		}
+		fmt.Fprintf(w, "\t%s [label=\"%s\"", dotQuote(node.Resource.ID), label)
+		if node.Stub {
+			w.WriteString(", style=dashed")
+		}
+		w.WriteString("];\n")
+	}
+	for _, edge := range g.Edges {
+		fmt.Fprintf(w, "\t%s -> %s [label=%s];\n", dotQuote(edge.From), dotQuote(edge.To), dotQuote(edge.Relation))
+	}
+	w.WriteString("}\n")
+	return w.Flush()
+}
+
+// dotQuote quotes s as a DOT ID.
+func dotQuote(s string) string {
+	return `"` + dotEscape(s) + `"`
+}
+
+func dotEscape(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(s)
+}
+
+// writeCypher writes statements that merge every node into a :Resource
+// labeled with its type, keyed by id, and every edge between them, so
+// loading a later export updates the graph rather than duplicating it.
+// Tags and attributes become tag:<key> and attr:<key> properties, as
+// they are CSV columns.
+func (g resourceGraph) writeCypher(out io.Writer) error {
+	w := bufio.NewWriterSize(out, 64*1024)
+	w.WriteString("CREATE CONSTRAINT resource_id IF NOT EXISTS FOR (n:Resource) REQUIRE n.id IS UNIQUE;\n")
+	for _, node := range g.Nodes {
+		resource := node.Resource
+		fmt.Fprintf(w, "MERGE (n:Resource {id: %s}) SET n:%s", cypherString(resource.ID), cypherName(resource.Type))
+		if node.Stub {
+			w.WriteString(";\n")
+			continue
+		}
+
+		properties := []string{"name: " + cypherString(resource.Name), "type: " + cypherString(resource.Type)}
+		for _, field := range []struct{ name, value string }{
+			{"kind", resource.Kind},
+			{"state", resource.State},
+			{"region", resource.Region},
+			{"provider", resource.Provider},
+			{"partition", resource.Partition},
+			{"account_id", resource.AccountID},
+			{"account_name", resource.AccountName},
+		} {
+			if field.value != "" {
+				properties = append(properties, field.name+": "+cypherString(field.value))
+			}
+		}
+		for _, key := range sortedMapKeys(resource.Tags) {
+			properties = append(properties, cypherName("tag:"+key)+": "+cypherString(resource.Tags[key]))
+		}
+		for _, key := range sortedMapKeys(resource.Attributes) {
+			properties = append(properties, cypherName("attr:"+key)+": "+cypherString(resource.Attributes[key]))
+		}
+		fmt.Fprintf(w, ", n += {%s};\n", strings.Join(properties, ", "))
+	}
+	for _, edge := range g.Edges {
+		fmt.Fprintf(w, "MATCH (a:Resource {id: %s}), (b:Resource {id: %s}) MERGE (a)-[:%s]->(b);\n",
+			cypherString(edge.From), cypherString(edge.To), edge.Relation)
+	}
+	return w.Flush()
+}
+
+// cypherString quotes s as a Cypher string literal.
+func cypherString(s string) string {
+	return "'" + strings.NewReplacer(`\`, `\\`, `'`, `\'`, "\n", `\n`, "\r", `\r`).Replace(s) + "'"
+}
+
+// cypherName quotes s as a Cypher label or property name.
+func cypherName(s string) string {
+	return "`" + strings.ReplaceAll(s, "`", "``") + "`"
+}
+
+func sortedMapKeys(values map[string]string) []string {
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
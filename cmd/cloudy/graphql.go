@@ -0,0 +1,438 @@
+package main
+
+import (
+	"context"
+	"sort"
+
+	"github.com/gin-gonic/gin"
+	"github.com/graph-gophers/graphql-go"
+	"github.com/graph-gophers/graphql-go/relay"
+)
+
+const graphQLSchema = `
+schema {
+	query: Query
+}
+
+type Query {
+	# Scans the given regions, like POST /api/v1/resources; "all" expands to
+	# every region enabled for the account. provider defaults to aws
+	regions(names: [String!]!, types: [String!], kinds: [String!], states: [String!], tags: [TagFilter!], provider: String, credentials: Credentials): [Region!]!
+	# Looks a resource up by ID in the latest full scan
+	resource(id: ID!): Resource
+}
+
+# A tag filter; a missing value or "*" matches any value for the key
+input TagFilter {
+	key: String!
+	value: String
+}
+
+# Which of the server's credentials to scan with, as in the REST request
+input Credentials {
+	awsProfile: String
+	azureSubscriptionId: String
+	azureClientId: String
+	gcpProject: String
+	gcpKeyRef: String
+}
+
+type Region {
+	name: String!
+	error: String
+	# One entry per service that failed to list the region
+	errors: [ServiceError!]!
+	# AWS calls throttled and retried while scanning the region
+	throttles: Int!
+	# Why some of the region's resources have no cost estimate
+	costError: String
+	resourceCount: Int!
+	resources(type: String, first: Int): [Resource!]!
+}
+
+type Resource {
+	id: ID!
+	name: String!
+	type: String!
+	# The type's place in the taxonomy shared by every provider, e.g.
+	# compute.instance
+	kind: String
+	state: String
+	region: Region!
+	# The cloud the resource was listed from, e.g. aws
+	provider: String
+	# The AWS partition of the region, e.g. aws-us-gov
+	partition: String
+	# The account the resource belongs to, and the account's alias
+	accountId: String
+	accountName: String
+	tags: [Tag!]!
+	tag(key: String!): String
+	attributes: [Attribute!]!
+	attribute(key: String!): String
+	# Resources in the same region that this one references by ID, ARN or
+	# name (e.g. an alias's function, a listener's accelerator), or that
+	# reference it
+	related: [Resource!]!
+}
+
+type Tag {
+	key: String!
+	value: String!
+}
+
+type ServiceError {
+	service: String!
+	region: String!
+	code: String
+	retryable: Boolean!
+	message: String!
+}
+
+type Attribute {
+	key: String!
+	value: String!
+}
+`
+
+// graphQLHandler serves the inventory over GraphQL at /graphql.
+func graphQLHandler() gin.HandlerFunc {
+	schema := graphql.MustParseSchema(graphQLSchema, &graphQLResolver{})
+	return gin.WrapH(&relay.Handler{Schema: schema})
+}
+
+type graphQLResolver struct{}
+
+type tagFilterInput struct {
+	Key   string
+	Value *string
+}
+
+type credentialsInput struct {
+	AWSProfile          *string
+	AzureSubscriptionID *string
+	AzureClientID       *string
+	GCPProject          *string
+	GCPKeyRef           *string
+}
+
+func (in *credentialsInput) credentials() *Credentials {
+	value := func(s *string) string {
+		if s == nil {
+			return ""
+		}
+		return *s
+	}
+	return &Credentials{
+		AWSProfile:          value(in.AWSProfile),
+		AzureSubscriptionID: value(in.AzureSubscriptionID),
+		AzureClientID:       value(in.AzureClientID),
+		GCPProject:          value(in.GCPProject),
+		GCPKeyRef:           value(in.GCPKeyRef),
+	}
+}
+
+func (r *graphQLResolver) Regions(ctx context.Context, args struct {
+	Names       []string
+	Types       *[]string
+	Kinds       *[]string
+	States      *[]string
+	Tags        *[]tagFilterInput
+	Provider    *string
+	Credentials *credentialsInput
+}) ([]*regionResolver, error) {
+	req := RegionsRequest{Regions: args.Names}
+	if args.Provider != nil {
+		req.Provider = *args.Provider
+	}
+	if args.Types != nil {
+		req.Types = *args.Types
+	}
+	if args.Kinds != nil {
+		req.Kinds = *args.Kinds
+	}
+	if args.States != nil {
+		req.States = *args.States
+	}
+	if args.Tags != nil {
+		req.TagFilters = make(map[string]string)
+		for _, tag := range *args.Tags {
+			value := "*"
+			if tag.Value != nil {
+				value = *tag.Value
+			}
+			req.TagFilters[tag.Key] = value
+		}
+	}
+
+	if args.Credentials != nil {
+		req.Credentials = args.Credentials.credentials()
+	}
+
+	lister, err := listerFor(ctx, req.Provider, req.Credentials)
+	if err != nil {
+		return nil, err
+	}
+
+	regions, err := lister.resolveRegions(ctx, req.Regions)
+	if err != nil {
+		return nil, err
+	}
+	req.Regions = regions
+
+	release, err := scanLimit.acquire(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	regionData := lister.scanRegions(ctx, req)
+	sortRegionData(regionData, "", "")
+	return newRegionResolvers(regionData), nil
+}
+
+func (r *graphQLResolver) Resource(ctx context.Context, args struct{ ID graphql.ID }) *resourceResolver {
+	regionData, _ := tenantFrom(ctx).latestScan.Snapshot()
+	for _, region := range newRegionResolvers(regionData) {
+		for _, resource := range region.resources {
+			if resource.resource.ID == string(args.ID) {
+				return resource
+			}
+		}
+	}
+	return nil
+}
+
+func newRegionResolvers(regionData []RegionResources) []*regionResolver {
+	regions := make([]*regionResolver, len(regionData))
+	for i, rd := range regionData {
+		region := &regionResolver{data: rd}
+		region.resources = make([]*resourceResolver, len(rd.Resources))
+		for j, resource := range rd.Resources {
+			region.resources[j] = &resourceResolver{resource: resource, region: region}
+		}
+		regions[i] = region
+	}
+	return regions
+}
+
+type regionResolver struct {
+	data      RegionResources
+	resources []*resourceResolver
+}
+
+func (r *regionResolver) Name() string {
+	return r.data.Region
+}
+
+func (r *regionResolver) Error() *string {
+	if r.data.Error == "" {
+		return nil
+	}
+	return &r.data.Error
+}
+
+func (r *regionResolver) Errors() []*serviceErrorResolver {
+	errs := make([]*serviceErrorResolver, len(r.data.Errors))
+	for i := range r.data.Errors {
+		errs[i] = &serviceErrorResolver{r.data.Errors[i]}
+	}
+	return errs
+}
+
+func (r *regionResolver) Throttles() int32 {
+	return int32(r.data.Throttles)
+}
+
+func (r *regionResolver) CostError() *string {
+	if r.data.CostError == "" {
+		return nil
+	}
+	return &r.data.CostError
+}
+
+func (r *regionResolver) ResourceCount() int32 {
+	return int32(len(r.resources))
+}
+
+func (r *regionResolver) Resources(args struct {
+	Type  *string
+	First *int32
+}) []*resourceResolver {
+	var resources []*resourceResolver
+	for _, resource := range r.resources {
+		if args.Type != nil && resource.resource.Type != *args.Type {
+			continue
+		}
+		if args.First != nil && len(resources) >= int(*args.First) {
+			break
+		}
+		resources = append(resources, resource)
+	}
+	return resources
+}
+
+type serviceErrorResolver struct {
+	err ServiceError
+}
+
+func (r *serviceErrorResolver) Service() string {
+	return r.err.Service
+}
+
+func (r *serviceErrorResolver) Region() string {
+	return r.err.Region
+}
+
+func (r *serviceErrorResolver) Code() *string {
+	if r.err.Code == "" {
+		return nil
+	}
+	return &r.err.Code
+}
+
+func (r *serviceErrorResolver) Retryable() bool {
+	return r.err.Retryable
+}
+
+func (r *serviceErrorResolver) Message() string {
+	return r.err.Message
+}
+
+type resourceResolver struct {
+	resource Resource
+	region   *regionResolver
+}
+
+func (r *resourceResolver) ID() graphql.ID {
+	return graphql.ID(r.resource.ID)
+}
+
+func (r *resourceResolver) Name() string {
+	return r.resource.Name
+}
+
+func (r *resourceResolver) Type() string {
+	return r.resource.Type
+}
+
+func (r *resourceResolver) Kind() *string {
+	if r.resource.Kind == "" {
+		return nil
+	}
+	return &r.resource.Kind
+}
+
+func (r *resourceResolver) State() *string {
+	if r.resource.State == "" {
+		return nil
+	}
+	return &r.resource.State
+}
+
+func (r *resourceResolver) Region() *regionResolver {
+	return r.region
+}
+
+func (r *resourceResolver) Provider() *string {
+	if r.resource.Provider == "" {
+		return nil
+	}
+	return &r.resource.Provider
+}
+
+func (r *resourceResolver) Partition() *string {
+	if r.resource.Partition == "" {
+		return nil
+	}
+	return &r.resource.Partition
+}
+
+func (r *resourceResolver) AccountID() *string {
+	if r.resource.AccountID == "" {
+		return nil
+	}
+	return &r.resource.AccountID
+}
+
+func (r *resourceResolver) AccountName() *string {
+	if r.resource.AccountName == "" {
+		return nil
+	}
+	return &r.resource.AccountName
+}
+
+func (r *resourceResolver) Tags() []*keyValueResolver {
+	return newKeyValueResolvers(r.resource.Tags)
+}
+
+func (r *resourceResolver) Tag(args struct{ Key string }) *string {
+	if value, ok := r.resource.Tags[args.Key]; ok {
+		return &value
+	}
+	return nil
+}
+
+func (r *resourceResolver) Attributes() []*keyValueResolver {
+	return newKeyValueResolvers(r.resource.Attributes)
+}
+
+func (r *resourceResolver) Attribute(args struct{ Key string }) *string {
+	if value, ok := r.resource.Attributes[args.Key]; ok {
+		return &value
+	}
+	return nil
+}
+
+func (r *resourceResolver) Related() []*resourceResolver {
+	var related []*resourceResolver
+	for _, other := range r.region.resources {
+		if other == r {
+			continue
+		}
+		if references(r.resource, other.resource) || references(other.resource, r.resource) {
+			related = append(related, other)
+		}
+	}
+	return related
+}
+
+// references reports whether one of from's attribute values names to, by
+// ID or by name.
+func references(from, to Resource) bool {
+	for _, value := range from.Attributes {
+		if value == "" {
+			continue
+		}
+		if value == to.ID || (to.Name != "" && value == to.Name && from.Name != to.Name) {
+			return true
+		}
+	}
+	return false
+}
+
+type keyValueResolver struct {
+	key, value string
+}
+
+func (r *keyValueResolver) Key() string {
+	return r.key
+}
+
+func (r *keyValueResolver) Value() string {
+	return r.value
+}
+
+func newKeyValueResolvers(values map[string]string) []*keyValueResolver {
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	resolvers := make([]*keyValueResolver, len(keys))
+	for i, key := range keys {
+		resolvers[i] = &keyValueResolver{key: key, value: values[key]}
+	}
+	return resolvers
+}
@@ -0,0 +1,109 @@
+package main
+
+import (
+	"errors"
+	"net"
+
+	"github.com/alwindoss/cloudy/pkg/cloudy"
+	cloudyv1 "github.com/alwindoss/cloudy/proto/cloudy/v1"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// grpcBatchSize bounds how many resources go in one streamed message so
+// large regions stay under gRPC's default 4 MB message limit.
+const grpcBatchSize = 500
+
+type inventoryServer struct {
+	cloudyv1.UnimplementedInventoryServiceServer
+}
+
+func (s *inventoryServer) ListResources(scan *cloudyv1.ScanRequest, stream grpc.ServerStreamingServer[cloudyv1.ListResourcesResponse]) error {
+	if len(scan.Regions) == 0 {
+		return status.Error(codes.InvalidArgument, "at least one region must be specified")
+	}
+
+	ctx := stream.Context()
+	lister, err := listerFor(ctx, cloudy.ProviderAWS, nil)
+	if err != nil {
+		return status.Error(codes.FailedPrecondition, err.Error())
+	}
+
+	req := RegionsRequest{
+		Regions:    scan.Regions,
+		Types:      scan.Types,
+		States:     scan.States,
+		TagFilters: scan.TagFilters,
+	}
+
+	regions, err := lister.resolveRegions(ctx, req.Regions)
+	if err != nil {
+		return status.Error(codes.Unavailable, err.Error())
+	}
+	req.Regions = regions
+
+	release, err := scanLimit.acquire(ctx)
+	if errors.Is(err, errScansBusy) {
+		return status.Error(codes.ResourceExhausted, err.Error())
+	}
+	if err != nil {
+		return status.FromContextError(err).Err()
+	}
+	defer release()
+
+	for rd := range lister.streamRegions(ctx, req) {
+		if err := sendRegion(stream, rd); err != nil {
+			return err
+		}
+	}
+	return ctx.Err()
+}
+
+// sendRegion streams a region's resources in batches, marking the last one.
+func sendRegion(stream grpc.ServerStreamingServer[cloudyv1.ListResourcesResponse], rd RegionResources) error {
+	for start := 0; ; start += grpcBatchSize {
+		end := min(start+grpcBatchSize, len(rd.Resources))
+		last := end == len(rd.Resources)
+
+		batch := &cloudyv1.RegionResources{Region: rd.Region}
+		for _, resource := range rd.Resources[start:end] {
+			batch.Resources = append(batch.Resources, &cloudyv1.Resource{
+				Id:         resource.ID,
+				Name:       resource.Name,
+				Type:       resource.Type,
+				State:      resource.State,
+				Region:     resource.Region,
+				Tags:       resource.Tags,
+				Attributes: resource.Attributes,
+			})
+		}
+		if last {
+			batch.Error = rd.Error
+		}
+
+		if err := stream.Send(&cloudyv1.ListResourcesResponse{Batch: batch, RegionComplete: last}); err != nil {
+			return err
+		}
+		if last {
+			return nil
+		}
+	}
+}
+
+// newGRPCServer returns the gRPC API's server.
+func newGRPCServer() *grpc.Server {
+	server := grpc.NewServer(grpc.StreamInterceptor(authenticateTenantStream))
+	cloudyv1.RegisterInventoryServiceServer(server, &inventoryServer{})
+	return server
+}
+
+// serveGRPC runs server on addr until it is stopped or the listener
+// fails.
+func serveGRPC(server *grpc.Server, addr string) error {
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+	return server.Serve(listener)
+}
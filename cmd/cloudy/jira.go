@@ -0,0 +1,349 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// jiraTimeout bounds each Jira API call, and jiraMaxSummary is the longest
+// summary Jira takes.
+const (
+	jiraTimeout    = 30 * time.Second
+	jiraMaxSummary = 255
+)
+
+// jira opens an issue in Jira Cloud for every policy violation whose
+// policy has a project, its jira_project or CLOUDY_JIRA_PROJECT, and
+// closes it when the violation is gone. Issues are assigned to the Jira
+// account of the team in the resource's teamTag tag, from
+// CLOUDY_JIRA_TEAM_ASSIGNEES, and labeled so each violation has one open
+// issue, however many scans find it; issues are remembered by label in
+// issues. It is nil if Jira isn't set up.
+var jira *jiraClient
+
+type jiraClient struct {
+	baseURL   string
+	user      string
+	token     string
+	project   string
+	issueType string
+	// transition is the name of the transition resolved violations'
+	// issues take, from CLOUDY_JIRA_DONE_TRANSITION; without it they are
+	// only commented on.
+	transition string
+	teamTag    string
+	assignees  map[string]string
+
+	// mu is held while issues are filed, so the filing of scans that
+	// finish at once doesn't open the same issue twice.
+	mu     sync.Mutex
+	issues map[string]string
+}
+
+// newJiraClient checks the settings, which need a project:
+// CLOUDY_JIRA_PROJECT or some policy's jira_project.
+func newJiraClient(baseURL, user, token, project, issueType, transition, teamTag string, assignees map[string]string) (*jiraClient, error) {
+	if _, err := url.ParseRequestURI(baseURL); err != nil {
+		return nil, fmt.Errorf("CLOUDY_JIRA_URL must be the site's URL: %w", err)
+	}
+	if user == "" || token == "" {
+		return nil, errors.New("set CLOUDY_JIRA_USER and CLOUDY_JIRA_TOKEN to the email and API token Cloudy uses")
+	}
+	if project == "" {
+		projects := 0
+		for _, policy := range policies {
+			if policy.JiraProject != "" {
+				projects++
+			}
+		}
+		if projects == 0 {
+			return nil, errors.New("set CLOUDY_JIRA_PROJECT, or jira_project on the policies that open issues")
+		}
+	}
+	if issueType == "" {
+		issueType = "Task"
+	}
+	if teamTag == "" {
+		teamTag = "team"
+	}
+	return &jiraClient{
+		baseURL:    strings.TrimSuffix(baseURL, "/"),
+		user:       user,
+		token:      token,
+		project:    project,
+		issueType:  issueType,
+		transition: transition,
+		teamTag:    teamTag,
+		assignees:  assignees,
+		issues:     make(map[string]string),
+	}, nil
+}
+
+// projectFor returns the key of the project v's issue goes in, or "" if
+// it doesn't get one.
+func (j *jiraClient) projectFor(v Violation) string {
+	if v.Policy.JiraProject != "" {
+		return v.Policy.JiraProject
+	}
+	return j.project
+}
+
+// jiraLabel labels the issue of t's violation v, as Jira labels can't
+// hold its violationRef.
+func jiraLabel(t *tenant, v Violation) string {
+	sum := sha256.Sum256([]byte(violationRef(t, v)))
+	return "cloudy-" + hex.EncodeToString(sum[:10])
+}
+
+// fileJiraIssues opens an issue for each of t's opened violations that has
+// none open, and resolves those of its resolved ones, in the background.
+func fileJiraIssues(t *tenant, opened, resolved []Violation) {
+	if jira == nil || len(opened)+len(resolved) == 0 {
+		return
+	}
+	go func() {
+		jira.mu.Lock()
+		defer jira.mu.Unlock()
+		ctx := context.Background()
+		for _, v := range opened {
+			if jira.projectFor(v) == "" {
+				continue
+			}
+			if err := jira.open(ctx, t, v); err != nil {
+				log.Printf("Failed to open Jira issue for %s: %v", violationRef(t, v), err)
+			}
+		}
+		for _, v := range resolved {
+			if jira.projectFor(v) == "" {
+				continue
+			}
+			if err := jira.resolve(ctx, t, v); err != nil {
+				log.Printf("Failed to resolve Jira issue for %s: %v", violationRef(t, v), err)
+			}
+		}
+	}()
+}
+
+// open creates v's issue unless it has one open already.
+func (j *jiraClient) open(ctx context.Context, t *tenant, v Violation) error {
+	label := jiraLabel(t, v)
+	key, err := j.openIssue(ctx, label)
+	if err != nil || key != "" {
+		return err
+	}
+
+	resource := v.Resource
+	summary := fmt.Sprintf("%s: %s %s in %s", v.Policy.Name, resource.Type, resourceLabel(resource), resource.Region)
+	if len(summary) > jiraMaxSummary {
+		summary = summary[:jiraMaxSummary]
+	}
+	fields := map[string]any{
+		"project":     map[string]string{"key": j.projectFor(v)},
+		"issuetype":   map[string]string{"name": j.issueType},
+		"summary":     summary,
+		"description": jiraDocument(jiraViolationLines(t, v)),
+		"labels":      []string{"cloudy", label},
+	}
+	if account := j.assignees[resource.Tags[j.teamTag]]; account != "" {
+		fields["assignee"] = map[string]string{"accountId": account}
+	}
+	var created struct {
+		Key string `json:"key"`
+	}
+	if err := j.call(ctx, http.MethodPost, "issue", nil, map[string]any{"fields": fields}, &created); err != nil {
+		return err
+	}
+	j.issues[label] = created.Key
+	return nil
+}
+
+// resolve comments on v's open issue, if it has one, and moves it through
+// the done transition, if there is one.
+func (j *jiraClient) resolve(ctx context.Context, t *tenant, v Violation) error {
+	label := jiraLabel(t, v)
+	key, err := j.openIssue(ctx, label)
+	if err != nil || key == "" {
+		return err
+	}
+	// Should the violation come back, its issue is looked up again, in
+	// case it was closed in Jira meanwhile
+	delete(j.issues, label)
+	comment := jiraDocument([]string{fmt.Sprintf("Resolved: %s %s no longer violates %s as of %s.",
+		v.Resource.Type, resourceLabel(v.Resource), v.Policy.Name, time.Now().UTC().Format(time.RFC3339))})
+	if err := j.call(ctx, http.MethodPost, "issue/"+key+"/comment", nil, map[string]any{"body": comment}, nil); err != nil {
+		return err
+	}
+	if j.transition != "" {
+		var answer struct {
+			Transitions []struct {
+				ID   string `json:"id"`
+				Name string `json:"name"`
+			} `json:"transitions"`
+		}
+		if err := j.call(ctx, http.MethodGet, "issue/"+key+"/transitions", nil, nil, &answer); err != nil {
+			return err
+		}
+		id := ""
+		for _, transition := range answer.Transitions {
+			if strings.EqualFold(transition.Name, j.transition) {
+				id = transition.ID
+			}
+		}
+		if id == "" {
+			return fmt.Errorf("issue %s has no transition %q", key, j.transition)
+		}
+		body := map[string]any{"transition": map[string]string{"id": id}}
+		if err := j.call(ctx, http.MethodPost, "issue/"+key+"/transitions", nil, body, nil); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// openIssue returns the key of the unresolved issue labeled label, or ""
+// if there is none.
+func (j *jiraClient) openIssue(ctx context.Context, label string) (string, error) {
+	if key, ok := j.issues[label]; ok {
+		return key, nil
+	}
+	query := url.Values{
+		"jql":        {fmt.Sprintf("labels = %q AND statusCategory != Done", label)},
+		"fields":     {"key"},
+		"maxResults": {"1"},
+	}
+	var answer struct {
+		Issues []struct {
+			Key string `json:"key"`
+		} `json:"issues"`
+	}
+	if err := j.call(ctx, http.MethodGet, "search/jql", query, nil, &answer); err != nil {
+		return "", err
+	}
+	if len(answer.Issues) == 0 {
+		return "", nil
+	}
+	j.issues[label] = answer.Issues[0].Key
+	return answer.Issues[0].Key, nil
+}
+
+// jiraViolationLines describes t's violation v, a line per fact.
+func jiraViolationLines(t *tenant, v Violation) []string {
+	resource := v.Resource
+	lines := []string{fmt.Sprintf("%s %s violates policy %s (%s).", resource.Type, resourceLabel(resource), v.Policy.Name, v.Policy.Severity)}
+	if v.Policy.Description != "" {
+		lines = append(lines, v.Policy.Description)
+	}
+	for _, field := range []struct{ name, value string }{
+		{"ID", resource.ID},
+		{"Name", resource.Name},
+		{"Type", resource.Type},
+		{"State", resource.State},
+		{"Region", resource.Region},
+		{"Account", strings.TrimSpace(resource.AccountID + " " + resource.AccountName)},
+	} {
+		if field.value != "" {
+			lines = append(lines, field.name+": "+field.value)
+		}
+	}
+	if t != serverTenant {
+		lines = append(lines, "Tenant: "+t.name)
+	}
+	keys := make([]string, 0, len(resource.Tags))
+	for key := range resource.Tags {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		lines = append(lines, "Tag "+key+": "+resource.Tags[key])
+	}
+	return append(lines, "Opened by Cloudy, which resolves it once a scan finds the violation gone.")
+}
+
+// jiraDocument is lines as an Atlassian Document, a paragraph each, as
+// the v3 API takes rich text.
+func jiraDocument(lines []string) map[string]any {
+	content := make([]any, len(lines))
+	for i, line := range lines {
+		content[i] = map[string]any{
+			"type":    "paragraph",
+			"content": []any{map[string]any{"type": "text", "text": line}},
+		}
+	}
+	return map[string]any{"type": "doc", "version": 1, "content": content}
+}
+
+// call makes a Jira REST API v3 call on path, below /rest/api/3/,
+// decoding the response into out, retrying with backoff after network
+// errors, 429s and 5xx responses as webhook deliveries are.
+func (j *jiraClient) call(ctx context.Context, method, path string, query url.Values, body, out any) error {
+	var payload []byte
+	if body != nil {
+		var err error
+		if payload, err = json.Marshal(body); err != nil {
+			return err
+		}
+	}
+	endpoint := j.baseURL + "/rest/api/3/" + path
+	if query != nil {
+		endpoint += "?" + query.Encode()
+	}
+
+	backoff := webhookBackoff
+	for attempt := 1; ; attempt++ {
+		retry, err := j.attempt(ctx, method, endpoint, payload, out)
+		if err == nil {
+			return nil
+		}
+		if !retry || attempt == webhookAttempts {
+			return fmt.Errorf("%s %s: %w", method, path, err)
+		}
+		time.Sleep(backoff)
+		backoff *= 2
+	}
+}
+
+func (j *jiraClient) attempt(ctx context.Context, method, endpoint string, payload []byte, out any) (retry bool, err error) {
+	ctx, cancel := context.WithTimeout(ctx, jiraTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, method, endpoint, bytes.NewReader(payload))
+	if err != nil {
+		return false, err
+	}
+	req.Header.Set("Accept", "application/json")
+	if payload != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	req.SetBasicAuth(j.user, j.token)
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return true, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		var answer struct {
+			ErrorMessages []string          `json:"errorMessages"`
+			Errors        map[string]string `json:"errors"`
+		}
+		json.NewDecoder(resp.Body).Decode(&answer)
+		retry = resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500
+		return retry, fmt.Errorf("Jira answered %s: %v %v", resp.Status, answer.ErrorMessages, answer.Errors)
+	}
+	if out == nil {
+		return false, nil
+	}
+	return false, json.NewDecoder(resp.Body).Decode(out)
+}
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jmespath/go-jmespath"
+)
+
+// compileQuery parses the query parameter as a JMESPath expression. It
+// returns nil when there is no query.
+func compileQuery(query string) (*jmespath.JMESPath, error) {
+	if query == "" {
+		return nil, nil
+	}
+	expr, err := jmespath.Compile(query)
+	if err != nil {
+		return nil, fmt.Errorf("invalid query: %w", err)
+	}
+	return expr, nil
+}
+
+// writeQueryResult evaluates expr against the JSON form of the response
+// (after field projection) and writes whatever it selects, as JSON or YAML.
+func writeQueryResult(c *gin.Context, format string, expr *jmespath.JMESPath, response ListResourcesResponse, p *projection) {
+	var buf bytes.Buffer
+	if err := encodeResourcesJSON(&buf, response, p); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	// go-jmespath walks generic JSON values; it ignores json struct tags
+	var data any
+	if err := json.Unmarshal(buf.Bytes(), &data); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	result, err := expr.Search(data)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "query failed: " + err.Error()})
+		return
+	}
+
+	if format == formatYAML {
+		c.YAML(http.StatusOK, result)
+		return
+	}
+	c.JSON(http.StatusOK, result)
+}
@@ -0,0 +1,100 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	defaultMaxScans  = 8
+	defaultScanQueue = 32
+
+	// scanRetryAfter is the Retry-After, in seconds, sent with a 429.
+	scanRetryAfter = "5"
+)
+
+// errScansBusy is returned when every scan slot is taken and the queue
+// for them is full.
+var errScansBusy = errors.New("too many scans in progress, try again later")
+
+// scanLimiter bounds how many scans run at once, so a burst of requests
+// can't overload the server or the account's AWS rate limits. Scans beyond
+// the limit wait in a bounded queue for a slot.
+type scanLimiter struct {
+	slots chan struct{}
+
+	mu        sync.Mutex
+	queued    int
+	maxQueued int
+}
+
+func newScanLimiter(scans, queue int) *scanLimiter {
+	return &scanLimiter{slots: make(chan struct{}, scans), maxQueued: queue}
+}
+
+// scanLimit is shared by every API, from CLOUDY_MAX_SCANS and
+// CLOUDY_SCAN_QUEUE.
+var scanLimit = newScanLimiter(defaultMaxScans, defaultScanQueue)
+
+// acquire takes a scan slot, waiting in the queue for one if they are all
+// taken. It fails with errScansBusy if the queue is full too, or with
+// ctx's error if ctx is done first. release gives the slot back.
+func (l *scanLimiter) acquire(ctx context.Context) (release func(), err error) {
+	select {
+	case l.slots <- struct{}{}:
+		return l.release, nil
+	default:
+	}
+
+	l.mu.Lock()
+	if l.queued >= l.maxQueued {
+		l.mu.Unlock()
+		return nil, errScansBusy
+	}
+	l.queued++
+	l.mu.Unlock()
+
+	defer func() {
+		l.mu.Lock()
+		l.queued--
+		l.mu.Unlock()
+	}()
+	return l.wait(ctx)
+}
+
+// wait takes a scan slot, however long the queue. Async scans use it, as
+// they wait in the background rather than holding a request open.
+func (l *scanLimiter) wait(ctx context.Context) (release func(), err error) {
+	select {
+	case l.slots <- struct{}{}:
+		return l.release, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+func (l *scanLimiter) release() {
+	<-l.slots
+}
+
+// limitScan takes a scan slot for the request, answering 429 with a
+// Retry-After if there is no room. It reports whether the scan may go
+// ahead; if so, the caller must call release once it is done.
+func limitScan(c *gin.Context) (release func(), ok bool) {
+	release, err := scanLimit.acquire(c.Request.Context())
+	switch {
+	case errors.Is(err, errScansBusy):
+		c.Header("Retry-After", scanRetryAfter)
+		c.JSON(http.StatusTooManyRequests, gin.H{"error": err.Error()})
+		return nil, false
+	case err != nil:
+		// The client has gone away
+		c.Status(http.StatusServiceUnavailable)
+		return nil, false
+	}
+	return release, true
+}
@@ -2,435 +2,392 @@ package main
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log"
 	"net/http"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
 	"sync"
-
+	"syscall"
+	"time"
+
+	"github.com/alwindoss/cloudy/pkg/cloudy"
+	"github.com/alwindoss/cloudy/pkg/cloudy/azure"
+	"github.com/alwindoss/cloudy/pkg/cloudy/cloudflare"
+	"github.com/alwindoss/cloudy/pkg/cloudy/digitalocean"
+	"github.com/alwindoss/cloudy/pkg/cloudy/gcp"
+	"github.com/alwindoss/cloudy/pkg/cloudy/hetzner"
+	"github.com/alwindoss/cloudy/pkg/cloudy/kubernetes"
+	"github.com/alwindoss/cloudy/pkg/cloudy/linode"
+	"github.com/alwindoss/cloudy/pkg/cloudy/oci"
+	"github.com/alwindoss/cloudy/pkg/cloudy/openstack"
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/config"
-	"github.com/aws/aws-sdk-go-v2/service/ec2"
-	"github.com/aws/aws-sdk-go-v2/service/ecs"
-	"github.com/aws/aws-sdk-go-v2/service/iam"
-	"github.com/aws/aws-sdk-go-v2/service/lambda"
-	"github.com/aws/aws-sdk-go-v2/service/rds"
-	"github.com/aws/aws-sdk-go-v2/service/s3"
 	"github.com/gin-gonic/gin"
 )
 
 type RegionsRequest struct {
-	Regions []string `json:"regions" binding:"required"`
-}
-
-type Resource struct {
-	ID         string            `json:"id"`
-	Name       string            `json:"name"`
-	Type       string            `json:"type"`
-	State      string            `json:"state,omitempty"`
-	Region     string            `json:"region"`
-	Tags       map[string]string `json:"tags,omitempty"`
-	Attributes map[string]string `json:"attributes,omitempty"`
+	Regions     []string          `json:"regions" binding:"required"`
+	Types       []string          `json:"types,omitempty"`
+	Kinds       []string          `json:"kinds,omitempty"`
+	States      []string          `json:"states,omitempty"`
+	TagFilters  map[string]string `json:"tag_filters,omitempty"`
+	NamePattern string            `json:"name_pattern,omitempty"`
+	NameRegex   string            `json:"name_regex,omitempty"`
+	Sort        string            `json:"sort,omitempty"`
+	Order       string            `json:"order,omitempty"`
+	Limit       int               `json:"limit,omitempty"`
+	NextToken   string            `json:"next_token,omitempty"`
+	Fields      []string          `json:"fields,omitempty"`
+	Query       string            `json:"query,omitempty"`
+	Refresh     bool              `json:"refresh,omitempty"`
+	Mode        string            `json:"mode,omitempty"`
+	Provider    string            `json:"provider,omitempty"`
+	Credentials *Credentials      `json:"credentials,omitempty"`
+	View        string            `json:"view,omitempty"`
 }
 
-type RegionResources struct {
-	Region    string     `json:"region"`
-	Resources []Resource `json:"resources"`
-	Error     string     `json:"error,omitempty"`
-}
+// Resource, RegionResources and ServiceError are the library's, so the
+// API serves exactly what the Scanner produces.
+type (
+	Resource        = cloudy.Resource
+	RegionResources = cloudy.RegionResources
+	ServiceError    = cloudy.ServiceError
+)
 
 type ListResourcesResponse struct {
-	RegionData []RegionResources `json:"region_data"`
-	TotalCount int               `json:"total_count"`
+	RegionData []RegionResources `json:"region_data" yaml:"region_data"`
+	TotalCount int               `json:"total_count" yaml:"total_count"`
+	NextToken  string            `json:"next_token,omitempty" yaml:"next_token,omitempty"`
 }
 
-type AWSResourceLister struct {
-	cfg config.Config
-}
+// scanMaxResults overrides the library's per-lister cap when set from
+// CLOUDY_MAX_RESULTS.
+var scanMaxResults int
 
-func NewAWSResourceLister() (*AWSResourceLister, error) {
-	cfg, err := config.LoadDefaultConfig(context.TODO())
-	if err != nil {
-		return nil, fmt.Errorf("unable to load SDK config: %w", err)
-	}
+// scanPool is shared by every request's Scanner, so concurrent requests
+// stay within one set of concurrency limits.
+var scanPool = cloudy.NewWorkerPool(cloudy.DefaultConcurrency)
 
-	return &AWSResourceLister{cfg: cfg}, nil
-}
-
-func (a *AWSResourceLister) ListResourcesInRegion(ctx context.Context, region string) ([]Resource, error) {
-	var resources []Resource
-	var wg sync.WaitGroup
-	var mu sync.Mutex
-
-	// Create region-specific config
-	var regionCfg aws.Config
-	regionCfg.Region = region
-
-	// Channel to collect errors
-	errCh := make(chan error, 6)
+// scanTimeouts bound every request's scan, from CLOUDY_*_TIMEOUT.
+var scanTimeouts = cloudy.DefaultTimeouts
 
-	// List EC2 Instances
-	wg.Add(1)
-	go func() {
-		defer wg.Done()
-		if ec2Resources, err := a.listEC2Instances(ctx, regionCfg); err != nil {
-			errCh <- fmt.Errorf("EC2 instances in %s: %w", region, err)
-		} else {
-			mu.Lock()
-			resources = append(resources, ec2Resources...)
-			mu.Unlock()
-		}
-	}()
+// scanCache is shared by every request's Scanner, so repeated requests
+// within CLOUDY_CACHE_TTL, scanCacheTTL, are answered without calling AWS.
+// Tenants each have their own.
+var (
+	scanCache    = cloudy.NewResultCache(defaultCacheTTL)
+	scanCacheTTL = defaultCacheTTL
+)
 
-	// List S3 Buckets (only in us-east-1 to avoid duplicates)
-	if region == "us-east-1" {
-		wg.Add(1)
-		go func() {
-			defer wg.Done()
-			if s3Resources, err := a.listS3Buckets(ctx, regionCfg); err != nil {
-				errCh <- fmt.Errorf("S3 buckets: %w", err)
-			} else {
-				mu.Lock()
-				resources = append(resources, s3Resources...)
-				mu.Unlock()
-			}
-		}()
+// accountNames is shared by every request's Scanner, so each account is
+// named once per CLOUDY_ACCOUNT_NAME_TTL, and holds the names set with
+// CLOUDY_ACCOUNT_NAMES. Tenants each have their own.
+var accountNames = cloudy.NewAccountNames(cloudy.DefaultAccountNameTTL)
+
+// newAccountNames returns AccountNames set up as CLOUDY_ACCOUNT_NAME_TTL
+// and CLOUDY_ACCOUNT_NAMES say.
+func newAccountNames() *cloudy.AccountNames {
+	names := cloudy.NewAccountNames(envDuration("CLOUDY_ACCOUNT_NAME_TTL", cloudy.DefaultAccountNameTTL))
+	for account, name := range envMap("CLOUDY_ACCOUNT_NAMES") {
+		names.Set(account, name)
 	}
+	return names
+}
 
-	// List RDS Instances
-	wg.Add(1)
-	go func() {
-		defer wg.Done()
-		if rdsResources, err := a.listRDSInstances(ctx, regionCfg); err != nil {
-			errCh <- fmt.Errorf("RDS instances in %s: %w", region, err)
-		} else {
-			mu.Lock()
-			resources = append(resources, rdsResources...)
-			mu.Unlock()
-		}
-	}()
+// explorerRegion and explorerView select the Resource Explorer index
+// explorer scans query, from CLOUDY_EXPLORER_REGION and
+// CLOUDY_EXPLORER_VIEW.
+var explorerRegion, explorerView string
 
-	// List Lambda Functions
-	wg.Add(1)
-	go func() {
-		defer wg.Done()
-		if lambdaResources, err := a.listLambdaFunctions(ctx, regionCfg); err != nil {
-			errCh <- fmt.Errorf("lambda functions in %s: %w", region, err)
-		} else {
-			mu.Lock()
-			resources = append(resources, lambdaResources...)
-			mu.Unlock()
-		}
-	}()
+const defaultCacheTTL = 5 * time.Minute
 
-	// List ECS Clusters
-	wg.Add(1)
-	go func() {
-		defer wg.Done()
-		if ecsResources, err := a.listECSClusters(ctx, regionCfg); err != nil {
-			errCh <- fmt.Errorf("ECS clusters in %s: %w", region, err)
-		} else {
-			mu.Lock()
-			resources = append(resources, ecsResources...)
-			mu.Unlock()
-		}
-	}()
+// defaultShutdownGrace leaves in-flight scans time to finish within
+// Kubernetes' default 30s termination grace period.
+const defaultShutdownGrace = 25 * time.Second
 
-	// List IAM Users (only in us-east-1 to avoid duplicates)
-	if region == "us-east-1" {
-		wg.Add(1)
-		go func() {
-			defer wg.Done()
-			if iamResources, err := a.listIAMUsers(ctx, regionCfg); err != nil {
-				errCh <- fmt.Errorf("IAM users: %w", err)
-			} else {
-				mu.Lock()
-				resources = append(resources, iamResources...)
-				mu.Unlock()
-			}
-		}()
-	}
-
-	wg.Wait()
-	close(errCh)
-
-	// Collect any errors
-	var errors []error
-	for err := range errCh {
-		errors = append(errors, err)
-	}
+// ResourceLister is one of the server's Scanners, one per provider,
+// extended with the region handling and caching the API needs.
+type ResourceLister struct {
+	*cloudy.Scanner
 
-	if len(errors) > 0 {
-		return resources, fmt.Errorf("encountered %d errors while listing resources", len(errors))
+	// regions caches the provider's regions; see discoverRegions.
+	regions struct {
+		mu        sync.Mutex
+		names     []string
+		fetchedAt time.Time
 	}
 
-	return resources, nil
+	// perRequest marks a lister built for a request's Credentials, whose
+	// scans don't replace the server's latest scan or trends.
+	perRequest bool
 }
 
-func (a *AWSResourceLister) listEC2Instances(ctx context.Context, cfg aws.Config) ([]Resource, error) {
-	client := ec2.NewFromConfig(cfg)
-	result, err := client.DescribeInstances(ctx, &ec2.DescribeInstancesInput{})
-	if err != nil {
-		return nil, err
-	}
-
-	var resources []Resource
-	for _, reservation := range result.Reservations {
-		for _, instance := range reservation.Instances {
-			tags := make(map[string]string)
-			name := ""
-			for _, tag := range instance.Tags {
-				if tag.Key != nil && tag.Value != nil {
-					tags[*tag.Key] = *tag.Value
-					if *tag.Key == "Name" {
-						name = *tag.Value
-					}
-				}
-			}
-
-			attributes := map[string]string{
-				"instance_type": string(instance.InstanceType),
-				"vpc_id":        aws_string_value(instance.VpcId),
-				"subnet_id":     aws_string_value(instance.SubnetId),
-			}
+// awsLister is created once at startup and serves every request, so the
+// loaded credentials and the Scanner's AWS clients are reused.
+var awsLister *ResourceLister
 
-			if instance.PublicIpAddress != nil {
-				attributes["public_ip"] = *instance.PublicIpAddress
-			}
-			if instance.PrivateIpAddress != nil {
-				attributes["private_ip"] = *instance.PrivateIpAddress
-			}
+// assumeRoleARN is the role the server scans AWS as, from CLOUDY_ROLE_ARN,
+// and assumeRole sets up the sessions of every role assumed, including
+// those of profiles, from the other CLOUDY_ROLE_* variables.
+var (
+	assumeRoleARN string
+	assumeRole    cloudy.AssumeRoleOptions
+)
 
-			resources = append(resources, Resource{
-				ID:         aws_string_value(instance.InstanceId),
-				Name:       name,
-				Type:       "EC2 Instance",
-				State:      string(instance.State.Name),
-				Region:     cfg.Region,
-				Tags:       tags,
-				Attributes: attributes,
-			})
-		}
+// NewAWSResourceLister loads the default AWS configuration and applies the
+// server's scan settings to a new Scanner.
+func NewAWSResourceLister() (*ResourceLister, error) {
+	cfg, err := loadAWSConfig()
+	if err != nil {
+		return nil, fmt.Errorf("unable to load SDK config: %w", err)
+	}
+	if assumeRoleARN != "" {
+		cfg = cloudy.AssumeRole(cfg, assumeRoleARN, assumeRole)
 	}
+	return newResourceLister(cloudy.NewScannerFromConfig(cfg)), nil
+}
 
-	return resources, nil
+// loadAWSConfig loads the AWS configuration, with the server's settings
+// for the roles its profiles assume.
+func loadAWSConfig(optFns ...func(*config.LoadOptions) error) (aws.Config, error) {
+	return cloudy.LoadConfig(context.TODO(), append(optFns, cloudy.WithAssumeRole(assumeRole))...)
 }
 
-func (a *AWSResourceLister) listS3Buckets(ctx context.Context, cfg aws.Config) ([]Resource, error) {
-	client := s3.NewFromConfig(cfg)
-	result, err := client.ListBuckets(ctx, &s3.ListBucketsInput{})
-	if err != nil {
-		return nil, err
+// newResourceLister applies the server's scan settings to scanner.
+func newResourceLister(scanner *cloudy.Scanner) *ResourceLister {
+	if scanMaxResults > 0 {
+		scanner.SetMaxResults(scanMaxResults)
 	}
+	scanner.SetWorkerPool(scanPool)
+	scanner.SetTimeouts(scanTimeouts)
+	scanner.SetCache(scanCache)
+	scanner.SetAccountNames(accountNames)
+	scanner.SetExplorer(explorerRegion, explorerView)
+	return &ResourceLister{Scanner: scanner}
+}
 
-	var resources []Resource
-	for _, bucket := range result.Buckets {
-		resources = append(resources, Resource{
-			ID:     aws_string_value(bucket.Name),
-			Name:   aws_string_value(bucket.Name),
-			Type:   "S3 Bucket",
-			Region: "global", // S3 buckets are global but shown in us-east-1
-			Attributes: map[string]string{
-				"created": bucket.CreationDate.String(),
-			},
-		})
+// scanRegions lists every requested region concurrently and applies the
+// request's filters. Regions that failed keep their partial results along
+// with the error.
+func (a *ResourceLister) scanRegions(ctx context.Context, req RegionsRequest) []RegionResources {
+	var regionData []RegionResources
+	for rd := range a.streamRegions(ctx, req) {
+		regionData = append(regionData, rd)
 	}
-
-	return resources, nil
+	return regionData
 }
 
-func (a *AWSResourceLister) listRDSInstances(ctx context.Context, cfg aws.Config) ([]Resource, error) {
-	client := rds.NewFromConfig(cfg)
-	result, err := client.DescribeDBInstances(ctx, &rds.DescribeDBInstancesInput{})
+// streamRegions scans the requested regions concurrently and sends each
+// region's filtered resources on the returned channel as soon as it
+// finishes. The channel is closed once every region is done. Cached
+// results are used unless req.Refresh is set. Fast and explorer scans use
+// their index unless the request needs detail only the listers return.
+// Incremental scans start from the latest full scan of each region.
+// Handlers reject an invalid name_regex beforehand; should one get here,
+// every region is sent with the error rather than unfiltered.
+func (a *ResourceLister) streamRegions(ctx context.Context, req RegionsRequest) <-chan RegionResources {
+	matchesName, err := nameMatcher(req)
 	if err != nil {
-		return nil, err
+		regionCh := make(chan RegionResources, len(req.Regions))
+		for _, region := range req.Regions {
+			regionCh <- RegionResources{Region: region, Resources: []Resource{}, Error: err.Error()}
+		}
+		close(regionCh)
+		return regionCh
+	}
+	if req.Refresh {
+		ctx = cloudy.WithRefresh(ctx)
 	}
 
-	var resources []Resource
-	for _, instance := range result.DBInstances {
-		attributes := map[string]string{
-			"engine":         aws_string_value(instance.Engine),
-			"engine_version": aws_string_value(instance.EngineVersion),
-			"instance_class": aws_string_value(instance.DBInstanceClass),
-		}
+	types := scanTypes(req)
+	mode := req.Mode
+	if needsDetail(req) && (mode == scanModeFast || mode == scanModeExplorer) {
+		mode = scanModeFull
+	}
+	var scanned <-chan RegionResources
+	switch mode {
+	case scanModeFast:
+		scanned = a.StreamFast(ctx, req.Regions, types)
+	case scanModeExplorer:
+		scanned = a.StreamExplorer(ctx, req.Regions, types)
+	case scanModeIncremental:
+		scanned = a.StreamIncremental(ctx, req.Regions, tenantFrom(ctx).latestScan.Baselines(a.Provider().Name(), req.Regions))
+	default:
+		scanned = a.StreamRegions(ctx, req.Regions, types, req.States)
+	}
+	indexed := mode == scanModeFast || mode == scanModeExplorer
+	// Incremental scans list every type whatever the request's filters
+	full := !a.perRequest && (mode == scanModeIncremental || (!indexed && len(types) == 0 && len(req.States) == 0))
+
+	// Buffered so regions never block on a reader that has gone away
+	regionCh := make(chan RegionResources, len(req.Regions))
+	t := tenantFrom(ctx)
+	if mode == "" {
+		mode = scanModeFull
+	}
+	started := time.Now()
 
-		if instance.Endpoint != nil {
-			attributes["endpoint"] = aws_string_value(instance.Endpoint.Address)
-			if instance.Endpoint.Port != nil {
-				attributes["port"] = fmt.Sprintf("%d", *instance.Endpoint.Port)
+	go func() {
+		defer close(regionCh)
+		for rd := range scanned {
+			labelAccounts(a.Provider().Name(), rd.Resources)
+			if costs != nil && a.Provider().Name() == cloudy.ProviderAWS {
+				if err := costs.Estimate(ctx, rd.Resources); err != nil {
+					rd.CostError = err.Error()
+				}
+			}
+			reportRegionScan(t, a.Provider().Name(), mode, rd, time.Since(started), full && ctx.Err() == nil)
+			// Only a full listing of the region replaces what search sees;
+			// one cut short by a cancelled request isn't full, and an
+			// index lacks detail and may miss resources
+			if full && ctx.Err() == nil {
+				t.latestScan.Record(a.Provider().Name(), rd.Region, rd.Resources, rd.Error == "")
+				// A partial listing would show up as a dip in the trend
+				if rd.Error == "" {
+					t.trends.Record(a.Provider().Name(), rd.Region, rd.Resources)
+					t.snapshots.Record(a.Provider().Name(), rd.Region, rd.Resources)
+				}
 			}
+			rd.Resources = filterResources(rd.Resources, req, matchesName)
+			regionCh <- rd
 		}
+		reportScan(t, a.Provider().Name(), mode, len(req.Regions), time.Since(started))
+	}()
+	return regionCh
+}
 
-		resources = append(resources, Resource{
-			ID:         aws_string_value(instance.DBInstanceIdentifier),
-			Name:       aws_string_value(instance.DBInstanceIdentifier),
-			Type:       "RDS Instance",
-			State:      aws_string_value(instance.DBInstanceStatus),
-			Region:     cfg.Region,
-			Attributes: attributes,
-		})
+func listResources(c *gin.Context) {
+	var req RegionsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
 	}
 
-	return resources, nil
+	respondWithResources(c, req)
 }
 
-func (a *AWSResourceLister) listLambdaFunctions(ctx context.Context, cfg aws.Config) ([]Resource, error) {
-	client := lambda.NewFromConfig(cfg)
-	result, err := client.ListFunctions(ctx, &lambda.ListFunctionsInput{})
+// listResourcesQuery is the GET form of listResources, taking the request
+// from query parameters.
+func listResourcesQuery(c *gin.Context) {
+	req, err := bindResourcesQuery(c)
 	if err != nil {
-		return nil, err
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
 	}
 
-	var resources []Resource
-	for _, function := range result.Functions {
-		attributes := map[string]string{
-			"runtime":     string(function.Runtime),
-			"handler":     aws_string_value(function.Handler),
-			"memory_size": fmt.Sprintf("%d", aws_int32_value(function.MemorySize)),
-			"timeout":     fmt.Sprintf("%d", aws_int32_value(function.Timeout)),
-		}
+	respondWithResources(c, req)
+}
 
-		resources = append(resources, Resource{
-			ID:         aws_string_value(function.FunctionArn),
-			Name:       aws_string_value(function.FunctionName),
-			Type:       "Lambda Function",
-			State:      string(function.State),
-			Region:     cfg.Region,
-			Attributes: attributes,
-		})
+func respondWithResources(c *gin.Context, req RegionsRequest) {
+	if len(req.Regions) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "at least one region must be specified"})
+		return
 	}
 
-	return resources, nil
-}
-
-func (a *AWSResourceLister) listECSClusters(ctx context.Context, cfg aws.Config) ([]Resource, error) {
-	client := ecs.NewFromConfig(cfg)
-	listResult, err := client.ListClusters(ctx, &ecs.ListClustersInput{})
-	if err != nil {
-		return nil, err
+	if err := validateSort(req.Sort, req.Order); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
 	}
 
-	if len(listResult.ClusterArns) == 0 {
-		return []Resource{}, nil
+	if err := validateMode(req.Mode); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
 	}
 
-	describeResult, err := client.DescribeClusters(ctx, &ecs.DescribeClustersInput{
-		Clusters: listResult.ClusterArns,
-	})
-	if err != nil {
-		return nil, err
+	if err := validateCredentialsTransport(c.Request, req.Credentials); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
 	}
 
-	var resources []Resource
-	for _, cluster := range describeResult.Clusters {
-		attributes := map[string]string{
-			"active_services_count": fmt.Sprintf("%d", cluster.ActiveServicesCount),
-			"running_tasks_count":   fmt.Sprintf("%d", cluster.RunningTasksCount),
-			"pending_tasks_count":   fmt.Sprintf("%d", cluster.PendingTasksCount),
-		}
+	if _, err := nameMatcher(req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
 
-		resources = append(resources, Resource{
-			ID:         aws_string_value(cluster.ClusterArn),
-			Name:       aws_string_value(cluster.ClusterName),
-			Type:       "ECS Cluster",
-			State:      aws_string_value(cluster.Status),
-			Region:     cfg.Region,
-			Attributes: attributes,
-		})
+	if err := validateKinds(req.Kinds); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
 	}
 
-	return resources, nil
-}
+	if req.Limit < 0 || req.Limit > maxPageLimit {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("limit must be between 0 and %d", maxPageLimit)})
+		return
+	}
 
-func (a *AWSResourceLister) listIAMUsers(ctx context.Context, cfg aws.Config) ([]Resource, error) {
-	client := iam.NewFromConfig(cfg)
-	result, err := client.ListUsers(ctx, &iam.ListUsersInput{})
+	offset, err := decodePageToken(req.NextToken)
 	if err != nil {
-		return nil, err
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
 	}
 
-	var resources []Resource
-	for _, user := range result.Users {
-		attributes := map[string]string{
-			"path":    aws_string_value(user.Path),
-			"created": user.CreateDate.String(),
-			"user_id": aws_string_value(user.UserId),
-		}
-
-		resources = append(resources, Resource{
-			ID:         aws_string_value(user.Arn),
-			Name:       aws_string_value(user.UserName),
-			Type:       "IAM User",
-			Region:     "global", // IAM is global
-			Attributes: attributes,
-		})
+	format, err := responseFormat(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
 	}
-
-	return resources, nil
-}
-
-// Helper functions
-func aws_string_value(s *string) string {
-	if s == nil {
-		return ""
+	fields, err := parseFields(req.Fields)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
 	}
-	return *s
-}
-
-func aws_int32_value(i *int32) int32 {
-	if i == nil {
-		return 0
+	query, err := compileQuery(req.Query)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
 	}
-	return *i
-}
-
-func listResources(c *gin.Context) {
-	var req RegionsRequest
-	if err := c.ShouldBindJSON(&req); err != nil {
+	if err := validateView(req, format); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
+	if query != nil && format != formatJSON && format != formatYAML {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "query is only supported with json and yaml output"})
+		return
+	}
+	if (format == formatDOT || format == formatCypher) && len(req.Fields) > 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "fields is not supported with dot and cypher output"})
+		return
+	}
+	if format == formatNDJSON && (req.Sort != "" || req.Limit != 0 || req.NextToken != "") {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "sort, limit and next_token are not supported with ndjson output"})
+		return
+	}
 
-	if len(req.Regions) == 0 {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "at least one region must be specified"})
+	lister, err := listerFor(c.Request.Context(), req.Provider, req.Credentials)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := validateProviderMode(lister, req.Mode); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
-	lister, err := NewAWSResourceLister()
+	req.Regions, err = lister.resolveRegions(c.Request.Context(), req.Regions)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to initialize AWS client: " + err.Error()})
+		c.JSON(http.StatusBadGateway, gin.H{"error": err.Error()})
 		return
 	}
 
-	ctx := context.Background()
-	var wg sync.WaitGroup
-	var mu sync.Mutex
-	var regionData []RegionResources
+	release, ok := limitScan(c)
+	if !ok {
+		return
+	}
+	defer release()
 
-	for _, region := range req.Regions {
-		wg.Add(1)
-		go func(r string) {
-			defer wg.Done()
-
-			resources, err := lister.ListResourcesInRegion(ctx, r)
-
-			mu.Lock()
-			if err != nil {
-				regionData = append(regionData, RegionResources{
-					Region:    r,
-					Resources: resources, // Include partial results even with errors
-					Error:     err.Error(),
-				})
-			} else {
-				regionData = append(regionData, RegionResources{
-					Region:    r,
-					Resources: resources,
-				})
-			}
-			mu.Unlock()
-		}(region)
+	if format == formatNDJSON {
+		streamResourcesNDJSON(c, lister, req, fields)
+		return
+	}
+	if format == formatJSON && query == nil && req.View == "" {
+		spoolResourcesJSON(c, lister, req, offset, fields)
+		return
 	}
 
-	wg.Wait()
+	regionData := lister.scanRegions(c.Request.Context(), req)
 
 	// Calculate total count
 	totalCount := 0
@@ -438,12 +395,45 @@ func listResources(c *gin.Context) {
 		totalCount += len(rd.Resources)
 	}
 
+	sortRegionData(regionData, req.Sort, req.Order)
+
+	if req.View == viewAccounts {
+		writeAccountsView(c, format, regionData, fields)
+		return
+	}
+
 	response := ListResourcesResponse{
 		RegionData: regionData,
 		TotalCount: totalCount,
 	}
 
-	c.JSON(http.StatusOK, response)
+	if next := paginateRegionData(regionData, offset, req.Limit); next >= 0 {
+		response.NextToken = encodePageToken(next)
+	}
+
+	if notModified(c, resourcesETag(format+"\n"+req.Query, response, fields)) {
+		return
+	}
+
+	if query != nil {
+		writeQueryResult(c, format, query, response, fields)
+		return
+	}
+
+	switch format {
+	case formatCSV:
+		writeResourcesCSV(c, response, fields)
+	case formatXLSX:
+		writeResourcesXLSX(c, response, fields)
+	case formatParquet:
+		writeResourcesParquet(c, response, fields)
+	case formatDOT, formatCypher:
+		writeResourcesGraph(c, format, response)
+	case formatYAML:
+		c.YAML(http.StatusOK, fields.response(response))
+	default:
+		writeResourcesJSON(c, response, fields)
+	}
 }
 
 func healthCheck(c *gin.Context) {
@@ -457,12 +447,20 @@ func healthCheck(c *gin.Context) {
 func setupRouter() *gin.Engine {
 	gin.SetMode(gin.ReleaseMode)
 	r := gin.Default()
+	proxies := make([]string, len(trustedProxies))
+	for i, prefix := range trustedProxies {
+		proxies[i] = prefix.String()
+	}
+	// The prefixes are already valid
+	_ = r.SetTrustedProxies(proxies)
+	r.Use(gzipMiddleware())
 
 	// Add CORS middleware
 	r.Use(func(c *gin.Context) {
 		c.Header("Access-Control-Allow-Origin", "*")
 		c.Header("Access-Control-Allow-Methods", "POST, GET, OPTIONS, PUT, DELETE")
-		c.Header("Access-Control-Allow-Headers", "Accept, Content-Type, Content-Length, Accept-Encoding, X-CSRF-Token, Authorization")
+		c.Header("Access-Control-Allow-Headers", "Accept, Content-Type, Content-Length, Accept-Encoding, X-CSRF-Token, Authorization, X-API-Key, If-None-Match")
+		c.Header("Access-Control-Expose-Headers", "X-Total-Count, X-Next-Token, ETag, Location")
 
 		if c.Request.Method == "OPTIONS" {
 			c.AbortWithStatus(204)
@@ -474,16 +472,377 @@ func setupRouter() *gin.Engine {
 
 	// Routes
 	r.GET("/health", healthCheck)
-	r.POST("/api/v1/resources", listResources)
+	r.GET("/openapi.json", openAPIHandler)
+	r.GET("/docs/*file", swaggerUIHandler())
+
+	// Everything else is scanned for, and only shown to, a tenant
+	api := r.Group("", authenticateTenant)
+	api.GET("/api/v1/resources", listResourcesQuery)
+	api.POST("/api/v1/resources", listResources)
+	api.GET("/api/v1/resources/*id", getResource)
+	api.POST("/api/v1/resources/lookup", lookupResources)
+	api.GET("/api/v1/summary", summarizeResources)
+	api.GET("/api/v1/trends", getTrends)
+	api.GET("/api/v1/diff", diffSnapshots)
+	api.GET("/api/v1/snapshots", listSnapshots)
+	api.GET("/api/v1/snapshots/resources", getSnapshotResources)
+	api.GET("/api/v1/grafana", healthCheck)
+	api.POST("/api/v1/grafana/search", grafanaSearch)
+	api.POST("/api/v1/grafana/query", grafanaQuery)
+	api.POST("/api/v1/scans", startScan)
+	api.GET("/api/v1/scans/:id", getScan)
+	api.DELETE("/api/v1/scans/:id", deleteScan)
+	api.GET("/api/v1/search", searchResources)
+	api.POST("/api/v1/query", queryInventory)
+	api.GET("/api/v2/services", listServices)
+	api.POST("/api/v2/resources", listResourcesV2)
+	api.POST("/graphql", graphQLHandler())
+	if metricsEnabled {
+		api.GET("/metrics", serveMetrics)
+	}
 
 	return r
 }
 
+// envInt reads a positive number from the environment variable name, or
+// returns fallback if it isn't set.
+func envInt(name string, fallback int) int {
+	value := os.Getenv(name)
+	if value == "" {
+		return fallback
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil || n <= 0 {
+		log.Fatalf("%s must be a positive number, got %q", name, value)
+	}
+	return n
+}
+
+// envBool reads a boolean, such as "true", from the environment variable
+// name, or returns false if it isn't set.
+func envBool(name string) bool {
+	value := os.Getenv(name)
+	if value == "" {
+		return false
+	}
+	b, err := strconv.ParseBool(value)
+	if err != nil {
+		log.Fatalf("%s must be true or false, got %q", name, value)
+	}
+	return b
+}
+
+// envMap reads a comma-separated list of key=value pairs, such as
+// "team=platform,env=prod".
+func envMap(name string) map[string]string {
+	pairs := make(map[string]string)
+	for _, item := range envList(name) {
+		key, value, ok := strings.Cut(item, "=")
+		if !ok || strings.TrimSpace(key) == "" {
+			log.Fatalf("%s must be a list of key=value pairs, got %q", name, item)
+		}
+		pairs[strings.TrimSpace(key)] = strings.TrimSpace(value)
+	}
+	return pairs
+}
+
+// envDuration reads a positive duration, such as "30s", from the
+// environment variable name, or returns fallback if it isn't set.
+func envDuration(name string, fallback time.Duration) time.Duration {
+	value := os.Getenv(name)
+	if value == "" {
+		return fallback
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil || d <= 0 {
+		log.Fatalf("%s must be a positive duration such as 30s, got %q", name, value)
+	}
+	return d
+}
+
+// envList reads a comma-separated list, leaving out empty items.
+func envList(name string) []string {
+	var items []string
+	for _, item := range strings.Split(os.Getenv(name), ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
 func main() {
-	r := setupRouter()
+	if path := os.Getenv("CLOUDY_TRENDS_FILE"); path != "" {
+		if err := resourceTrends.Load(path); err != nil {
+			log.Fatal("Failed to load trends:", err)
+		}
+	}
+
+	scanMaxResults = envInt("CLOUDY_MAX_RESULTS", 0)
+	scanPool = cloudy.NewWorkerPool(cloudy.Concurrency{
+		Total:       envInt("CLOUDY_CONCURRENCY", cloudy.DefaultConcurrency.Total),
+		PerAccount:  envInt("CLOUDY_ACCOUNT_CONCURRENCY", cloudy.DefaultConcurrency.PerAccount),
+		PerRegion:   envInt("CLOUDY_REGION_CONCURRENCY", cloudy.DefaultConcurrency.PerRegion),
+		PerService:  envInt("CLOUDY_SERVICE_CONCURRENCY", cloudy.DefaultConcurrency.PerService),
+		AccountRate: float64(envInt("CLOUDY_ACCOUNT_RATE", 0)),
+	})
+	scanTimeouts = cloudy.Timeouts{
+		Call:   envDuration("CLOUDY_CALL_TIMEOUT", cloudy.DefaultTimeouts.Call),
+		Region: envDuration("CLOUDY_REGION_TIMEOUT", cloudy.DefaultTimeouts.Region),
+		Scan:   envDuration("CLOUDY_SCAN_TIMEOUT", cloudy.DefaultTimeouts.Scan),
+	}
+	scanCacheTTL = envDuration("CLOUDY_CACHE_TTL", defaultCacheTTL)
+	sqlTimeout = envDuration("CLOUDY_SQL_TIMEOUT", sqlTimeout)
+	scanCache = cloudy.NewResultCache(scanCacheTTL)
+	accountNames = newAccountNames()
+	explorerRegion = os.Getenv("CLOUDY_EXPLORER_REGION")
+	explorerView = os.Getenv("CLOUDY_EXPLORER_VIEW")
+	requestCredentials = envBool("CLOUDY_REQUEST_CREDENTIALS")
+	awsProfiles = envList("CLOUDY_AWS_PROFILES")
+	gcpKeyDir = os.Getenv("CLOUDY_GCP_KEY_DIR")
+	proxies, err := parseTrustedProxies(envList("CLOUDY_TRUSTED_PROXIES"))
+	if err != nil {
+		log.Fatalf("CLOUDY_TRUSTED_PROXIES: %v", err)
+	}
+	trustedProxies = proxies
+	assumeRoleARN = os.Getenv("CLOUDY_ROLE_ARN")
+	assumeRole = cloudy.AssumeRoleOptions{
+		ExternalID:  os.Getenv("CLOUDY_ROLE_EXTERNAL_ID"),
+		SessionName: os.Getenv("CLOUDY_ROLE_SESSION_NAME"),
+		Tags:        envMap("CLOUDY_ROLE_SESSION_TAGS"),
+	}
+
+	awsLister, err = NewAWSResourceLister()
+	if err != nil {
+		log.Fatal("Failed to initialize AWS client:", err)
+	}
+	// Scans fail until someone signs in again, but the server can start
+	if err := awsLister.CheckSSOSession(context.TODO()); err != nil {
+		log.Printf("AWS scans will fail: %v", err)
+	}
+	providerListers[cloudy.ProviderAWS] = awsLister
+	if envBool("CLOUDY_COSTS") {
+		// Prices are public, so the server's identity looks up everyone's
+		costs = newCostEstimator(awsLister.Config())
+	}
+	if subscriptions := envList("AZURE_SUBSCRIPTION_ID"); len(subscriptions) > 0 {
+		azureLister, err := NewAzureResourceLister(subscriptions)
+		if err != nil {
+			log.Fatal("Failed to initialize Azure client:", err)
+		}
+		providerListers[azure.ProviderName] = azureLister
+	}
+	if projects := envList("GOOGLE_CLOUD_PROJECT"); len(projects) > 0 {
+		gcpLister, err := NewGCPResourceLister(projects)
+		if err != nil {
+			log.Fatal("Failed to initialize GCP client:", err)
+		}
+		providerListers[gcp.ProviderName] = gcpLister
+	}
+	if token := os.Getenv("DIGITALOCEAN_TOKEN"); token != "" {
+		providerListers[digitalocean.ProviderName] = NewDigitalOceanResourceLister(token, os.Getenv("SPACES_ACCESS_KEY_ID"), os.Getenv("SPACES_SECRET_ACCESS_KEY"))
+	}
+	if compartments := envList("OCI_COMPARTMENT_ID"); len(compartments) > 0 {
+		ociLister, err := NewOCIResourceLister(compartments)
+		if err != nil {
+			log.Fatal("Failed to initialize OCI client:", err)
+		}
+		providerListers[oci.ProviderName] = ociLister
+	}
+	if token := os.Getenv("HCLOUD_TOKEN"); token != "" {
+		providerListers[hetzner.ProviderName] = NewHetznerResourceLister(token)
+	}
+	if token := os.Getenv("LINODE_TOKEN"); token != "" {
+		providerListers[linode.ProviderName] = NewLinodeResourceLister(token)
+	}
+	if token, account := os.Getenv("CLOUDFLARE_API_TOKEN"), os.Getenv("CLOUDFLARE_ACCOUNT_ID"); token != "" && account != "" {
+		cloudflareLister, err := NewCloudflareResourceLister(token, account)
+		if err != nil {
+			log.Fatal("Failed to initialize Cloudflare client:", err)
+		}
+		providerListers[cloudflare.ProviderName] = cloudflareLister
+	}
+	if contexts := envList("CLOUDY_KUBE_CONTEXTS"); len(contexts) > 0 {
+		kubernetesLister, err := NewKubernetesResourceLister(contexts)
+		if err != nil {
+			log.Fatal("Failed to initialize Kubernetes client:", err)
+		}
+		providerListers[kubernetes.ProviderName] = kubernetesLister
+	}
+	if os.Getenv("OS_AUTH_URL") != "" {
+		openStackLister, err := NewOpenStackResourceLister()
+		if err != nil {
+			log.Fatal("Failed to initialize OpenStack client:", err)
+		}
+		providerListers[openstack.ProviderName] = openStackLister
+	}
+	if path := os.Getenv("CLOUDY_TENANTS_FILE"); path != "" {
+		tenantsByKey, err = loadTenants(path)
+		if err != nil {
+			log.Fatal("Failed to load tenants:", err)
+		}
+	}
+	snapshotRetention = envDuration("CLOUDY_SNAPSHOT_RETENTION", defaultSnapshotRetention)
+	snapshotLimit = envInt("CLOUDY_SNAPSHOT_LIMIT", 0)
+	snapshotCompactAfter = envDuration("CLOUDY_SNAPSHOT_COMPACT_AFTER", 0)
+	if dir := os.Getenv("CLOUDY_SNAPSHOTS_DIR"); dir != "" {
+		for _, t := range scheduledTenants() {
+			if err := t.snapshots.Load(snapshotDir(dir, t)); err != nil {
+				log.Fatal("Failed to load snapshots:", err)
+			}
+		}
+	}
+	if table := os.Getenv("CLOUDY_DYNAMODB_TABLE"); table != "" {
+		if err := openInventory(context.TODO(), table, os.Getenv("CLOUDY_DYNAMODB_REGION")); err != nil {
+			log.Fatal("Failed to load the inventory from DynamoDB:", err)
+		}
+	}
+	if path := os.Getenv("CLOUDY_SCHEDULE_FILE"); path != "" {
+		schedules, err = loadSchedules(path)
+		if err != nil {
+			log.Fatal("Failed to load schedules:", err)
+		}
+	}
+	metricsEnabled = envBool("CLOUDY_METRICS")
+	if metricsEnabled && len(schedules) == 0 {
+		// Exporter mode keeps the metrics fresh without an outside scanner
+		scheduleInterval = envDuration("CLOUDY_SCHEDULE_INTERVAL", defaultMetricsInterval)
+	} else {
+		scheduleInterval = envDuration("CLOUDY_SCHEDULE_INTERVAL", 0)
+	}
+	if regions := envList("CLOUDY_SCHEDULE_REGIONS"); len(regions) > 0 {
+		scheduleRegions = regions
+	}
+	if path := os.Getenv("CLOUDY_POLICIES_FILE"); path != "" {
+		policies, err = loadPolicies(path)
+		if err != nil {
+			log.Fatal("Failed to load policies:", err)
+		}
+		if !scheduled() {
+			log.Println("Policies are only checked on scheduled scans; set CLOUDY_SCHEDULE_INTERVAL or CLOUDY_SCHEDULE_FILE")
+		}
+	}
+	pagerDuty.routingKey = os.Getenv("CLOUDY_PAGERDUTY_ROUTING_KEY")
+	pagerDuty.teamKeys = envMap("CLOUDY_PAGERDUTY_TEAM_KEYS")
+	if tag := os.Getenv("CLOUDY_PAGERDUTY_TEAM_TAG"); tag != "" {
+		pagerDuty.teamTag = tag
+	}
+	if jiraURL := os.Getenv("CLOUDY_JIRA_URL"); jiraURL != "" {
+		jira, err = newJiraClient(jiraURL, os.Getenv("CLOUDY_JIRA_USER"), os.Getenv("CLOUDY_JIRA_TOKEN"), os.Getenv("CLOUDY_JIRA_PROJECT"),
+			os.Getenv("CLOUDY_JIRA_ISSUE_TYPE"), os.Getenv("CLOUDY_JIRA_DONE_TRANSITION"), os.Getenv("CLOUDY_JIRA_TEAM_TAG"), envMap("CLOUDY_JIRA_TEAM_ASSIGNEES"))
+		if err != nil {
+			log.Fatal("Failed to set up Jira:", err)
+		}
+	}
+	if path := os.Getenv("CLOUDY_SERVICENOW_FILE"); path != "" {
+		serviceNow, err = loadServiceNow(path, os.Getenv("CLOUDY_SERVICENOW_USER"), os.Getenv("CLOUDY_SERVICENOW_PASSWORD"), os.Getenv("CLOUDY_SERVICENOW_TOKEN"))
+		if err != nil {
+			log.Fatal("Failed to load the ServiceNow export:", err)
+		}
+		if !scheduled() {
+			log.Println("Resources are only exported to ServiceNow on scheduled scans; set CLOUDY_SCHEDULE_INTERVAL or CLOUDY_SCHEDULE_FILE")
+		}
+	}
+	if path := os.Getenv("CLOUDY_WEBHOOKS_FILE"); path != "" {
+		webhooks, err = loadWebhooks(path)
+		if err != nil {
+			log.Fatal("Failed to load webhooks:", err)
+		}
+	}
+	if bucket := os.Getenv("CLOUDY_EXPORT_BUCKET"); bucket != "" {
+		formats := envList("CLOUDY_EXPORT_FORMATS")
+		if len(formats) == 0 {
+			formats = []string{formatNDJSON}
+		}
+		if err := newSnapshotExport(bucket, os.Getenv("CLOUDY_EXPORT_PREFIX"), os.Getenv("CLOUDY_EXPORT_REGION"), formats); err != nil {
+			log.Fatal("Failed to set up snapshot exports:", err)
+		}
+		if !scheduled() {
+			log.Println("Snapshots are only exported for scheduled scans; set CLOUDY_SCHEDULE_INTERVAL or CLOUDY_SCHEDULE_FILE")
+		}
+	}
+	changePublishers, err = newChangePublishers(os.Getenv("CLOUDY_EVENTS_SNS_TOPIC"), os.Getenv("CLOUDY_EVENTS_SQS_QUEUE"), os.Getenv("CLOUDY_EVENTS_BUS"))
+	if err != nil {
+		log.Fatal("Failed to set up change events:", err)
+	}
+	if addr := os.Getenv("CLOUDY_DATADOG_ADDR"); addr != "" {
+		datadog, err = newDogStatsD(addr, envList("CLOUDY_DATADOG_TAGS"))
+		if err != nil {
+			log.Fatal("Failed to connect to the Datadog agent:", err)
+		}
+	}
+	if path := os.Getenv("CLOUDY_REPORTS_FILE"); path != "" {
+		reports, err = loadReports(path)
+		if err != nil {
+			log.Fatal("Failed to load reports:", err)
+		}
+		err = setupReportMail(os.Getenv("CLOUDY_REPORTS_FROM"), os.Getenv("CLOUDY_SMTP_ADDR"), os.Getenv("CLOUDY_SMTP_USER"), os.Getenv("CLOUDY_SMTP_PASSWORD"), os.Getenv("CLOUDY_SES_REGION"))
+		if err != nil {
+			log.Fatal("Failed to set up report emails:", err)
+		}
+	}
+	slackBotToken = os.Getenv("CLOUDY_SLACK_BOT_TOKEN")
+	if path := os.Getenv("CLOUDY_SLACK_FILE"); path != "" {
+		slackChannels, err = loadSlackChannels(path)
+		if err != nil {
+			log.Fatal("Failed to load Slack channels:", err)
+		}
+		if !scheduled() {
+			log.Println("Slack digests are only posted for scheduled scans; set CLOUDY_SCHEDULE_INTERVAL or CLOUDY_SCHEDULE_FILE")
+		}
+	}
+	if path := os.Getenv("CLOUDY_TEAMS_FILE"); path != "" {
+		teamsChannels, err = loadTeamsChannels(path)
+		if err != nil {
+			log.Fatal("Failed to load Teams channels:", err)
+		}
+		if !scheduled() {
+			log.Println("Teams digests are only posted for scheduled scans; set CLOUDY_SCHEDULE_INTERVAL or CLOUDY_SCHEDULE_FILE")
+		}
+	}
+
+	scanLimit = newScanLimiter(envInt("CLOUDY_MAX_SCANS", defaultMaxScans), envInt("CLOUDY_SCAN_QUEUE", defaultScanQueue))
+	shutdownGrace := envDuration("CLOUDY_SHUTDOWN_GRACE", defaultShutdownGrace)
+
+	tlsCert, tlsKey := os.Getenv("CLOUDY_TLS_CERT_FILE"), os.Getenv("CLOUDY_TLS_KEY_FILE")
+	server := &http.Server{Addr: ":8080", Handler: setupRouter()}
+	grpcServer := newGRPCServer()
+
+	go func() {
+		log.Println("Starting Cloudy gRPC API on port 9090")
+		if err := serveGRPC(grpcServer, ":9090"); err != nil {
+			log.Fatal("Failed to start gRPC server:", err)
+		}
+	}()
+
+	go func() {
+		var err error
+		if tlsCert != "" && tlsKey != "" {
+			log.Println("Starting Cloudy AWS Resource Lister on port 8080 (HTTPS)")
+			err = server.ListenAndServeTLS(tlsCert, tlsKey)
+		} else {
+			log.Println("Starting Cloudy AWS Resource Lister on port 8080")
+			err = server.ListenAndServe()
+		}
+		if err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Fatal("Failed to start server:", err)
+		}
+	}()
 
-	log.Println("Starting Cloudy AWS Resource Lister on port 8080")
-	if err := r.Run(":8080"); err != nil {
-		log.Fatal("Failed to start server:", err)
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	if scheduleInterval > 0 {
+		log.Printf("Scanning every %s", scheduleInterval)
+		go runSchedule(ctx)
+	}
+	for _, schedule := range schedules {
+		log.Printf("Scanning on schedule %s: %s", schedule.Name, schedule.Cron)
 	}
+	runSchedules(ctx)
+	runReports(ctx)
+	<-ctx.Done()
+	stop()
+
+	log.Printf("Shutting down, giving in-flight scans up to %s", shutdownGrace)
+	shutdown(server, grpcServer, shutdownGrace)
+	log.Println("Shut down")
 }
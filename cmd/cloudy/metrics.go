@@ -0,0 +1,74 @@
+package main
+
+import (
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// defaultMetricsInterval is how often exporter mode scans when neither
+// CLOUDY_SCHEDULE_INTERVAL nor CLOUDY_SCHEDULE_FILE is set.
+const defaultMetricsInterval = 5 * time.Minute
+
+// metricsEnabled serves the latest scans as Prometheus metrics on
+// /metrics, from CLOUDY_METRICS.
+var metricsEnabled bool
+
+var (
+	resourcesDesc = prometheus.NewDesc(
+		"cloudy_resources",
+		"Resources in the latest full scan of each region.",
+		[]string{"provider", "account", "region", "type", "state"}, nil,
+	)
+	regionScanTimeDesc = prometheus.NewDesc(
+		"cloudy_region_scan_timestamp_seconds",
+		"When the latest full scan of a region finished.",
+		[]string{"provider", "region"}, nil,
+	)
+	regionScanCompleteDesc = prometheus.NewDesc(
+		"cloudy_region_scan_complete",
+		"Whether every service listed the region without error in its latest full scan.",
+		[]string{"provider", "region"}, nil,
+	)
+)
+
+// inventoryCollector reports a tenant's latest scans as they are when
+// scraped, so resources that are gone stop being reported.
+type inventoryCollector struct {
+	store *scanStore
+}
+
+func (c inventoryCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- resourcesDesc
+	ch <- regionScanTimeDesc
+	ch <- regionScanCompleteDesc
+}
+
+func (c inventoryCollector) Collect(ch chan<- prometheus.Metric) {
+	type key struct{ provider, account, region, resourceType, state string }
+	counts := make(map[key]int)
+	c.store.Each(func(region regionKey, stored storedRegion) {
+		complete := 0.0
+		if stored.Complete {
+			complete = 1
+		}
+		ch <- prometheus.MustNewConstMetric(regionScanTimeDesc, prometheus.GaugeValue, float64(stored.ScannedAt.UnixMilli())/1000, region.Provider, region.Region)
+		ch <- prometheus.MustNewConstMetric(regionScanCompleteDesc, prometheus.GaugeValue, complete, region.Provider, region.Region)
+		for _, resource := range stored.Resources {
+			counts[key{resource.Provider, resource.AccountID, resource.Region, resource.Type, resource.State}]++
+		}
+	})
+	for k, n := range counts {
+		ch <- prometheus.MustNewConstMetric(resourcesDesc, prometheus.GaugeValue, float64(n), k.provider, k.account, k.region, k.resourceType, k.state)
+	}
+}
+
+// serveMetrics serves the requesting tenant's latest scans in the
+// Prometheus exposition format.
+func serveMetrics(c *gin.Context) {
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(inventoryCollector{store: tenantFrom(c.Request.Context()).latestScan})
+	promhttp.HandlerFor(registry, promhttp.HandlerOpts{}).ServeHTTP(c.Writer, c.Request)
+}
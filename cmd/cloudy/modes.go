@@ -0,0 +1,41 @@
+package main
+
+import (
+	"errors"
+	"strings"
+)
+
+// Scan modes. Full scans call every service's lister; fast and explorer
+// scans list each region from an index, the Resource Groups Tagging API or
+// AWS Resource Explorer, without states or attributes. Incremental scans
+// update the latest full scan of each region with the services CloudTrail
+// recorded changes to since.
+const (
+	scanModeFull        = "full"
+	scanModeFast        = "fast"
+	scanModeExplorer    = "explorer"
+	scanModeIncremental = "incremental"
+)
+
+func validateMode(mode string) error {
+	switch mode {
+	case "", scanModeFull, scanModeFast, scanModeExplorer, scanModeIncremental:
+		return nil
+	}
+	return errors.New("mode must be full, fast, explorer or incremental")
+}
+
+// needsDetail reports whether req needs what only the per-service listers
+// return: states, attributes or creation dates. A fast or explorer scan of
+// such a request runs as a full one.
+func needsDetail(req RegionsRequest) bool {
+	if len(req.States) > 0 || req.Sort == "state" || req.Sort == "created" {
+		return true
+	}
+	for _, field := range req.Fields {
+		if field == "state" || field == "attributes" || strings.HasPrefix(field, "attributes.") {
+			return true
+		}
+	}
+	return false
+}
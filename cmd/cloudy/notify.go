@@ -0,0 +1,219 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Notifications a chat channel can get after each scheduled scan.
+const (
+	notifyChanges    = "changes"
+	notifySummary    = "summary"
+	notifyViolations = "violations"
+)
+
+// chatDigestLines caps how many lines a digest's section lists; the rest
+// are counted.
+const chatDigestLines = 20
+
+// chatChannel is what Slack and Teams channels have in common: whose
+// scans they hear about, which resources, and what of them.
+type chatChannel struct {
+	// Tenant is the tenant whose scans the channel hears about, when
+	// tenants are enabled.
+	Tenant string `json:"tenant,omitempty"`
+	// Regions, Types and Tags filter the resources reported as a
+	// request's regions, types and tag_filters do; empty ones match
+	// everything.
+	Regions []string          `json:"regions,omitempty"`
+	Types   []string          `json:"types,omitempty"`
+	Tags    map[string]string `json:"tags,omitempty"`
+	// Notify is what the channel gets: changes, summary and violations;
+	// changes alone by default.
+	Notify []string `json:"notify,omitempty"`
+}
+
+// check validates the channel's settings, naming it as name in errors.
+func (ch chatChannel) check(name string, tenants map[string]bool) error {
+	if !tenants[ch.Tenant] {
+		if len(tenantsByKey) == 0 {
+			return fmt.Errorf("channel %s names tenant %q, but tenants aren't enabled", name, ch.Tenant)
+		}
+		return fmt.Errorf("channel %s needs the tenant whose changes it gets, got %q", name, ch.Tenant)
+	}
+	for _, notify := range ch.Notify {
+		switch notify {
+		case notifyChanges, notifySummary, notifyViolations:
+		default:
+			return fmt.Errorf("channel %s: unknown notify %q; expected %s, %s or %s", name, notify, notifyChanges, notifySummary, notifyViolations)
+		}
+	}
+	return nil
+}
+
+// matches reports whether the channel hears about resource.
+func (ch chatChannel) matches(resource Resource) bool {
+	return matchesAny(resource.Region, ch.Regions) && matchesAny(resource.Type, ch.Types) && matchesTagFilters(resource, ch.Tags)
+}
+
+func (ch chatChannel) notifies(notify string) bool {
+	if len(ch.Notify) == 0 {
+		return notify == notifyChanges
+	}
+	for _, n := range ch.Notify {
+		if n == notify {
+			return true
+		}
+	}
+	return false
+}
+
+// chatDigest is a notification, which each chat renders its own way.
+type chatDigest struct {
+	Title    string
+	Sections []chatSection
+}
+
+type chatSection struct {
+	Title string
+	Lines []chatLine
+}
+
+// chatLine describes Resource, followed by Text if set, or is Text alone.
+type chatLine struct {
+	Resource *Resource
+	Text     string
+}
+
+// chatDigests returns what ch gets for scan, leaving out what it has
+// nothing to report in.
+func chatDigests(ch chatChannel, scan scheduledScan) []chatDigest {
+	var digests []chatDigest
+	if ch.notifies(notifySummary) {
+		digests = append(digests, summaryDigest(ch, scan))
+	}
+	if ch.notifies(notifyChanges) {
+		if changes := scan.changes.filter(ch.matches); !changes.empty() {
+			digests = append(digests, changesDigest(changes))
+		}
+	}
+	if ch.notifies(notifyViolations) {
+		var opened, resolved []Violation
+		for _, v := range scan.opened {
+			if ch.matches(v.Resource) {
+				opened = append(opened, v)
+			}
+		}
+		for _, v := range scan.resolved {
+			if ch.matches(v.Resource) {
+				resolved = append(resolved, v)
+			}
+		}
+		if len(opened)+len(resolved) > 0 {
+			digests = append(digests, violationsDigest(opened, resolved))
+		}
+	}
+	return digests
+}
+
+// changesDigest lists what was created, deleted and changed.
+func changesDigest(changes ResourceChanges) chatDigest {
+	digest := chatDigest{Title: fmt.Sprintf("Cloudy found %d new, %d deleted and %d changed resources", len(changes.Added), len(changes.Removed), len(changes.Changed))}
+	if len(changes.Added) > 0 {
+		section := chatSection{Title: "New"}
+		for i := range changes.Added {
+			section.Lines = append(section.Lines, chatLine{Resource: &changes.Added[i]})
+		}
+		digest.Sections = append(digest.Sections, section)
+	}
+	if len(changes.Removed) > 0 {
+		section := chatSection{Title: "Deleted"}
+		for i := range changes.Removed {
+			section.Lines = append(section.Lines, chatLine{Resource: &changes.Removed[i]})
+		}
+		digest.Sections = append(digest.Sections, section)
+	}
+	if len(changes.Changed) > 0 {
+		section := chatSection{Title: "Changed"}
+		for i := range changes.Changed {
+			changed := changes.Changed[i]
+			text := strings.Join(changedFields(changed.Before, changed.After), ", ")
+			if changed.Before.State != changed.After.State {
+				text += fmt.Sprintf(" (%s → %s)", changed.Before.State, changed.After.State)
+			}
+			section.Lines = append(section.Lines, chatLine{Resource: &changes.Changed[i].After, Text: text})
+		}
+		digest.Sections = append(digest.Sections, section)
+	}
+	return digest
+}
+
+// summaryDigest counts the resources scan listed by type, and names the
+// regions it failed to list in full.
+func summaryDigest(ch chatChannel, scan scheduledScan) chatDigest {
+	total, regions := 0, 0
+	byType := make(map[string]int)
+	failed := chatSection{Title: "Errors"}
+	for _, rd := range scan.regionData {
+		if !matchesAny(rd.Region, ch.Regions) {
+			continue
+		}
+		regions++
+		if rd.Error != "" {
+			failed.Lines = append(failed.Lines, chatLine{Text: rd.Region + ": " + rd.Error})
+		}
+		for _, resource := range rd.Resources {
+			if ch.matches(resource) {
+				total++
+				byType[resource.Type]++
+			}
+		}
+	}
+
+	took := scan.finishedAt.Sub(scan.startedAt).Round(time.Second)
+	digest := chatDigest{Title: fmt.Sprintf("Cloudy scanned %d regions in %s and found %d resources", regions, took, total)}
+	types := make([]string, 0, len(byType))
+	for resourceType := range byType {
+		types = append(types, resourceType)
+	}
+	sort.Slice(types, func(i, j int) bool {
+		if byType[types[i]] != byType[types[j]] {
+			return byType[types[i]] > byType[types[j]]
+		}
+		return types[i] < types[j]
+	})
+	if len(types) > 0 {
+		section := chatSection{Title: "By type"}
+		for _, resourceType := range types {
+			section.Lines = append(section.Lines, chatLine{Text: fmt.Sprintf("%s: %d", resourceType, byType[resourceType])})
+		}
+		digest.Sections = append(digest.Sections, section)
+	}
+	if len(failed.Lines) > 0 {
+		digest.Sections = append(digest.Sections, failed)
+	}
+	return digest
+}
+
+// violationsDigest lists the violations that are new and those that are
+// gone.
+func violationsDigest(opened, resolved []Violation) chatDigest {
+	digest := chatDigest{Title: fmt.Sprintf("Cloudy found %d new policy violations and %d resolved", len(opened), len(resolved))}
+	for _, group := range []struct {
+		title      string
+		violations []Violation
+	}{{"New", opened}, {"Resolved", resolved}} {
+		if len(group.violations) == 0 {
+			continue
+		}
+		section := chatSection{Title: group.title}
+		for i := range group.violations {
+			v := group.violations[i]
+			section.Lines = append(section.Lines, chatLine{Resource: &group.violations[i].Resource, Text: fmt.Sprintf("%s (%s)", v.Policy.Name, v.Policy.Severity)})
+		}
+		digest.Sections = append(digest.Sections, section)
+	}
+	return digest
+}
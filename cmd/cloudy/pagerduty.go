@@ -0,0 +1,188 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+)
+
+// pagerDutyEventsURL is the PagerDuty Events API v2 endpoint.
+var pagerDutyEventsURL = "https://events.pagerduty.com/v2/enqueue"
+
+// pagerDutyMaxDedupKey and pagerDutyMaxSummary are the longest dedup key
+// and summary the Events API takes.
+const (
+	pagerDutyMaxDedupKey = 255
+	pagerDutyMaxSummary  = 1024
+)
+
+// pagerDuty routes policy violations to PagerDuty services: to the
+// integration key of the team in the resource's teamTag tag, from
+// CLOUDY_PAGERDUTY_TEAM_KEYS, then to the policy's routing_key, then to
+// routingKey, from CLOUDY_PAGERDUTY_ROUTING_KEY. Violations with none of
+// them don't page.
+var pagerDuty = struct {
+	routingKey string
+	teamTag    string
+	teamKeys   map[string]string
+}{teamTag: "team"}
+
+// PagerDutyEvent is a trigger or resolve event of the Events API v2.
+type PagerDutyEvent struct {
+	RoutingKey  string            `json:"routing_key"`
+	EventAction string            `json:"event_action"`
+	DedupKey    string            `json:"dedup_key"`
+	Client      string            `json:"client,omitempty"`
+	Payload     *PagerDutyPayload `json:"payload,omitempty"`
+}
+
+type PagerDutyPayload struct {
+	Summary       string         `json:"summary"`
+	Source        string         `json:"source"`
+	Severity      string         `json:"severity"`
+	Component     string         `json:"component,omitempty"`
+	Group         string         `json:"group,omitempty"`
+	Class         string         `json:"class,omitempty"`
+	CustomDetails map[string]any `json:"custom_details,omitempty"`
+}
+
+// pagerDutyRoutingKey returns the integration key v pages, or "" if none.
+func pagerDutyRoutingKey(v Violation) string {
+	if key := pagerDuty.teamKeys[v.Resource.Tags[pagerDuty.teamTag]]; key != "" {
+		return key
+	}
+	if v.Policy.RoutingKey != "" {
+		return v.Policy.RoutingKey
+	}
+	return pagerDuty.routingKey
+}
+
+// pageViolations triggers an alert for each of t's opened violations and
+// resolves those of its resolved ones. Each resource's alert for a policy
+// has its own dedup key, so repeats, such as every violation being opened
+// again after a restart, update the open incident rather than page again.
+// Events are sent in the background, in order.
+func pageViolations(t *tenant, opened, resolved []Violation) {
+	var events []PagerDutyEvent
+	for _, v := range opened {
+		if key := pagerDutyRoutingKey(v); key != "" {
+			events = append(events, newPagerDutyTrigger(t, v, key))
+		}
+	}
+	for _, v := range resolved {
+		if key := pagerDutyRoutingKey(v); key != "" {
+			events = append(events, PagerDutyEvent{RoutingKey: key, EventAction: "resolve", DedupKey: pagerDutyDedupKey(t, v)})
+		}
+	}
+	if len(events) == 0 {
+		return
+	}
+	go func() {
+		for _, event := range events {
+			sendPagerDuty(event)
+		}
+	}()
+}
+
+func newPagerDutyTrigger(t *tenant, v Violation, routingKey string) PagerDutyEvent {
+	resource := v.Resource
+	summary := fmt.Sprintf("%s: %s %s in %s", v.Policy.Name, resource.Type, resourceLabel(resource), resource.Region)
+	if len(summary) > pagerDutyMaxSummary {
+		summary = summary[:pagerDutyMaxSummary]
+	}
+	group := resource.AccountID
+	if resource.AccountName != "" {
+		group = resource.AccountName
+	}
+	details := map[string]any{"resource": resource}
+	if v.Policy.Description != "" {
+		details["description"] = v.Policy.Description
+	}
+	if t != serverTenant {
+		details["tenant"] = t.name
+	}
+	return PagerDutyEvent{
+		RoutingKey:  routingKey,
+		EventAction: "trigger",
+		DedupKey:    pagerDutyDedupKey(t, v),
+		Client:      "Cloudy",
+		Payload: &PagerDutyPayload{
+			Summary:       summary,
+			Source:        resource.ID,
+			Severity:      v.Policy.Severity,
+			Component:     resource.Type,
+			Group:         group,
+			Class:         v.Policy.Name,
+			CustomDetails: details,
+		},
+	}
+}
+
+// pagerDutyDedupKey is v's violationRef, or its hash if that is too long.
+func pagerDutyDedupKey(t *tenant, v Violation) string {
+	key := violationRef(t, v)
+	if len(key) > pagerDutyMaxDedupKey {
+		sum := sha256.Sum256([]byte(key))
+		key = "cloudy/" + hex.EncodeToString(sum[:])
+	}
+	return key
+}
+
+// sendPagerDuty sends event, retrying with backoff after network errors,
+// 429s and 5xx responses as webhooks are.
+func sendPagerDuty(event PagerDutyEvent) {
+	body, err := json.Marshal(event)
+	if err != nil {
+		log.Printf("Failed to encode PagerDuty event %s: %v", event.DedupKey, err)
+		return
+	}
+
+	backoff := webhookBackoff
+	for attempt := 1; ; attempt++ {
+		retry, err := postPagerDuty(body)
+		if err == nil {
+			return
+		}
+		if !retry || attempt == webhookAttempts {
+			log.Printf("Failed to %s PagerDuty alert %s after %d attempts: %v", event.EventAction, event.DedupKey, attempt, err)
+			return
+		}
+		time.Sleep(backoff)
+		backoff *= 2
+	}
+}
+
+// postPagerDuty makes one attempt at sending body, reporting whether a
+// failure is worth retrying.
+func postPagerDuty(body []byte) (retry bool, err error) {
+	ctx, cancel := context.WithTimeout(context.Background(), webhookTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, pagerDutyEventsURL, bytes.NewReader(body))
+	if err != nil {
+		return false, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return true, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return false, nil
+	}
+	var answer struct {
+		Message string   `json:"message"`
+		Errors  []string `json:"errors"`
+	}
+	json.NewDecoder(resp.Body).Decode(&answer)
+	retry = resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500
+	return retry, fmt.Errorf("PagerDuty answered %s: %s %v", resp.Status, answer.Message, answer.Errors)
+}
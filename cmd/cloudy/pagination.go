@@ -0,0 +1,67 @@
+package main
+
+import (
+	"encoding/base64"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// maxPageLimit caps how many resources a single page may hold.
+const maxPageLimit = 5000
+
+// paginateRegionData trims the resources of each region to the window
+// [offset, offset+limit) of the flattened, ordered list. Regions keep their
+// entry (and error) even when none of their resources fall in the window.
+// It returns the offset of the next page, or -1 if this is the last one.
+func paginateRegionData(regionData []RegionResources, offset, limit int) int {
+	if limit <= 0 {
+		return -1
+	}
+
+	end := offset + limit
+	position := 0
+	for i, rd := range regionData {
+		count := len(rd.Resources)
+		start := clamp(offset-position, 0, count)
+		stop := clamp(end-position, 0, count)
+		regionData[i].Resources = rd.Resources[start:stop]
+		position += count
+	}
+
+	if end >= position {
+		return -1
+	}
+	return end
+}
+
+func clamp(v, low, high int) int {
+	if v < low {
+		return low
+	}
+	if v > high {
+		return high
+	}
+	return v
+}
+
+// encodePageToken and decodePageToken keep next_token opaque to clients
+// so the pagination scheme can change without breaking them.
+func encodePageToken(offset int) string {
+	return base64.RawURLEncoding.EncodeToString([]byte("offset:" + strconv.Itoa(offset)))
+}
+
+func decodePageToken(token string) (int, error) {
+	if token == "" {
+		return 0, nil
+	}
+	raw, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return 0, fmt.Errorf("invalid next_token")
+	}
+	offset, err := strconv.Atoi(strings.TrimPrefix(string(raw), "offset:"))
+	if err != nil || offset < 0 || !strings.HasPrefix(string(raw), "offset:") {
+		return 0, fmt.Errorf("invalid next_token")
+	}
+	return offset, nil
+}
@@ -0,0 +1,117 @@
+package main
+
+import (
+	"encoding/base64"
+	"reflect"
+	"testing"
+)
+
+func TestPageTokenRoundTrip(t *testing.T) {
+	for _, offset := range []int{0, 1, 100, maxPageLimit, 1 << 40} {
+		token := encodePageToken(offset)
+		got, err := decodePageToken(token)
+		if err != nil {
+			t.Errorf("decodePageToken(encodePageToken(%d)): %v", offset, err)
+			continue
+		}
+		if got != offset {
+			t.Errorf("decodePageToken(encodePageToken(%d)) = %d", offset, got)
+		}
+	}
+}
+
+func TestDecodePageToken(t *testing.T) {
+	encode := func(raw string) string {
+		return base64.RawURLEncoding.EncodeToString([]byte(raw))
+	}
+	tests := []struct {
+		name    string
+		token   string
+		want    int
+		wantErr bool
+	}{
+		{name: "empty is the first page", token: "", want: 0},
+		{name: "offset", token: encode("offset:250"), want: 250},
+		{name: "zero", token: encode("offset:0"), want: 0},
+		{name: "negative", token: encode("offset:-1"), wantErr: true},
+		{name: "no prefix", token: encode("250"), wantErr: true},
+		{name: "other prefix", token: encode("cursor:250"), wantErr: true},
+		{name: "not a number", token: encode("offset:ten"), wantErr: true},
+		{name: "empty offset", token: encode("offset:"), wantErr: true},
+		{name: "trailing data", token: encode("offset:10;drop"), wantErr: true},
+		{name: "padded base64", token: base64.URLEncoding.EncodeToString([]byte("offset:1")), wantErr: true},
+		{name: "standard alphabet", token: "b2Zmc2V0OjE+Pz8", wantErr: true},
+		{name: "not base64", token: "!!!", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := decodePageToken(tt.token)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("decodePageToken(%q) = %d, want an error", tt.token, got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("decodePageToken(%q): %v", tt.token, err)
+			}
+			if got != tt.want {
+				t.Errorf("decodePageToken(%q) = %d, want %d", tt.token, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPaginateRegionData(t *testing.T) {
+	resources := func(ids ...string) []Resource {
+		list := make([]Resource, len(ids))
+		for i, id := range ids {
+			list[i] = Resource{ID: id}
+		}
+		return list
+	}
+	regions := func() []RegionResources {
+		return []RegionResources{
+			{Region: "eu-west-1", Resources: resources("a", "b", "c")},
+			{Region: "us-east-1", Error: "denied"},
+			{Region: "us-west-2", Resources: resources("d", "e")},
+		}
+	}
+
+	tests := []struct {
+		name   string
+		offset int
+		limit  int
+		want   [][]string
+		next   int
+	}{
+		{name: "no limit", offset: 0, limit: 0, want: [][]string{{"a", "b", "c"}, {}, {"d", "e"}}, next: -1},
+		{name: "first page", offset: 0, limit: 2, want: [][]string{{"a", "b"}, {}, {}}, next: 2},
+		{name: "across regions", offset: 2, limit: 2, want: [][]string{{"c"}, {}, {"d"}}, next: 4},
+		{name: "last page", offset: 4, limit: 2, want: [][]string{{}, {}, {"e"}}, next: -1},
+		{name: "exactly the rest", offset: 3, limit: 2, want: [][]string{{}, {}, {"d", "e"}}, next: -1},
+		{name: "past the end", offset: 10, limit: 2, want: [][]string{{}, {}, {}}, next: -1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			regionData := regions()
+			next := paginateRegionData(regionData, tt.offset, tt.limit)
+			if next != tt.next {
+				t.Errorf("next offset = %d, want %d", next, tt.next)
+			}
+			got := make([][]string, len(regionData))
+			for i, rd := range regionData {
+				got[i] = []string{}
+				for _, resource := range rd.Resources {
+					got[i] = append(got[i], resource.ID)
+				}
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("pages = %v, want %v", got, tt.want)
+			}
+			if regionData[1].Error != "denied" {
+				t.Errorf("region error = %q, want it kept", regionData[1].Error)
+			}
+		})
+	}
+}
@@ -0,0 +1,243 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"sync"
+
+	"github.com/jmespath/go-jmespath"
+)
+
+// Policy severities, as PagerDuty names them.
+const (
+	severityCritical = "critical"
+	severityError    = "error"
+	severityWarning  = "warning"
+	severityInfo     = "info"
+)
+
+// Policy is one rule in CLOUDY_POLICIES_FILE. Every resource a scheduled
+// scan lists that it selects and When holds for is in violation of it.
+type Policy struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	// Severity is critical, error, warning (the default) or info.
+	Severity string `json:"severity,omitempty"`
+	// When is a JMESPath expression evaluated against each resource's
+	// JSON; it is violated when the result is true, or any other value
+	// JMESPath doesn't count as false.
+	When string `json:"when"`
+	// Tenant is the tenant whose resources the policy checks, when
+	// tenants are enabled.
+	Tenant string `json:"tenant,omitempty"`
+	// Regions, Types and Tags select the resources checked, as a
+	// request's regions, types and tag_filters do; empty ones select
+	// everything.
+	Regions []string          `json:"regions,omitempty"`
+	Types   []string          `json:"types,omitempty"`
+	Tags    map[string]string `json:"tags,omitempty"`
+	// RoutingKey is the PagerDuty integration key violations page, for
+	// resources whose team has none.
+	RoutingKey string `json:"routing_key,omitempty"`
+	// JiraProject is the key of the Jira project violations open issues
+	// in, instead of CLOUDY_JIRA_PROJECT.
+	JiraProject string `json:"jira_project,omitempty"`
+
+	expr *jmespath.JMESPath
+}
+
+// policies are checked on every scheduled scan, from CLOUDY_POLICIES_FILE.
+var policies []*Policy
+
+// Violation is a resource in violation of a policy.
+type Violation struct {
+	Policy   *Policy
+	Resource Resource
+}
+
+// key identifies the violation among its tenant's.
+func (v Violation) key() string {
+	return v.Policy.Name + "\n" + v.Resource.ID
+}
+
+// violationRef identifies t's violation v to other systems, as
+// cloudy/[tenant/]policy/resource ID.
+func violationRef(t *tenant, v Violation) string {
+	ref := "cloudy/"
+	if t != serverTenant {
+		ref += t.name + "/"
+	}
+	return ref + v.Policy.Name + "/" + v.Resource.ID
+}
+
+// loadPolicies reads the policies in path, a JSON file of the form
+// {"policies": [...]}.
+func loadPolicies(path string) ([]*Policy, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var file struct {
+		Policies []*Policy `json:"policies"`
+	}
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+
+	tenants := make(map[string]bool)
+	for _, t := range scheduledTenants() {
+		tenants[t.name] = true
+	}
+	names := make(map[string]bool)
+	for i, policy := range file.Policies {
+		switch {
+		case policy.Name == "":
+			return nil, fmt.Errorf("policy %d needs a name", i)
+		case names[policy.Name]:
+			return nil, fmt.Errorf("policy %s is defined twice", policy.Name)
+		case policy.When == "":
+			return nil, fmt.Errorf("policy %s needs a when expression", policy.Name)
+		case !tenants[policy.Tenant]:
+			if len(tenantsByKey) == 0 {
+				return nil, fmt.Errorf("policy %s names tenant %q, but tenants aren't enabled", policy.Name, policy.Tenant)
+			}
+			return nil, fmt.Errorf("policy %s needs the tenant whose resources it checks, got %q", policy.Name, policy.Tenant)
+		}
+		names[policy.Name] = true
+		switch policy.Severity {
+		case "":
+			policy.Severity = severityWarning
+		case severityCritical, severityError, severityWarning, severityInfo:
+		default:
+			return nil, fmt.Errorf("policy %s: unknown severity %q; expected critical, error, warning or info", policy.Name, policy.Severity)
+		}
+		policy.expr, err = jmespath.Compile(policy.When)
+		if err != nil {
+			return nil, fmt.Errorf("policy %s: invalid when: %w", policy.Name, err)
+		}
+	}
+	return file.Policies, nil
+}
+
+// selects reports whether the policy checks resource.
+func (p *Policy) selects(resource Resource) bool {
+	return matchesAny(resource.Region, p.Regions) && matchesAny(resource.Type, p.Types) && matchesTagFilters(resource, p.Tags)
+}
+
+// violatedBy evaluates When against document, resource's JSON form.
+func (p *Policy) violatedBy(document any) (bool, error) {
+	result, err := p.expr.Search(document)
+	if err != nil {
+		return false, err
+	}
+	return jmespathTruthy(result), nil
+}
+
+// jmespathTruthy reports whether JMESPath counts value as true: anything
+// but false, null and empty strings, arrays and objects.
+func jmespathTruthy(value any) bool {
+	switch v := value.(type) {
+	case nil:
+		return false
+	case bool:
+		return v
+	case string:
+		return v != ""
+	case []any:
+		return len(v) > 0
+	case map[string]any:
+		return len(v) > 0
+	}
+	return true
+}
+
+// violationStore keeps the violations a tenant's latest scheduled scan of
+// each region found, so the next only reports what changed.
+type violationStore struct {
+	mu      sync.Mutex
+	regions map[string]map[string]Violation
+}
+
+// update checks t's policies against regionData, returning the violations
+// that are new since the previous scan of their region and those that
+// are gone. Regions listed with an error are skipped, as what they
+// missed would look resolved.
+func (s *violationStore) update(t *tenant, regionData []RegionResources) (opened, resolved []Violation) {
+	var tenantPolicies []*Policy
+	for _, policy := range policies {
+		if policy.Tenant == t.name {
+			tenantPolicies = append(tenantPolicies, policy)
+		}
+	}
+	if len(tenantPolicies) == 0 {
+		return nil, nil
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.regions == nil {
+		s.regions = make(map[string]map[string]Violation)
+	}
+
+	for _, rd := range regionData {
+		if rd.Error != "" {
+			continue
+		}
+		current := make(map[string]Violation)
+		for _, resource := range rd.Resources {
+			var document any
+			for _, policy := range tenantPolicies {
+				if !policy.selects(resource) {
+					continue
+				}
+				if document == nil {
+					document = resourceDocument(resource)
+				}
+				// An expression that can't be evaluated, say a function
+				// given the wrong type, doesn't hold
+				if violated, err := policy.violatedBy(document); err == nil && violated {
+					v := Violation{Policy: policy, Resource: resource}
+					current[v.key()] = v
+				}
+			}
+		}
+
+		previous := s.regions[rd.Region]
+		for key, v := range current {
+			if _, ok := previous[key]; !ok {
+				opened = append(opened, v)
+			}
+		}
+		for key, v := range previous {
+			if _, ok := current[key]; !ok {
+				resolved = append(resolved, v)
+			}
+		}
+		s.regions[rd.Region] = current
+	}
+
+	sortViolations(opened)
+	sortViolations(resolved)
+	return opened, resolved
+}
+
+// resourceDocument is resource as the generic JSON JMESPath evaluates.
+func resourceDocument(resource Resource) any {
+	data, err := json.Marshal(resource)
+	if err != nil {
+		return map[string]any{}
+	}
+	var document any
+	if err := json.Unmarshal(data, &document); err != nil {
+		return map[string]any{}
+	}
+	return document
+}
+
+func sortViolations(violations []Violation) {
+	sort.Slice(violations, func(i, j int) bool {
+		return violations[i].key() < violations[j].key()
+	})
+}
@@ -0,0 +1,190 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// projection is a parsed fields parameter: the top-level Resource fields to
+// return and, for tags and attributes, either every key or just the named
+// ones. A nil projection returns everything.
+type projection struct {
+	fields        map[string]bool
+	tagKeys       map[string]bool
+	attributeKeys map[string]bool
+}
+
+// parseFields parses field names such as id, region, tags (every tag) or
+// tags.env (just the env tag). It returns nil when no fields are given.
+func parseFields(names []string) (*projection, error) {
+	if len(names) == 0 {
+		return nil, nil
+	}
+
+	p := &projection{fields: make(map[string]bool)}
+	for _, name := range names {
+		field, key, hasKey := strings.Cut(name, ".")
+		switch field {
+		case "id", "name", "type", "kind", "state", "region", "provider", "partition", "account_id", "account_name":
+			if hasKey {
+				return nil, fmt.Errorf("field %q has no subfields", field)
+			}
+			p.fields[field] = true
+		case "tags", "attributes":
+			if !hasKey {
+				p.fields[field] = true
+				continue
+			}
+			if key == "" {
+				return nil, fmt.Errorf("field %q needs a key after the dot", name)
+			}
+			keys := &p.tagKeys
+			if field == "attributes" {
+				keys = &p.attributeKeys
+			}
+			if *keys == nil {
+				*keys = make(map[string]bool)
+			}
+			(*keys)[key] = true
+		default:
+			return nil, fmt.Errorf("unknown field %q; expected id, name, type, kind, state, region, provider, partition, account_id, account_name, tags, attributes, tags.<key> or attributes.<key>", name)
+		}
+	}
+	return p, nil
+}
+
+func (p *projection) keeps(field string) bool {
+	return p == nil || p.fields[field]
+}
+
+// apply clears the fields of r that aren't selected, so tabular formats
+// can leave them empty.
+func (p *projection) apply(r Resource) Resource {
+	if p == nil {
+		return r
+	}
+	projected := Resource{
+		Tags:       projectMap(r.Tags, p.fields["tags"], p.tagKeys),
+		Attributes: projectMap(r.Attributes, p.fields["attributes"], p.attributeKeys),
+	}
+	if p.fields["id"] {
+		projected.ID = r.ID
+	}
+	if p.fields["name"] {
+		projected.Name = r.Name
+	}
+	if p.fields["type"] {
+		projected.Type = r.Type
+	}
+	if p.fields["kind"] {
+		projected.Kind = r.Kind
+	}
+	if p.fields["state"] {
+		projected.State = r.State
+	}
+	if p.fields["region"] {
+		projected.Region = r.Region
+	}
+	if p.fields["provider"] {
+		projected.Provider = r.Provider
+	}
+	if p.fields["partition"] {
+		projected.Partition = r.Partition
+	}
+	if p.fields["account_id"] {
+		projected.AccountID = r.AccountID
+	}
+	if p.fields["account_name"] {
+		projected.AccountName = r.AccountName
+	}
+	return projected
+}
+
+func projectMap(values map[string]string, all bool, keys map[string]bool) map[string]string {
+	if all {
+		return values
+	}
+	var projected map[string]string
+	for key := range keys {
+		if value, ok := values[key]; ok {
+			if projected == nil {
+				projected = make(map[string]string)
+			}
+			projected[key] = value
+		}
+	}
+	return projected
+}
+
+// projectedResource is how a projected resource is encoded: fields that
+// weren't selected are left out entirely rather than sent empty.
+type projectedResource struct {
+	ID          *string           `json:"id,omitempty" yaml:"id,omitempty"`
+	Name        *string           `json:"name,omitempty" yaml:"name,omitempty"`
+	Type        *string           `json:"type,omitempty" yaml:"type,omitempty"`
+	Kind        string            `json:"kind,omitempty" yaml:"kind,omitempty"`
+	State       string            `json:"state,omitempty" yaml:"state,omitempty"`
+	Region      *string           `json:"region,omitempty" yaml:"region,omitempty"`
+	Provider    string            `json:"provider,omitempty" yaml:"provider,omitempty"`
+	Partition   string            `json:"partition,omitempty" yaml:"partition,omitempty"`
+	AccountID   string            `json:"account_id,omitempty" yaml:"account_id,omitempty"`
+	AccountName string            `json:"account_name,omitempty" yaml:"account_name,omitempty"`
+	Tags        map[string]string `json:"tags,omitempty" yaml:"tags,omitempty"`
+	Attributes  map[string]string `json:"attributes,omitempty" yaml:"attributes,omitempty"`
+}
+
+// value returns what to encode for r: r itself without a projection, or a
+// projectedResource holding just the selected fields.
+func (p *projection) value(r Resource) any {
+	if p == nil {
+		return r
+	}
+	r = p.apply(r)
+	projected := projectedResource{Kind: r.Kind, State: r.State, Provider: r.Provider, Partition: r.Partition, AccountID: r.AccountID, AccountName: r.AccountName, Tags: r.Tags, Attributes: r.Attributes}
+	if p.fields["id"] {
+		projected.ID = &r.ID
+	}
+	if p.fields["name"] {
+		projected.Name = &r.Name
+	}
+	if p.fields["type"] {
+		projected.Type = &r.Type
+	}
+	if p.fields["region"] {
+		projected.Region = &r.Region
+	}
+	return projected
+}
+
+// projectedRegion and projectedResponse mirror RegionResources and
+// ListResourcesResponse for formats encoded in one go, like YAML.
+type projectedRegion struct {
+	Region    string         `json:"region" yaml:"region"`
+	Resources []any          `json:"resources" yaml:"resources"`
+	Error     string         `json:"error,omitempty" yaml:"error,omitempty"`
+	Errors    []ServiceError `json:"errors,omitempty" yaml:"errors,omitempty"`
+	Throttles int            `json:"throttles,omitempty" yaml:"throttles,omitempty"`
+	CostError string         `json:"cost_error,omitempty" yaml:"cost_error,omitempty"`
+}
+
+type projectedResponse struct {
+	RegionData []projectedRegion `json:"region_data" yaml:"region_data"`
+	TotalCount int               `json:"total_count" yaml:"total_count"`
+	NextToken  string            `json:"next_token,omitempty" yaml:"next_token,omitempty"`
+}
+
+// response returns response itself without a projection.
+func (p *projection) response(response ListResourcesResponse) any {
+	if p == nil {
+		return response
+	}
+	projected := projectedResponse{TotalCount: response.TotalCount, NextToken: response.NextToken}
+	for _, rd := range response.RegionData {
+		region := projectedRegion{Region: rd.Region, Error: rd.Error, Errors: rd.Errors, Throttles: rd.Throttles, CostError: rd.CostError, Resources: make([]any, len(rd.Resources))}
+		for i, resource := range rd.Resources {
+			region.Resources[i] = p.value(resource)
+		}
+		projected.RegionData = append(projected.RegionData, region)
+	}
+	return projected
+}
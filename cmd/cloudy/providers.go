@@ -0,0 +1,194 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azidentity"
+	"github.com/alwindoss/cloudy/pkg/cloudy"
+	"github.com/alwindoss/cloudy/pkg/cloudy/azure"
+	"github.com/alwindoss/cloudy/pkg/cloudy/cloudflare"
+	"github.com/alwindoss/cloudy/pkg/cloudy/digitalocean"
+	"github.com/alwindoss/cloudy/pkg/cloudy/gcp"
+	"github.com/alwindoss/cloudy/pkg/cloudy/hetzner"
+	"github.com/alwindoss/cloudy/pkg/cloudy/kubernetes"
+	"github.com/alwindoss/cloudy/pkg/cloudy/linode"
+	"github.com/alwindoss/cloudy/pkg/cloudy/oci"
+	"github.com/alwindoss/cloudy/pkg/cloudy/openstack"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/credentials"
+	cloudflareapi "github.com/cloudflare/cloudflare-go"
+	"github.com/digitalocean/godo"
+	openstackclient "github.com/gophercloud/gophercloud/v2/openstack"
+	"github.com/hetznercloud/hcloud-go/v2/hcloud"
+	"github.com/linode/linodego"
+	"github.com/oracle/oci-go-sdk/v65/common"
+	"github.com/oracle/oci-go-sdk/v65/common/auth"
+	"k8s.io/client-go/tools/clientcmd"
+)
+
+// providerListers are the providers requests can ask for by name, each
+// with its lister. AWS is always there and is the default; Azure is added
+// when AZURE_SUBSCRIPTION_ID is set, GCP when GOOGLE_CLOUD_PROJECT is,
+// DigitalOcean when DIGITALOCEAN_TOKEN is, OCI when OCI_COMPARTMENT_ID is,
+// Hetzner Cloud when HCLOUD_TOKEN is, Linode when LINODE_TOKEN is,
+// Cloudflare when CLOUDFLARE_API_TOKEN and CLOUDFLARE_ACCOUNT_ID are,
+// Kubernetes when CLOUDY_KUBE_CONTEXTS is, and OpenStack when OS_AUTH_URL
+// is.
+var providerListers = map[string]*ResourceLister{}
+
+// listerFor returns the lister of the named provider, or of AWS if
+// provider is "", of the tenant ctx's request is for, scanning with creds
+// if any are given. Tenants may only give temporary AWS credentials; the
+// server's profiles and keys aren't theirs to pick.
+func listerFor(ctx context.Context, provider string, creds *Credentials) (*ResourceLister, error) {
+	if provider == "" {
+		provider = cloudy.ProviderAWS
+	}
+	provider = strings.ToLower(provider)
+	t := tenantFrom(ctx)
+	if creds != nil && *creds != (Credentials{}) {
+		if t == serverTenant {
+			return credentialsLister(provider, *creds)
+		}
+		if !creds.hasSession() || creds.AWSProfile != "" {
+			return nil, errors.New("tenants can only send temporary AWS credentials")
+		}
+		lister, err := credentialsLister(provider, *creds)
+		if err != nil {
+			return nil, err
+		}
+		lister.SetAccountNames(t.accountNames)
+		return lister, nil
+	}
+	lister, ok := t.listers[provider]
+	if !ok {
+		names := make([]string, 0, len(t.listers))
+		for name := range t.listers {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		return nil, fmt.Errorf("unknown provider %q; expected %s", provider, strings.Join(names, ", "))
+	}
+	return lister, nil
+}
+
+// NewAzureResourceLister scans subscriptions with the credentials
+// azidentity finds: from the environment, a workload or managed identity,
+// or the Azure CLI.
+func NewAzureResourceLister(subscriptions []string) (*ResourceLister, error) {
+	cred, err := azidentity.NewDefaultAzureCredential(nil)
+	if err != nil {
+		return nil, err
+	}
+	return newResourceLister(cloudy.NewProviderScanner(azure.NewProvider(cred, subscriptions, nil))), nil
+}
+
+// NewGCPResourceLister scans projects with Application Default
+// Credentials: from GOOGLE_APPLICATION_CREDENTIALS, the gcloud CLI, or the
+// metadata server.
+func NewGCPResourceLister(projects []string) (*ResourceLister, error) {
+	provider, err := gcp.NewProvider(context.TODO(), projects)
+	if err != nil {
+		return nil, err
+	}
+	return newResourceLister(cloudy.NewProviderScanner(provider)), nil
+}
+
+// NewDigitalOceanResourceLister scans the account token belongs to, and
+// its Spaces if a Spaces access key is given.
+func NewDigitalOceanResourceLister(token, spacesKey, spacesSecret string) *ResourceLister {
+	var spaces aws.CredentialsProvider
+	if spacesKey != "" && spacesSecret != "" {
+		spaces = credentials.NewStaticCredentialsProvider(spacesKey, spacesSecret, "")
+	}
+	return newResourceLister(cloudy.NewProviderScanner(digitalocean.NewProvider(godo.NewFromToken(token), spaces)))
+}
+
+// NewOCIResourceLister scans compartments with the API key in
+// ~/.oci/config, or as an instance principal when OCI_CLI_AUTH is
+// instance_principal, as the OCI CLI does.
+func NewOCIResourceLister(compartments []string) (*ResourceLister, error) {
+	config := common.DefaultConfigProvider()
+	if os.Getenv("OCI_CLI_AUTH") == "instance_principal" {
+		var err error
+		if config, err = auth.InstancePrincipalConfigurationProvider(); err != nil {
+			return nil, err
+		}
+	}
+	provider, err := oci.NewProvider(config, compartments)
+	if err != nil {
+		return nil, err
+	}
+	return newResourceLister(cloudy.NewProviderScanner(provider)), nil
+}
+
+// NewHetznerResourceLister scans the Hetzner Cloud project token belongs
+// to.
+func NewHetznerResourceLister(token string) *ResourceLister {
+	return newResourceLister(cloudy.NewProviderScanner(hetzner.NewProvider(hcloud.NewClient(hcloud.WithToken(token)))))
+}
+
+// NewLinodeResourceLister scans the Linode account token belongs to.
+func NewLinodeResourceLister(token string) *ResourceLister {
+	client := linodego.NewClient(nil)
+	client.SetToken(token)
+	return newResourceLister(cloudy.NewProviderScanner(linode.NewProvider(&client)))
+}
+
+// NewCloudflareResourceLister scans account with the API token token.
+func NewCloudflareResourceLister(token, account string) (*ResourceLister, error) {
+	api, err := cloudflareapi.NewWithAPIToken(token)
+	if err != nil {
+		return nil, err
+	}
+	return newResourceLister(cloudy.NewProviderScanner(cloudflare.NewProvider(api, account))), nil
+}
+
+// NewKubernetesResourceLister scans the clusters of contexts in the
+// kubeconfig kubectl would use: the files in KUBECONFIG, or
+// ~/.kube/config.
+func NewKubernetesResourceLister(contexts []string) (*ResourceLister, error) {
+	config, err := clientcmd.NewDefaultClientConfigLoadingRules().Load()
+	if err != nil {
+		return nil, err
+	}
+	provider, err := kubernetes.NewProvider(config, contexts)
+	if err != nil {
+		return nil, err
+	}
+	return newResourceLister(cloudy.NewProviderScanner(provider)), nil
+}
+
+// NewOpenStackResourceLister scans the project the OS_* variables
+// authenticate to, as the OpenStack CLI does. The token is renewed when it
+// expires.
+func NewOpenStackResourceLister() (*ResourceLister, error) {
+	opts, err := openstackclient.AuthOptionsFromEnv()
+	if err != nil {
+		return nil, err
+	}
+	opts.AllowReauth = true
+	client, err := openstackclient.AuthenticatedClient(context.TODO(), opts)
+	if err != nil {
+		return nil, err
+	}
+	return newResourceLister(cloudy.NewProviderScanner(openstack.NewProvider(client))), nil
+}
+
+// validateProviderMode rejects the scan modes only AWS has for other
+// providers, and incremental scans, which start from the server's own
+// latest scan, for request credentials.
+func validateProviderMode(lister *ResourceLister, mode string) error {
+	if mode == scanModeIncremental && lister.perRequest {
+		return fmt.Errorf("mode %s is not available with credentials", mode)
+	}
+	if mode == "" || mode == scanModeFull || lister.Provider().Name() == cloudy.ProviderAWS {
+		return nil
+	}
+	return fmt.Errorf("mode %s is only available for %s", mode, cloudy.ProviderAWS)
+}
@@ -0,0 +1,101 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// queryList collects a list parameter given either repeated
+// (?regions=a&regions=b) or comma-separated (?regions=a,b).
+func queryList(c *gin.Context, name string) []string {
+	var values []string
+	for _, raw := range c.QueryArray(name) {
+		for _, value := range strings.Split(raw, ",") {
+			if value = strings.TrimSpace(value); value != "" {
+				values = append(values, value)
+			}
+		}
+	}
+	return values
+}
+
+// queryTagFilters reads tag query parameters, each key:value or a bare
+// key for any value, as tag_filters; it is nil without any.
+func queryTagFilters(c *gin.Context) (map[string]string, error) {
+	var filters map[string]string
+	for _, tag := range c.QueryArray("tag") {
+		key, value, found := strings.Cut(tag, ":")
+		if key == "" {
+			return nil, fmt.Errorf("tag filters must be key:value, got %q", tag)
+		}
+		if !found {
+			value = "*"
+		}
+		if filters == nil {
+			filters = make(map[string]string)
+		}
+		filters[key] = value
+	}
+	return filters, nil
+}
+
+// bindResourcesQuery builds a RegionsRequest from query parameters, e.g.
+// ?regions=us-east-1,eu-west-1&types=EC2 Instance&tag=env:prod&limit=100.
+// Tag filters are repeated tag=key:value parameters; a bare key or a value
+// of "*" matches any value.
+func bindResourcesQuery(c *gin.Context) (RegionsRequest, error) {
+	req := RegionsRequest{
+		Regions:     queryList(c, "regions"),
+		Types:       queryList(c, "types"),
+		Kinds:       queryList(c, "kinds"),
+		States:      queryList(c, "states"),
+		Sort:        c.Query("sort"),
+		Order:       c.Query("order"),
+		NextToken:   c.Query("next_token"),
+		Fields:      queryList(c, "fields"),
+		Query:       c.Query("query"),
+		NamePattern: c.Query("name_pattern"),
+		NameRegex:   c.Query("name_regex"),
+		Mode:        c.Query("mode"),
+		Provider:    c.Query("provider"),
+		View:        c.Query("view"),
+	}
+
+	creds := Credentials{
+		AWSProfile:          c.Query("aws_profile"),
+		AzureSubscriptionID: c.Query("azure_subscription_id"),
+		AzureClientID:       c.Query("azure_client_id"),
+		GCPProject:          c.Query("gcp_project"),
+		GCPKeyRef:           c.Query("gcp_key_ref"),
+	}
+	if creds != (Credentials{}) {
+		req.Credentials = &creds
+	}
+
+	tags, err := queryTagFilters(c)
+	if err != nil {
+		return req, err
+	}
+	req.TagFilters = tags
+
+	if raw := c.Query("limit"); raw != "" {
+		limit, err := strconv.Atoi(raw)
+		if err != nil {
+			return req, fmt.Errorf("limit must be a number")
+		}
+		req.Limit = limit
+	}
+
+	if raw := c.Query("refresh"); raw != "" {
+		refresh, err := strconv.ParseBool(raw)
+		if err != nil {
+			return req, fmt.Errorf("refresh must be true or false")
+		}
+		req.Refresh = refresh
+	}
+
+	return req, nil
+}
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// allRegions is the keyword that expands to every region enabled for the
+// account.
+const allRegions = "all"
+
+// regionCacheTTL bounds how long the discovered region list is reused, so
+// a newly opted-in region shows up without a restart.
+const regionCacheTTL = time.Hour
+
+// resolveRegions expands the "all" keyword into the regions enabled for the
+// account. Other names are passed through, without duplicates.
+func (a *ResourceLister) resolveRegions(ctx context.Context, regions []string) ([]string, error) {
+	var resolved []string
+	seen := make(map[string]bool, len(regions))
+	for _, region := range regions {
+		names := []string{region}
+		if strings.EqualFold(region, allRegions) {
+			discovered, err := a.discoverRegions(ctx)
+			if err != nil {
+				return nil, fmt.Errorf("failed to discover regions: %w", err)
+			}
+			names = discovered
+		}
+		for _, name := range names {
+			if !seen[name] {
+				seen[name] = true
+				resolved = append(resolved, name)
+			}
+		}
+	}
+	return resolved, nil
+}
+
+// discoverRegions lists the regions enabled for the account with the
+// provider; for AWS, those that need no opt-in plus those it has opted in
+// to. The result is cached for regionCacheTTL.
+func (a *ResourceLister) discoverRegions(ctx context.Context) ([]string, error) {
+	a.regions.mu.Lock()
+	defer a.regions.mu.Unlock()
+
+	if a.regions.names != nil && time.Since(a.regions.fetchedAt) < regionCacheTTL {
+		return a.regions.names, nil
+	}
+
+	regions, err := a.Provider().Regions(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	a.regions.names = regions
+	a.regions.fetchedAt = time.Now()
+	return regions, nil
+}
@@ -0,0 +1,536 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/base64"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html/template"
+	"log"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"net/textproto"
+	"os"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/service/sesv2"
+	sestypes "github.com/aws/aws-sdk-go-v2/service/sesv2/types"
+)
+
+// defaultReportInterval is how often a report is sent when it doesn't
+// say: weekly.
+const defaultReportInterval = 7 * 24 * time.Hour
+
+// reportTimeout bounds sending one report.
+const reportTimeout = time.Minute
+
+// Report is one email report in CLOUDY_REPORTS_FILE: a summary of the
+// latest scan of every region, and what changed since the previous
+// report, with the resources attached as CSV.
+type Report struct {
+	Name    string   `json:"name"`
+	To      []string `json:"to"`
+	Subject string   `json:"subject,omitempty"`
+	// Interval is how often the report is sent, as a Go duration such as
+	// 24h; a week by default. Reports go out at multiples of it since
+	// Monday 00:00 UTC, plus Offset, so restarts don't move them.
+	Interval string `json:"interval,omitempty"`
+	Offset   string `json:"offset,omitempty"`
+	// Tenant is the tenant whose resources are reported, when tenants are
+	// enabled.
+	Tenant string `json:"tenant,omitempty"`
+	// Regions, Types and Tags select the resources reported, as a
+	// request's regions, types and tag_filters do; empty ones select
+	// everything. Changes are only counted by region and type.
+	Regions []string          `json:"regions,omitempty"`
+	Types   []string          `json:"types,omitempty"`
+	Tags    map[string]string `json:"tags,omitempty"`
+
+	interval time.Duration
+	offset   time.Duration
+}
+
+// reports are sent on their schedules, from CLOUDY_REPORTS_FILE.
+var reports []*Report
+
+// reportMail sends reports from from, CLOUDY_REPORTS_FROM, through the
+// SMTP server at smtpAddr, CLOUDY_SMTP_ADDR, or through SES if there is
+// none.
+var reportMail struct {
+	from         string
+	smtpAddr     string
+	smtpUser     string
+	smtpPassword string
+	ses          *sesv2.Client
+}
+
+// loadReports reads the reports in path, a JSON file of the form
+// {"reports": [...]}.
+func loadReports(path string) ([]*Report, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var file struct {
+		Reports []*Report `json:"reports"`
+	}
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+
+	tenants := make(map[string]bool)
+	for _, t := range scheduledTenants() {
+		tenants[t.name] = true
+	}
+	names := make(map[string]bool)
+	for i, report := range file.Reports {
+		switch {
+		case report.Name == "":
+			return nil, fmt.Errorf("report %d needs a name", i)
+		case names[report.Name]:
+			return nil, fmt.Errorf("report %s is defined twice", report.Name)
+		case len(report.To) == 0:
+			return nil, fmt.Errorf("report %s needs recipients in to", report.Name)
+		case !tenants[report.Tenant]:
+			if len(tenantsByKey) == 0 {
+				return nil, fmt.Errorf("report %s names tenant %q, but tenants aren't enabled", report.Name, report.Tenant)
+			}
+			return nil, fmt.Errorf("report %s needs the tenant whose resources it reports, got %q", report.Name, report.Tenant)
+		}
+		names[report.Name] = true
+		for _, to := range report.To {
+			if _, err := mail.ParseAddress(to); err != nil {
+				return nil, fmt.Errorf("report %s: invalid recipient %q: %w", report.Name, to, err)
+			}
+		}
+
+		report.interval = defaultReportInterval
+		if report.Interval != "" {
+			if report.interval, err = time.ParseDuration(report.Interval); err != nil {
+				return nil, fmt.Errorf("report %s: invalid interval: %w", report.Name, err)
+			}
+		}
+		if report.Offset != "" {
+			if report.offset, err = time.ParseDuration(report.Offset); err != nil {
+				return nil, fmt.Errorf("report %s: invalid offset: %w", report.Name, err)
+			}
+		}
+		if report.interval < time.Hour {
+			return nil, fmt.Errorf("report %s: interval must be at least 1h", report.Name)
+		}
+		if report.offset < 0 || report.offset >= report.interval {
+			return nil, fmt.Errorf("report %s: offset must be between 0 and the interval", report.Name)
+		}
+		if report.Subject == "" {
+			report.Subject = "Cloudy report: " + report.Name
+		}
+	}
+	return file.Reports, nil
+}
+
+// setupReportMail checks the sender and picks how reports are sent.
+func setupReportMail(from, smtpAddr, smtpUser, smtpPassword, sesRegion string) error {
+	if from == "" {
+		return errors.New("set CLOUDY_REPORTS_FROM to the address reports are sent from")
+	}
+	if _, err := mail.ParseAddress(from); err != nil {
+		return fmt.Errorf("invalid CLOUDY_REPORTS_FROM: %w", err)
+	}
+	reportMail.from = from
+	if smtpAddr != "" {
+		if _, _, err := net.SplitHostPort(smtpAddr); err != nil {
+			return fmt.Errorf("CLOUDY_SMTP_ADDR must be host:port: %w", err)
+		}
+		reportMail.smtpAddr, reportMail.smtpUser, reportMail.smtpPassword = smtpAddr, smtpUser, smtpPassword
+		return nil
+	}
+	cfg, err := loadAWSConfig()
+	if err != nil {
+		return err
+	}
+	if sesRegion != "" {
+		cfg.Region = sesRegion
+	}
+	reportMail.ses = sesv2.NewFromConfig(cfg)
+	return nil
+}
+
+// runReports sends each report on its schedule until ctx is done.
+func runReports(ctx context.Context) {
+	for _, report := range reports {
+		go func() {
+			for {
+				next := nextReportTime(time.Now(), report.interval, report.offset)
+				timer := time.NewTimer(time.Until(next))
+				select {
+				case <-ctx.Done():
+					timer.Stop()
+					return
+				case <-timer.C:
+				}
+				if err := sendReport(ctx, report, next.UTC()); err != nil {
+					log.Printf("Failed to send report %s: %v", report.Name, err)
+				}
+			}
+		}()
+	}
+}
+
+// nextReportTime is the first multiple of interval since Monday, January
+// 1, year 1, 00:00 UTC, plus offset, after now.
+func nextReportTime(now time.Time, interval, offset time.Duration) time.Time {
+	return now.Add(-offset).Truncate(interval).Add(interval + offset)
+}
+
+// reportTenant returns the tenant report is about.
+func reportTenant(report *Report) *tenant {
+	for _, t := range scheduledTenants() {
+		if t.name == report.Tenant {
+			return t
+		}
+	}
+	return serverTenant
+}
+
+// sendReport emails report as of at to its recipients.
+func sendReport(ctx context.Context, report *Report, at time.Time) error {
+	summary, resources, ok := buildReport(reportTenant(report), report, at)
+	if !ok {
+		log.Printf("Skipped report %s: no scan results yet", report.Name)
+		return nil
+	}
+	var body bytes.Buffer
+	if err := reportTemplate.Execute(&body, summary); err != nil {
+		return err
+	}
+	var attachment bytes.Buffer
+	w := csv.NewWriter(&attachment)
+	columns := newResourceColumns(resources, nil, true)
+	w.Write(columns.header())
+	for _, resource := range resources {
+		w.Write(columns.row(resource))
+	}
+	w.Flush()
+
+	filename := fmt.Sprintf("resources-%s.csv", at.Format("20060102"))
+	message, err := newReportMessage(reportMail.from, report.To, report.Subject, at, body.Bytes(), filename, attachment.Bytes())
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, reportTimeout)
+	defer cancel()
+	if reportMail.smtpAddr != "" {
+		return sendSMTP(ctx, reportMail.smtpAddr, reportMail.smtpUser, reportMail.smtpPassword, reportMail.from, report.To, message)
+	}
+	_, err = reportMail.ses.SendEmail(ctx, &sesv2.SendEmailInput{
+		FromEmailAddress: &reportMail.from,
+		Destination:      &sestypes.Destination{ToAddresses: report.To},
+		Content:          &sestypes.EmailContent{Raw: &sestypes.RawMessage{Data: message}},
+	})
+	return err
+}
+
+// reportSummary is what a report's email shows.
+type reportSummary struct {
+	Name       string
+	Tenant     string
+	At         time.Time
+	ScannedAt  time.Time
+	Period     string
+	Total      int
+	Incomplete []string
+	Changes    []reportChanges
+	ByType     []reportCount
+	ByAccount  []reportCount
+	ByRegion   []reportCount
+}
+
+type reportCount struct {
+	Name  string
+	Count int
+}
+
+// reportChanges counts the changes to one type of resource, or to all of
+// them if Total.
+type reportChanges struct {
+	Type    string
+	Total   bool
+	Created int
+	Updated int
+	Deleted int
+}
+
+// buildReport summarizes t's latest scan of every region and the changes
+// scheduled scans found in the interval before at, returning the
+// resources report selects. It reports false if nothing was scanned yet.
+func buildReport(t *tenant, report *Report, at time.Time) (reportSummary, []Resource, bool) {
+	summary := reportSummary{Name: report.Name, At: at, Period: reportPeriod(report.interval)}
+	if t != serverTenant {
+		summary.Tenant = t.name
+	}
+
+	regions := 0
+	t.latestScan.Each(func(key regionKey, stored storedRegion) {
+		regions++
+		// Providers can share a region name; it is listed once
+		if !stored.Complete && matchesAny(key.Region, report.Regions) && !slices.Contains(summary.Incomplete, key.Region) {
+			summary.Incomplete = append(summary.Incomplete, key.Region)
+		}
+	})
+	if regions == 0 {
+		return summary, nil, false
+	}
+	sort.Strings(summary.Incomplete)
+
+	regionData, scannedAt := t.latestScan.Snapshot()
+	summary.ScannedAt = scannedAt
+	var resources []Resource
+	byType := make(map[string]int)
+	byAccount := make(map[string]int)
+	byRegion := make(map[string]int)
+	for _, rd := range regionData {
+		for _, resource := range rd.Resources {
+			if !matchesAny(resource.Region, report.Regions) || !matchesAny(resource.Type, report.Types) || !matchesTagFilters(resource, report.Tags) {
+				continue
+			}
+			resources = append(resources, resource)
+			byType[resource.Type]++
+			byRegion[resource.Region]++
+			account := resource.AccountName
+			if account == "" {
+				account = resource.AccountID
+			}
+			if account == "" {
+				account = noAccount
+			}
+			byAccount[account]++
+		}
+	}
+	summary.Total = len(resources)
+	summary.ByType = reportCounts(byType)
+	summary.ByAccount = reportCounts(byAccount)
+	summary.ByRegion = reportCounts(byRegion)
+
+	changes := make(map[string]*reportChanges)
+	total := reportChanges{Type: "Total", Total: true}
+	for _, s := range t.drift.Samples(at.Add(-report.interval), at) {
+		if !matchesAny(s.Region, report.Regions) || !matchesAny(s.Type, report.Types) {
+			continue
+		}
+		c := changes[s.Type]
+		if c == nil {
+			c = &reportChanges{Type: s.Type}
+			changes[s.Type] = c
+		}
+		for _, counts := range []*reportChanges{c, &total} {
+			switch s.Change {
+			case changeCreated:
+				counts.Created += s.Count
+			case changeUpdated:
+				counts.Updated += s.Count
+			case changeDeleted:
+				counts.Deleted += s.Count
+			}
+		}
+	}
+	for _, c := range changes {
+		summary.Changes = append(summary.Changes, *c)
+	}
+	sort.Slice(summary.Changes, func(i, j int) bool {
+		return summary.Changes[i].Type < summary.Changes[j].Type
+	})
+	if len(summary.Changes) > 0 {
+		summary.Changes = append(summary.Changes, total)
+	}
+	return summary, resources, true
+}
+
+// reportCounts orders counts from the largest, then by name.
+func reportCounts(counts map[string]int) []reportCount {
+	sorted := make([]reportCount, 0, len(counts))
+	for name, count := range counts {
+		sorted = append(sorted, reportCount{Name: name, Count: count})
+	}
+	sort.Slice(sorted, func(i, j int) bool {
+		if sorted[i].Count != sorted[j].Count {
+			return sorted[i].Count > sorted[j].Count
+		}
+		return sorted[i].Name < sorted[j].Name
+	})
+	return sorted
+}
+
+// reportPeriod describes interval as the period a report covers.
+func reportPeriod(interval time.Duration) string {
+	switch {
+	case interval == 24*time.Hour:
+		return "day"
+	case interval == defaultReportInterval:
+		return "week"
+	case interval%(24*time.Hour) == 0:
+		return fmt.Sprintf("%d days", interval/(24*time.Hour))
+	}
+	return interval.String()
+}
+
+var reportTemplate = template.Must(template.New("report").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<style>
+body { font-family: Helvetica, Arial, sans-serif; color: #222; }
+table { border-collapse: collapse; margin-bottom: 24px; }
+th, td { border: 1px solid #ddd; padding: 4px 10px; text-align: left; }
+td.n { text-align: right; }
+tr.total td { font-weight: bold; }
+.warning { color: #b35900; }
+</style>
+</head>
+<body>
+<h2>{{.Name}}{{if .Tenant}} ({{.Tenant}}){{end}}</h2>
+<p><strong>{{.Total}}</strong> resources as of {{.ScannedAt.Format "Mon, 02 Jan 2006 15:04 MST"}}, the oldest region scan. The full list is attached as CSV.</p>
+{{if .Incomplete}}<p class="warning">Some services failed to list {{range $i, $r := .Incomplete}}{{if $i}}, {{end}}{{$r}}{{end}}; counts there may be low.</p>{{end}}
+<h3>Changes in the last {{.Period}}</h3>
+{{if .Changes}}<table>
+<tr><th>Type</th><th>Created</th><th>Updated</th><th>Deleted</th></tr>
+{{range .Changes}}<tr{{if .Total}} class="total"{{end}}><td>{{.Type}}</td><td class="n">{{.Created}}</td><td class="n">{{.Updated}}</td><td class="n">{{.Deleted}}</td></tr>
+{{end}}</table>
+{{else}}<p>None found by scheduled scans.</p>
+{{end}}
+<h3>By type</h3>
+<table>
+<tr><th>Type</th><th>Resources</th></tr>
+{{range .ByType}}<tr><td>{{.Name}}</td><td class="n">{{.Count}}</td></tr>
+{{end}}</table>
+<h3>By account</h3>
+<table>
+<tr><th>Account</th><th>Resources</th></tr>
+{{range .ByAccount}}<tr><td>{{.Name}}</td><td class="n">{{.Count}}</td></tr>
+{{end}}</table>
+<h3>By region</h3>
+<table>
+<tr><th>Region</th><th>Resources</th></tr>
+{{range .ByRegion}}<tr><td>{{.Name}}</td><td class="n">{{.Count}}</td></tr>
+{{end}}</table>
+<p style="color: #888; font-size: 12px;">Sent by Cloudy at {{.At.Format "2006-01-02 15:04 MST"}}.</p>
+</body>
+</html>
+`))
+
+// newReportMessage builds a MIME message with an HTML body and a CSV
+// attachment.
+func newReportMessage(from string, to []string, subject string, date time.Time, html []byte, filename string, attachment []byte) ([]byte, error) {
+	var message bytes.Buffer
+	w := multipart.NewWriter(&message)
+	header := []string{
+		"From: " + from,
+		"To: " + strings.Join(to, ", "),
+		"Subject: " + mime.QEncoding.Encode("utf-8", subject),
+		"Date: " + date.Format(time.RFC1123Z),
+		"MIME-Version: 1.0",
+		"Content-Type: multipart/mixed; boundary=" + w.Boundary(),
+	}
+	message.WriteString(strings.Join(header, "\r\n") + "\r\n\r\n")
+
+	part, err := w.CreatePart(textproto.MIMEHeader{
+		"Content-Type":              {"text/html; charset=utf-8"},
+		"Content-Transfer-Encoding": {"quoted-printable"},
+	})
+	if err != nil {
+		return nil, err
+	}
+	qp := quotedprintable.NewWriter(part)
+	qp.Write(html)
+	qp.Close()
+
+	part, err = w.CreatePart(textproto.MIMEHeader{
+		"Content-Type":              {mime.FormatMediaType("text/csv", map[string]string{"charset": "utf-8", "name": filename})},
+		"Content-Transfer-Encoding": {"base64"},
+		"Content-Disposition":       {mime.FormatMediaType("attachment", map[string]string{"filename": filename})},
+	})
+	if err != nil {
+		return nil, err
+	}
+	// Lines of base64 can't be longer than 76 characters
+	encoded := base64.StdEncoding.EncodeToString(attachment)
+	for len(encoded) > 76 {
+		part.Write([]byte(encoded[:76] + "\r\n"))
+		encoded = encoded[76:]
+	}
+	part.Write([]byte(encoded + "\r\n"))
+
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+	return message.Bytes(), nil
+}
+
+// sendSMTP delivers message through the server at addr, upgrading to TLS
+// when it offers STARTTLS and authenticating if user is set.
+func sendSMTP(ctx context.Context, addr, user, password, from string, to []string, message []byte) error {
+	host, _, _ := net.SplitHostPort(addr)
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return err
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+	client, err := smtp.NewClient(conn, host)
+	if err != nil {
+		conn.Close()
+		return err
+	}
+	defer client.Close()
+
+	if ok, _ := client.Extension("STARTTLS"); ok {
+		if err := client.StartTLS(&tls.Config{ServerName: host}); err != nil {
+			return err
+		}
+	}
+	if user != "" {
+		if err := client.Auth(smtp.PlainAuth("", user, password, host)); err != nil {
+			return err
+		}
+	}
+	sender, err := mail.ParseAddress(from)
+	if err != nil {
+		return err
+	}
+	if err := client.Mail(sender.Address); err != nil {
+		return err
+	}
+	for _, recipient := range to {
+		address, err := mail.ParseAddress(recipient)
+		if err != nil {
+			return err
+		}
+		if err := client.Rcpt(address.Address); err != nil {
+			return err
+		}
+	}
+	data, err := client.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := data.Write(message); err != nil {
+		return err
+	}
+	if err := data.Close(); err != nil {
+		return err
+	}
+	return client.Quit()
+}
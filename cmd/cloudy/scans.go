@@ -0,0 +1,261 @@
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	scanQueued  = "queued"
+	scanRunning = "running"
+	scanDone    = "done"
+)
+
+// scanRetention is how long a finished async scan's result is kept for
+// clients to fetch.
+const scanRetention = time.Hour
+
+// asyncScan is a scan started with POST /api/v1/scans. It runs on its own
+// context, so it outlives the request that started it until it finishes
+// or is deleted. It is queued until a scan slot is free, however many
+// scans are waiting. Only its tenant can see or delete it.
+type asyncScan struct {
+	ID         string                 `json:"id"`
+	Status     string                 `json:"status"`
+	StartedAt  time.Time              `json:"started_at"`
+	FinishedAt *time.Time             `json:"finished_at,omitempty"`
+	Result     *ListResourcesResponse `json:"result,omitempty"`
+
+	cancel context.CancelFunc
+	tenant *tenant
+}
+
+var asyncScans = struct {
+	mu    sync.Mutex
+	scans map[string]*asyncScan
+	// running counts the scans still running, so shutdown can wait for
+	// them; once draining, no more are started.
+	running  sync.WaitGroup
+	draining bool
+}{scans: make(map[string]*asyncScan)}
+
+// startScan starts a scan in the background and returns its ID right away.
+// The request takes the same filters as POST /api/v1/resources; the result
+// is sorted but not paginated, projected or queried.
+func startScan(c *gin.Context) {
+	var req RegionsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if len(req.Regions) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "at least one region must be specified"})
+		return
+	}
+	if req.Limit != 0 || req.NextToken != "" || len(req.Fields) > 0 || req.Query != "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "limit, next_token, fields and query are not supported for async scans"})
+		return
+	}
+	if err := validateSort(req.Sort, req.Order); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := validateMode(req.Mode); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if _, err := nameMatcher(req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := validateCredentialsTransport(c.Request, req.Credentials); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := validateKinds(req.Kinds); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	t := tenantFrom(c.Request.Context())
+	lister, err := listerFor(c.Request.Context(), req.Provider, req.Credentials)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := validateProviderMode(lister, req.Mode); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	regions, err := lister.resolveRegions(c.Request.Context(), req.Regions)
+	if err != nil {
+		c.JSON(http.StatusBadGateway, gin.H{"error": err.Error()})
+		return
+	}
+	req.Regions = regions
+
+	id, err := newScanID()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx, cancel := context.WithCancel(withTenant(context.Background(), t))
+	scan := &asyncScan{
+		ID:        id,
+		Status:    scanQueued,
+		StartedAt: time.Now().UTC(),
+		cancel:    cancel,
+		tenant:    t,
+	}
+
+	asyncScans.mu.Lock()
+	if asyncScans.draining {
+		asyncScans.mu.Unlock()
+		cancel()
+		c.Header("Retry-After", "5")
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "server is shutting down"})
+		return
+	}
+	pruneScans()
+	asyncScans.scans[id] = scan
+	asyncScans.running.Add(1)
+	response := *scan
+	asyncScans.mu.Unlock()
+
+	go runScan(ctx, lister, req, scan)
+
+	c.Header("Location", "/api/v1/scans/"+id)
+	c.JSON(http.StatusAccepted, response)
+}
+
+func runScan(ctx context.Context, lister *ResourceLister, req RegionsRequest, scan *asyncScan) {
+	defer asyncScans.running.Done()
+	defer scan.cancel()
+
+	// Only a deleted scan, or shutdown, stops the wait
+	release, err := scanLimit.wait(ctx)
+	if err != nil {
+		return
+	}
+	defer release()
+	asyncScans.mu.Lock()
+	scan.Status = scanRunning
+	asyncScans.mu.Unlock()
+
+	regionData := lister.scanRegions(ctx, req)
+	totalCount := 0
+	for _, rd := range regionData {
+		totalCount += len(rd.Resources)
+	}
+	sortRegionData(regionData, req.Sort, req.Order)
+
+	finishedAt := time.Now().UTC()
+	asyncScans.mu.Lock()
+	scan.Status = scanDone
+	scan.FinishedAt = &finishedAt
+	scan.Result = &ListResourcesResponse{RegionData: regionData, TotalCount: totalCount}
+	asyncScans.mu.Unlock()
+
+	// A deleted scan, or one cut short by shutdown, didn't complete
+	if ctx.Err() == nil {
+		webhookScan := newWebhookScan(regionData, scan.StartedAt, finishedAt)
+		webhookScan.ID = scan.ID
+		fireWebhooks(scan.tenant, webhookScan, regionData, ResourceChanges{})
+	}
+}
+
+// getScan reports a scan's status, with its result once it is done.
+func getScan(c *gin.Context) {
+	asyncScans.mu.Lock()
+	scan, ok := asyncScans.scans[c.Param("id")]
+	ok = ok && scan.tenant == tenantFrom(c.Request.Context())
+	var response asyncScan
+	if ok {
+		response = *scan
+	}
+	asyncScans.mu.Unlock()
+
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "scan not found"})
+		return
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// deleteScan cancels a running scan, stopping its AWS calls, and forgets
+// it. A finished scan is just forgotten.
+func deleteScan(c *gin.Context) {
+	asyncScans.mu.Lock()
+	scan, ok := asyncScans.scans[c.Param("id")]
+	ok = ok && scan.tenant == tenantFrom(c.Request.Context())
+	if ok {
+		delete(asyncScans.scans, c.Param("id"))
+	}
+	asyncScans.mu.Unlock()
+
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "scan not found"})
+		return
+	}
+	scan.cancel()
+	c.Status(http.StatusNoContent)
+}
+
+// drainScans stops new async scans from starting, cancels queued ones and
+// waits for the running ones to finish. Those still running when ctx is
+// done are cancelled, keeping what they listed so far.
+func drainScans(ctx context.Context) {
+	asyncScans.mu.Lock()
+	asyncScans.draining = true
+	for _, scan := range asyncScans.scans {
+		if scan.Status == scanQueued {
+			scan.cancel()
+		}
+	}
+	asyncScans.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		asyncScans.running.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return
+	case <-ctx.Done():
+	}
+
+	asyncScans.mu.Lock()
+	for _, scan := range asyncScans.scans {
+		scan.cancel()
+	}
+	asyncScans.mu.Unlock()
+	<-done
+}
+
+// pruneScans drops finished scans older than scanRetention. The caller
+// must hold asyncScans.mu.
+func pruneScans() {
+	for id, scan := range asyncScans.scans {
+		if scan.FinishedAt != nil && time.Since(*scan.FinishedAt) > scanRetention {
+			delete(asyncScans.scans, id)
+		}
+	}
+}
+
+func newScanID() (string, error) {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
@@ -0,0 +1,136 @@
+package main
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/alwindoss/cloudy/pkg/cloudy"
+)
+
+// regionKey names a region of a provider. Region names alone aren't
+// unique across providers: Hetzner's network zones us-east and eu-central
+// are Linode regions too.
+type regionKey struct {
+	Provider string
+	Region   string
+}
+
+// lessRegionKey orders regions by name, and the same region of different
+// providers by provider.
+func lessRegionKey(a, b regionKey) bool {
+	if a.Region != b.Region {
+		return a.Region < b.Region
+	}
+	return a.Provider < b.Provider
+}
+
+// scanStore keeps the most recent complete scan of each provider's
+// regions so endpoints like search can work without calling AWS again.
+// With a table set it is also saved to DynamoDB, as the tenant's.
+type scanStore struct {
+	mu      sync.RWMutex
+	regions map[regionKey]storedRegion
+	// generation counts the regions recorded, so copies of the store can
+	// tell they are out of date.
+	generation uint64
+
+	table  *inventoryTable
+	tenant string
+}
+
+type storedRegion struct {
+	Resources []Resource
+	ScannedAt time.Time
+	// Complete is false if some services failed to list the region.
+	Complete bool
+}
+
+var latestScan = &scanStore{regions: make(map[regionKey]storedRegion)}
+
+// Record replaces the stored resources for provider's region. The slice
+// is copied since callers go on to sort and trim their own. complete
+// reports whether every service listed the region without error.
+func (s *scanStore) Record(provider, region string, resources []Resource, complete bool) {
+	key := regionKey{provider, region}
+	stored := storedRegion{
+		Resources: append([]Resource(nil), resources...),
+		ScannedAt: time.Now().UTC(),
+		Complete:  complete,
+	}
+
+	s.mu.Lock()
+	previous := s.regions[key].Resources
+	s.regions[key] = stored
+	s.generation++
+	table := s.table
+	s.mu.Unlock()
+
+	if table != nil {
+		table.enqueue(inventoryWrite{tenant: s.tenant, region: region, previous: previous, stored: stored})
+	}
+}
+
+// Generation changes whenever a region is recorded.
+func (s *scanStore) Generation() uint64 {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.generation
+}
+
+// Each calls fn with every stored region. fn must not record scans.
+func (s *scanStore) Each(fn func(key regionKey, stored storedRegion)) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	for key, stored := range s.regions {
+		fn(key, stored)
+	}
+}
+
+// persist saves the scans recorded from now on to table, as tenant's.
+func (s *scanStore) persist(table *inventoryTable, tenant string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.table = table
+	s.tenant = tenant
+}
+
+// Baselines returns the stored scans of provider's regions, by region,
+// for an incremental scan or a scheduled diff to start from. Regions whose
+// scan wasn't complete have none, so they are listed in full again.
+func (s *scanStore) Baselines(provider string, regions []string) map[string]cloudy.Baseline {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	baselines := make(map[string]cloudy.Baseline, len(regions))
+	for _, region := range regions {
+		if stored, ok := s.regions[regionKey{provider, region}]; ok && stored.Complete {
+			baselines[region] = cloudy.Baseline{Resources: stored.Resources, ListedAt: stored.ScannedAt}
+		}
+	}
+	return baselines
+}
+
+// Snapshot returns the stored resources of every region, ordered by
+// region and then provider, and the time of the oldest scan among them.
+func (s *scanStore) Snapshot() ([]RegionResources, time.Time) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var oldest time.Time
+	keys := make([]regionKey, 0, len(s.regions))
+	for key, stored := range s.regions {
+		keys = append(keys, key)
+		if oldest.IsZero() || stored.ScannedAt.Before(oldest) {
+			oldest = stored.ScannedAt
+		}
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		return lessRegionKey(keys[i], keys[j])
+	})
+	regionData := make([]RegionResources, len(keys))
+	for i, key := range keys {
+		regionData[i] = RegionResources{Region: key.Region, Resources: s.regions[key].Resources}
+	}
+	return regionData, oldest
+}
@@ -0,0 +1,248 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"sort"
+	"strings"
+	"time"
+	// Schedules' CRON_TZ zones resolve in images without a zone database
+	_ "time/tzdata"
+
+	"github.com/alwindoss/cloudy/pkg/cloudy"
+	"github.com/robfig/cron/v3"
+)
+
+// scheduleInterval is how often every tenant's AWS account is scanned in
+// full, from CLOUDY_SCHEDULE_INTERVAL, and scheduleRegions the regions
+// scanned, from CLOUDY_SCHEDULE_REGIONS. Scheduled scans are off while
+// scheduleInterval is zero.
+var (
+	scheduleInterval time.Duration
+	scheduleRegions  = []string{allRegions}
+)
+
+// Schedule is one schedule in CLOUDY_SCHEDULE_FILE: when to scan which
+// regions of one tenant's account with one provider.
+type Schedule struct {
+	Name string `json:"name"`
+	// Cron is a five-field cron expression, or a descriptor such as @daily
+	// or @every 30m, in UTC unless it starts with CRON_TZ=<zone>.
+	Cron string `json:"cron"`
+	// Tenant is the tenant whose account is scanned, when tenants are
+	// enabled.
+	Tenant string `json:"tenant,omitempty"`
+	// Provider is the provider scanned, aws by default.
+	Provider string `json:"provider,omitempty"`
+	// Regions are the regions scanned, every region by default.
+	Regions []string `json:"regions,omitempty"`
+
+	spec   cron.Schedule
+	tenant *tenant
+}
+
+// schedules are the schedules in CLOUDY_SCHEDULE_FILE, which run next to
+// scheduleInterval's.
+var schedules []*Schedule
+
+// loadSchedules reads the schedules in path, a JSON file of the form
+// {"schedules": [...]}.
+func loadSchedules(path string) ([]*Schedule, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var file struct {
+		Schedules []*Schedule `json:"schedules"`
+	}
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+
+	tenants := make(map[string]*tenant)
+	for _, t := range scheduledTenants() {
+		tenants[t.name] = t
+	}
+	names := make(map[string]bool)
+	for i, schedule := range file.Schedules {
+		if schedule.Name == "" {
+			return nil, fmt.Errorf("schedule %d needs a name", i)
+		}
+		if names[schedule.Name] {
+			return nil, fmt.Errorf("schedule %s is defined twice", schedule.Name)
+		}
+		names[schedule.Name] = true
+
+		if schedule.spec, err = parseCron(schedule.Cron); err != nil {
+			return nil, fmt.Errorf("schedule %s: cron: %w", schedule.Name, err)
+		}
+		t, ok := tenants[schedule.Tenant]
+		schedule.tenant = t
+		if !ok {
+			if len(tenantsByKey) == 0 {
+				return nil, fmt.Errorf("schedule %s names tenant %q, but tenants aren't enabled", schedule.Name, schedule.Tenant)
+			}
+			return nil, fmt.Errorf("schedule %s needs the tenant whose account it scans, got %q", schedule.Name, schedule.Tenant)
+		}
+		if _, err := listerFor(withTenant(context.Background(), t), schedule.Provider, nil); err != nil {
+			return nil, fmt.Errorf("schedule %s: %w", schedule.Name, err)
+		}
+		if len(schedule.Regions) == 0 {
+			schedule.Regions = []string{allRegions}
+		}
+	}
+	return file.Schedules, nil
+}
+
+// parseCron parses a schedule's cron expression, in UTC unless it names
+// its zone with CRON_TZ= or TZ=.
+func parseCron(spec string) (cron.Schedule, error) {
+	if !strings.HasPrefix(spec, "CRON_TZ=") && !strings.HasPrefix(spec, "TZ=") {
+		spec = "CRON_TZ=UTC " + spec
+	}
+	return cron.ParseStandard(spec)
+}
+
+// scheduled reports whether any scans are scheduled.
+func scheduled() bool {
+	return scheduleInterval > 0 || len(schedules) > 0
+}
+
+// runSchedules runs every schedule in the background until ctx is done.
+// A scan still running when its schedule is next due skips that run.
+func runSchedules(ctx context.Context) {
+	for _, schedule := range schedules {
+		t := schedule.tenant
+		go func() {
+			for {
+				next := schedule.spec.Next(time.Now())
+				timer := time.NewTimer(time.Until(next))
+				select {
+				case <-ctx.Done():
+					timer.Stop()
+					return
+				case <-timer.C:
+				}
+				// Listings stay cached until the run after next is due, so
+				// reads are answered from them until they are replaced
+				runScheduledScan(withTenant(ctx, t), t, schedule.Provider, schedule.Regions, schedule.spec.Next(next))
+			}
+		}()
+	}
+}
+
+// scheduledScan is the result of one tenant's scheduled scan.
+type scheduledScan struct {
+	tenant     *tenant
+	regionData []RegionResources
+	// changes are those since the previous full scan, of regions that had
+	// one and were listed without error this time. A region listed in
+	// part would show what it missed as deleted.
+	changes ResourceChanges
+	// opened and resolved are the policy violations that are new since
+	// the previous scan, and that are gone, of regions listed without
+	// error.
+	opened     []Violation
+	resolved   []Violation
+	startedAt  time.Time
+	finishedAt time.Time
+}
+
+// runSchedule scans every tenant once per scheduleInterval until ctx is
+// done. A scan still running when the next is due delays it.
+func runSchedule(ctx context.Context) {
+	ticker := time.NewTicker(scheduleInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		for _, t := range scheduledTenants() {
+			runScheduledScan(withTenant(ctx, t), t, "", scheduleRegions, time.Now().Add(2*scheduleInterval))
+		}
+	}
+}
+
+// scheduledTenants returns the tenants in name order, or the server's own
+// when there are none.
+func scheduledTenants() []*tenant {
+	if len(tenantsByKey) == 0 {
+		return []*tenant{serverTenant}
+	}
+	seen := make(map[*tenant]bool)
+	var tenants []*tenant
+	for _, t := range tenantsByKey {
+		if !seen[t] {
+			seen[t] = true
+			tenants = append(tenants, t)
+		}
+	}
+	sort.Slice(tenants, func(i, j int) bool {
+		return tenants[i].name < tenants[j].name
+	})
+	return tenants
+}
+
+// runScheduledScan scans regions of t's account with provider in full,
+// bypassing the result cache, and reports what changed. What it lists
+// stays cached until cacheUntil. Tenants without credentials for provider
+// are skipped.
+func runScheduledScan(ctx context.Context, t *tenant, provider string, regions []string, cacheUntil time.Time) {
+	lister, err := listerFor(ctx, provider, nil)
+	if err != nil {
+		return
+	}
+	regions, err = lister.resolveRegions(ctx, regions)
+	if err != nil {
+		log.Printf("Scheduled scan%s failed: %v", tenantSuffix(t), err)
+		return
+	}
+
+	release, err := scanLimit.wait(ctx)
+	if err != nil {
+		return
+	}
+	defer release()
+
+	scan := scheduledScan{tenant: t, startedAt: time.Now().UTC()}
+	baselines := t.latestScan.Baselines(lister.Provider().Name(), regions)
+	scan.regionData = lister.scanRegions(cloudy.WithCacheUntil(ctx, cacheUntil), RegionsRequest{Regions: regions, Refresh: true})
+	scan.finishedAt = time.Now().UTC()
+	if ctx.Err() != nil {
+		return
+	}
+	for _, rd := range scan.regionData {
+		if baseline, ok := baselines[rd.Region]; ok && rd.Error == "" {
+			scan.changes.add(diffResources(baseline.Resources, rd.Resources))
+		}
+	}
+
+	scan.opened, scan.resolved = t.violations.update(t, scan.regionData)
+	if !scan.changes.empty() {
+		t.drift.Record(scan.finishedAt, scan.changes)
+	}
+	notifySlack(ctx, scan)
+	notifyTeams(ctx, scan)
+	webhookScan := newWebhookScan(scan.regionData, scan.startedAt, scan.finishedAt)
+	webhookScan.Scheduled = true
+	fireWebhooks(t, webhookScan, scan.regionData, scan.changes)
+	exportSnapshot(ctx, scan)
+	publishChanges(ctx, scan)
+	reportDrift(scan)
+	pageViolations(t, scan.opened, scan.resolved)
+	fileJiraIssues(t, scan.opened, scan.resolved)
+	exportToServiceNow(scan)
+}
+
+// tenantSuffix names t in log messages, if it isn't the server's own.
+func tenantSuffix(t *tenant) string {
+	if t == serverTenant {
+		return ""
+	}
+	return " of tenant " + t.name
+}
@@ -0,0 +1,50 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseCron(t *testing.T) {
+	winter := time.Date(2024, 1, 15, 0, 0, 0, 0, time.UTC)
+	summer := time.Date(2024, 7, 15, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name    string
+		spec    string
+		from    time.Time
+		want    time.Time
+		wantErr bool
+	}{
+		{name: "UTC by default", spec: "0 9 * * *", from: winter, want: time.Date(2024, 1, 15, 9, 0, 0, 0, time.UTC)},
+		{name: "CRON_TZ in winter", spec: "CRON_TZ=America/New_York 0 9 * * *", from: winter, want: time.Date(2024, 1, 15, 14, 0, 0, 0, time.UTC)},
+		{name: "CRON_TZ in summer", spec: "CRON_TZ=America/New_York 0 9 * * *", from: summer, want: time.Date(2024, 7, 15, 13, 0, 0, 0, time.UTC)},
+		{name: "TZ", spec: "TZ=Asia/Kolkata 30 9 * * *", from: winter, want: time.Date(2024, 1, 15, 4, 0, 0, 0, time.UTC)},
+		{name: "explicit UTC", spec: "CRON_TZ=UTC 0 0 1 * *", from: winter, want: time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC)},
+		{name: "descriptor", spec: "@daily", from: winter.Add(time.Hour), want: time.Date(2024, 1, 16, 0, 0, 0, 0, time.UTC)},
+		{name: "descriptor with zone", spec: "CRON_TZ=Europe/Berlin @daily", from: winter, want: time.Date(2024, 1, 15, 23, 0, 0, 0, time.UTC)},
+		{name: "every", spec: "@every 30m", from: winter, want: winter.Add(30 * time.Minute)},
+		{name: "weekday", spec: "0 6 * * MON", from: winter.Add(7 * time.Hour), want: time.Date(2024, 1, 22, 6, 0, 0, 0, time.UTC)},
+		{name: "unknown zone", spec: "CRON_TZ=Nowhere/Atlantis 0 9 * * *", wantErr: true},
+		{name: "out of range", spec: "61 * * * *", wantErr: true},
+		{name: "six fields", spec: "0 0 9 * * *", wantErr: true},
+		{name: "empty", spec: "", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			schedule, err := parseCron(tt.spec)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("parseCron(%q) succeeded, want an error", tt.spec)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("parseCron(%q): %v", tt.spec, err)
+			}
+			if got := schedule.Next(tt.from).UTC(); !got.Equal(tt.want) {
+				t.Errorf("parseCron(%q).Next(%s) = %s, want %s", tt.spec, tt.from, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,146 @@
+package main
+
+import (
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	defaultSearchLimit = 50
+	maxSearchLimit     = 1000
+)
+
+type SearchResult struct {
+	Resource
+	Score   int      `json:"score"`
+	Matched []string `json:"matched"`
+}
+
+type SearchResponse struct {
+	Query     string         `json:"query"`
+	Results   []SearchResult `json:"results"`
+	Count     int            `json:"count"`
+	ScannedAt time.Time      `json:"scanned_at"`
+}
+
+// Match weights: identity matches rank above tags, tags above attributes.
+const (
+	scoreExact     = 100
+	scorePrefix    = 50
+	scoreIdentity  = 30
+	scoreTagValue  = 20
+	scoreAttribute = 10
+)
+
+// scoreResource scores a resource against every search term. Each term
+// must match somewhere, otherwise the resource scores 0.
+func scoreResource(resource Resource, terms []string) (int, []string) {
+	total := 0
+	matchedFields := make(map[string]bool)
+
+	for _, term := range terms {
+		best := 0
+		for _, field := range []struct{ name, value string }{{"id", resource.ID}, {"name", resource.Name}} {
+			value := strings.ToLower(field.value)
+			score := 0
+			switch {
+			case value == term:
+				score = scoreExact
+			case strings.HasPrefix(value, term):
+				score = scorePrefix
+			case strings.Contains(value, term):
+				score = scoreIdentity
+			}
+			if score > 0 {
+				matchedFields[field.name] = true
+				best = max(best, score)
+			}
+		}
+		for key, value := range resource.Tags {
+			if strings.Contains(strings.ToLower(value), term) {
+				matchedFields["tags."+key] = true
+				best = max(best, scoreTagValue)
+			}
+		}
+		for key, value := range resource.Attributes {
+			if strings.Contains(strings.ToLower(value), term) {
+				matchedFields["attributes."+key] = true
+				best = max(best, scoreAttribute)
+			}
+		}
+
+		if best == 0 {
+			return 0, nil
+		}
+		total += best
+	}
+
+	matched := make([]string, 0, len(matchedFields))
+	for field := range matchedFields {
+		matched = append(matched, field)
+	}
+	sort.Strings(matched)
+	return total, matched
+}
+
+func searchResources(c *gin.Context) {
+	query := strings.TrimSpace(c.Query("q"))
+	if query == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "q must be specified"})
+		return
+	}
+
+	limit := defaultSearchLimit
+	if raw := c.Query("limit"); raw != "" {
+		n, err := strconv.Atoi(raw)
+		if err != nil || n < 1 || n > maxSearchLimit {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "limit must be between 1 and 1000"})
+			return
+		}
+		limit = n
+	}
+
+	regionData, scannedAt := tenantFrom(c.Request.Context()).latestScan.Snapshot()
+	if len(regionData) == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "no scan results yet; list resources first"})
+		return
+	}
+
+	terms := strings.Fields(strings.ToLower(query))
+	var results []SearchResult
+	for _, rd := range regionData {
+		for _, resource := range rd.Resources {
+			if score, matched := scoreResource(resource, terms); score > 0 {
+				results = append(results, SearchResult{Resource: resource, Score: score, Matched: matched})
+			}
+		}
+	}
+
+	sort.SliceStable(results, func(i, j int) bool {
+		if results[i].Score != results[j].Score {
+			return results[i].Score > results[j].Score
+		}
+		return results[i].ID < results[j].ID
+	})
+
+	count := len(results)
+	if len(results) > limit {
+		results = results[:limit]
+	}
+
+	response := SearchResponse{
+		Query:     query,
+		Results:   results,
+		Count:     count,
+		ScannedAt: scannedAt,
+	}
+	if notModified(c, jsonETag(response)) {
+		return
+	}
+	c.JSON(http.StatusOK, response)
+}
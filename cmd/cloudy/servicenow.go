@@ -0,0 +1,402 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jmespath/go-jmespath"
+)
+
+// serviceNowTimeout bounds each Table API call.
+const serviceNowTimeout = 30 * time.Second
+
+// ServiceNowConfig is CLOUDY_SERVICENOW_FILE: the instance resources are
+// exported to, and how they map to its CMDB tables.
+type ServiceNowConfig struct {
+	// InstanceURL is the instance's URL, such as
+	// https://acme.service-now.com.
+	InstanceURL string               `json:"instance_url"`
+	Mappings    []*ServiceNowMapping `json:"mappings"`
+}
+
+// ServiceNowMapping exports the resources it selects as CIs of one table.
+// A resource is exported by the first mapping that selects it.
+type ServiceNowMapping struct {
+	// Table is the CMDB table the CIs are in, such as cmdb_ci_vm_instance.
+	Table string `json:"table"`
+	// Fields maps each of the table's columns set to a JMESPath
+	// expression evaluated against the resource's JSON, such as
+	// {"name": "name", "object_id": "id", "u_team": "tags.team"}.
+	// Columns whose expression is null are left as they are.
+	Fields map[string]string `json:"fields"`
+	// Key is the column, one of Fields, that identifies a resource's CI,
+	// so it is updated rather than created again.
+	Key string `json:"key"`
+	// RetiredFields are set, as they are, on the CIs of resources that
+	// are deleted, such as {"install_status": "7"}. Without them those
+	// CIs are left alone.
+	RetiredFields map[string]string `json:"retired_fields,omitempty"`
+	// Tenant is the tenant whose resources are exported, when tenants are
+	// enabled.
+	Tenant string `json:"tenant,omitempty"`
+	// Regions, Types and Tags select the resources exported, as a
+	// request's regions, types and tag_filters do; empty ones select
+	// everything.
+	Regions []string          `json:"regions,omitempty"`
+	Types   []string          `json:"types,omitempty"`
+	Tags    map[string]string `json:"tags,omitempty"`
+
+	exprs map[string]*jmespath.JMESPath
+}
+
+// serviceNow exports scheduled scans to a ServiceNow CMDB, or is nil if
+// they aren't exported.
+var serviceNow *serviceNowExporter
+
+// serviceNowExporter upserts CIs through the Table API, authenticating
+// with CLOUDY_SERVICENOW_TOKEN, or CLOUDY_SERVICENOW_USER and
+// CLOUDY_SERVICENOW_PASSWORD. It remembers each CI's sys_id and what it
+// last wrote to it, so unchanged resources aren't written again.
+type serviceNowExporter struct {
+	instanceURL string
+	user        string
+	password    string
+	token       string
+	mappings    []*ServiceNowMapping
+
+	// mu is held by the export in progress, so exports of scans that
+	// finish while it runs wait their turn.
+	mu      sync.Mutex
+	sysIDs  map[string]string
+	written map[string]string
+}
+
+// loadServiceNow reads the configuration in path.
+func loadServiceNow(path, user, password, token string) (*serviceNowExporter, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var cfg ServiceNowConfig
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	if _, err := url.ParseRequestURI(cfg.InstanceURL); err != nil {
+		return nil, fmt.Errorf("instance_url must be the instance's URL: %w", err)
+	}
+	if token == "" && (user == "" || password == "") {
+		return nil, errors.New("set CLOUDY_SERVICENOW_TOKEN, or CLOUDY_SERVICENOW_USER and CLOUDY_SERVICENOW_PASSWORD")
+	}
+
+	tenants := make(map[string]bool)
+	for _, t := range scheduledTenants() {
+		tenants[t.name] = true
+	}
+	for i, mapping := range cfg.Mappings {
+		switch {
+		case mapping.Table == "":
+			return nil, fmt.Errorf("mapping %d needs a table", i)
+		case mapping.Fields[mapping.Key] == "":
+			return nil, fmt.Errorf("mapping %s needs a key, one of its fields", mapping.Table)
+		case !tenants[mapping.Tenant]:
+			if len(tenantsByKey) == 0 {
+				return nil, fmt.Errorf("mapping %s names tenant %q, but tenants aren't enabled", mapping.Table, mapping.Tenant)
+			}
+			return nil, fmt.Errorf("mapping %s needs the tenant whose resources it exports, got %q", mapping.Table, mapping.Tenant)
+		}
+		mapping.exprs = make(map[string]*jmespath.JMESPath, len(mapping.Fields))
+		for column, expression := range mapping.Fields {
+			mapping.exprs[column], err = jmespath.Compile(expression)
+			if err != nil {
+				return nil, fmt.Errorf("mapping %s: field %s: %w", mapping.Table, column, err)
+			}
+		}
+	}
+
+	return &serviceNowExporter{
+		instanceURL: strings.TrimSuffix(cfg.InstanceURL, "/"),
+		user:        user,
+		password:    password,
+		token:       token,
+		mappings:    cfg.Mappings,
+		sysIDs:      make(map[string]string),
+		written:     make(map[string]string),
+	}, nil
+}
+
+// selects reports whether the mapping exports t's resource.
+func (m *ServiceNowMapping) selects(t *tenant, resource Resource) bool {
+	return m.Tenant == t.name && matchesAny(resource.Region, m.Regions) && matchesAny(resource.Type, m.Types) && matchesTagFilters(resource, m.Tags)
+}
+
+// record returns the columns resource sets, leaving out those whose
+// expression is null or fails.
+func (m *ServiceNowMapping) record(resource Resource) map[string]string {
+	document := resourceDocument(resource)
+	record := make(map[string]string, len(m.exprs))
+	for column, expr := range m.exprs {
+		value, err := expr.Search(document)
+		if err != nil || value == nil {
+			continue
+		}
+		switch v := value.(type) {
+		case string:
+			record[column] = v
+		case float64, bool:
+			record[column] = fmt.Sprint(v)
+		default:
+			data, _ := json.Marshal(v)
+			record[column] = string(data)
+		}
+	}
+	return record
+}
+
+func (e *serviceNowExporter) mappingFor(t *tenant, resource Resource) *ServiceNowMapping {
+	for _, mapping := range e.mappings {
+		if mapping.selects(t, resource) {
+			return mapping
+		}
+	}
+	return nil
+}
+
+// exportToServiceNow upserts the CIs of the resources of scan's regions
+// listed without error, and retires those of the resources it found
+// deleted, in the background.
+func exportToServiceNow(scan scheduledScan) {
+	if serviceNow == nil {
+		return
+	}
+	go func() {
+		serviceNow.mu.Lock()
+		defer serviceNow.mu.Unlock()
+		serviceNow.export(context.Background(), scan)
+	}()
+}
+
+// export stops at the first call that fails after its retries, as the
+// rest would most likely fail too; the next scan's export writes what
+// this one didn't. It runs with e.mu held.
+func (e *serviceNowExporter) export(ctx context.Context, scan scheduledScan) {
+	var created, updated, retired int
+	err := func() error {
+		for _, rd := range scan.regionData {
+			if rd.Error != "" {
+				continue
+			}
+			for _, resource := range rd.Resources {
+				mapping := e.mappingFor(scan.tenant, resource)
+				if mapping == nil {
+					continue
+				}
+				isNew, changed, err := e.upsert(ctx, mapping, mapping.record(resource))
+				switch {
+				case err != nil:
+					return err
+				case isNew:
+					created++
+				case changed:
+					updated++
+				}
+			}
+		}
+		for _, resource := range scan.changes.Removed {
+			mapping := e.mappingFor(scan.tenant, resource)
+			if mapping == nil || len(mapping.RetiredFields) == 0 {
+				continue
+			}
+			ok, err := e.retire(ctx, mapping, mapping.record(resource)[mapping.Key])
+			if err != nil {
+				return err
+			}
+			if ok {
+				retired++
+			}
+		}
+		return nil
+	}()
+
+	if created+updated+retired > 0 {
+		log.Printf("Exported to ServiceNow%s: %d CIs created, %d updated, %d retired", tenantSuffix(scan.tenant), created, updated, retired)
+	}
+	if err != nil {
+		log.Printf("Failed to export to ServiceNow%s: %v", tenantSuffix(scan.tenant), err)
+	}
+}
+
+// upsert writes record to the CI with its key, creating it if there is
+// none, unless it is what was last written.
+func (e *serviceNowExporter) upsert(ctx context.Context, mapping *ServiceNowMapping, record map[string]string) (created, changed bool, err error) {
+	key := record[mapping.Key]
+	if key == "" {
+		return false, false, nil
+	}
+	ci := mapping.Table + "\n" + key
+	hash := recordHash(record)
+	if e.written[ci] == hash {
+		return false, false, nil
+	}
+
+	sysID, err := e.sysID(ctx, mapping, key)
+	if err != nil {
+		return false, false, err
+	}
+	if sysID == "" {
+		var result struct {
+			SysID string `json:"sys_id"`
+		}
+		if err := e.call(ctx, http.MethodPost, mapping.Table, nil, record, &result); err != nil {
+			return false, false, err
+		}
+		e.sysIDs[ci] = result.SysID
+		created = true
+	} else if err := e.call(ctx, http.MethodPatch, mapping.Table+"/"+sysID, nil, record, nil); err != nil {
+		return false, false, err
+	}
+	e.written[ci] = hash
+	return created, true, nil
+}
+
+// retire sets the mapping's retired fields on the CI with key, if there
+// is one.
+func (e *serviceNowExporter) retire(ctx context.Context, mapping *ServiceNowMapping, key string) (bool, error) {
+	if key == "" {
+		return false, nil
+	}
+	sysID, err := e.sysID(ctx, mapping, key)
+	if err != nil || sysID == "" {
+		return false, err
+	}
+	if err := e.call(ctx, http.MethodPatch, mapping.Table+"/"+sysID, nil, mapping.RetiredFields, nil); err != nil {
+		return false, err
+	}
+	delete(e.written, mapping.Table+"\n"+key)
+	return true, nil
+}
+
+// sysID finds the sys_id of the CI with key, or "" if there is none.
+func (e *serviceNowExporter) sysID(ctx context.Context, mapping *ServiceNowMapping, key string) (string, error) {
+	ci := mapping.Table + "\n" + key
+	if sysID, ok := e.sysIDs[ci]; ok {
+		return sysID, nil
+	}
+	query := url.Values{
+		// ^ separates the conditions of an encoded query
+		"sysparm_query":  {mapping.Key + "=" + strings.ReplaceAll(key, "^", "^^")},
+		"sysparm_fields": {"sys_id"},
+		"sysparm_limit":  {"1"},
+	}
+	var result []struct {
+		SysID string `json:"sys_id"`
+	}
+	if err := e.call(ctx, http.MethodGet, mapping.Table, query, nil, &result); err != nil {
+		return "", err
+	}
+	if len(result) == 0 {
+		return "", nil
+	}
+	e.sysIDs[ci] = result[0].SysID
+	return result[0].SysID, nil
+}
+
+// call makes a Table API call on path, below /api/now/table/, decoding the
+// response's result into out, retrying with backoff after network errors,
+// 429s and 5xx responses as webhook deliveries are.
+func (e *serviceNowExporter) call(ctx context.Context, method, path string, query url.Values, body, out any) error {
+	var payload []byte
+	if body != nil {
+		var err error
+		if payload, err = json.Marshal(body); err != nil {
+			return err
+		}
+	}
+	endpoint := e.instanceURL + "/api/now/table/" + path
+	if query != nil {
+		endpoint += "?" + query.Encode()
+	}
+
+	backoff := webhookBackoff
+	for attempt := 1; ; attempt++ {
+		retry, err := e.attempt(ctx, method, endpoint, payload, out)
+		if err == nil {
+			return nil
+		}
+		if !retry || attempt == webhookAttempts {
+			return fmt.Errorf("%s %s: %w", method, path, err)
+		}
+		time.Sleep(backoff)
+		backoff *= 2
+	}
+}
+
+func (e *serviceNowExporter) attempt(ctx context.Context, method, endpoint string, payload []byte, out any) (retry bool, err error) {
+	ctx, cancel := context.WithTimeout(ctx, serviceNowTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, method, endpoint, bytes.NewReader(payload))
+	if err != nil {
+		return false, err
+	}
+	req.Header.Set("Accept", "application/json")
+	if payload != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if e.token != "" {
+		req.Header.Set("Authorization", "Bearer "+e.token)
+	} else {
+		req.SetBasicAuth(e.user, e.password)
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return true, err
+	}
+	defer resp.Body.Close()
+	var answer struct {
+		Result json.RawMessage `json:"result"`
+		Error  struct {
+			Message string `json:"message"`
+			Detail  string `json:"detail"`
+		} `json:"error"`
+	}
+	decodeErr := json.NewDecoder(resp.Body).Decode(&answer)
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		retry = resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500
+		return retry, fmt.Errorf("ServiceNow answered %s: %s %s", resp.Status, answer.Error.Message, answer.Error.Detail)
+	}
+	if out == nil {
+		return false, nil
+	}
+	if decodeErr != nil {
+		return false, decodeErr
+	}
+	return false, json.Unmarshal(answer.Result, out)
+}
+
+// recordHash tells records apart, whatever order their columns are in.
+func recordHash(record map[string]string) string {
+	columns := make([]string, 0, len(record))
+	for column := range record {
+		columns = append(columns, column)
+	}
+	sort.Strings(columns)
+	h := sha256.New()
+	for _, column := range columns {
+		fmt.Fprintf(h, "%s\x00%s\x00", column, record[column])
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
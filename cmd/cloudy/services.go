@@ -0,0 +1,173 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/alwindoss/cloudy/pkg/cloudy"
+	"github.com/gin-gonic/gin"
+)
+
+// Service is an AWS service the v2 API can be asked to scan, with the
+// resource types it lists. Global services are only listed once, under
+// their partition's global region, and services with Partitions only in
+// regions of those partitions.
+type Service struct {
+	Name        string   `json:"name"`
+	Description string   `json:"description"`
+	Global      bool     `json:"global"`
+	Types       []string `json:"types"`
+	IAMActions  []string `json:"iam_actions,omitempty"`
+	Partitions  []string `json:"partitions,omitempty"`
+}
+
+var supportedServices = []Service{
+	{Name: "ec2", Description: "EC2 instances, EBS volumes and NAT gateways", Types: []string{"EC2 Instance", "EBS Volume", "NAT Gateway"}},
+	{Name: "s3", Description: "S3 buckets", Global: true, Types: []string{"S3 Bucket"}},
+	{Name: "rds", Description: "RDS instances, Aurora clusters and snapshots", Types: []string{"RDS Instance", "Aurora Cluster", "RDS Snapshot", "RDS Cluster Snapshot"}},
+	{Name: "neptune", Description: "Neptune clusters", Types: []string{"Neptune Cluster"}},
+	{Name: "docdb", Description: "DocumentDB clusters", Types: []string{"DocumentDB Cluster"}},
+	{Name: "lambda", Description: "Lambda functions, versions, aliases, layers and event source mappings", Types: []string{"Lambda Function", "Lambda Version", "Lambda Alias", "Lambda Layer", "Lambda Event Source Mapping"}},
+	{Name: "ecs", Description: "ECS clusters", Types: []string{"ECS Cluster"}},
+	{Name: "iam", Description: "IAM users", Global: true, Types: []string{"IAM User"}},
+	{Name: "eventbridge", Description: "EventBridge event buses, rules and Scheduler schedules", Types: []string{"EventBridge Event Bus", "EventBridge Rule", "EventBridge Schedule"}},
+	{Name: "globalaccelerator", Description: "Global Accelerator accelerators and listeners", Global: true, Types: []string{"Global Accelerator", "Global Accelerator Listener"}, Partitions: []string{cloudy.PartitionAWS}},
+	{Name: "apprunner", Description: "App Runner services", Types: []string{"App Runner Service"}, Partitions: []string{cloudy.PartitionAWS}},
+	{Name: "amplify", Description: "Amplify apps and branches", Types: []string{"Amplify App", "Amplify Branch"}, Partitions: []string{cloudy.PartitionAWS}},
+	{Name: "emr", Description: "EMR clusters that are still running", Types: []string{"EMR Cluster"}},
+	{Name: "dms", Description: "DMS replication instances and tasks", Types: []string{"DMS Replication Instance", "DMS Replication Task"}},
+	{Name: "workspaces", Description: "WorkSpaces virtual desktops", Types: []string{"WorkSpace"}},
+	{Name: "guardduty", Description: "GuardDuty detectors", Types: []string{"GuardDuty Detector"}},
+	{Name: "config", Description: "AWS Config recorders and rules", Types: []string{"Config Recorder", "Config Rule"}},
+	{Name: "cloudtrail", Description: "CloudTrail trails, in their home region", Types: []string{"CloudTrail Trail"}},
+}
+
+// RegionsRequestV2 is the body of POST /api/v2/resources. Services
+// defaults to every supported service and Regions to the region of the
+// server's AWS configuration.
+type RegionsRequestV2 struct {
+	Regions     []string          `json:"regions,omitempty"`
+	Services    []string          `json:"services,omitempty"`
+	Kinds       []string          `json:"kinds,omitempty"`
+	States      []string          `json:"states,omitempty"`
+	TagFilters  map[string]string `json:"tag_filters,omitempty"`
+	NamePattern string            `json:"name_pattern,omitempty"`
+	NameRegex   string            `json:"name_regex,omitempty"`
+	Sort        string            `json:"sort,omitempty"`
+	Order       string            `json:"order,omitempty"`
+	Limit       int               `json:"limit,omitempty"`
+	NextToken   string            `json:"next_token,omitempty"`
+	Fields      []string          `json:"fields,omitempty"`
+	Query       string            `json:"query,omitempty"`
+	Refresh     bool              `json:"refresh,omitempty"`
+	Mode        string            `json:"mode,omitempty"`
+	Provider    string            `json:"provider,omitempty"`
+	Credentials *Credentials      `json:"credentials,omitempty"`
+}
+
+// serviceTypes returns the resource types listed by the named services.
+func serviceTypes(names []string) ([]string, error) {
+	byName := make(map[string]Service, len(supportedServices))
+	for _, service := range supportedServices {
+		byName[service.Name] = service
+	}
+
+	var types []string
+	for _, name := range names {
+		service, ok := byName[strings.ToLower(name)]
+		if !ok {
+			return nil, fmt.Errorf("unknown service %q; see GET /api/v2/services", name)
+		}
+		types = append(types, service.Types...)
+	}
+	return types, nil
+}
+
+// serviceIAMActions returns the IAM actions the registered listers need to
+// produce any of types.
+func serviceIAMActions(types []string) []string {
+	var actions []string
+	seen := make(map[string]bool)
+	for _, lister := range cloudy.Listers() {
+		if !slices.ContainsFunc(lister.Types(), func(t string) bool { return slices.Contains(types, t) }) {
+			continue
+		}
+		for _, action := range lister.IAMActions() {
+			if !seen[action] {
+				seen[action] = true
+				actions = append(actions, action)
+			}
+		}
+	}
+	sort.Strings(actions)
+	return actions
+}
+
+func listServices(c *gin.Context) {
+	services := make([]Service, len(supportedServices))
+	for i, service := range supportedServices {
+		service.IAMActions = serviceIAMActions(service.Types)
+		services[i] = service
+	}
+	c.JSON(http.StatusOK, gin.H{"services": services})
+}
+
+func listResourcesV2(c *gin.Context) {
+	var body RegionsRequestV2
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	req := RegionsRequest{
+		Regions:     body.Regions,
+		Kinds:       body.Kinds,
+		States:      body.States,
+		TagFilters:  body.TagFilters,
+		NamePattern: body.NamePattern,
+		NameRegex:   body.NameRegex,
+		Sort:        body.Sort,
+		Order:       body.Order,
+		Limit:       body.Limit,
+		NextToken:   body.NextToken,
+		Fields:      body.Fields,
+		Query:       body.Query,
+		Refresh:     body.Refresh,
+		Mode:        body.Mode,
+		Provider:    body.Provider,
+		Credentials: body.Credentials,
+	}
+
+	if len(body.Services) > 0 {
+		types, err := serviceTypes(body.Services)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		req.Types = types
+	}
+
+	if err := validateCredentialsTransport(c.Request, req.Credentials); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if len(req.Regions) == 0 {
+		lister, err := listerFor(c.Request.Context(), req.Provider, req.Credentials)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if cfg := lister.Config(); cfg.Region != "" {
+			req.Regions = []string{cfg.Region}
+		} else {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "no regions given and no default region configured"})
+			return
+		}
+	}
+
+	respondWithResources(c, req)
+}
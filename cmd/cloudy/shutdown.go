@@ -0,0 +1,62 @@
+package main
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc"
+)
+
+// shutdown stops both APIs from taking new requests and waits up to grace
+// for in-flight requests and async scans to finish, and then for the
+// webhook deliveries they fired. Whatever is still running then is
+// cancelled. Trends and the DynamoDB inventory are flushed
+// last, so what the draining scans recorded is saved.
+func shutdown(server *http.Server, grpcServer *grpc.Server, grace time.Duration) {
+	ctx, cancel := context.WithTimeout(context.Background(), grace)
+	defer cancel()
+
+	var wg sync.WaitGroup
+	wg.Add(3)
+	go func() {
+		defer wg.Done()
+		if err := server.Shutdown(ctx); err != nil {
+			log.Printf("HTTP requests still running after %s were cut off: %v", grace, err)
+			server.Close()
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		stopped := make(chan struct{})
+		go func() {
+			grpcServer.GracefulStop()
+			close(stopped)
+		}()
+		select {
+		case <-stopped:
+		case <-ctx.Done():
+			grpcServer.Stop()
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		drainScans(ctx)
+		drainWebhooks(ctx)
+	}()
+	wg.Wait()
+
+	if err := resourceTrends.Flush(); err != nil {
+		log.Printf("Failed to save trends: %v", err)
+	}
+	if inventory != nil {
+		// The writes queued get a grace period of their own
+		flushCtx, cancel := context.WithTimeout(context.Background(), grace)
+		defer cancel()
+		if err := inventory.Close(flushCtx); err != nil {
+			log.Printf("Scans not yet saved to DynamoDB were lost: %v", err)
+		}
+	}
+}
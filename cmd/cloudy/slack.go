@@ -0,0 +1,177 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// slackAPIURL is where messages posted with a bot token go.
+const slackAPIURL = "https://slack.com/api/chat.postMessage"
+
+// slackTimeout bounds posting one digest.
+const slackTimeout = 10 * time.Second
+
+// SlackChannel is one channel in CLOUDY_SLACK_FILE, which gets digests
+// of the scheduled scans of its tenant: by default, of the changes they
+// find to the resources that match its filters.
+type SlackChannel struct {
+	// WebhookURL is an incoming webhook's URL, which posts to the channel
+	// it was created for. Otherwise Channel, a channel name or ID, is
+	// posted to with CLOUDY_SLACK_BOT_TOKEN.
+	WebhookURL string `json:"webhook_url,omitempty"`
+	Channel    string `json:"channel,omitempty"`
+	chatChannel
+}
+
+// slackChannels get digests, from CLOUDY_SLACK_FILE, and slackBotToken
+// posts to those without a webhook, from CLOUDY_SLACK_BOT_TOKEN.
+var (
+	slackChannels []SlackChannel
+	slackBotToken string
+)
+
+// loadSlackChannels reads the channels in path, a JSON file of the form
+// {"channels": [...]}.
+func loadSlackChannels(path string) ([]SlackChannel, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var file struct {
+		Channels []SlackChannel `json:"channels"`
+	}
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+
+	tenants := make(map[string]bool)
+	for _, t := range scheduledTenants() {
+		tenants[t.name] = true
+	}
+	for i, channel := range file.Channels {
+		switch {
+		case (channel.WebhookURL == "") == (channel.Channel == ""):
+			return nil, fmt.Errorf("channel %d needs either webhook_url or channel", i)
+		case channel.Channel != "" && slackBotToken == "":
+			return nil, fmt.Errorf("channel %s needs CLOUDY_SLACK_BOT_TOKEN to be set", channel.Channel)
+		}
+		if err := channel.check(strconv.Itoa(i), tenants); err != nil {
+			return nil, err
+		}
+	}
+	return file.Channels, nil
+}
+
+// notifySlack posts the digests of scan that every channel of its tenant
+// gets.
+func notifySlack(ctx context.Context, scan scheduledScan) {
+	for _, channel := range slackChannels {
+		if channel.Tenant != scan.tenant.name {
+			continue
+		}
+		for _, digest := range chatDigests(channel.chatChannel, scan) {
+			if err := postSlack(ctx, channel, slackDigest(digest)); err != nil {
+				log.Printf("Failed to post Slack digest%s: %v", tenantSuffix(scan.tenant), err)
+			}
+		}
+	}
+}
+
+// slackDigest writes digest as a Slack message.
+func slackDigest(digest chatDigest) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "*%s*\n", slackEscape(digest.Title))
+	for _, section := range digest.Sections {
+		fmt.Fprintf(&b, "\n*%s*\n", slackEscape(section.Title))
+		for _, line := range section.Lines[:min(len(section.Lines), chatDigestLines)] {
+			text := slackEscape(line.Text)
+			if line.Resource != nil {
+				text = slackResource(*line.Resource)
+				if line.Text != "" {
+					text += ": " + slackEscape(line.Text)
+				}
+			}
+			b.WriteString("• " + text + "\n")
+		}
+		if n := len(section.Lines); n > chatDigestLines {
+			fmt.Fprintf(&b, "_and %d more_\n", n-chatDigestLines)
+		}
+	}
+	return b.String()
+}
+
+// slackResource describes a resource on one line, such as
+// EC2 Instance `web-1` (i-0abc) in us-east-1.
+func slackResource(resource Resource) string {
+	line := slackEscape(resource.Type) + " `" + slackEscape(resource.Name) + "`"
+	if resource.Name == "" {
+		line = slackEscape(resource.Type) + " `" + slackEscape(resource.ID) + "`"
+	} else if resource.Name != resource.ID {
+		line += " (" + slackEscape(resource.ID) + ")"
+	}
+	return line + " in " + slackEscape(resource.Region)
+}
+
+// slackEscape escapes the characters Slack's message formatting reserves.
+func slackEscape(s string) string {
+	return strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;").Replace(s)
+}
+
+// postSlack posts text to channel, through its webhook or with the bot
+// token.
+func postSlack(ctx context.Context, channel SlackChannel, text string) error {
+	ctx, cancel := context.WithTimeout(ctx, slackTimeout)
+	defer cancel()
+
+	url := channel.WebhookURL
+	message := map[string]string{"text": text}
+	if url == "" {
+		url = slackAPIURL
+		message["channel"] = channel.Channel
+	}
+	body, err := json.Marshal(message)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json; charset=utf-8")
+	if channel.WebhookURL == "" {
+		req.Header.Set("Authorization", "Bearer "+slackBotToken)
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("slack answered %s", resp.Status)
+	}
+	if channel.WebhookURL != "" {
+		return nil
+	}
+	// The Web API answers 200 even when it didn't post
+	var result struct {
+		OK    bool   `json:"ok"`
+		Error string `json:"error"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return err
+	}
+	if !result.OK {
+		return errors.New("slack: " + result.Error)
+	}
+	return nil
+}
@@ -0,0 +1,676 @@
+package main
+
+import (
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/alwindoss/cloudy/pkg/cloudy"
+	"github.com/gin-gonic/gin"
+)
+
+// defaultSnapshotRetention is how long snapshots are kept unless
+// CLOUDY_SNAPSHOT_RETENTION says otherwise.
+const defaultSnapshotRetention = 30 * 24 * time.Hour
+
+// snapshotRetention bounds how far back snapshots go, from
+// CLOUDY_SNAPSHOT_RETENTION, and snapshotLimit how many of each region are
+// kept, from CLOUDY_SNAPSHOT_LIMIT, without a limit while zero. Snapshots
+// older than snapshotCompactAfter, from CLOUDY_SNAPSHOT_COMPACT_AFTER, are
+// compacted to the last of each day; none are while it is zero.
+var (
+	snapshotRetention    = defaultSnapshotRetention
+	snapshotLimit        int
+	snapshotCompactAfter time.Duration
+)
+
+// snapshotTimeLayout names snapshot files by when they were taken, so
+// they sort in order; their resource count follows, after an underscore.
+const snapshotTimeLayout = "20060102T150405.000000000Z"
+
+// resourceSnapshot is the resources a full scan listed in one region of
+// a provider without error.
+type resourceSnapshot struct {
+	Provider string
+	Region   string
+	At       time.Time
+	Count    int
+	// resources are nil for a snapshot saved to path until read.
+	resources []Resource
+	path      string
+}
+
+// snapshotStore keeps the snapshots of every full scan, for comparing
+// the inventory at two points in time. With a directory set, snapshots
+// are saved there rather than kept in memory, one gzipped JSON file per
+// region and scan in a directory per provider and region, so they survive
+// restarts.
+type snapshotStore struct {
+	mu sync.Mutex
+	// snapshots are in the order they were taken.
+	snapshots []*resourceSnapshot
+	dir       string
+}
+
+var resourceSnapshots = &snapshotStore{}
+
+// Record adds a snapshot of provider's region. Kept in memory, a snapshot
+// the same as the region's previous one shares its resources.
+func (s *snapshotStore) Record(provider, region string, resources []Resource) {
+	snapshot := &resourceSnapshot{Provider: provider, Region: region, At: time.Now().UTC(), Count: len(resources)}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.dir != "" {
+		name := fmt.Sprintf("%s_%d.json.gz", snapshot.At.Format(snapshotTimeLayout), snapshot.Count)
+		snapshot.path = filepath.Join(s.dir, url.PathEscape(provider), url.PathEscape(region), name)
+		if err := writeSnapshot(snapshot.path, resources); err != nil {
+			log.Printf("Failed to save snapshot of %s to %s: %v", region, s.dir, err)
+			return
+		}
+	} else {
+		snapshot.resources = append([]Resource(nil), resources...)
+		if previous := s.latest(snapshot.key(), snapshot.At); previous != nil && diffResources(previous.resources, resources).empty() {
+			snapshot.resources = previous.resources
+		}
+	}
+
+	s.snapshots = append(s.snapshots, snapshot)
+	s.prune(snapshot.At)
+}
+
+// key is the provider and region snapshot was taken of.
+func (snapshot *resourceSnapshot) key() regionKey {
+	return regionKey{snapshot.Provider, snapshot.Region}
+}
+
+// prune drops the snapshots retention doesn't keep as of now: those past
+// snapshotRetention, all but the last of each region and day of those
+// past snapshotCompactAfter, and all but the last snapshotLimit of each
+// region. The caller must hold s.mu.
+func (s *snapshotStore) prune(now time.Time) {
+	cutoff := now.Add(-snapshotRetention)
+	var compactBefore time.Time
+	if snapshotCompactAfter > 0 {
+		compactBefore = now.Add(-snapshotCompactAfter)
+	}
+
+	type regionDay struct {
+		region regionKey
+		day    time.Time
+	}
+	days := make(map[regionDay]bool)
+	counts := make(map[regionKey]int)
+	kept := make([]*resourceSnapshot, 0, len(s.snapshots))
+	// Newest first, so the snapshot kept of each day is its last
+	for i := len(s.snapshots) - 1; i >= 0; i-- {
+		snapshot := s.snapshots[i]
+		drop := !snapshot.At.After(cutoff)
+		if !drop && snapshot.At.Before(compactBefore) {
+			day := regionDay{snapshot.key(), snapshot.At.Truncate(24 * time.Hour)}
+			drop = days[day]
+			days[day] = true
+		}
+		if !drop && snapshotLimit > 0 {
+			drop = counts[snapshot.key()] >= snapshotLimit
+		}
+		if drop {
+			if snapshot.path != "" {
+				os.Remove(snapshot.path)
+			}
+			continue
+		}
+		counts[snapshot.key()]++
+		kept = append(kept, snapshot)
+	}
+	slices.Reverse(kept)
+	s.snapshots = kept
+}
+
+// latest returns the last snapshot of region taken up to at, or nil. The
+// caller must hold s.mu.
+func (s *snapshotStore) latest(region regionKey, at time.Time) *resourceSnapshot {
+	for i := len(s.snapshots) - 1; i >= 0; i-- {
+		if snapshot := s.snapshots[i]; snapshot.key() == region && !snapshot.At.After(at) {
+			return snapshot
+		}
+	}
+	return nil
+}
+
+// Load reads the snapshots saved in dir, if it exists, and saves them
+// there from then on. Snapshots saved before they were kept by provider,
+// straight in a region's directory, are taken to be of AWS, the default
+// provider: a directory is a region's if it holds snapshot files, and a
+// provider's otherwise. Anything else in either is skipped.
+func (s *snapshotStore) Load(dir string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.dir = dir
+	providers, err := os.ReadDir(dir)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	for _, entry := range providers {
+		name, err := url.PathUnescape(entry.Name())
+		// The server's directory holds its tenants' below tenants
+		if !entry.IsDir() || err != nil || entry.Name() == "tenants" {
+			continue
+		}
+		entries, err := os.ReadDir(filepath.Join(dir, entry.Name()))
+		if err != nil {
+			return err
+		}
+		if slices.ContainsFunc(entries, isSnapshotFile) {
+			// A region's directory from before providers had their own
+			s.loadRegion(cloudy.ProviderAWS, name, filepath.Join(dir, entry.Name()), entries)
+			continue
+		}
+		for _, region := range entries {
+			if !region.IsDir() {
+				continue
+			}
+			regionName, err := url.PathUnescape(region.Name())
+			if err != nil {
+				continue
+			}
+			regionDir := filepath.Join(dir, entry.Name(), region.Name())
+			files, err := os.ReadDir(regionDir)
+			if err != nil {
+				return err
+			}
+			s.loadRegion(name, regionName, regionDir, files)
+		}
+	}
+	sort.SliceStable(s.snapshots, func(i, j int) bool {
+		return s.snapshots[i].At.Before(s.snapshots[j].At)
+	})
+	s.prune(time.Now().UTC())
+	return nil
+}
+
+// loadRegion adds the snapshots of provider's region saved as files in
+// regionDir. The caller must hold s.mu.
+func (s *snapshotStore) loadRegion(provider, region, regionDir string, files []os.DirEntry) {
+	for _, file := range files {
+		if file.IsDir() {
+			continue
+		}
+		at, n, ok := parseSnapshotName(file.Name())
+		if !ok {
+			continue
+		}
+		s.snapshots = append(s.snapshots, &resourceSnapshot{Provider: provider, Region: region, At: at, Count: n, path: filepath.Join(regionDir, file.Name())})
+	}
+}
+
+// parseSnapshotName returns when the snapshot saved as name was taken and
+// how many resources it holds, or false if name isn't a snapshot's:
+// <stamp>_<count>.json.gz.
+func parseSnapshotName(name string) (time.Time, int, bool) {
+	base, ok := strings.CutSuffix(name, ".json.gz")
+	if !ok {
+		return time.Time{}, 0, false
+	}
+	stamp, count, ok := strings.Cut(base, "_")
+	if !ok {
+		return time.Time{}, 0, false
+	}
+	at, err := time.Parse(snapshotTimeLayout, stamp)
+	if err != nil {
+		return time.Time{}, 0, false
+	}
+	n, err := strconv.Atoi(count)
+	if err != nil {
+		return time.Time{}, 0, false
+	}
+	return at, n, true
+}
+
+// isSnapshotFile reports whether entry is a saved snapshot.
+func isSnapshotFile(entry os.DirEntry) bool {
+	_, _, ok := parseSnapshotName(entry.Name())
+	return !entry.IsDir() && ok
+}
+
+// SnapshotInfo describes a snapshot without its resources.
+type SnapshotInfo struct {
+	Provider      string    `json:"provider" yaml:"provider"`
+	Region        string    `json:"region" yaml:"region"`
+	TakenAt       time.Time `json:"taken_at" yaml:"taken_at"`
+	ResourceCount int       `json:"resource_count" yaml:"resource_count"`
+}
+
+// List describes the snapshots of regions taken from from up to to, in
+// the order they were taken.
+func (s *snapshotStore) List(regions []string, from, to time.Time) []SnapshotInfo {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	infos := []SnapshotInfo{}
+	for _, snapshot := range s.snapshots {
+		if matchesAny(snapshot.Region, regions) && !snapshot.At.Before(from) && !snapshot.At.After(to) {
+			infos = append(infos, snapshot.info())
+		}
+	}
+	return infos
+}
+
+// info describes snapshot.
+func (snapshot *resourceSnapshot) info() SnapshotInfo {
+	return SnapshotInfo{Provider: snapshot.Provider, Region: snapshot.Region, TakenAt: snapshot.At, ResourceCount: snapshot.Count}
+}
+
+// AsOf returns the last snapshot of each provider's regions among regions
+// taken up to at, by region and then provider.
+func (s *snapshotStore) AsOf(regions []string, at time.Time) []*resourceSnapshot {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	latest := make(map[regionKey]*resourceSnapshot)
+	for _, snapshot := range s.snapshots {
+		if matchesAny(snapshot.Region, regions) && !snapshot.At.After(at) {
+			latest[snapshot.key()] = snapshot
+		}
+	}
+	snapshots := make([]*resourceSnapshot, 0, len(latest))
+	for _, snapshot := range latest {
+		snapshots = append(snapshots, snapshot)
+	}
+	sort.Slice(snapshots, func(i, j int) bool {
+		return lessRegionKey(snapshots[i].key(), snapshots[j].key())
+	})
+	return snapshots
+}
+
+// snapshotDir is where t's snapshots are saved, below dir.
+func snapshotDir(dir string, t *tenant) string {
+	if t == serverTenant {
+		return dir
+	}
+	return filepath.Join(dir, "tenants", url.PathEscape(t.name))
+}
+
+// Resources returns the resources of snapshot, reading them if it was
+// saved.
+func (snapshot *resourceSnapshot) Resources() ([]Resource, error) {
+	if snapshot.path == "" {
+		return snapshot.resources, nil
+	}
+	f, err := os.Open(snapshot.path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	zr, err := gzip.NewReader(f)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", snapshot.path, err)
+	}
+	var resources []Resource
+	if err := json.NewDecoder(zr).Decode(&resources); err != nil {
+		return nil, fmt.Errorf("%s: %w", snapshot.path, err)
+	}
+	return resources, nil
+}
+
+// writeSnapshot saves resources to path through a temporary file, so a
+// crash never leaves a truncated snapshot behind.
+func writeSnapshot(path string, resources []Resource) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".cloudy-snapshot-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	zw := gzip.NewWriter(tmp)
+	if err := json.NewEncoder(zw).Encode(resources); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := zw.Close(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// FieldChange is one field of a resource that differs between two
+// snapshots: name, state, tags.<key> or attributes.<key>. Before or After
+// is null when a tag or attribute was added or removed.
+type FieldChange struct {
+	Field  string  `json:"field"`
+	Before *string `json:"before"`
+	After  *string `json:"after"`
+}
+
+// ModifiedResource is a resource as both snapshots have it, with what
+// changed.
+type ModifiedResource struct {
+	Before  Resource      `json:"before"`
+	After   Resource      `json:"after"`
+	Changes []FieldChange `json:"changes"`
+}
+
+// DiffRegion is a provider's region compared, with when the snapshots
+// compared were taken. From is null for a region without a snapshot as of
+// from, which isn't compared.
+type DiffRegion struct {
+	Provider string     `json:"provider"`
+	Region   string     `json:"region"`
+	From     *time.Time `json:"from"`
+	To       time.Time  `json:"to"`
+}
+
+type DiffResponse struct {
+	From     time.Time          `json:"from"`
+	To       time.Time          `json:"to"`
+	Regions  []DiffRegion       `json:"regions"`
+	Added    []Resource         `json:"added"`
+	Removed  []Resource         `json:"removed"`
+	Modified []ModifiedResource `json:"modified"`
+}
+
+// fieldChanges lists the fields that differ between before and after, in
+// the order of changedFields, tags and attributes by key.
+func fieldChanges(before, after Resource) []FieldChange {
+	var changes []FieldChange
+	if before.Name != after.Name {
+		changes = append(changes, FieldChange{Field: "name", Before: &before.Name, After: &after.Name})
+	}
+	if before.State != after.State {
+		changes = append(changes, FieldChange{Field: "state", Before: &before.State, After: &after.State})
+	}
+	for _, field := range []struct {
+		prefix        string
+		before, after map[string]string
+	}{{"tags.", before.Tags, after.Tags}, {"attributes.", before.Attributes, after.Attributes}} {
+		keys := make(map[string]bool)
+		for key := range field.before {
+			keys[key] = true
+		}
+		for key := range field.after {
+			keys[key] = true
+		}
+		for _, key := range sortedKeys(keys) {
+			old, hadOld := field.before[key]
+			value, hasValue := field.after[key]
+			if hadOld == hasValue && old == value {
+				continue
+			}
+			change := FieldChange{Field: field.prefix + key}
+			if hadOld {
+				change.Before = &old
+			}
+			if hasValue {
+				change.After = &value
+			}
+			changes = append(changes, change)
+		}
+	}
+	return changes
+}
+
+// Diff compares the inventory as of from with that as of to, each region
+// as its last snapshot up to then had it, keeping the resources keep
+// accepts.
+func (s *snapshotStore) Diff(from, to time.Time, regions []string, keep func(Resource) bool) (DiffResponse, error) {
+	diff := DiffResponse{From: from, To: to, Regions: []DiffRegion{}, Added: []Resource{}, Removed: []Resource{}, Modified: []ModifiedResource{}}
+
+	type pair struct{ before, after *resourceSnapshot }
+	pairs := make(map[regionKey]pair)
+	s.mu.Lock()
+	for _, snapshot := range s.snapshots {
+		if matchesAny(snapshot.Region, regions) && !snapshot.At.After(to) {
+			pairs[snapshot.key()] = pair{s.latest(snapshot.key(), from), s.latest(snapshot.key(), to)}
+		}
+	}
+	s.mu.Unlock()
+
+	keys := make([]regionKey, 0, len(pairs))
+	for key := range pairs {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		return lessRegionKey(keys[i], keys[j])
+	})
+	for _, key := range keys {
+		p := pairs[key]
+		compared := DiffRegion{Provider: key.Provider, Region: key.Region, To: p.after.At}
+		diff.Regions = append(diff.Regions, compared)
+		if p.before == nil {
+			continue
+		}
+		diff.Regions[len(diff.Regions)-1].From = &p.before.At
+		if p.before == p.after {
+			continue
+		}
+
+		before, err := p.before.Resources()
+		if err != nil {
+			return diff, err
+		}
+		after, err := p.after.Resources()
+		if err != nil {
+			return diff, err
+		}
+		changes := diffResources(before, after).filter(keep)
+		diff.Added = append(diff.Added, changes.Added...)
+		diff.Removed = append(diff.Removed, changes.Removed...)
+		for _, changed := range changes.Changed {
+			diff.Modified = append(diff.Modified, ModifiedResource{Before: changed.Before, After: changed.After, Changes: fieldChanges(changed.Before, changed.After)})
+		}
+	}
+	return diff, nil
+}
+
+// parseSnapshotTime reads the time query parameter name: an RFC 3339
+// time, or a duration, such as 24h, meaning that long before now.
+func parseSnapshotTime(name, raw string, now time.Time) (time.Time, error) {
+	if at, err := time.Parse(time.RFC3339, raw); err == nil {
+		return at.UTC(), nil
+	}
+	if ago, err := time.ParseDuration(raw); err == nil && ago >= 0 {
+		return now.Add(-ago), nil
+	}
+	return time.Time{}, fmt.Errorf("%s must be an RFC 3339 time or a duration ago such as 24h, got %q", name, raw)
+}
+
+// diffSnapshots answers GET /api/v1/diff: what full scans found added,
+// removed and modified between from and to, by default now.
+func diffSnapshots(c *gin.Context) {
+	now := time.Now().UTC()
+	if c.Query("from") == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "from must be specified"})
+		return
+	}
+	from, err := parseSnapshotTime("from", c.Query("from"), now)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	to := now
+	if raw := c.Query("to"); raw != "" {
+		if to, err = parseSnapshotTime("to", raw, now); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+	if !from.Before(to) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "from must be before to"})
+		return
+	}
+	tags, err := queryTagFilters(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	types := queryList(c, "types")
+	keep := func(resource Resource) bool {
+		return matchesAny(resource.Type, types) && matchesTagFilters(resource, tags)
+	}
+	diff, err := tenantFrom(c.Request.Context()).snapshots.Diff(from, to, queryList(c, "regions"), keep)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if len(diff.Regions) == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "no snapshots as of to; list resources first"})
+		return
+	}
+	c.JSON(http.StatusOK, diff)
+}
+
+// SnapshotsResponse lists the snapshots kept, with the retention that
+// keeps them.
+type SnapshotsResponse struct {
+	Retention    string         `json:"retention" yaml:"retention"`
+	Limit        int            `json:"limit,omitempty" yaml:"limit,omitempty"`
+	CompactAfter string         `json:"compact_after,omitempty" yaml:"compact_after,omitempty"`
+	Snapshots    []SnapshotInfo `json:"snapshots" yaml:"snapshots"`
+}
+
+// listSnapshots answers GET /api/v1/snapshots: the snapshots of the
+// regions asked for taken between from and to, by default all of them.
+func listSnapshots(c *gin.Context) {
+	now := time.Now().UTC()
+	var from time.Time
+	to := now
+	var err error
+	if raw := c.Query("from"); raw != "" {
+		if from, err = parseSnapshotTime("from", raw, now); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+	if raw := c.Query("to"); raw != "" {
+		if to, err = parseSnapshotTime("to", raw, now); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+	format, err := responseFormat(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if format != formatJSON && format != formatYAML {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "snapshots are only listed as json or yaml"})
+		return
+	}
+
+	response := SnapshotsResponse{
+		Retention: snapshotRetention.String(),
+		Limit:     snapshotLimit,
+		Snapshots: tenantFrom(c.Request.Context()).snapshots.List(queryList(c, "regions"), from, to),
+	}
+	if snapshotCompactAfter > 0 {
+		response.CompactAfter = snapshotCompactAfter.String()
+	}
+	if format == formatYAML {
+		c.YAML(http.StatusOK, response)
+		return
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// SnapshotResourcesResponse is the inventory as of At: each region as its
+// last snapshot up to then had it.
+type SnapshotResourcesResponse struct {
+	At         time.Time         `json:"at" yaml:"at"`
+	Snapshots  []SnapshotInfo    `json:"snapshots" yaml:"snapshots"`
+	RegionData []RegionResources `json:"region_data" yaml:"region_data"`
+	TotalCount int               `json:"total_count" yaml:"total_count"`
+}
+
+// getSnapshotResources answers GET /api/v1/snapshots/resources: the
+// resources snapshots had as of at, by default now, filtered by regions,
+// types and tag, in any format the resources endpoint has but ndjson.
+func getSnapshotResources(c *gin.Context) {
+	now := time.Now().UTC()
+	at := now
+	var err error
+	if raw := c.Query("at"); raw != "" {
+		if at, err = parseSnapshotTime("at", raw, now); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+	tags, err := queryTagFilters(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	format, err := responseFormat(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if format == formatNDJSON {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "snapshots aren't available as ndjson"})
+		return
+	}
+
+	snapshots := tenantFrom(c.Request.Context()).snapshots.AsOf(queryList(c, "regions"), at)
+	if len(snapshots) == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "no snapshot was taken as of at"})
+		return
+	}
+	types := queryList(c, "types")
+	response := SnapshotResourcesResponse{At: at, Snapshots: []SnapshotInfo{}, RegionData: []RegionResources{}}
+	for _, snapshot := range snapshots {
+		resources, err := snapshot.Resources()
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		kept := []Resource{}
+		for _, resource := range resources {
+			if matchesAny(resource.Type, types) && matchesTagFilters(resource, tags) {
+				kept = append(kept, resource)
+			}
+		}
+		response.Snapshots = append(response.Snapshots, snapshot.info())
+		response.RegionData = append(response.RegionData, RegionResources{Region: snapshot.Region, Resources: kept})
+		response.TotalCount += len(kept)
+	}
+
+	listed := ListResourcesResponse{RegionData: response.RegionData, TotalCount: response.TotalCount}
+	switch format {
+	case formatCSV:
+		writeResourcesCSV(c, listed, nil)
+	case formatXLSX:
+		writeResourcesXLSX(c, listed, nil)
+	case formatParquet:
+		writeResourcesParquet(c, listed, nil)
+	case formatDOT, formatCypher:
+		writeResourcesGraph(c, format, listed)
+	case formatYAML:
+		c.YAML(http.StatusOK, response)
+	default:
+		c.JSON(http.StatusOK, response)
+	}
+}
@@ -0,0 +1,419 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/alwindoss/cloudy/pkg/cloudy"
+)
+
+// changeStrings renders changes as field=before->after, with <nil> for a
+// key that is absent.
+func changeStrings(changes []FieldChange) []string {
+	value := func(s *string) string {
+		if s == nil {
+			return "<nil>"
+		}
+		return *s
+	}
+	rendered := []string{}
+	for _, change := range changes {
+		rendered = append(rendered, change.Field+"="+value(change.Before)+"->"+value(change.After))
+	}
+	return rendered
+}
+
+func TestFieldChanges(t *testing.T) {
+	base := Resource{ID: "i-1", Name: "web", State: "running", Tags: map[string]string{"env": "dev"}, Attributes: map[string]string{"type": "t3.micro"}}
+	with := func(change func(*Resource)) Resource {
+		resource := base
+		resource.Tags = map[string]string{"env": "dev"}
+		resource.Attributes = map[string]string{"type": "t3.micro"}
+		change(&resource)
+		return resource
+	}
+
+	tests := []struct {
+		name          string
+		before, after Resource
+		want          []string
+	}{
+		{name: "unchanged", before: base, after: with(func(*Resource) {}), want: []string{}},
+		{name: "nil and empty tags", before: with(func(r *Resource) { r.Tags = nil }), after: with(func(r *Resource) { r.Tags = map[string]string{} }), want: []string{}},
+		{name: "nil and empty attributes", before: with(func(r *Resource) { r.Attributes = map[string]string{} }), after: with(func(r *Resource) { r.Attributes = nil }), want: []string{}},
+		{name: "name and state", before: base, after: with(func(r *Resource) { r.Name, r.State = "api", "stopped" }), want: []string{"name=web->api", "state=running->stopped"}},
+		{name: "tag changed", before: base, after: with(func(r *Resource) { r.Tags["env"] = "prod" }), want: []string{"tags.env=dev->prod"}},
+		{name: "tag added to nil tags", before: with(func(r *Resource) { r.Tags = nil }), after: base, want: []string{"tags.env=<nil>->dev"}},
+		{name: "tags dropped", before: base, after: with(func(r *Resource) { r.Tags = nil }), want: []string{"tags.env=dev-><nil>"}},
+		{name: "tag set to empty", before: base, after: with(func(r *Resource) { r.Tags["env"] = "" }), want: []string{"tags.env=dev->"}},
+		{name: "empty tag dropped", before: with(func(r *Resource) { r.Tags["team"] = "" }), after: base, want: []string{"tags.team=-><nil>"}},
+		{
+			name:   "keys in order",
+			before: base,
+			after: with(func(r *Resource) {
+				r.Tags = map[string]string{"b": "2", "a": "1"}
+				r.Attributes["az"] = "us-east-1a"
+			}),
+			want: []string{"tags.a=<nil>->1", "tags.b=<nil>->2", "tags.env=dev-><nil>", "attributes.az=<nil>->us-east-1a"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := changeStrings(fieldChanges(tt.before, tt.after))
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("fieldChanges = %v, want %v", got, tt.want)
+			}
+			// A change fieldChanges sees must make diffResources report
+			// the resource as changed, and the other way round
+			changed := len(diffResources([]Resource{tt.before}, []Resource{tt.after}).Changed) > 0
+			if changed != (len(tt.want) > 0) {
+				t.Errorf("diffResources changed = %t, want %t", changed, len(tt.want) > 0)
+			}
+		})
+	}
+}
+
+func TestDiffResources(t *testing.T) {
+	ids := func(resources []Resource) []string {
+		list := []string{}
+		for _, resource := range resources {
+			list = append(list, resource.ID)
+		}
+		return list
+	}
+
+	tests := []struct {
+		name                   string
+		before, after          []Resource
+		added, removed, change []string
+	}{
+		{name: "nothing", added: []string{}, removed: []string{}, change: []string{}},
+		{
+			name:   "renamed ID",
+			before: []Resource{{ID: "vol-old", Name: "data"}},
+			after:  []Resource{{ID: "vol-new", Name: "data"}},
+			added:  []string{"vol-new"}, removed: []string{"vol-old"}, change: []string{},
+		},
+		{
+			name:   "same ID in a new order",
+			before: []Resource{{ID: "a"}, {ID: "b"}},
+			after:  []Resource{{ID: "b"}, {ID: "a"}},
+			added:  []string{}, removed: []string{}, change: []string{},
+		},
+		{
+			name:   "listing order kept",
+			before: []Resource{{ID: "x"}, {ID: "b", State: "running"}, {ID: "y"}},
+			after:  []Resource{{ID: "d"}, {ID: "b", State: "stopped"}, {ID: "c"}},
+			added:  []string{"d", "c"}, removed: []string{"x", "y"}, change: []string{"b"},
+		},
+		{
+			name:   "nil to empty tags",
+			before: []Resource{{ID: "a"}},
+			after:  []Resource{{ID: "a", Tags: map[string]string{}, Attributes: map[string]string{}}},
+			added:  []string{}, removed: []string{}, change: []string{},
+		},
+		{
+			name:   "everything gone",
+			before: []Resource{{ID: "a"}, {ID: "b"}},
+			after:  []Resource{},
+			added:  []string{}, removed: []string{"a", "b"}, change: []string{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			changes := diffResources(tt.before, tt.after)
+			changed := []string{}
+			for _, c := range changes.Changed {
+				changed = append(changed, c.After.ID)
+			}
+			if got := ids(changes.Added); !reflect.DeepEqual(got, tt.added) {
+				t.Errorf("added = %v, want %v", got, tt.added)
+			}
+			if got := ids(changes.Removed); !reflect.DeepEqual(got, tt.removed) {
+				t.Errorf("removed = %v, want %v", got, tt.removed)
+			}
+			if !reflect.DeepEqual(changed, tt.change) {
+				t.Errorf("changed = %v, want %v", changed, tt.change)
+			}
+		})
+	}
+}
+
+func TestSnapshotDiffPartialRegions(t *testing.T) {
+	day := func(d int) time.Time {
+		return time.Date(2024, 1, d, 12, 0, 0, 0, time.UTC)
+	}
+	snapshot := func(provider, region string, at time.Time, ids ...string) *resourceSnapshot {
+		resources := make([]Resource, len(ids))
+		for i, id := range ids {
+			resources[i] = Resource{ID: id, Region: region}
+		}
+		return &resourceSnapshot{Provider: provider, Region: region, At: at, Count: len(ids), resources: resources}
+	}
+	store := &snapshotStore{snapshots: []*resourceSnapshot{
+		// Compared: a snapshot on each side
+		snapshot(cloudy.ProviderAWS, "us-east-1", day(1), "i-1", "i-2"),
+		// Not scanned since from: the same snapshot on both sides
+		snapshot(cloudy.ProviderAWS, "eu-west-1", day(1), "i-9"),
+		// Another provider's region of the same name, compared apart
+		snapshot("linode", "us-east", day(2), "l-1"),
+		snapshot(cloudy.ProviderAWS, "us-east-1", day(3), "i-2", "i-3"),
+		// First scanned after from: listed, not compared
+		snapshot(cloudy.ProviderAWS, "ap-south-1", day(3), "i-7"),
+		snapshot("hetzner", "us-east", day(3), "h-1"),
+		snapshot("linode", "us-east", day(4), "l-2"),
+		// After to: left out
+		snapshot(cloudy.ProviderAWS, "sa-east-1", day(6), "i-8"),
+	}}
+
+	diff, err := store.Diff(day(2), day(5), nil, func(Resource) bool { return true })
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	type compared struct {
+		provider, region string
+		from             *time.Time
+		to               time.Time
+	}
+	var got []compared
+	for _, region := range diff.Regions {
+		got = append(got, compared{region.Provider, region.Region, region.From, region.To})
+	}
+	at := func(d int) *time.Time {
+		when := day(d)
+		return &when
+	}
+	want := []compared{
+		{cloudy.ProviderAWS, "ap-south-1", nil, day(3)},
+		{cloudy.ProviderAWS, "eu-west-1", at(1), day(1)},
+		{"hetzner", "us-east", nil, day(3)},
+		{"linode", "us-east", at(2), day(4)},
+		{cloudy.ProviderAWS, "us-east-1", at(1), day(3)},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("regions = %+v, want %+v", got, want)
+	}
+	for i := range want {
+		if got[i].provider != want[i].provider || got[i].region != want[i].region || !got[i].to.Equal(want[i].to) ||
+			(got[i].from == nil) != (want[i].from == nil) || (got[i].from != nil && !got[i].from.Equal(*want[i].from)) {
+			t.Errorf("region %d = %+v, want %+v", i, got[i], want[i])
+		}
+	}
+
+	ids := func(resources []Resource) []string {
+		list := []string{}
+		for _, resource := range resources {
+			list = append(list, resource.ID)
+		}
+		return list
+	}
+	if added := ids(diff.Added); !reflect.DeepEqual(added, []string{"l-2", "i-3"}) {
+		t.Errorf("added = %v, want [l-2 i-3]", added)
+	}
+	if removed := ids(diff.Removed); !reflect.DeepEqual(removed, []string{"l-1", "i-1"}) {
+		t.Errorf("removed = %v, want [l-1 i-1]", removed)
+	}
+
+	only, err := store.Diff(day(2), day(5), []string{"us-east"}, func(Resource) bool { return true })
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(only.Regions) != 2 || only.Regions[0].Provider != "hetzner" || only.Regions[1].Provider != "linode" {
+		t.Errorf("regions filtered to us-east = %+v, want hetzner's and linode's", only.Regions)
+	}
+}
+
+func TestSnapshotPrune(t *testing.T) {
+	defer func(retention time.Duration, limit int, compactAfter time.Duration) {
+		snapshotRetention, snapshotLimit, snapshotCompactAfter = retention, limit, compactAfter
+	}(snapshotRetention, snapshotLimit, snapshotCompactAfter)
+
+	now := time.Date(2024, 1, 31, 12, 0, 0, 0, time.UTC)
+	type taken struct {
+		provider, region string
+		at               time.Time
+	}
+	aws := func(at time.Time) taken { return taken{cloudy.ProviderAWS, "us-east-1", at} }
+
+	tests := []struct {
+		name         string
+		retention    time.Duration
+		limit        int
+		compactAfter time.Duration
+		snapshots    []taken
+		want         []taken
+	}{
+		{
+			name:      "retention keeps what is newer than the cutoff",
+			retention: 24 * time.Hour,
+			snapshots: []taken{aws(now.Add(-25 * time.Hour)), aws(now.Add(-24 * time.Hour)), aws(now.Add(-24*time.Hour + time.Nanosecond)), aws(now)},
+			want:      []taken{aws(now.Add(-24*time.Hour + time.Nanosecond)), aws(now)},
+		},
+		{
+			name:      "limit keeps the latest of each region",
+			retention: defaultSnapshotRetention,
+			limit:     2,
+			snapshots: []taken{
+				aws(now.Add(-3 * time.Hour)),
+				{"linode", "us-east", now.Add(-3 * time.Hour)},
+				aws(now.Add(-2 * time.Hour)),
+				aws(now.Add(-time.Hour)),
+				{"hetzner", "us-east", now.Add(-time.Hour)},
+				{"linode", "us-east", now},
+			},
+			want: []taken{
+				{"linode", "us-east", now.Add(-3 * time.Hour)},
+				aws(now.Add(-2 * time.Hour)),
+				aws(now.Add(-time.Hour)),
+				{"hetzner", "us-east", now.Add(-time.Hour)},
+				{"linode", "us-east", now},
+			},
+		},
+		{
+			name:         "compaction keeps the last of each UTC day past its age",
+			retention:    defaultSnapshotRetention,
+			compactAfter: 48 * time.Hour,
+			snapshots: []taken{
+				aws(time.Date(2024, 1, 27, 0, 0, 0, 0, time.UTC)),
+				aws(time.Date(2024, 1, 27, 23, 59, 59, 0, time.UTC)),
+				aws(time.Date(2024, 1, 28, 0, 0, 0, 0, time.UTC)),
+				aws(time.Date(2024, 1, 28, 6, 0, 0, 0, time.UTC)),
+				{"linode", "us-east", time.Date(2024, 1, 28, 1, 0, 0, 0, time.UTC)},
+				// Exactly compactAfter old, and so not compacted
+				aws(time.Date(2024, 1, 29, 12, 0, 0, 0, time.UTC)),
+				aws(time.Date(2024, 1, 29, 13, 0, 0, 0, time.UTC)),
+			},
+			want: []taken{
+				aws(time.Date(2024, 1, 27, 23, 59, 59, 0, time.UTC)),
+				aws(time.Date(2024, 1, 28, 6, 0, 0, 0, time.UTC)),
+				{"linode", "us-east", time.Date(2024, 1, 28, 1, 0, 0, 0, time.UTC)},
+				aws(time.Date(2024, 1, 29, 12, 0, 0, 0, time.UTC)),
+				aws(time.Date(2024, 1, 29, 13, 0, 0, 0, time.UTC)),
+			},
+		},
+		{
+			name:         "compaction before the limit",
+			retention:    defaultSnapshotRetention,
+			limit:        2,
+			compactAfter: 24 * time.Hour,
+			snapshots: []taken{
+				aws(time.Date(2024, 1, 28, 1, 0, 0, 0, time.UTC)),
+				aws(time.Date(2024, 1, 29, 1, 0, 0, 0, time.UTC)),
+				aws(time.Date(2024, 1, 29, 2, 0, 0, 0, time.UTC)),
+				aws(time.Date(2024, 1, 31, 1, 0, 0, 0, time.UTC)),
+			},
+			want: []taken{
+				aws(time.Date(2024, 1, 29, 2, 0, 0, 0, time.UTC)),
+				aws(time.Date(2024, 1, 31, 1, 0, 0, 0, time.UTC)),
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			snapshotRetention, snapshotLimit, snapshotCompactAfter = tt.retention, tt.limit, tt.compactAfter
+			store := &snapshotStore{}
+			for _, s := range tt.snapshots {
+				store.snapshots = append(store.snapshots, &resourceSnapshot{Provider: s.provider, Region: s.region, At: s.at})
+			}
+			store.prune(now)
+
+			got := []taken{}
+			for _, s := range store.snapshots {
+				got = append(got, taken{s.Provider, s.Region, s.At})
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("kept %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSnapshotPruneRemovesFiles(t *testing.T) {
+	defer func(retention time.Duration, limit int) {
+		snapshotRetention, snapshotLimit = retention, limit
+	}(snapshotRetention, snapshotLimit)
+	snapshotRetention, snapshotLimit = defaultSnapshotRetention, 1
+
+	dir := t.TempDir()
+	store := &snapshotStore{}
+	if err := store.Load(dir); err != nil {
+		t.Fatal(err)
+	}
+	store.Record(cloudy.ProviderAWS, "us-east-1", []Resource{{ID: "i-1"}})
+	first := store.snapshots[0].path
+	store.Record(cloudy.ProviderAWS, "us-east-1", []Resource{{ID: "i-2"}})
+
+	if len(store.snapshots) != 1 || store.snapshots[0].path == first {
+		t.Fatalf("kept %d snapshots, want the latest only", len(store.snapshots))
+	}
+	if _, err := os.Stat(first); !os.IsNotExist(err) {
+		t.Errorf("pruned snapshot file still there: %v", err)
+	}
+
+	// What was saved comes back after a restart
+	reloaded := &snapshotStore{}
+	if err := reloaded.Load(dir); err != nil {
+		t.Fatal(err)
+	}
+	if len(reloaded.snapshots) != 1 || reloaded.snapshots[0].Provider != cloudy.ProviderAWS || reloaded.snapshots[0].Region != "us-east-1" {
+		t.Fatalf("reloaded %+v, want the one snapshot kept", reloaded.snapshots)
+	}
+	resources, err := reloaded.snapshots[0].Resources()
+	if err != nil || len(resources) != 1 || resources[0].ID != "i-2" {
+		t.Errorf("reloaded resources = %v, %v; want [i-2]", resources, err)
+	}
+}
+
+func TestSnapshotLoadLayouts(t *testing.T) {
+	dir := t.TempDir()
+	now := time.Now().UTC().Truncate(time.Second)
+	name := func(hoursAgo int) string {
+		return now.Add(-time.Duration(hoursAgo)*time.Hour).Format(snapshotTimeLayout) + "_1.json.gz"
+	}
+	write := func(parts ...string) {
+		path := filepath.Join(append([]string{dir}, parts...)...)
+		if err := writeSnapshot(path, []Resource{{ID: "r"}}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// Before snapshots were kept by provider: straight in a region's
+	// directory
+	write("us-east-1", name(4))
+	write("aws", "us-east-1", name(3))
+	write("linode", "us-east", name(2))
+	write("linode", "us-east", "not-a-snapshot.json.gz")
+	// A tenant's snapshots are loaded by its own store
+	write("tenants", "payments", "aws", "us-east-1", name(1))
+	// Stray files, sorting before the directories and snapshots next to
+	// them, don't decide what a directory is
+	for _, stray := range [][]string{{"aws", ".DS_Store"}, {"us-east-1", "README"}, {"linode", "notes.json.gz"}} {
+		if err := os.WriteFile(filepath.Join(append([]string{dir}, stray...)...), []byte("stray"), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	store := &snapshotStore{}
+	if err := store.Load(dir); err != nil {
+		t.Fatal(err)
+	}
+	type loaded struct {
+		provider, region string
+		at               time.Time
+	}
+	var got []loaded
+	for _, snapshot := range store.List(nil, time.Time{}, now) {
+		got = append(got, loaded{snapshot.Provider, snapshot.Region, snapshot.TakenAt})
+	}
+	want := []loaded{
+		{cloudy.ProviderAWS, "us-east-1", now.Add(-4 * time.Hour)},
+		{cloudy.ProviderAWS, "us-east-1", now.Add(-3 * time.Hour)},
+		{"linode", "us-east", now.Add(-2 * time.Hour)},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("loaded %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,117 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// sortKeys are the fields results can be sorted by. Responses group
+// resources by region, so every key orders resources within a region;
+// keys only meaningful as an order across regions, like cost, aren't
+// offered.
+var sortKeys = map[string]bool{
+	"":        true, // default: region, then type, then ID
+	"name":    true,
+	"type":    true,
+	"region":  true,
+	"state":   true,
+	"created": true,
+}
+
+// createdLayouts are the formats listers use for the "created" attribute:
+// time.Time.String() for SDK timestamps, plus the ISO 8601 strings some
+// services return as-is (Lambda layers, GuardDuty).
+var createdLayouts = []string{
+	"2006-01-02 15:04:05.999999999 -0700 MST",
+	time.RFC3339Nano,
+	"2006-01-02T15:04:05.999-0700",
+}
+
+func parseCreated(value string) (time.Time, bool) {
+	for _, layout := range createdLayouts {
+		if t, err := time.Parse(layout, value); err == nil {
+			return t, true
+		}
+	}
+	return time.Time{}, false
+}
+
+func validateSort(sortBy, order string) error {
+	if field, ok := strings.CutPrefix(sortBy, "-"); ok && sortKeys[field] {
+		return fmt.Errorf("sort takes a field name; use order=desc to reverse it")
+	}
+	if !sortKeys[sortBy] {
+		return fmt.Errorf("sort must be one of name, type, region, state, created; resources are sorted within each region")
+	}
+	if order != "" && order != "asc" && order != "desc" {
+		return fmt.Errorf("order must be asc or desc")
+	}
+	return nil
+}
+
+// compareResources orders two resources by sortBy, returning <0, 0 or >0.
+// Resources without a creation date sort after those that have one,
+// whichever the order.
+func compareResources(a, b Resource, sortBy string, descending bool) int {
+	sign := 1
+	if descending {
+		sign = -1
+	}
+
+	switch sortBy {
+	case "name":
+		return sign * strings.Compare(strings.ToLower(a.Name), strings.ToLower(b.Name))
+	case "type":
+		return sign * strings.Compare(a.Type, b.Type)
+	case "state":
+		return sign * strings.Compare(strings.ToLower(a.State), strings.ToLower(b.State))
+	case "created":
+		aTime, aOK := parseCreated(a.Attributes["created"])
+		bTime, bOK := parseCreated(b.Attributes["created"])
+		switch {
+		case aOK && !bOK:
+			return -1
+		case !aOK && bOK:
+			return 1
+		case aOK && bOK:
+			return sign * aTime.Compare(bTime)
+		}
+	}
+	return 0
+}
+
+// sortRegionData puts regions and the resources within them in a stable
+// order so pages line up across requests. Regions are ordered by name
+// (reversed for a descending region sort); resources within a region by
+// sortBy, with type and ID breaking ties.
+func sortRegionData(regionData []RegionResources, sortBy, order string) {
+	descending := order == "desc"
+
+	sort.Slice(regionData, func(i, j int) bool {
+		if sortBy == "region" && descending {
+			return regionData[i].Region > regionData[j].Region
+		}
+		return regionData[i].Region < regionData[j].Region
+	})
+
+	for _, rd := range regionData {
+		sortResources(rd.Resources, sortBy, descending)
+	}
+}
+
+// sortResources orders one region's resources by sortBy, with type and ID
+// breaking ties.
+func sortResources(resources []Resource, sortBy string, descending bool) {
+	sort.SliceStable(resources, func(i, j int) bool {
+		a, b := resources[i], resources[j]
+		if c := compareResources(a, b, sortBy, descending); c != 0 {
+			return c < 0
+		}
+		if a.Type != b.Type {
+			return a.Type < b.Type
+		}
+		return a.ID < b.ID
+	})
+}
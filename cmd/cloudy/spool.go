@@ -0,0 +1,215 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"sort"
+
+	"github.com/gin-gonic/gin"
+)
+
+// spoolMemoryLimit is how much encoded JSON a response keeps in memory
+// before the rest goes to a temporary file.
+const spoolMemoryLimit = 32 << 20
+
+// resourceSpool holds a response's regions as they are scanned, with their
+// resources already sorted and encoded, so a JSON response can be ordered,
+// paginated and given an ETag without keeping every region's resources in
+// memory at once. Encoded resources spill to a temporary file once they
+// outgrow spoolMemoryLimit.
+type resourceSpool struct {
+	buf  bytes.Buffer
+	file *os.File
+	size int64
+
+	regions []spooledRegion
+	total   int
+}
+
+// spooledRegion is a region's result without its resources, which are in
+// the spool between offsets[i] and offsets[i+1].
+type spooledRegion struct {
+	rd           RegionResources
+	nilResources bool
+	offsets      []int64
+	// start and stop are the resources on the current page.
+	start, stop int
+}
+
+func (s *resourceSpool) Write(p []byte) (int, error) {
+	if s.file == nil && s.buf.Len()+len(p) > spoolMemoryLimit {
+		file, err := os.CreateTemp("", "cloudy-spool-*")
+		if err != nil {
+			return 0, err
+		}
+		s.file = file
+		if _, err := s.file.Write(s.buf.Bytes()); err != nil {
+			return 0, err
+		}
+		s.buf = bytes.Buffer{}
+	}
+
+	var n int
+	var err error
+	if s.file != nil {
+		n, err = s.file.Write(p)
+	} else {
+		n, err = s.buf.Write(p)
+	}
+	s.size += int64(n)
+	return n, err
+}
+
+// Close removes the spool's temporary file, if it has one.
+func (s *resourceSpool) Close() error {
+	if s.file == nil {
+		return nil
+	}
+	s.file.Close()
+	return os.Remove(s.file.Name())
+}
+
+func (s *resourceSpool) readerAt() io.ReaderAt {
+	if s.file != nil {
+		return s.file
+	}
+	return bytes.NewReader(s.buf.Bytes())
+}
+
+// add sorts rd's resources by sortBy, encodes them with p and keeps the
+// rest of rd.
+func (s *resourceSpool) add(rd RegionResources, sortBy, order string, p *projection) error {
+	sortResources(rd.Resources, sortBy, order == "desc")
+
+	region := spooledRegion{nilResources: rd.Resources == nil, offsets: make([]int64, 0, len(rd.Resources)+1)}
+	w := bufio.NewWriterSize(s, 64*1024)
+	offset := s.size
+	for _, resource := range rd.Resources {
+		data, err := json.Marshal(p.value(resource))
+		if err != nil {
+			return err
+		}
+		region.offsets = append(region.offsets, offset)
+		w.Write(data)
+		offset += int64(len(data))
+	}
+	region.offsets = append(region.offsets, offset)
+	if err := w.Flush(); err != nil {
+		return err
+	}
+
+	s.total += len(rd.Resources)
+	region.stop = len(rd.Resources)
+	rd.Resources = nil
+	region.rd = rd
+	s.regions = append(s.regions, region)
+	return nil
+}
+
+// paginate orders the regions as sortRegionData does and trims them to a
+// page as paginateRegionData does, returning the same next offset.
+func (s *resourceSpool) paginate(sortBy, order string, offset, limit int) int {
+	sort.Slice(s.regions, func(i, j int) bool {
+		if sortBy == "region" && order == "desc" {
+			return s.regions[i].rd.Region > s.regions[j].rd.Region
+		}
+		return s.regions[i].rd.Region < s.regions[j].rd.Region
+	})
+	if limit <= 0 {
+		return -1
+	}
+
+	end := offset + limit
+	position := 0
+	for i := range s.regions {
+		count := len(s.regions[i].offsets) - 1
+		s.regions[i].start = clamp(offset-position, 0, count)
+		s.regions[i].stop = clamp(end-position, 0, count)
+		position += count
+	}
+
+	if end >= position {
+		return -1
+	}
+	return end
+}
+
+// encodeJSON writes the spooled response exactly as encodeResourcesJSON
+// would have written it.
+func (s *resourceSpool) encodeJSON(out io.Writer, nextToken string) error {
+	w := bufio.NewWriterSize(out, 64*1024)
+	spooled := s.readerAt()
+
+	w.WriteString(`{"region_data":`)
+	if s.regions == nil {
+		w.WriteString("null")
+	} else {
+		w.WriteByte('[')
+		for i, region := range s.regions {
+			if i > 0 {
+				w.WriteByte(',')
+			}
+			err := writeRegionFieldsJSON(w, region.rd, region.nilResources, func() error {
+				for j := region.start; j < region.stop; j++ {
+					if j > region.start {
+						w.WriteByte(',')
+					}
+					start, end := region.offsets[j], region.offsets[j+1]
+					if _, err := io.Copy(w, io.NewSectionReader(spooled, start, end-start)); err != nil {
+						return err
+					}
+				}
+				return nil
+			})
+			if err != nil {
+				return err
+			}
+		}
+		w.WriteByte(']')
+	}
+	fmt.Fprintf(w, `,"total_count":%d`, s.total)
+	if nextToken != "" {
+		w.WriteString(`,"next_token":`)
+		writeJSONValue(w, nextToken)
+	}
+	w.WriteByte('}')
+	return w.Flush()
+}
+
+// spoolResourcesJSON answers a JSON request through a resourceSpool, so
+// the response itself only holds the region being added and the spool's
+// index in memory. Full scans are still recorded whole in the tenant's
+// stores as they pass through streamRegions. The response is the same
+// as writeResourcesJSON's.
+func spoolResourcesJSON(c *gin.Context, lister *ResourceLister, req RegionsRequest, offset int, p *projection) {
+	spool := &resourceSpool{}
+	defer spool.Close()
+
+	for rd := range lister.streamRegions(c.Request.Context(), req) {
+		if err := spool.add(rd, req.Sort, req.Order, p); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to buffer response: " + err.Error()})
+			return
+		}
+	}
+
+	var nextToken string
+	if next := spool.paginate(req.Sort, req.Order, offset, req.Limit); next >= 0 {
+		nextToken = encodePageToken(next)
+	}
+
+	etag := encodedETag(formatJSON+"\n"+req.Query, func(w io.Writer) error {
+		return spool.encodeJSON(w, nextToken)
+	})
+	if notModified(c, etag) {
+		return
+	}
+
+	c.Header("Content-Type", "application/json; charset=utf-8")
+	c.Status(http.StatusOK)
+	spool.encodeJSON(c.Writer, nextToken)
+}
@@ -0,0 +1,370 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"modernc.org/sqlite"
+	sqlite3 "modernc.org/sqlite/lib"
+)
+
+const defaultSQLLimit = 1000
+
+// sqlTimeout bounds each SQL query, from CLOUDY_SQL_TIMEOUT.
+var sqlTimeout = 10 * time.Second
+
+// sqlSchema is the tables the latest scan is loaded into. tags and
+// attributes have a row per key, for joins, besides the JSON objects in
+// resources.
+const sqlSchema = `
+CREATE TABLE resources (
+	id TEXT NOT NULL,
+	name TEXT NOT NULL,
+	type TEXT NOT NULL,
+	kind TEXT,
+	state TEXT,
+	region TEXT NOT NULL,
+	provider TEXT,
+	"partition" TEXT,
+	account_id TEXT,
+	account_name TEXT,
+	scanned_region TEXT NOT NULL,
+	tags TEXT NOT NULL,
+	attributes TEXT NOT NULL
+);
+CREATE TABLE tags (resource_id TEXT NOT NULL, key TEXT NOT NULL, value TEXT NOT NULL);
+CREATE TABLE attributes (resource_id TEXT NOT NULL, key TEXT NOT NULL, value TEXT NOT NULL);
+CREATE INDEX resources_id ON resources (id);
+CREATE INDEX resources_type ON resources (type);
+CREATE INDEX tags_resource ON tags (resource_id);
+CREATE INDEX tags_key ON tags (key, value);
+CREATE INDEX attributes_resource ON attributes (resource_id);
+CREATE INDEX attributes_key ON attributes (key, value);
+`
+
+type SQLQueryRequest struct {
+	SQL   string `json:"sql" binding:"required"`
+	Limit int    `json:"limit,omitempty"`
+}
+
+type SQLQueryResponse struct {
+	Columns []string `json:"columns"`
+	Rows    [][]any  `json:"rows"`
+	Count   int      `json:"count"`
+	// Truncated is set when the query returned more than limit rows.
+	Truncated bool      `json:"truncated,omitempty"`
+	ScannedAt time.Time `json:"scanned_at"`
+}
+
+// sqlDatabases numbers the in-memory databases, which are named so every
+// connection to one opens the same database.
+var sqlDatabases atomic.Uint64
+
+// sqlInventory is a tenant's latest scan loaded into an in-memory SQLite
+// database, rebuilt on the first query after a region is recorded.
+type sqlInventory struct {
+	mu         sync.Mutex
+	built      bool
+	generation uint64
+	current    *sqlSnapshot
+}
+
+// sqlSnapshot is one load of the latest scan. writer loaded it and keeps
+// it alive; reader's connections can only query it.
+type sqlSnapshot struct {
+	writer    *sql.DB
+	reader    *sql.DB
+	regions   int
+	scannedAt time.Time
+	// users counts the queries running, which the snapshot is only closed
+	// after.
+	users sync.WaitGroup
+}
+
+// acquire returns the snapshot of store's latest scan, loading it first if
+// a region was recorded since. Callers release it when done.
+func (i *sqlInventory) acquire(store *scanStore) (*sqlSnapshot, error) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	generation := store.Generation()
+	if !i.built || generation != i.generation {
+		regionData, scannedAt := store.Snapshot()
+		snapshot, err := loadSQLSnapshot(regionData, scannedAt)
+		if err != nil {
+			return nil, err
+		}
+		if old := i.current; old != nil {
+			go func() {
+				old.users.Wait()
+				old.close()
+			}()
+		}
+		i.current, i.built, i.generation = snapshot, true, generation
+	}
+	i.current.users.Add(1)
+	return i.current, nil
+}
+
+func (s *sqlSnapshot) release() {
+	s.users.Done()
+}
+
+func (s *sqlSnapshot) close() {
+	s.reader.Close()
+	s.writer.Close()
+}
+
+// loadSQLSnapshot loads regionData into a new in-memory database.
+func loadSQLSnapshot(regionData []RegionResources, scannedAt time.Time) (*sqlSnapshot, error) {
+	dsn := fmt.Sprintf("file:cloudy-%d?mode=memory&cache=shared", sqlDatabases.Add(1))
+	writer, err := sql.Open("sqlite", dsn)
+	if err != nil {
+		return nil, err
+	}
+	// The database lasts as long as a connection to it is open
+	writer.SetMaxOpenConns(1)
+	writer.SetConnMaxIdleTime(0)
+	if err := loadSQLResources(writer, regionData); err != nil {
+		writer.Close()
+		return nil, err
+	}
+	reader, err := sql.Open("sqlite", dsn+"&_pragma=query_only(1)")
+	if err != nil {
+		writer.Close()
+		return nil, err
+	}
+	return &sqlSnapshot{writer: writer, reader: reader, regions: len(regionData), scannedAt: scannedAt}, nil
+}
+
+func loadSQLResources(db *sql.DB, regionData []RegionResources) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(sqlSchema); err != nil {
+		return err
+	}
+	insertResource, err := tx.Prepare(`INSERT INTO resources VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`)
+	if err != nil {
+		return err
+	}
+	insertTag, err := tx.Prepare(`INSERT INTO tags VALUES (?, ?, ?)`)
+	if err != nil {
+		return err
+	}
+	insertAttribute, err := tx.Prepare(`INSERT INTO attributes VALUES (?, ?, ?)`)
+	if err != nil {
+		return err
+	}
+
+	for _, rd := range regionData {
+		for _, resource := range rd.Resources {
+			tags, err := jsonObject(resource.Tags)
+			if err != nil {
+				return err
+			}
+			attributes, err := jsonObject(resource.Attributes)
+			if err != nil {
+				return err
+			}
+			_, err = insertResource.Exec(resource.ID, resource.Name, resource.Type, nullString(resource.Kind), nullString(resource.State),
+				resource.Region, nullString(resource.Provider), nullString(resource.Partition), nullString(resource.AccountID),
+				nullString(resource.AccountName), rd.Region, tags, attributes)
+			if err != nil {
+				return err
+			}
+			for key, value := range resource.Tags {
+				if _, err := insertTag.Exec(resource.ID, key, value); err != nil {
+					return err
+				}
+			}
+			for key, value := range resource.Attributes {
+				if _, err := insertAttribute.Exec(resource.ID, key, value); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return tx.Commit()
+}
+
+// jsonObject encodes values as a JSON object, {} if there are none, for
+// SQLite's JSON functions.
+func jsonObject(values map[string]string) (string, error) {
+	if values == nil {
+		return "{}", nil
+	}
+	data, err := json.Marshal(values)
+	return string(data), err
+}
+
+// nullString stores empty fields as NULL, as the API leaves them out.
+func nullString(value string) sql.NullString {
+	return sql.NullString{String: value, Valid: value != ""}
+}
+
+// query runs a read-only query, returning up to limit rows. Its connection
+// can't write to the snapshot or attach other databases.
+func (s *sqlSnapshot) query(ctx context.Context, query string, limit int) (SQLQueryResponse, error) {
+	response := SQLQueryResponse{Rows: [][]any{}, ScannedAt: s.scannedAt}
+	if err := checkSQL(query); err != nil {
+		return response, err
+	}
+
+	conn, err := s.reader.Conn(ctx)
+	if err != nil {
+		return response, err
+	}
+	defer conn.Close()
+	if _, err := sqlite.Limit(conn, sqlite3.SQLITE_LIMIT_ATTACHED, 0); err != nil {
+		return response, err
+	}
+
+	rows, err := conn.QueryContext(ctx, query)
+	if err != nil {
+		return response, err
+	}
+	defer rows.Close()
+
+	response.Columns, err = rows.Columns()
+	if err != nil {
+		return response, err
+	}
+	for rows.Next() {
+		if response.Count == limit {
+			response.Truncated = true
+			break
+		}
+		values := make([]any, len(response.Columns))
+		pointers := make([]any, len(values))
+		for i := range values {
+			pointers[i] = &values[i]
+		}
+		if err := rows.Scan(pointers...); err != nil {
+			return response, err
+		}
+		for i, value := range values {
+			// Blobs would otherwise be base64-encoded
+			if b, ok := value.([]byte); ok {
+				values[i] = string(b)
+			}
+		}
+		response.Rows = append(response.Rows, values)
+		response.Count++
+	}
+	return response, rows.Err()
+}
+
+// sqlStatements are the statements queries may start with; writes are
+// refused by the connection in any case, but pragmas aren't.
+var sqlStatements = map[string]bool{"SELECT": true, "WITH": true, "VALUES": true, "EXPLAIN": true}
+
+// checkSQL rejects a query that isn't a single statement of
+// sqlStatements. Whitespace and comments are skipped, and quotes are
+// matched so semicolons in them don't count.
+func checkSQL(query string) error {
+	var quote byte
+	first := ""
+	ended := false
+	for i := 0; i < len(query); i++ {
+		ch := query[i]
+		switch {
+		case quote != 0:
+			if ch == quote {
+				quote = 0
+			}
+		case strings.HasPrefix(query[i:], "--"):
+			end := strings.IndexByte(query[i:], '\n')
+			if end < 0 {
+				i = len(query)
+			} else {
+				i += end
+			}
+		case strings.HasPrefix(query[i:], "/*"):
+			end := strings.Index(query[i+2:], "*/")
+			if end < 0 {
+				i = len(query)
+			} else {
+				i += end + 3
+			}
+		case ch == ' ' || ch == '\t' || ch == '\n' || ch == '\r':
+		case ended:
+			return errors.New("sql must be a single statement")
+		case ch == ';':
+			ended = true
+		case first == "":
+			end := strings.IndexFunc(query[i:], func(r rune) bool {
+				return !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z')
+			})
+			if end < 0 {
+				end = len(query) - i
+			}
+			first = strings.ToUpper(query[i : i+end])
+			if !sqlStatements[first] {
+				return errors.New("sql must be a SELECT, WITH, VALUES or EXPLAIN statement")
+			}
+			i += max(end, 1) - 1
+		case ch == '\'' || ch == '"' || ch == '`':
+			quote = ch
+		case ch == '[':
+			quote = ']'
+		}
+	}
+	if first == "" {
+		return errors.New("sql is empty")
+	}
+	return nil
+}
+
+// queryInventory runs read-only SQL over the latest scan of every region,
+// loaded into the resources, tags and attributes tables.
+func queryInventory(c *gin.Context) {
+	var req SQLQueryRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if req.Limit == 0 {
+		req.Limit = defaultSQLLimit
+	}
+	if req.Limit < 1 || req.Limit > maxPageLimit {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("limit must be between 1 and %d", maxPageLimit)})
+		return
+	}
+
+	t := tenantFrom(c.Request.Context())
+	snapshot, err := t.inventoryDB.acquire(t.latestScan)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "loading the latest scan: " + err.Error()})
+		return
+	}
+	defer snapshot.release()
+	if snapshot.regions == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "no scan results yet; list resources first"})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), sqlTimeout)
+	defer cancel()
+	response, err := snapshot.query(ctx, req.SQL, req.Limit)
+	if err != nil {
+		if ctx.Err() == context.DeadlineExceeded {
+			err = fmt.Errorf("query took longer than %s", sqlTimeout)
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, response)
+}
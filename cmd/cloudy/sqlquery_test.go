@@ -0,0 +1,58 @@
+package main
+
+import "testing"
+
+func TestCheckSQL(t *testing.T) {
+	tests := []struct {
+		name    string
+		query   string
+		wantErr bool
+	}{
+		{name: "select", query: "SELECT * FROM resources"},
+		{name: "lower case", query: "select id from resources"},
+		{name: "with", query: "WITH t AS (SELECT 1) SELECT * FROM t"},
+		{name: "values", query: "VALUES (1), (2)"},
+		{name: "explain", query: "EXPLAIN QUERY PLAN SELECT * FROM resources"},
+		{name: "no space before star", query: "SELECT*FROM resources"},
+		{name: "trailing semicolon", query: "SELECT 1;"},
+		{name: "trailing semicolon and whitespace", query: "SELECT 1 ;\n\t "},
+		{name: "leading line comment", query: "-- count them\nSELECT count(*) FROM resources"},
+		{name: "leading block comment", query: "/* count them */SELECT count(*) FROM resources"},
+		{name: "empty block comment", query: "/**/SELECT 1"},
+		{name: "comment after the statement", query: "SELECT 1; -- done"},
+		{name: "block comment after the statement", query: "SELECT 1; /* done */"},
+		{name: "semicolon in a string", query: "SELECT * FROM tags WHERE value = 'a;b'"},
+		{name: "escaped quote in a string", query: "SELECT * FROM tags WHERE value = 'it''s; fine'"},
+		{name: "semicolon in a double-quoted identifier", query: `SELECT "a;b" FROM resources`},
+		{name: "semicolon in a backquoted identifier", query: "SELECT `a;b` FROM resources"},
+		{name: "semicolon in a bracketed identifier", query: "SELECT [a;b] FROM resources"},
+		{name: "comment markers in a string", query: "SELECT '--', '/*' FROM resources"},
+		{name: "semicolon in a comment", query: "SELECT 1 /* ; PRAGMA writable_schema */"},
+		{name: "semicolon in a line comment", query: "SELECT 1 -- ; DROP TABLE resources\n"},
+
+		{name: "empty", query: "", wantErr: true},
+		{name: "whitespace", query: " \n\t", wantErr: true},
+		{name: "only comments", query: "-- nothing\n/* here */", wantErr: true},
+		{name: "only a semicolon", query: ";", wantErr: true},
+		{name: "pragma", query: "PRAGMA table_info(resources)", wantErr: true},
+		{name: "lower case pragma", query: "pragma writable_schema = 1", wantErr: true},
+		{name: "pragma after a comment", query: "/* SELECT */ PRAGMA query_only = 0", wantErr: true},
+		{name: "pragma after a select", query: "SELECT 1; PRAGMA query_only = 0", wantErr: true},
+		{name: "pragma after a semicolon in a string", query: "SELECT ';'; PRAGMA query_only = 0", wantErr: true},
+		{name: "pragma after a bracketed identifier", query: "SELECT [x]; PRAGMA query_only = 0", wantErr: true},
+		{name: "statement after a comment", query: "SELECT 1; /* and */ SELECT 2", wantErr: true},
+		{name: "two semicolons", query: "SELECT 1;;", wantErr: true},
+		{name: "attach", query: "ATTACH DATABASE '/tmp/x.db' AS x", wantErr: true},
+		{name: "delete", query: "DELETE FROM resources", wantErr: true},
+		{name: "keyword prefix", query: "SELECTED 1", wantErr: true},
+		{name: "parenthesized", query: "(SELECT 1)", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := checkSQL(tt.query)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("checkSQL(%q) = %v, want error %t", tt.query, err, tt.wantErr)
+			}
+		})
+	}
+}
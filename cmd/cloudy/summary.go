@@ -0,0 +1,164 @@
+package main
+
+import (
+	"net/http"
+
+	"github.com/alwindoss/cloudy/pkg/cloudy"
+	"github.com/gin-gonic/gin"
+)
+
+// noState is the by_state key for resources that have no state, such as
+// S3 buckets, so the counts of every breakdown add up to total_count.
+const noState = "none"
+
+// noAccount is the by_account key for resources whose account can't be
+// told.
+const noAccount = "unknown"
+
+// noKind is the by_kind key for resources of types outside the taxonomy,
+// such as those only an index names.
+const noKind = "other"
+
+// SummaryResponse counts resources. With CLOUDY_COSTS set, it also adds up
+// their estimated monthly costs, in US dollars, in total and by type,
+// region and account; resources without an estimate count as nothing.
+type SummaryResponse struct {
+	TotalCount           int                `json:"total_count" yaml:"total_count"`
+	ByType               map[string]int     `json:"by_type" yaml:"by_type"`
+	ByKind               map[string]int     `json:"by_kind" yaml:"by_kind"`
+	ByRegion             map[string]int     `json:"by_region" yaml:"by_region"`
+	ByState              map[string]int     `json:"by_state" yaml:"by_state"`
+	ByAccount            map[string]int     `json:"by_account" yaml:"by_account"`
+	EstimatedMonthlyCost *float64           `json:"estimated_monthly_cost,omitempty" yaml:"estimated_monthly_cost,omitempty"`
+	CostByType           map[string]float64 `json:"cost_by_type,omitempty" yaml:"cost_by_type,omitempty"`
+	CostByRegion         map[string]float64 `json:"cost_by_region,omitempty" yaml:"cost_by_region,omitempty"`
+	CostByAccount        map[string]float64 `json:"cost_by_account,omitempty" yaml:"cost_by_account,omitempty"`
+	Errors               map[string]string  `json:"errors,omitempty" yaml:"errors,omitempty"`
+	CostErrors           map[string]string  `json:"cost_errors,omitempty" yaml:"cost_errors,omitempty"`
+}
+
+// summarizeResources scans like GET /api/v1/resources, with the same query
+// parameters, but only returns counts.
+func summarizeResources(c *gin.Context) {
+	req, err := bindResourcesQuery(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if len(req.Regions) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "at least one region must be specified"})
+		return
+	}
+	if _, err := nameMatcher(req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := validateKinds(req.Kinds); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := validateMode(req.Mode); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	format, err := responseFormat(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if format != formatJSON && format != formatYAML {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "the summary is only available as json or yaml"})
+		return
+	}
+
+	lister, err := listerFor(c.Request.Context(), req.Provider, req.Credentials)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := validateProviderMode(lister, req.Mode); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx := c.Request.Context()
+	req.Regions, err = lister.resolveRegions(ctx, req.Regions)
+	if err != nil {
+		c.JSON(http.StatusBadGateway, gin.H{"error": err.Error()})
+		return
+	}
+
+	release, ok := limitScan(c)
+	if !ok {
+		return
+	}
+	defer release()
+
+	summary := SummaryResponse{
+		ByType:    make(map[string]int),
+		ByKind:    make(map[string]int),
+		ByRegion:  make(map[string]int),
+		ByState:   make(map[string]int),
+		ByAccount: make(map[string]int),
+	}
+	var totalCost int64
+	costByType, costByRegion, costByAccount := make(costTotals), make(costTotals), make(costTotals)
+	for _, rd := range lister.scanRegions(ctx, req) {
+		if rd.Error != "" {
+			if summary.Errors == nil {
+				summary.Errors = make(map[string]string)
+			}
+			summary.Errors[rd.Region] = rd.Error
+		}
+		if rd.CostError != "" {
+			if summary.CostErrors == nil {
+				summary.CostErrors = make(map[string]string)
+			}
+			summary.CostErrors[rd.Region] = rd.CostError
+		}
+		summary.ByRegion[rd.Region] += len(rd.Resources)
+		for _, resource := range rd.Resources {
+			summary.TotalCount++
+			summary.ByType[resource.Type]++
+
+			kind := resource.Kind
+			if kind == "" {
+				kind = noKind
+			}
+			summary.ByKind[kind]++
+
+			state := resource.State
+			if state == "" {
+				state = noState
+			}
+			summary.ByState[state]++
+
+			account := resource.AccountID
+			if account == "" {
+				account = noAccount
+			}
+			summary.ByAccount[account]++
+
+			if cost, ok := resourceCost(resource); ok {
+				totalCost += cost
+				costByType[resource.Type] += cost
+				costByRegion[rd.Region] += cost
+				costByAccount[account] += cost
+			}
+		}
+	}
+	if costs != nil && lister.Provider().Name() == cloudy.ProviderAWS {
+		total := float64(totalCost) / 100
+		summary.EstimatedMonthlyCost = &total
+		summary.CostByType = costByType.dollars()
+		summary.CostByRegion = costByRegion.dollars()
+		summary.CostByAccount = costByAccount.dollars()
+	}
+
+	if format == formatYAML {
+		c.YAML(http.StatusOK, summary)
+		return
+	}
+	c.JSON(http.StatusOK, summary)
+}
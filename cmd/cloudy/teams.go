@@ -0,0 +1,153 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// teamsTimeout bounds posting one card.
+const teamsTimeout = 10 * time.Second
+
+// TeamsChannel is one channel in CLOUDY_TEAMS_FILE, which gets the same
+// digests of scheduled scans a Slack channel would, as Adaptive Cards.
+type TeamsChannel struct {
+	// WebhookURL is the URL of the channel's incoming webhook: a Workflows
+	// webhook or an Office 365 connector's.
+	WebhookURL string `json:"webhook_url"`
+	chatChannel
+}
+
+// teamsChannels get digests, from CLOUDY_TEAMS_FILE.
+var teamsChannels []TeamsChannel
+
+// loadTeamsChannels reads the channels in path, a JSON file of the form
+// {"channels": [...]}.
+func loadTeamsChannels(path string) ([]TeamsChannel, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var file struct {
+		Channels []TeamsChannel `json:"channels"`
+	}
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+
+	tenants := make(map[string]bool)
+	for _, t := range scheduledTenants() {
+		tenants[t.name] = true
+	}
+	for i, channel := range file.Channels {
+		if _, err := url.ParseRequestURI(channel.WebhookURL); err != nil {
+			return nil, fmt.Errorf("channel %d needs its webhook_url: %w", i, err)
+		}
+		if err := channel.check(strconv.Itoa(i), tenants); err != nil {
+			return nil, err
+		}
+	}
+	return file.Channels, nil
+}
+
+// notifyTeams posts the digests of scan that every channel of its tenant
+// gets.
+func notifyTeams(ctx context.Context, scan scheduledScan) {
+	for _, channel := range teamsChannels {
+		if channel.Tenant != scan.tenant.name {
+			continue
+		}
+		for _, digest := range chatDigests(channel.chatChannel, scan) {
+			if err := postTeams(ctx, channel, teamsCard(digest)); err != nil {
+				log.Printf("Failed to post Teams digest%s: %v", tenantSuffix(scan.tenant), err)
+			}
+		}
+	}
+}
+
+// teamsCard lays digest out as an Adaptive Card: its title, then each
+// section's title over a list of its lines.
+func teamsCard(digest chatDigest) map[string]any {
+	body := []any{map[string]any{"type": "TextBlock", "text": digest.Title, "weight": "Bolder", "size": "Medium", "wrap": true}}
+	for _, section := range digest.Sections {
+		items := make([]string, 0, min(len(section.Lines), chatDigestLines))
+		for _, line := range section.Lines[:min(len(section.Lines), chatDigestLines)] {
+			text := line.Text
+			if line.Resource != nil {
+				text = teamsResource(*line.Resource)
+				if line.Text != "" {
+					text += ": " + line.Text
+				}
+			}
+			items = append(items, "- "+text)
+		}
+		body = append(body,
+			map[string]any{"type": "TextBlock", "text": section.Title, "weight": "Bolder", "spacing": "Medium", "wrap": true},
+			map[string]any{"type": "TextBlock", "text": strings.Join(items, "\r"), "spacing": "Small", "wrap": true},
+		)
+		if n := len(section.Lines); n > chatDigestLines {
+			body = append(body, map[string]any{"type": "TextBlock", "text": fmt.Sprintf("and %d more", n-chatDigestLines), "isSubtle": true, "spacing": "Small", "wrap": true})
+		}
+	}
+	return map[string]any{
+		"$schema": "http://adaptivecards.io/schemas/adaptive-card.json",
+		"type":    "AdaptiveCard",
+		"version": "1.4",
+		"body":    body,
+		"msteams": map[string]string{"width": "Full"},
+	}
+}
+
+// teamsResource describes a resource on one line, such as
+// EC2 Instance **web-1** (i-0abc) in us-east-1.
+func teamsResource(resource Resource) string {
+	line := resource.Type + " **" + resourceLabel(resource) + "**"
+	if resource.Name != "" && resource.Name != resource.ID {
+		line += " (" + resource.ID + ")"
+	}
+	return line + " in " + resource.Region
+}
+
+// postTeams posts card to channel's webhook, as the message attachment
+// both kinds of webhook take.
+func postTeams(ctx context.Context, channel TeamsChannel, card map[string]any) error {
+	ctx, cancel := context.WithTimeout(ctx, teamsTimeout)
+	defer cancel()
+
+	body, err := json.Marshal(map[string]any{
+		"type": "message",
+		"attachments": []any{map[string]any{
+			"contentType": "application/vnd.microsoft.card.adaptive",
+			"content":     card,
+		}},
+	})
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, channel.WebhookURL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json; charset=utf-8")
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	// Workflows answer 202, connectors 200
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		detail, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("teams answered %s: %s", resp.Status, strings.TrimSpace(string(detail)))
+	}
+	return nil
+}
@@ -0,0 +1,259 @@
+package main
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/alwindoss/cloudy/pkg/cloudy"
+	"github.com/alwindoss/cloudy/pkg/cloudy/azure"
+	"github.com/alwindoss/cloudy/pkg/cloudy/gcp"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/gin-gonic/gin"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// tenant is who a request scans for: its providers' listers, and the
+// latest scan and trends its full scans keep, and the drift its scheduled
+// scans find. Without CLOUDY_TENANTS_FILE every request is the server's
+// own, serverTenant.
+type tenant struct {
+	name       string
+	listers    map[string]*ResourceLister
+	latestScan *scanStore
+	trends     *trendStore
+	drift      *driftStore
+	snapshots  *snapshotStore
+	// inventoryDB is latestScan loaded for SQL queries.
+	inventoryDB sqlInventory
+	// violations are those of the policies scheduled scans last found.
+	violations violationStore
+	// accountNames names the accounts of the tenant's scans, including
+	// those made with a request's temporary credentials.
+	accountNames *cloudy.AccountNames
+}
+
+// serverTenant scans with the credentials the server was started with.
+var serverTenant = &tenant{listers: providerListers, latestScan: latestScan, trends: resourceTrends, drift: resourceDrift, snapshots: resourceSnapshots}
+
+// tenantsByKey maps the SHA-256 of each API key to its tenant, from
+// CLOUDY_TENANTS_FILE. Requests need an API key when it isn't empty.
+var tenantsByKey map[string]*tenant
+
+// TenantConfig is one tenant in CLOUDY_TENANTS_FILE: the hashes of its
+// API keys and the credentials its scans use. A tenant only scans the
+// providers it has credentials for, never with the server's own.
+type TenantConfig struct {
+	Name string `json:"name"`
+	// APIKeySHA256 are the hex SHA-256 hashes of the tenant's API keys,
+	// so the file doesn't hold the keys themselves.
+	APIKeySHA256 []string `json:"api_key_sha256"`
+	// AWSProfile is the shared config profile to scan AWS with, and
+	// AWSRoleARN a role to assume with it, or with the server's
+	// credentials if AWSProfile is "". AWSExternalID replaces
+	// CLOUDY_ROLE_EXTERNAL_ID for the tenant's roles.
+	AWSProfile    string `json:"aws_profile,omitempty"`
+	AWSRoleARN    string `json:"aws_role_arn,omitempty"`
+	AWSExternalID string `json:"aws_external_id,omitempty"`
+	// AzureSubscriptions are scanned as the managed identity
+	// AzureClientID, or with the server's Azure credentials if it is "".
+	AzureSubscriptions []string `json:"azure_subscriptions,omitempty"`
+	AzureClientID      string   `json:"azure_client_id,omitempty"`
+	// GCPProjects are scanned with the key GCPKeyRef in
+	// CLOUDY_GCP_KEY_DIR, or with Application Default Credentials if it
+	// is "".
+	GCPProjects []string `json:"gcp_projects,omitempty"`
+	GCPKeyRef   string   `json:"gcp_key_ref,omitempty"`
+}
+
+// loadTenants reads the tenants in path, a JSON file of the form
+// {"tenants": [...]}, and builds their listers.
+func loadTenants(path string) (map[string]*tenant, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var file struct {
+		Tenants []TenantConfig `json:"tenants"`
+	}
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	if len(file.Tenants) == 0 {
+		return nil, fmt.Errorf("%s: no tenants", path)
+	}
+
+	tenants := make(map[string]*tenant)
+	names := make(map[string]bool)
+	for _, cfg := range file.Tenants {
+		if cfg.Name == "" {
+			return nil, errors.New("every tenant needs a name")
+		}
+		if names[cfg.Name] {
+			return nil, fmt.Errorf("tenant %s is defined twice", cfg.Name)
+		}
+		names[cfg.Name] = true
+		if len(cfg.APIKeySHA256) == 0 {
+			return nil, fmt.Errorf("tenant %s has no api_key_sha256", cfg.Name)
+		}
+
+		t, err := newTenant(cfg)
+		if err != nil {
+			return nil, fmt.Errorf("tenant %s: %w", cfg.Name, err)
+		}
+		for _, hash := range cfg.APIKeySHA256 {
+			hash = strings.ToLower(hash)
+			if decoded, err := hex.DecodeString(hash); err != nil || len(decoded) != sha256.Size {
+				return nil, fmt.Errorf("tenant %s: api_key_sha256 %q isn't a hex SHA-256 hash", cfg.Name, hash)
+			}
+			if other, ok := tenants[hash]; ok {
+				return nil, fmt.Errorf("tenants %s and %s share an API key", other.name, cfg.Name)
+			}
+			tenants[hash] = t
+		}
+	}
+	return tenants, nil
+}
+
+// newTenant builds the listers of cfg's providers. They share the
+// server's worker pool, but not its result cache or account names, so
+// nothing one tenant's credentials listed is served to another.
+func newTenant(cfg TenantConfig) (*tenant, error) {
+	t := &tenant{
+		name:         cfg.Name,
+		listers:      make(map[string]*ResourceLister),
+		latestScan:   &scanStore{regions: make(map[regionKey]storedRegion)},
+		trends:       &trendStore{},
+		drift:        &driftStore{},
+		snapshots:    &snapshotStore{},
+		accountNames: newAccountNames(),
+	}
+
+	if cfg.AWSProfile != "" || cfg.AWSRoleARN != "" {
+		var optFns []func(*config.LoadOptions) error
+		if cfg.AWSProfile != "" {
+			optFns = append(optFns, config.WithSharedConfigProfile(cfg.AWSProfile))
+		}
+		cfgAWS, err := loadAWSConfig(optFns...)
+		if err != nil {
+			return nil, err
+		}
+		if cfg.AWSRoleARN != "" {
+			opts := assumeRole
+			if cfg.AWSExternalID != "" {
+				opts.ExternalID = cfg.AWSExternalID
+			}
+			cfgAWS = cloudy.AssumeRole(cfgAWS, cfg.AWSRoleARN, opts)
+		}
+		t.listers[cloudy.ProviderAWS] = newResourceLister(cloudy.NewScannerFromConfig(cfgAWS))
+	}
+	if len(cfg.AzureSubscriptions) > 0 {
+		lister, err := newAzureCredentialsResourceLister(cfg.AzureSubscriptions, cfg.AzureClientID)
+		if err != nil {
+			return nil, err
+		}
+		t.listers[azure.ProviderName] = lister
+	}
+	if len(cfg.GCPProjects) > 0 {
+		lister, err := newGCPCredentialsResourceLister(cfg.GCPProjects, cfg.GCPKeyRef)
+		if err != nil {
+			return nil, err
+		}
+		t.listers[gcp.ProviderName] = lister
+	}
+	if len(t.listers) == 0 {
+		return nil, errors.New("no credentials for any provider")
+	}
+
+	cache := cloudy.NewResultCache(scanCacheTTL)
+	for _, lister := range t.listers {
+		lister.SetCache(cache)
+		lister.SetAccountNames(t.accountNames)
+	}
+	return t, nil
+}
+
+type tenantKey struct{}
+
+// withTenant returns a copy of ctx whose requests are t's.
+func withTenant(ctx context.Context, t *tenant) context.Context {
+	return context.WithValue(ctx, tenantKey{}, t)
+}
+
+// tenantFrom returns the tenant ctx's request is for.
+func tenantFrom(ctx context.Context) *tenant {
+	if t, ok := ctx.Value(tenantKey{}).(*tenant); ok {
+		return t
+	}
+	return serverTenant
+}
+
+// tenantForKey returns the tenant of apiKey, or an error if tenants are
+// enabled and it isn't one of theirs.
+func tenantForKey(apiKey string) (*tenant, error) {
+	if len(tenantsByKey) == 0 {
+		return serverTenant, nil
+	}
+	if apiKey == "" {
+		return nil, errors.New("an API key is required; send it as Authorization: Bearer <key> or X-API-Key")
+	}
+	hash := sha256.Sum256([]byte(apiKey))
+	t, ok := tenantsByKey[hex.EncodeToString(hash[:])]
+	if !ok {
+		return nil, errors.New("invalid API key")
+	}
+	return t, nil
+}
+
+// authenticateTenant serves each request for the tenant its API key
+// belongs to, and turns away requests without a valid one when tenants
+// are enabled.
+func authenticateTenant(c *gin.Context) {
+	apiKey := c.GetHeader("X-API-Key")
+	if bearer, ok := strings.CutPrefix(c.GetHeader("Authorization"), "Bearer "); ok && apiKey == "" {
+		apiKey = strings.TrimSpace(bearer)
+	}
+	t, err := tenantForKey(apiKey)
+	if err != nil {
+		c.Header("WWW-Authenticate", "Bearer")
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+	c.Request = c.Request.WithContext(withTenant(c.Request.Context(), t))
+	c.Next()
+}
+
+// tenantStream is a gRPC stream whose context carries its tenant.
+type tenantStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s tenantStream) Context() context.Context { return s.ctx }
+
+// authenticateTenantStream is authenticateTenant for gRPC streams, taking
+// the API key from the authorization or x-api-key metadata.
+func authenticateTenantStream(srv any, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	var apiKey string
+	if md, ok := metadata.FromIncomingContext(stream.Context()); ok {
+		if values := md.Get("x-api-key"); len(values) > 0 {
+			apiKey = values[0]
+		} else if values := md.Get("authorization"); len(values) > 0 {
+			apiKey, _ = strings.CutPrefix(values[0], "Bearer ")
+		}
+	}
+	t, err := tenantForKey(apiKey)
+	if err != nil {
+		return status.Error(codes.Unauthenticated, err.Error())
+	}
+	return handler(srv, tenantStream{ServerStream: stream, ctx: withTenant(stream.Context(), t)})
+}
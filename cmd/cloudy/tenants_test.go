@@ -0,0 +1,305 @@
+package main
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// keyHash returns the api_key_sha256 of key.
+func keyHash(key string) string {
+	hash := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(hash[:])
+}
+
+// withTenants enables tenants for the length of a test, each tenant
+// having the API key of its name.
+func withTenants(t *testing.T, names ...string) map[string]*tenant {
+	t.Helper()
+	old := tenantsByKey
+	t.Cleanup(func() { tenantsByKey = old })
+
+	tenants := make(map[string]*tenant)
+	tenantsByKey = make(map[string]*tenant)
+	for _, name := range names {
+		tenants[name] = &tenant{name: name}
+		tenantsByKey[keyHash(name+"-key")] = tenants[name]
+	}
+	return tenants
+}
+
+func TestLoadTenants(t *testing.T) {
+	// Tenants scan AWS with a profile loaded from the shared config
+	configFile := filepath.Join(t.TempDir(), "config")
+	if err := os.WriteFile(configFile, []byte("[profile acme]\nregion = us-east-1\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("AWS_CONFIG_FILE", configFile)
+	t.Setenv("AWS_SHARED_CREDENTIALS_FILE", filepath.Join(t.TempDir(), "credentials"))
+
+	acme := keyHash("acme-key")
+	globex := keyHash("globex-key")
+	tests := []struct {
+		name    string
+		file    string
+		wantErr string
+		// keys are the hashes loaded, mapped to their tenant's name
+		keys map[string]string
+	}{
+		{
+			name: "valid",
+			file: `{"tenants": [
+				{"name": "acme", "api_key_sha256": ["` + acme + `", "` + strings.ToUpper(keyHash("acme-ci")) + `"], "aws_profile": "acme"},
+				{"name": "globex", "api_key_sha256": ["` + globex + `"], "aws_profile": "acme"}
+			]}`,
+			keys: map[string]string{acme: "acme", keyHash("acme-ci"): "acme", globex: "globex"},
+		},
+		{
+			name:    "not hex",
+			file:    `{"tenants": [{"name": "acme", "api_key_sha256": ["` + strings.Repeat("z", 64) + `"], "aws_profile": "acme"}]}`,
+			wantErr: "isn't a hex SHA-256 hash",
+		},
+		{
+			name:    "too short",
+			file:    `{"tenants": [{"name": "acme", "api_key_sha256": ["` + acme[:40] + `"], "aws_profile": "acme"}]}`,
+			wantErr: "isn't a hex SHA-256 hash",
+		},
+		{
+			name:    "the key itself",
+			file:    `{"tenants": [{"name": "acme", "api_key_sha256": ["acme-key"], "aws_profile": "acme"}]}`,
+			wantErr: "isn't a hex SHA-256 hash",
+		},
+		{
+			name: "hash shared by two tenants",
+			file: `{"tenants": [
+				{"name": "acme", "api_key_sha256": ["` + acme + `"], "aws_profile": "acme"},
+				{"name": "globex", "api_key_sha256": ["` + strings.ToUpper(acme) + `"], "aws_profile": "acme"}
+			]}`,
+			wantErr: "tenants acme and globex share an API key",
+		},
+		{
+			name: "tenant defined twice",
+			file: `{"tenants": [
+				{"name": "acme", "api_key_sha256": ["` + acme + `"], "aws_profile": "acme"},
+				{"name": "acme", "api_key_sha256": ["` + globex + `"], "aws_profile": "acme"}
+			]}`,
+			wantErr: "tenant acme is defined twice",
+		},
+		{
+			name:    "no name",
+			file:    `{"tenants": [{"api_key_sha256": ["` + acme + `"], "aws_profile": "acme"}]}`,
+			wantErr: "every tenant needs a name",
+		},
+		{
+			name:    "no keys",
+			file:    `{"tenants": [{"name": "acme", "aws_profile": "acme"}]}`,
+			wantErr: "tenant acme has no api_key_sha256",
+		},
+		{
+			name:    "no credentials",
+			file:    `{"tenants": [{"name": "acme", "api_key_sha256": ["` + acme + `"]}]}`,
+			wantErr: "no credentials for any provider",
+		},
+		{
+			name:    "no tenants",
+			file:    `{"tenants": []}`,
+			wantErr: "no tenants",
+		},
+		{
+			name:    "malformed",
+			file:    `{"tenants": [`,
+			wantErr: "unexpected end of JSON input",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "tenants.json")
+			if err := os.WriteFile(path, []byte(tt.file), 0o600); err != nil {
+				t.Fatal(err)
+			}
+
+			tenants, err := loadTenants(path)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("loadTenants = %v, want an error containing %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(tenants) != len(tt.keys) {
+				t.Errorf("loaded %d keys, want %d", len(tenants), len(tt.keys))
+			}
+			for hash, name := range tt.keys {
+				if got := tenants[hash]; got == nil || got.name != name {
+					t.Errorf("key %s… is %v's, want %s's", hash[:8], got, name)
+				}
+			}
+			if tenants[acme] != tenants[keyHash("acme-ci")] {
+				t.Error("one tenant's keys load as two tenants")
+			}
+		})
+	}
+}
+
+func TestTenantForKey(t *testing.T) {
+	t.Run("tenants disabled", func(t *testing.T) {
+		old := tenantsByKey
+		tenantsByKey = nil
+		t.Cleanup(func() { tenantsByKey = old })
+
+		for _, key := range []string{"", "acme-key"} {
+			if got, err := tenantForKey(key); err != nil || got != serverTenant {
+				t.Errorf("tenantForKey(%q) = %v, %v; want the server's", key, got, err)
+			}
+		}
+	})
+
+	tenants := withTenants(t, "acme", "globex")
+	tests := []struct {
+		key     string
+		want    *tenant
+		wantErr string
+	}{
+		{key: "", wantErr: "an API key is required"},
+		{key: "wrong", wantErr: "invalid API key"},
+		{key: keyHash("acme-key"), wantErr: "invalid API key"},
+		{key: "acme-key", want: tenants["acme"]},
+		{key: "globex-key", want: tenants["globex"]},
+	}
+	for _, tt := range tests {
+		got, err := tenantForKey(tt.key)
+		if tt.wantErr != "" {
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("tenantForKey(%q) = %v, want an error containing %q", tt.key, err, tt.wantErr)
+			}
+			continue
+		}
+		if err != nil || got != tt.want {
+			t.Errorf("tenantForKey(%q) = %v, %v; want %s", tt.key, got, err, tt.want.name)
+		}
+	}
+}
+
+func TestAuthenticateTenant(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	withTenants(t, "acme", "globex")
+	r := gin.New()
+	r.GET("/whoami", authenticateTenant, func(c *gin.Context) {
+		c.String(http.StatusOK, tenantFrom(c.Request.Context()).name)
+	})
+
+	tests := []struct {
+		name    string
+		headers map[string]string
+		status  int
+		tenant  string
+	}{
+		{name: "no key", status: http.StatusUnauthorized},
+		{name: "wrong key", headers: map[string]string{"X-API-Key": "wrong"}, status: http.StatusUnauthorized},
+		{name: "x-api-key", headers: map[string]string{"X-API-Key": "acme-key"}, status: http.StatusOK, tenant: "acme"},
+		{name: "bearer", headers: map[string]string{"Authorization": "Bearer globex-key"}, status: http.StatusOK, tenant: "globex"},
+		{name: "x-api-key over bearer", headers: map[string]string{"X-API-Key": "acme-key", "Authorization": "Bearer globex-key"}, status: http.StatusOK, tenant: "acme"},
+		{name: "basic auth", headers: map[string]string{"Authorization": "Basic YWNtZS1rZXk6"}, status: http.StatusUnauthorized},
+		{name: "bare authorization", headers: map[string]string{"Authorization": "acme-key"}, status: http.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/whoami", nil)
+			for name, value := range tt.headers {
+				req.Header.Set(name, value)
+			}
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			if w.Code != tt.status {
+				t.Fatalf("status = %d, want %d", w.Code, tt.status)
+			}
+			if tt.status == http.StatusUnauthorized && w.Header().Get("WWW-Authenticate") != "Bearer" {
+				t.Error("401 without WWW-Authenticate: Bearer")
+			}
+			if tt.tenant != "" && w.Body.String() != tt.tenant {
+				t.Errorf("served for %q, want %q", w.Body.String(), tt.tenant)
+			}
+		})
+	}
+}
+
+func TestAuthenticateTenantExemptions(t *testing.T) {
+	withTenants(t, "acme")
+	r := setupRouter()
+
+	for path, status := range map[string]int{
+		"/health":            http.StatusOK,
+		"/openapi.json":      http.StatusOK,
+		"/docs/index.html":   http.StatusOK,
+		"/api/v1/summary":    http.StatusUnauthorized,
+		"/api/v2/services":   http.StatusUnauthorized,
+		"/api/v1/resources/": http.StatusUnauthorized,
+	} {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+		if w.Code != status {
+			t.Errorf("GET %s without an API key = %d, want %d", path, w.Code, status)
+		}
+	}
+}
+
+// metadataStream is a gRPC server stream carrying incoming metadata.
+type metadataStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s metadataStream) Context() context.Context { return s.ctx }
+
+func TestAuthenticateTenantStream(t *testing.T) {
+	withTenants(t, "acme", "globex")
+
+	tests := []struct {
+		name   string
+		md     metadata.MD
+		code   codes.Code
+		tenant string
+	}{
+		{name: "no metadata", code: codes.Unauthenticated},
+		{name: "wrong key", md: metadata.Pairs("x-api-key", "wrong"), code: codes.Unauthenticated},
+		{name: "x-api-key", md: metadata.Pairs("x-api-key", "acme-key"), code: codes.OK, tenant: "acme"},
+		{name: "authorization", md: metadata.Pairs("authorization", "Bearer globex-key"), code: codes.OK, tenant: "globex"},
+		{name: "x-api-key over authorization", md: metadata.Pairs("x-api-key", "acme-key", "authorization", "Bearer globex-key"), code: codes.OK, tenant: "acme"},
+		{name: "basic authorization", md: metadata.Pairs("authorization", "Basic YWNtZS1rZXk6"), code: codes.Unauthenticated},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			if tt.md != nil {
+				ctx = metadata.NewIncomingContext(ctx, tt.md)
+			}
+			var served string
+			err := authenticateTenantStream(nil, metadataStream{ctx: ctx}, &grpc.StreamServerInfo{}, func(srv any, stream grpc.ServerStream) error {
+				served = tenantFrom(stream.Context()).name
+				return nil
+			})
+
+			if code := status.Code(err); code != tt.code {
+				t.Fatalf("code = %s, want %s", code, tt.code)
+			}
+			if served != tt.tenant {
+				t.Errorf("served for %q, want %q", served, tt.tenant)
+			}
+		})
+	}
+}
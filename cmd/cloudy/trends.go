@@ -0,0 +1,235 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/alwindoss/cloudy/pkg/cloudy"
+	"github.com/gin-gonic/gin"
+)
+
+// trendResolution is the finest granularity trends are kept at: later
+// scans of a region within the same hour replace its earlier sample.
+const trendResolution = time.Hour
+
+// trendRetention bounds how far back trends go.
+const trendRetention = 400 * 24 * time.Hour
+
+// trendSample is the count of each resource type in one region of a
+// provider, as of a full scan.
+type trendSample struct {
+	Provider string         `json:"provider,omitempty"`
+	Region   string         `json:"region"`
+	At       time.Time      `json:"at"`
+	Counts   map[string]int `json:"counts"`
+}
+
+// trendStore keeps per-region resource counts over time. With a path set
+// it is saved to that file after every sample, so history survives
+// restarts.
+type trendStore struct {
+	mu      sync.Mutex
+	samples []trendSample
+	path    string
+}
+
+var resourceTrends = &trendStore{}
+
+// Record adds a sample for a full scan of provider's region.
+func (t *trendStore) Record(provider, region string, resources []Resource) {
+	sample := trendSample{
+		Provider: provider,
+		Region:   region,
+		At:       time.Now().UTC().Truncate(trendResolution),
+		Counts:   make(map[string]int),
+	}
+	for _, resource := range resources {
+		sample.Counts[resource.Type]++
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	cutoff := sample.At.Add(-trendRetention)
+	kept := t.samples[:0]
+	for _, s := range t.samples {
+		if s.At.After(cutoff) && !(s.Provider == provider && s.Region == region && s.At.Equal(sample.At)) {
+			kept = append(kept, s)
+		}
+	}
+	t.samples = append(kept, sample)
+
+	if t.path != "" {
+		if err := t.save(); err != nil {
+			log.Printf("Failed to save trends to %s: %v", t.path, err)
+		}
+	}
+}
+
+// Load reads saved samples from path, if it exists, and saves to it from
+// then on.
+func (t *trendStore) Load(path string) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.path = path
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(data, &t.samples); err != nil {
+		return err
+	}
+	// Samples saved before they named their provider are taken to be
+	// AWS's, the default provider, so they aren't counted alongside its
+	// newer samples of the same region.
+	for i := range t.samples {
+		if t.samples[i].Provider == "" {
+			t.samples[i].Provider = cloudy.ProviderAWS
+		}
+	}
+	return nil
+}
+
+// Flush saves the samples one last time, after any Record in progress,
+// if they are saved to a file.
+func (t *trendStore) Flush() error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.path == "" {
+		return nil
+	}
+	return t.save()
+}
+
+// save writes the samples through a temporary file so a crash never
+// leaves a truncated file behind. The caller must hold t.mu.
+func (t *trendStore) save() error {
+	data, err := json.Marshal(t.samples)
+	if err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(t.path), ".cloudy-trends-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), t.path)
+}
+
+type TrendPoint struct {
+	Time  time.Time `json:"time"`
+	Count int       `json:"count"`
+}
+
+type TrendResponse struct {
+	Type     string       `json:"type,omitempty"`
+	Region   string       `json:"region,omitempty"`
+	Interval string       `json:"interval"`
+	Points   []TrendPoint `json:"points"`
+}
+
+// Series returns the count of resourceType (all types if empty) in region
+// (all regions if empty, and every provider's region of that name) per
+// interval. Each point uses the last sample of each provider's region up
+// to the end of that interval, so a region that wasn't scanned in an
+// interval still counts as it was last seen.
+func (t *trendStore) Series(resourceType, region string, interval time.Duration) []TrendPoint {
+	t.mu.Lock()
+	var samples []trendSample
+	for _, s := range t.samples {
+		if region == "" || s.Region == region {
+			samples = append(samples, s)
+		}
+	}
+	t.mu.Unlock()
+
+	sort.SliceStable(samples, func(i, j int) bool {
+		return samples[i].At.Before(samples[j].At)
+	})
+
+	points := []TrendPoint{}
+	latest := make(map[regionKey]int)
+	for i, s := range samples {
+		count := 0
+		for typ, n := range s.Counts {
+			if resourceType == "" || typ == resourceType {
+				count += n
+			}
+		}
+		latest[regionKey{s.Provider, s.Region}] = count
+
+		// Emit once per bucket, after its last sample
+		bucket := s.At.Truncate(interval)
+		if i+1 < len(samples) && samples[i+1].At.Truncate(interval).Equal(bucket) {
+			continue
+		}
+		total := 0
+		for _, n := range latest {
+			total += n
+		}
+		points = append(points, TrendPoint{Time: bucket, Count: total})
+	}
+	return points
+}
+
+// dimensions returns the resource types and regions the samples have
+// counts of, in order.
+func (t *trendStore) dimensions() (types, regions []string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	typeSet := make(map[string]bool)
+	regionSet := make(map[string]bool)
+	for _, s := range t.samples {
+		regionSet[s.Region] = true
+		for typ := range s.Counts {
+			typeSet[typ] = true
+		}
+	}
+	return sortedKeys(typeSet), sortedKeys(regionSet)
+}
+
+var trendIntervals = map[string]time.Duration{
+	"hour": time.Hour,
+	"day":  24 * time.Hour,
+}
+
+// getTrends returns resource counts over time, from the full scans the
+// server has seen, for charting growth.
+func getTrends(c *gin.Context) {
+	interval := c.DefaultQuery("interval", "day")
+	duration, ok := trendIntervals[interval]
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "interval must be hour or day"})
+		return
+	}
+
+	resourceType := c.Query("type")
+	region := c.Query("region")
+	c.JSON(http.StatusOK, TrendResponse{
+		Type:     resourceType,
+		Region:   region,
+		Interval: interval,
+		Points:   tenantFrom(c.Request.Context()).trends.Series(resourceType, region, duration),
+	})
+}
@@ -0,0 +1,283 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"slices"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const (
+	eventScanCompleted    = "scan.completed"
+	eventResourcesChanged = "resources.changed"
+)
+
+// webhookAttempts is how many times a delivery is tried before it is
+// given up on, waiting webhookBackoff, then twice as long each time, in
+// between.
+const (
+	webhookAttempts = 5
+	webhookBackoff  = time.Second
+)
+
+// webhookTimeout bounds each attempt to deliver an event.
+const webhookTimeout = 10 * time.Second
+
+// Webhook is one endpoint in CLOUDY_WEBHOOKS_FILE, which is sent the
+// events it subscribes to as JSON POSTs.
+type Webhook struct {
+	URL string `json:"url"`
+	// Secret signs each delivery with HMAC-SHA256 over the Unix time it
+	// was sent, a dot and the body, sent as X-Cloudy-Signature:
+	// sha256=<hex> with the time in X-Cloudy-Timestamp, so receivers can
+	// reject old deliveries replayed to them.
+	Secret string `json:"secret,omitempty"`
+	// Events are the events sent, scan.completed and resources.changed,
+	// or all of them if empty.
+	Events []string `json:"events,omitempty"`
+	// Resources adds every resource listed to scan.completed events,
+	// rather than just each region's count.
+	Resources bool `json:"resources,omitempty"`
+	// Tenant is the tenant whose events are sent, when tenants are
+	// enabled.
+	Tenant string `json:"tenant,omitempty"`
+}
+
+// webhooks are sent events, from CLOUDY_WEBHOOKS_FILE.
+var webhooks []Webhook
+
+// webhookDeliveries tracks the deliveries in progress, retries included,
+// for shutdown to wait on, and webhookCtx is cancelled to give up on them.
+var (
+	webhookDeliveries            sync.WaitGroup
+	webhookCtx, cancelDeliveries = context.WithCancel(context.Background())
+)
+
+// WebhookEvent is the body of a delivery.
+type WebhookEvent struct {
+	ID        string    `json:"id"`
+	Type      string    `json:"type"`
+	Tenant    string    `json:"tenant,omitempty"`
+	Timestamp time.Time `json:"timestamp"`
+	// Scan is set for scan.completed, and for resources.changed is the
+	// scan that found the changes.
+	Scan    WebhookScan      `json:"scan"`
+	Changes *ResourceChanges `json:"changes,omitempty"`
+}
+
+// WebhookScan describes a scheduled or async scan.
+type WebhookScan struct {
+	// ID is an async scan's ID, and empty for scheduled scans.
+	ID         string          `json:"id,omitempty"`
+	Scheduled  bool            `json:"scheduled"`
+	StartedAt  time.Time       `json:"started_at"`
+	FinishedAt time.Time       `json:"finished_at"`
+	TotalCount int             `json:"total_count"`
+	Regions    []WebhookRegion `json:"regions"`
+	// RegionData is every resource listed, for webhooks that ask for it.
+	RegionData []RegionResources `json:"region_data,omitempty"`
+}
+
+type WebhookRegion struct {
+	Region string `json:"region"`
+	Count  int    `json:"count"`
+	Error  string `json:"error,omitempty"`
+}
+
+// loadWebhooks reads the webhooks in path, a JSON file of the form
+// {"webhooks": [...]}.
+func loadWebhooks(path string) ([]Webhook, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var file struct {
+		Webhooks []Webhook `json:"webhooks"`
+	}
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+
+	tenants := make(map[string]bool)
+	for _, t := range scheduledTenants() {
+		tenants[t.name] = true
+	}
+	for i, webhook := range file.Webhooks {
+		if webhook.URL == "" {
+			return nil, fmt.Errorf("webhook %d needs a url", i)
+		}
+		for _, event := range webhook.Events {
+			if event != eventScanCompleted && event != eventResourcesChanged {
+				return nil, fmt.Errorf("webhook %s: unknown event %q; expected %s or %s", webhook.URL, event, eventScanCompleted, eventResourcesChanged)
+			}
+		}
+		if !tenants[webhook.Tenant] {
+			if len(tenantsByKey) == 0 {
+				return nil, fmt.Errorf("webhook %s names tenant %q, but tenants aren't enabled", webhook.URL, webhook.Tenant)
+			}
+			return nil, fmt.Errorf("webhook %s needs the tenant whose events it gets, got %q", webhook.URL, webhook.Tenant)
+		}
+	}
+	return file.Webhooks, nil
+}
+
+// newWebhookScan describes a scan that listed regionData.
+func newWebhookScan(regionData []RegionResources, startedAt, finishedAt time.Time) WebhookScan {
+	scan := WebhookScan{StartedAt: startedAt, FinishedAt: finishedAt, Regions: []WebhookRegion{}}
+	for _, rd := range regionData {
+		scan.TotalCount += len(rd.Resources)
+		scan.Regions = append(scan.Regions, WebhookRegion{Region: rd.Region, Count: len(rd.Resources), Error: rd.Error})
+	}
+	return scan
+}
+
+// fireWebhooks sends t's scan.completed event for a scan that listed
+// regionData, and its resources.changed event if changes isn't empty, to
+// every webhook of t subscribed to them. Deliveries are retried in the
+// background, so a slow endpoint doesn't hold up scans.
+func fireWebhooks(t *tenant, scan WebhookScan, regionData []RegionResources, changes ResourceChanges) {
+	for _, webhook := range webhooks {
+		if webhook.Tenant != t.name {
+			continue
+		}
+		scan := scan
+		if webhook.Resources {
+			scan.RegionData = regionData
+		}
+		if webhook.subscribed(eventScanCompleted) {
+			webhook.deliverInBackground(newWebhookEvent(eventScanCompleted, t, scan, nil))
+		}
+		if webhook.subscribed(eventResourcesChanged) && !changes.empty() {
+			scan.RegionData = nil
+			webhook.deliverInBackground(newWebhookEvent(eventResourcesChanged, t, scan, &changes))
+		}
+	}
+}
+
+// deliverInBackground delivers event without waiting for it, tracked by
+// webhookDeliveries.
+func (w Webhook) deliverInBackground(event WebhookEvent) {
+	webhookDeliveries.Add(1)
+	go func() {
+		defer webhookDeliveries.Done()
+		w.deliver(webhookCtx, event)
+	}()
+}
+
+// drainWebhooks waits for the deliveries in progress to finish, and when
+// ctx is done first gives up on their remaining attempts.
+func drainWebhooks(ctx context.Context) {
+	done := make(chan struct{})
+	go func() {
+		webhookDeliveries.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return
+	case <-ctx.Done():
+	}
+	cancelDeliveries()
+	<-done
+}
+
+func newWebhookEvent(eventType string, t *tenant, scan WebhookScan, changes *ResourceChanges) WebhookEvent {
+	// An ID that can't be made only stops receivers telling retries apart
+	id, _ := newScanID()
+	if changes != nil {
+		// Receivers get empty lists rather than nulls
+		changes = &ResourceChanges{
+			Added:   append([]Resource{}, changes.Added...),
+			Removed: append([]Resource{}, changes.Removed...),
+			Changed: append([]ChangedResource{}, changes.Changed...),
+		}
+	}
+	return WebhookEvent{ID: id, Type: eventType, Tenant: t.name, Timestamp: time.Now().UTC(), Scan: scan, Changes: changes}
+}
+
+func (w Webhook) subscribed(event string) bool {
+	return len(w.Events) == 0 || slices.Contains(w.Events, event)
+}
+
+// deliver posts event, retrying with backoff after network errors, 429s
+// and 5xx responses until ctx is done. Every attempt sends the same body
+// and event ID, so receivers can drop repeats.
+func (w Webhook) deliver(ctx context.Context, event WebhookEvent) {
+	body, err := json.Marshal(event)
+	if err != nil {
+		log.Printf("Failed to encode %s webhook event: %v", event.Type, err)
+		return
+	}
+
+	backoff := webhookBackoff
+	for attempt := 1; ; attempt++ {
+		retry, err := w.post(ctx, event, body, time.Now())
+		if err == nil {
+			return
+		}
+		if !retry || attempt == webhookAttempts || ctx.Err() != nil {
+			log.Printf("Failed to deliver %s webhook event %s to %s after %d attempts: %v", event.Type, event.ID, w.URL, attempt, err)
+			return
+		}
+		select {
+		case <-time.After(backoff):
+		case <-ctx.Done():
+			log.Printf("Gave up delivering %s webhook event %s to %s after %d attempts at shutdown: %v", event.Type, event.ID, w.URL, attempt, err)
+			return
+		}
+		backoff *= 2
+	}
+}
+
+// post makes one attempt at delivering body, signed as sent at now,
+// reporting whether a failure is worth retrying.
+func (w Webhook) post(ctx context.Context, event WebhookEvent, body []byte, now time.Time) (retry bool, err error) {
+	ctx, cancel := context.WithTimeout(ctx, webhookTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, w.URL, bytes.NewReader(body))
+	if err != nil {
+		return false, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "cloudy-webhooks")
+	req.Header.Set("X-Cloudy-Event", event.Type)
+	req.Header.Set("X-Cloudy-Delivery", event.ID)
+	if w.Secret != "" {
+		timestamp := strconv.FormatInt(now.Unix(), 10)
+		req.Header.Set("X-Cloudy-Timestamp", timestamp)
+		req.Header.Set("X-Cloudy-Signature", "sha256="+w.sign(timestamp, body))
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return true, err
+	}
+	resp.Body.Close()
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return false, nil
+	}
+	retry = resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500
+	return retry, fmt.Errorf("endpoint answered %s", resp.Status)
+}
+
+// sign returns the hex HMAC-SHA256 of timestamp, a dot and body, keyed
+// with w's secret.
+func (w Webhook) sign(timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(w.Secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,92 @@
+package main
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestWebhookPostSignsTimestampAndBody(t *testing.T) {
+	const secret = "s3cret"
+	body := []byte(`{"id":"1","type":"scan.completed"}`)
+	sent := time.Unix(1704067200, 0)
+
+	var got http.Header
+	var gotBody []byte
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r.Header.Clone()
+		gotBody, _ = io.ReadAll(r.Body)
+	}))
+	defer server.Close()
+
+	webhook := Webhook{URL: server.URL, Secret: secret}
+	if _, err := webhook.post(context.Background(), WebhookEvent{ID: "1", Type: eventScanCompleted}, body, sent); err != nil {
+		t.Fatal(err)
+	}
+
+	if timestamp := got.Get("X-Cloudy-Timestamp"); timestamp != "1704067200" {
+		t.Errorf("X-Cloudy-Timestamp = %q, want 1704067200", timestamp)
+	}
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte("1704067200." + string(gotBody)))
+	if want := "sha256=" + hex.EncodeToString(mac.Sum(nil)); got.Get("X-Cloudy-Signature") != want {
+		t.Errorf("X-Cloudy-Signature = %q, want %q", got.Get("X-Cloudy-Signature"), want)
+	}
+
+	// The body alone, as deliveries were once signed, mustn't verify
+	mac = hmac.New(sha256.New, []byte(secret))
+	mac.Write(gotBody)
+	if bodyOnly := "sha256=" + hex.EncodeToString(mac.Sum(nil)); got.Get("X-Cloudy-Signature") == bodyOnly {
+		t.Error("X-Cloudy-Signature doesn't cover the timestamp")
+	}
+}
+
+func TestWebhookPostWithoutSecret(t *testing.T) {
+	var got http.Header
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r.Header.Clone()
+	}))
+	defer server.Close()
+
+	webhook := Webhook{URL: server.URL}
+	if _, err := webhook.post(context.Background(), WebhookEvent{ID: "1", Type: eventScanCompleted}, []byte("{}"), time.Now()); err != nil {
+		t.Fatal(err)
+	}
+	for _, header := range []string{"X-Cloudy-Timestamp", "X-Cloudy-Signature"} {
+		if value := got.Get(header); value != "" {
+			t.Errorf("%s = %q without a secret, want none", header, value)
+		}
+	}
+}
+
+func TestDrainWebhooksGivesUpOnRetries(t *testing.T) {
+	var attempts atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts.Add(1)
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	webhook := Webhook{URL: server.URL}
+	webhook.deliverInBackground(WebhookEvent{ID: "1", Type: eventScanCompleted})
+
+	// The first attempt fails and the delivery waits a second to retry;
+	// draining must not wait that out once its grace is over.
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	drainWebhooks(ctx)
+	if elapsed := time.Since(start); elapsed > webhookBackoff {
+		t.Errorf("drainWebhooks took %s, want it to give up after its grace", elapsed)
+	}
+	if n := attempts.Load(); n != 1 {
+		t.Errorf("endpoint got %d attempts, want 1", n)
+	}
+}
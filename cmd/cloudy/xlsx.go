@@ -0,0 +1,143 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/xuri/excelize/v2"
+)
+
+const xlsxSummarySheet = "Summary"
+
+// writeResourcesXLSX writes a workbook with a summary sheet (resource counts
+// per type and per region, with any region errors) followed by one sheet per
+// resource type, laid out like the CSV format without the type column.
+func writeResourcesXLSX(c *gin.Context, response ListResourcesResponse, p *projection) {
+	byType := map[string][]Resource{}
+	for _, rd := range response.RegionData {
+		for _, resource := range rd.Resources {
+			byType[resource.Type] = append(byType[resource.Type], resource)
+		}
+	}
+	types := make([]string, 0, len(byType))
+	for resourceType := range byType {
+		types = append(types, resourceType)
+	}
+	sort.Strings(types)
+
+	f := excelize.NewFile()
+	defer f.Close()
+
+	if err := buildXLSXWorkbook(f, response, types, byType, p); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to build workbook: " + err.Error()})
+		return
+	}
+
+	setExportHeaders(c, response, "resources.xlsx")
+	c.Status(http.StatusOK)
+	c.Writer.Header().Set("Content-Type", mimeXLSX)
+	f.Write(c.Writer)
+}
+
+func buildXLSXWorkbook(f *excelize.File, response ListResourcesResponse, types []string, byType map[string][]Resource, p *projection) error {
+	if err := f.SetSheetName(f.GetSheetName(0), xlsxSummarySheet); err != nil {
+		return err
+	}
+
+	summary := [][]interface{}{{"Resource type", "Count"}}
+	for _, resourceType := range types {
+		summary = append(summary, []interface{}{resourceType, len(byType[resourceType])})
+	}
+	summary = append(summary, []interface{}{"Total", response.TotalCount}, nil, []interface{}{"Region", "Count", "Error"})
+	for _, rd := range response.RegionData {
+		summary = append(summary, []interface{}{rd.Region, len(rd.Resources), rd.Error})
+	}
+	if err := writeXLSXRows(f, xlsxSummarySheet, summary); err != nil {
+		return err
+	}
+
+	used := map[string]bool{xlsxSummarySheet: true}
+	for _, resourceType := range types {
+		sheet := xlsxSheetName(resourceType, used)
+		if _, err := f.NewSheet(sheet); err != nil {
+			return err
+		}
+
+		resources := byType[resourceType]
+		columns := newResourceColumns(resources, p, false)
+		rows := [][]interface{}{toXLSXRow(columns.header())}
+		for _, resource := range resources {
+			rows = append(rows, toXLSXRow(columns.row(resource)))
+		}
+		if err := writeXLSXRows(f, sheet, rows); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// writeXLSXRows streams rows into sheet with the first row frozen as a
+// header.
+func writeXLSXRows(f *excelize.File, sheet string, rows [][]interface{}) error {
+	sw, err := f.NewStreamWriter(sheet)
+	if err != nil {
+		return err
+	}
+	if err := sw.SetPanes(&excelize.Panes{Freeze: true, YSplit: 1, TopLeftCell: "A2", ActivePane: "bottomLeft"}); err != nil {
+		return err
+	}
+	for i, row := range rows {
+		cell, err := excelize.CoordinatesToCellName(1, i+1)
+		if err != nil {
+			return err
+		}
+		if err := sw.SetRow(cell, row); err != nil {
+			return err
+		}
+	}
+	return sw.Flush()
+}
+
+func toXLSXRow(values []string) []interface{} {
+	row := make([]interface{}, len(values))
+	for i, value := range values {
+		row[i] = value
+	}
+	return row
+}
+
+// xlsxSheetName makes a resource type usable as a sheet name: at most 31
+// characters, none of []:*?/\, and unique within the workbook.
+func xlsxSheetName(resourceType string, used map[string]bool) string {
+	name := strings.Map(func(r rune) rune {
+		if strings.ContainsRune(`[]:*?/\`, r) {
+			return '-'
+		}
+		return r
+	}, resourceType)
+	name = strings.Trim(name, "'")
+	if name == "" {
+		name = "Unknown"
+	}
+
+	base := name
+	for i := 2; ; i++ {
+		if len([]rune(name)) > excelize.MaxSheetNameLength {
+			name = string([]rune(name)[:excelize.MaxSheetNameLength])
+		}
+		if !used[name] {
+			break
+		}
+		suffix := fmt.Sprintf(" (%d)", i)
+		runes := []rune(base)
+		if len(runes)+len(suffix) > excelize.MaxSheetNameLength {
+			runes = runes[:excelize.MaxSheetNameLength-len(suffix)]
+		}
+		name = string(runes) + suffix
+	}
+	used[name] = true
+	return name
+}
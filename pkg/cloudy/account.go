@@ -0,0 +1,148 @@
+package cloudy
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/iam"
+	"github.com/aws/aws-sdk-go-v2/service/organizations"
+	"github.com/aws/aws-sdk-go-v2/service/sts"
+)
+
+// Account is the AWS account a Scanner's credentials belong to. Name is
+// the account's IAM alias, or else its name in AWS Organizations, if the
+// credentials may read either; see AccountNames.
+type Account struct {
+	ID   string `json:"account_id" yaml:"account_id"`
+	Name string `json:"account_name,omitempty" yaml:"account_name,omitempty"`
+}
+
+// DefaultAccountNameTTL is how long AccountNames keeps the names it
+// looked up.
+const DefaultAccountNameTTL = time.Hour
+
+// AccountNames resolves account IDs to names people recognise, for the
+// AccountName of resources, and keeps them for a while so each account is
+// looked up once per TTL rather than once per scan. The Scanner's own
+// account is named by its IAM alias (iam:ListAccountAliases); it and
+// other accounts, such as those in the ARNs of shared resources, by their
+// name in AWS Organizations (organizations:DescribeAccount), which only
+// the organization's management account and delegated administrators can
+// read. Accounts neither names are left unnamed, unless given a name with
+// Set. Scanners sharing AccountNames share their names.
+type AccountNames struct {
+	ttl time.Duration
+
+	mu    sync.Mutex
+	names map[string]accountName
+}
+
+type accountName struct {
+	name string
+	// expires is zero for names given with Set, which never expire
+	expires time.Time
+}
+
+// NewAccountNames returns AccountNames keeping looked up names for ttl.
+func NewAccountNames(ttl time.Duration) *AccountNames {
+	return &AccountNames{ttl: ttl, names: make(map[string]accountName)}
+}
+
+// Set names account, in place of looking its name up.
+func (n *AccountNames) Set(account, name string) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.names[account] = accountName{name: name}
+}
+
+func (n *AccountNames) get(account string) (string, bool) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	entry, ok := n.names[account]
+	if !ok || (!entry.expires.IsZero() && time.Now().After(entry.expires)) {
+		return "", false
+	}
+	return entry.name, true
+}
+
+// put keeps name, which is "" for an account that couldn't be named, so
+// it isn't looked up again until the TTL runs out.
+func (n *AccountNames) put(account, name string) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if entry, ok := n.names[account]; ok && entry.expires.IsZero() {
+		return
+	}
+	n.names[account] = accountName{name: name, expires: time.Now().Add(n.ttl)}
+}
+
+// SetAccountNames shares names with other Scanners, in place of the
+// Scanner's own. It must be called before the Scanner is used.
+func (s *Scanner) SetAccountNames(names *AccountNames) {
+	s.accountNames = names
+}
+
+// Account returns the account the Scanner's credentials belong to, which
+// its resources are labelled with, or a zero Account if the Scanner
+// doesn't scan AWS or the account can't be told. The account is looked
+// up once per Scanner.
+func (s *Scanner) Account(ctx context.Context) Account {
+	if !s.isAWS() {
+		return Account{}
+	}
+	s.accountOnce.Do(func() {
+		region := s.cfg.Region
+		if region == "" {
+			region = globalRegion
+		}
+		identity, err := Client(ctx, s.RegionConfig(region), sts.NewFromConfig).GetCallerIdentity(ctx, &sts.GetCallerIdentityInput{})
+		if err == nil {
+			s.accountID = aws.ToString(identity.Account)
+		}
+	})
+	if s.accountID == "" {
+		return Account{}
+	}
+	return Account{ID: s.accountID, Name: s.accountName(ctx, s.accountID)}
+}
+
+// accountName returns the name of account, looking it up if the
+// Scanner's AccountNames doesn't have it.
+func (s *Scanner) accountName(ctx context.Context, account string) string {
+	if name, ok := s.accountNames.get(account); ok {
+		return name
+	}
+
+	cfg := s.globalConfig()
+	var name string
+	if account == s.accountID {
+		// An account has at most one alias
+		aliases, err := Client(ctx, cfg, iam.NewFromConfig).ListAccountAliases(ctx, &iam.ListAccountAliasesInput{})
+		if err == nil && len(aliases.AccountAliases) > 0 {
+			name = aliases.AccountAliases[0]
+		}
+	}
+	if name == "" {
+		described, err := Client(ctx, cfg, organizations.NewFromConfig).DescribeAccount(ctx, &organizations.DescribeAccountInput{AccountId: aws.String(account)})
+		if err == nil && described.Account != nil {
+			name = aws.ToString(described.Account.Name)
+		}
+	}
+	// A lookup cut short by a cancelled scan says nothing about the account
+	if ctx.Err() == nil {
+		s.accountNames.put(account, name)
+	}
+	return name
+}
+
+// globalConfig returns the config for the global services, like IAM and
+// Organizations, of the partition the Scanner's region is in.
+func (s *Scanner) globalConfig() aws.Config {
+	region := globalRegion
+	if s.cfg.Region != "" {
+		region = GlobalRegion(Partition(s.cfg.Region))
+	}
+	return s.RegionConfig(region)
+}
@@ -0,0 +1,101 @@
+package cloudy
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/amplify"
+	"github.com/aws/aws-sdk-go-v2/service/amplify/types"
+)
+
+func init() {
+	Register(lister{
+		name:       "Amplify apps",
+		types:      []string{"Amplify App", "Amplify Branch"},
+		iamActions: []string{"amplify:ListApps", "amplify:ListBranches"},
+		partitions: []string{PartitionAWS},
+		list:       withoutStates(listAmplifyApps),
+	})
+}
+
+func listAmplifyApps(ctx context.Context, cfg aws.Config) ([]Resource, error) {
+	client := Client(ctx, cfg, amplify.NewFromConfig)
+
+	var apps []types.App
+	paginator := amplify.NewListAppsPaginator(client, &amplify.ListAppsInput{})
+	for paginator.HasMorePages() {
+		if err := checkMaxResults(ctx, len(apps)); err != nil {
+			return nil, err
+		}
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return nil, err
+		}
+		apps = append(apps, page.Apps...)
+	}
+
+	var resources []Resource
+	for _, app := range apps {
+		attributes := map[string]string{
+			"app_id":         aws_string_value(app.AppId),
+			"platform":       string(app.Platform),
+			"repository":     aws_string_value(app.Repository),
+			"default_domain": aws_string_value(app.DefaultDomain),
+		}
+
+		// A branch failure is reported on its app so the other apps and
+		// their branches are still returned.
+		branchResources, branchErr := listAmplifyBranches(ctx, client, cfg.Region, app)
+		if branchErr != nil {
+			attributes["branches_error"] = branchErr.Error()
+		}
+
+		resources = append(resources, Resource{
+			ID:         aws_string_value(app.AppArn),
+			Name:       aws_string_value(app.Name),
+			Type:       "Amplify App",
+			Region:     cfg.Region,
+			Tags:       app.Tags,
+			Attributes: attributes,
+		})
+		resources = append(resources, branchResources...)
+	}
+
+	return resources, nil
+}
+
+func listAmplifyBranches(ctx context.Context, client *amplify.Client, region string, app types.App) ([]Resource, error) {
+	var branches []types.Branch
+	paginator := amplify.NewListBranchesPaginator(client, &amplify.ListBranchesInput{AppId: app.AppId})
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return nil, err
+		}
+		branches = append(branches, page.Branches...)
+	}
+
+	appName := aws_string_value(app.Name)
+	var resources []Resource
+	for _, branch := range branches {
+		branchName := aws_string_value(branch.BranchName)
+		resources = append(resources, Resource{
+			ID:     aws_string_value(branch.BranchArn),
+			Name:   appName + "/" + branchName,
+			Type:   "Amplify Branch",
+			Region: region,
+			Tags:   branch.Tags,
+			Attributes: map[string]string{
+				"app_id":     aws_string_value(app.AppId),
+				"app_name":   appName,
+				"stage":      string(branch.Stage),
+				"framework":  aws_string_value(branch.Framework),
+				"auto_build": fmt.Sprintf("%t", aws_bool_value(branch.EnableAutoBuild)),
+				"url":        branchName + "." + aws_string_value(app.DefaultDomain),
+			},
+		})
+	}
+
+	return resources, nil
+}
@@ -0,0 +1,87 @@
+package cloudy
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/apprunner"
+	"github.com/aws/aws-sdk-go-v2/service/apprunner/types"
+)
+
+func init() {
+	Register(lister{
+		name:       "App Runner services",
+		types:      []string{"App Runner Service"},
+		iamActions: []string{"apprunner:ListServices", "apprunner:DescribeService"},
+		partitions: []string{PartitionAWS},
+		list:       withoutStates(listAppRunnerServices),
+	})
+}
+
+func listAppRunnerServices(ctx context.Context, cfg aws.Config) ([]Resource, error) {
+	client := Client(ctx, cfg, apprunner.NewFromConfig)
+
+	var summaries []types.ServiceSummary
+	paginator := apprunner.NewListServicesPaginator(client, &apprunner.ListServicesInput{})
+	for paginator.HasMorePages() {
+		if err := checkMaxResults(ctx, len(summaries)); err != nil {
+			return nil, err
+		}
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return nil, err
+		}
+		summaries = append(summaries, page.ServiceSummaryList...)
+	}
+
+	resources := make([]Resource, len(summaries))
+	for i, summary := range summaries {
+		resources[i] = Resource{
+			ID:     aws_string_value(summary.ServiceArn),
+			Name:   aws_string_value(summary.ServiceName),
+			Type:   "App Runner Service",
+			State:  string(summary.Status),
+			Region: cfg.Region,
+			Attributes: map[string]string{
+				"service_url": aws_string_value(summary.ServiceUrl),
+				"created":     aws_time_string(summary.CreatedAt),
+			},
+		}
+	}
+
+	// Instance and source details need a DescribeService per service. A
+	// service that can't be described is still listed, with the failure
+	// recorded on it.
+	forEachBounded(len(summaries), describeConcurrency, func(i int) {
+		describeResult, err := client.DescribeService(ctx, &apprunner.DescribeServiceInput{
+			ServiceArn: summaries[i].ServiceArn,
+		})
+		if err != nil {
+			resources[i].Attributes["error"] = err.Error()
+			return
+		}
+		describeAppRunnerService(describeResult.Service, resources[i].Attributes)
+	})
+
+	return resources, nil
+}
+
+func describeAppRunnerService(service *types.Service, attributes map[string]string) {
+	if instance := service.InstanceConfiguration; instance != nil {
+		attributes["cpu"] = aws_string_value(instance.Cpu)
+		attributes["memory"] = aws_string_value(instance.Memory)
+	}
+
+	if source := service.SourceConfiguration; source != nil {
+		switch {
+		case source.ImageRepository != nil:
+			attributes["source"] = string(source.ImageRepository.ImageRepositoryType)
+			attributes["image"] = aws_string_value(source.ImageRepository.ImageIdentifier)
+		case source.CodeRepository != nil:
+			attributes["source"] = "CODE_REPOSITORY"
+			attributes["repository_url"] = aws_string_value(source.CodeRepository.RepositoryUrl)
+		}
+		attributes["auto_deployments_enabled"] = fmt.Sprintf("%t", aws_bool_value(source.AutoDeploymentsEnabled))
+	}
+}
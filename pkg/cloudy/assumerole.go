@@ -0,0 +1,75 @@
+package cloudy
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/credentials/stscreds"
+	"github.com/aws/aws-sdk-go-v2/service/sts"
+	ststypes "github.com/aws/aws-sdk-go-v2/service/sts/types"
+)
+
+// AssumeRoleOptions configure the sessions of the IAM roles Cloudy
+// assumes, such as cross-account roles in partner accounts that require
+// an external ID to guard against the confused deputy problem.
+type AssumeRoleOptions struct {
+	// ExternalID is passed to every role assumed, unless the profile
+	// assuming it has its own external_id.
+	ExternalID string
+	// SessionName names the role sessions, as they appear in CloudTrail,
+	// unless the profile has a role_session_name. {account} and {role}
+	// are replaced with the role's account ID and name, e.g.
+	// "cloudy-{account}". The SDK picks a name if it is "".
+	SessionName string
+	// Tags are the session tags passed to every role assumed.
+	Tags map[string]string
+}
+
+// WithAssumeRole applies opts to the roles the profiles LoadConfig loads
+// assume, for instance with role_arn and source_profile.
+func WithAssumeRole(opts AssumeRoleOptions) config.LoadOptionsFunc {
+	return config.WithAssumeRoleCredentialOptions(opts.apply)
+}
+
+// AssumeRole returns a copy of cfg whose credentials are those of roleARN,
+// assumed with cfg's credentials as set up by opts. They are refreshed
+// ahead of expiry, as LoadConfig's are.
+func AssumeRole(cfg aws.Config, roleARN string, opts AssumeRoleOptions) aws.Config {
+	provider := stscreds.NewAssumeRoleProvider(sts.NewFromConfig(cfg), roleARN, opts.apply)
+	cfg.Credentials = aws.NewCredentialsCache(provider, func(o *aws.CredentialsCacheOptions) {
+		o.ExpiryWindow = credentialsExpiryWindow
+	})
+	return cfg
+}
+
+// apply sets up a role's session, leaving what its profile set alone.
+func (opts AssumeRoleOptions) apply(o *stscreds.AssumeRoleOptions) {
+	if o.ExternalID == nil && opts.ExternalID != "" {
+		o.ExternalID = aws.String(opts.ExternalID)
+	}
+	if o.RoleSessionName == "" && opts.SessionName != "" {
+		o.RoleSessionName = sessionName(opts.SessionName, o.RoleARN)
+	}
+
+	keys := make([]string, 0, len(opts.Tags))
+	for key := range opts.Tags {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		o.Tags = append(o.Tags, ststypes.Tag{Key: aws.String(key), Value: aws.String(opts.Tags[key])})
+	}
+}
+
+// sessionName expands template for the role roleARN, e.g.
+// arn:aws:iam::123456789012:role/path/Name.
+func sessionName(template, roleARN string) string {
+	var account, role string
+	if parts := strings.SplitN(roleARN, ":", 6); len(parts) == 6 {
+		account = parts[4]
+		role = parts[5][strings.LastIndex(parts[5], "/")+1:]
+	}
+	return strings.NewReplacer("{account}", account, "{role}", role).Replace(template)
+}
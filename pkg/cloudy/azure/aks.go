@@ -0,0 +1,80 @@
+package azure
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/containerservice/armcontainerservice/v6"
+	"github.com/alwindoss/cloudy/pkg/cloudy"
+	"github.com/alwindoss/cloudy/pkg/cloudy/internal/listings"
+)
+
+func listAKSClusters(ctx context.Context, p *Provider, subscription, location string) ([]cloudy.Resource, error) {
+	// Clusters are listed for the whole subscription and shared between
+	// its locations
+	clusters, err := listings.Shared(ctx, p.listings, "aks-clusters/"+subscription, func(ctx context.Context) ([]*armcontainerservice.ManagedCluster, error) {
+		client, err := armcontainerservice.NewManagedClustersClient(subscription, p.cred, p.options)
+		if err != nil {
+			return nil, err
+		}
+
+		var clusters []*armcontainerservice.ManagedCluster
+		pager := client.NewListPager(nil)
+		for pager.More() {
+			if err := checkMaxResults(ctx, len(clusters)); err != nil {
+				return clusters, err
+			}
+			page, err := pager.NextPage(ctx)
+			if err != nil {
+				return clusters, apiError(err)
+			}
+			clusters = append(clusters, page.Value...)
+		}
+		return clusters, nil
+	})
+
+	var resources []cloudy.Resource
+	for _, cluster := range clusters {
+		if !inLocation(cluster.Location, location) {
+			continue
+		}
+
+		attributes := baseAttributes(str(cluster.ID))
+		var state string
+		if props := cluster.Properties; props != nil {
+			nodes := int32(0)
+			for _, pool := range props.AgentPoolProfiles {
+				if pool.Count != nil {
+					nodes += *pool.Count
+				}
+			}
+			attributes["kubernetes_version"] = str(props.CurrentKubernetesVersion)
+			if attributes["kubernetes_version"] == "" {
+				attributes["kubernetes_version"] = str(props.KubernetesVersion)
+			}
+			attributes["fqdn"] = str(props.Fqdn)
+			attributes["node_resource_group"] = str(props.NodeResourceGroup)
+			attributes["provisioning_state"] = str(props.ProvisioningState)
+			attributes["node_pools"] = fmt.Sprintf("%d", len(props.AgentPoolProfiles))
+			attributes["nodes"] = fmt.Sprintf("%d", nodes)
+			if props.PowerState != nil {
+				state = str(props.PowerState.Code)
+			}
+		}
+		if cluster.SystemData != nil {
+			attributes["created"] = timeString(cluster.SystemData.CreatedAt)
+		}
+
+		resources = append(resources, cloudy.Resource{
+			ID:         str(cluster.ID),
+			Name:       str(cluster.Name),
+			Type:       "AKS Cluster",
+			State:      state,
+			Region:     location,
+			Tags:       tags(cluster.Tags),
+			Attributes: attributes,
+		})
+	}
+
+	return resources, err
+}
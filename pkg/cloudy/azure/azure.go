@@ -0,0 +1,216 @@
+// Package azure is Cloudy's Azure provider. It lists VMs, storage
+// accounts, AKS clusters, SQL databases and function apps in one or more
+// subscriptions, region by region, as cloudy.Resources, so a Scanner from
+// cloudy.NewProviderScanner scans Azure the way it scans AWS.
+package azure
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/arm"
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/resources/armsubscriptions"
+	"github.com/alwindoss/cloudy/pkg/cloudy"
+	"github.com/alwindoss/cloudy/pkg/cloudy/internal/listings"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/smithy-go"
+)
+
+// ProviderName names the Azure provider in requests and on its resources.
+const ProviderName = "azure"
+
+// listingTTL is how long a subscription-wide listing is shared. Storage
+// accounts, SQL servers and VM power states can only be listed for a whole
+// subscription, so the regions of a scan, which all ask for them at about
+// the same time, take theirs out of one listing instead of each listing
+// the subscription again.
+const listingTTL = 30 * time.Second
+
+// Provider is Azure, scanned with one credential across subscriptions.
+type Provider struct {
+	cred          azcore.TokenCredential
+	subscriptions []string
+	options       *arm.ClientOptions
+
+	listings *listings.Cache
+}
+
+// NewProvider returns the Azure provider for subscriptions, authenticating
+// with cred, e.g. from azidentity.NewDefaultAzureCredential. options may
+// be nil, or select a sovereign cloud.
+func NewProvider(cred azcore.TokenCredential, subscriptions []string, options *arm.ClientOptions) *Provider {
+	return &Provider{cred: cred, subscriptions: subscriptions, options: options, listings: &listings.Cache{TTL: listingTTL}}
+}
+
+func (p *Provider) Name() string { return ProviderName }
+
+// Regions returns the physical regions, like eastus, available to any of
+// the subscriptions.
+func (p *Provider) Regions(ctx context.Context) ([]string, error) {
+	client, err := armsubscriptions.NewClient(p.cred, p.options)
+	if err != nil {
+		return nil, err
+	}
+
+	seen := make(map[string]bool)
+	var regions []string
+	for _, subscription := range p.subscriptions {
+		pager := client.NewListLocationsPager(subscription, nil)
+		for pager.More() {
+			page, err := pager.NextPage(ctx)
+			if err != nil {
+				return nil, apiError(err)
+			}
+			for _, location := range page.Value {
+				if location.Metadata == nil || location.Metadata.RegionType == nil || *location.Metadata.RegionType != armsubscriptions.RegionTypePhysical {
+					continue
+				}
+				name := str(location.Name)
+				if !seen[name] {
+					seen[name] = true
+					regions = append(regions, name)
+				}
+			}
+		}
+	}
+	sort.Strings(regions)
+	return regions, nil
+}
+
+// Listers returns a lister for each supported service.
+func (p *Provider) Listers() []cloudy.ServiceLister {
+	return []cloudy.ServiceLister{
+		lister{p: p, name: "Azure VMs", types: []string{"Azure VM"}, actions: []string{"Microsoft.Compute/virtualMachines/read"}, list: listVMs},
+		lister{p: p, name: "Azure storage accounts", types: []string{"Azure Storage Account"}, actions: []string{"Microsoft.Storage/storageAccounts/read"}, list: listStorageAccounts},
+		lister{p: p, name: "AKS clusters", types: []string{"AKS Cluster"}, actions: []string{"Microsoft.ContainerService/managedClusters/read"}, list: listAKSClusters},
+		lister{p: p, name: "Azure SQL databases", types: []string{"Azure SQL Database"}, actions: []string{"Microsoft.Sql/servers/read", "Microsoft.Sql/servers/databases/read"}, list: listSQLDatabases},
+		lister{p: p, name: "Azure function apps", types: []string{"Azure Function App"}, actions: []string{"Microsoft.Web/sites/read"}, list: listFunctionApps},
+	}
+}
+
+// listFunc lists a service's resources in one subscription and location.
+type listFunc func(ctx context.Context, p *Provider, subscription, location string) ([]cloudy.Resource, error)
+
+// lister is a cloudy.ServiceLister for an Azure service. List is passed
+// the location to list as the config's region, and lists it in every
+// subscription.
+type lister struct {
+	p       *Provider
+	name    string
+	types   []string
+	actions []string
+	list    listFunc
+}
+
+func (l lister) Name() string    { return l.name }
+func (l lister) Types() []string { return l.types }
+func (l lister) Global() bool    { return false }
+
+// IAMActions are the Azure RBAC actions List needs, all of which the
+// built-in Reader role has.
+func (l lister) IAMActions() []string { return l.actions }
+
+func (l lister) List(ctx context.Context, cfg aws.Config, _ []string) ([]cloudy.Resource, error) {
+	var resources []cloudy.Resource
+	var errs []error
+	for _, subscription := range l.p.subscriptions {
+		listed, err := l.list(ctx, l.p, subscription, cfg.Region)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("subscription %s: %w", subscription, err))
+		}
+		resources = append(resources, listed...)
+	}
+	return resources, errors.Join(errs...)
+}
+
+// checkMaxResults fails once n items have been listed, at the cap of the
+// scan ctx belongs to.
+func checkMaxResults(ctx context.Context, n int) error {
+	if limit := cloudy.MaxResults(ctx); n >= limit {
+		return fmt.Errorf("%w: stopped after %d", cloudy.ErrMaxResults, limit)
+	}
+	return nil
+}
+
+// responseError gives an Azure error's code and status to cloudy's
+// ServiceErrors, which read them as they read an AWS error's.
+type responseError struct {
+	*azcore.ResponseError
+}
+
+func (e responseError) ErrorCode() string    { return e.ResponseError.ErrorCode }
+func (e responseError) ErrorMessage() string { return e.ResponseError.Error() }
+func (e responseError) HTTPStatusCode() int  { return e.StatusCode }
+func (e responseError) Unwrap() error        { return e.ResponseError }
+
+func (e responseError) ErrorFault() smithy.ErrorFault {
+	if e.StatusCode >= 500 {
+		return smithy.FaultServer
+	}
+	return smithy.FaultClient
+}
+
+// apiError wraps err in a responseError if it is an Azure error response.
+func apiError(err error) error {
+	var respErr *azcore.ResponseError
+	if errors.As(err, &respErr) {
+		return responseError{respErr}
+	}
+	return err
+}
+
+// baseAttributes returns the attributes every resource carries: its
+// subscription and resource group, from its resource ID.
+func baseAttributes(id string) map[string]string {
+	attributes := make(map[string]string)
+	if parsed, err := arm.ParseResourceID(id); err == nil {
+		attributes["subscription_id"] = parsed.SubscriptionID
+		attributes["resource_group"] = parsed.ResourceGroupName
+	}
+	return attributes
+}
+
+// inLocation reports whether an Azure location, as returned on a
+// resource, is location.
+func inLocation(resourceLocation *string, location string) bool {
+	return strings.EqualFold(strings.ReplaceAll(str(resourceLocation), " ", ""), location)
+}
+
+// lastSegment returns the name at the end of a resource ID.
+func lastSegment(id string) string {
+	return id[strings.LastIndex(id, "/")+1:]
+}
+
+func str[T ~string](s *T) string {
+	if s == nil {
+		return ""
+	}
+	return string(*s)
+}
+
+func tags(tags map[string]*string) map[string]string {
+	converted := make(map[string]string, len(tags))
+	for key, value := range tags {
+		converted[key] = str(value)
+	}
+	return converted
+}
+
+func timeString(t *time.Time) string {
+	if t == nil {
+		return ""
+	}
+	return t.String()
+}
+
+func boolString(b *bool) string {
+	if b == nil {
+		return ""
+	}
+	return fmt.Sprintf("%t", *b)
+}
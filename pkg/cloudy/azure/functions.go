@@ -0,0 +1,71 @@
+package azure
+
+import (
+	"context"
+	"strings"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/appservice/armappservice/v5"
+	"github.com/alwindoss/cloudy/pkg/cloudy"
+	"github.com/alwindoss/cloudy/pkg/cloudy/internal/listings"
+)
+
+func listFunctionApps(ctx context.Context, p *Provider, subscription, location string) ([]cloudy.Resource, error) {
+	// Sites are listed for the whole subscription and shared between its
+	// locations. Sites of every kind are listed; function apps are those
+	// whose kind includes functionapp.
+	sites, err := listings.Shared(ctx, p.listings, "web-apps/"+subscription, func(ctx context.Context) ([]*armappservice.Site, error) {
+		client, err := armappservice.NewWebAppsClient(subscription, p.cred, p.options)
+		if err != nil {
+			return nil, err
+		}
+
+		var sites []*armappservice.Site
+		pager := client.NewListPager(nil)
+		for pager.More() {
+			if err := checkMaxResults(ctx, len(sites)); err != nil {
+				return sites, err
+			}
+			page, err := pager.NextPage(ctx)
+			if err != nil {
+				return sites, apiError(err)
+			}
+			sites = append(sites, page.Value...)
+		}
+		return sites, nil
+	})
+
+	var resources []cloudy.Resource
+	for _, site := range sites {
+		if !inLocation(site.Location, location) || !strings.Contains(strings.ToLower(str(site.Kind)), "functionapp") {
+			continue
+		}
+
+		attributes := baseAttributes(str(site.ID))
+		attributes["kind"] = str(site.Kind)
+		var state string
+		if props := site.Properties; props != nil {
+			state = str(props.State)
+			os := "windows"
+			if props.Reserved != nil && *props.Reserved {
+				os = "linux"
+			}
+			attributes["os"] = os
+			attributes["default_host_name"] = str(props.DefaultHostName)
+			attributes["https_only"] = boolString(props.HTTPSOnly)
+			attributes["app_service_plan"] = lastSegment(str(props.ServerFarmID))
+			attributes["last_modified"] = timeString(props.LastModifiedTimeUTC)
+		}
+
+		resources = append(resources, cloudy.Resource{
+			ID:         str(site.ID),
+			Name:       str(site.Name),
+			Type:       "Azure Function App",
+			State:      state,
+			Region:     location,
+			Tags:       tags(site.Tags),
+			Attributes: attributes,
+		})
+	}
+
+	return resources, err
+}
@@ -0,0 +1,109 @@
+package azure
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/arm"
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/sql/armsql"
+	"github.com/alwindoss/cloudy/pkg/cloudy"
+	"github.com/alwindoss/cloudy/pkg/cloudy/internal/listings"
+)
+
+func listSQLDatabases(ctx context.Context, p *Provider, subscription, location string) ([]cloudy.Resource, error) {
+	// SQL servers can only be listed for the whole subscription
+	servers, err := listings.Shared(ctx, p.listings, "sql-servers/"+subscription, func(ctx context.Context) ([]*armsql.Server, error) {
+		client, err := armsql.NewServersClient(subscription, p.cred, p.options)
+		if err != nil {
+			return nil, err
+		}
+
+		var servers []*armsql.Server
+		pager := client.NewListPager(nil)
+		for pager.More() {
+			page, err := pager.NextPage(ctx)
+			if err != nil {
+				return servers, apiError(err)
+			}
+			servers = append(servers, page.Value...)
+		}
+		return servers, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	client, err := armsql.NewDatabasesClient(subscription, p.cred, p.options)
+	if err != nil {
+		return nil, err
+	}
+
+	var resources []cloudy.Resource
+	var errs []error
+	for _, server := range servers {
+		if !inLocation(server.Location, location) {
+			continue
+		}
+		parsed, err := arm.ParseResourceID(str(server.ID))
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+
+		pager := client.NewListByServerPager(parsed.ResourceGroupName, parsed.Name, nil)
+		for pager.More() {
+			if err := checkMaxResults(ctx, len(resources)); err != nil {
+				return resources, errors.Join(append(errs, err)...)
+			}
+			page, err := pager.NextPage(ctx)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("server %s: %w", parsed.Name, apiError(err)))
+				break
+			}
+
+			for _, database := range page.Value {
+				// Every server has a master database, which isn't billed
+				// or managed separately
+				if str(database.Name) == "master" {
+					continue
+				}
+				resources = append(resources, sqlDatabaseResource(database, parsed.Name, location))
+			}
+		}
+	}
+
+	return resources, errors.Join(errs...)
+}
+
+func sqlDatabaseResource(database *armsql.Database, server, location string) cloudy.Resource {
+	attributes := baseAttributes(str(database.ID))
+	attributes["server"] = server
+	if database.SKU != nil {
+		attributes["sku"] = str(database.SKU.Name)
+		attributes["tier"] = str(database.SKU.Tier)
+	}
+
+	var state string
+	if props := database.Properties; props != nil {
+		state = str(props.Status)
+		if props.MaxSizeBytes != nil {
+			attributes["max_size_bytes"] = fmt.Sprintf("%d", *props.MaxSizeBytes)
+		}
+		attributes["zone_redundant"] = boolString(props.ZoneRedundant)
+		attributes["created"] = timeString(props.CreationDate)
+		if props.ElasticPoolID != nil {
+			attributes["elastic_pool"] = lastSegment(*props.ElasticPoolID)
+		}
+	}
+
+	return cloudy.Resource{
+		ID:         str(database.ID),
+		Name:       str(database.Name),
+		Type:       "Azure SQL Database",
+		State:      state,
+		Region:     location,
+		Tags:       tags(database.Tags),
+		Attributes: attributes,
+	}
+}
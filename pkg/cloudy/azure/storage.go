@@ -0,0 +1,70 @@
+package azure
+
+import (
+	"context"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/storage/armstorage"
+	"github.com/alwindoss/cloudy/pkg/cloudy"
+	"github.com/alwindoss/cloudy/pkg/cloudy/internal/listings"
+)
+
+func listStorageAccounts(ctx context.Context, p *Provider, subscription, location string) ([]cloudy.Resource, error) {
+	// Storage accounts can only be listed for the whole subscription
+	accounts, err := listings.Shared(ctx, p.listings, "storage-accounts/"+subscription, func(ctx context.Context) ([]*armstorage.Account, error) {
+		client, err := armstorage.NewAccountsClient(subscription, p.cred, p.options)
+		if err != nil {
+			return nil, err
+		}
+
+		var accounts []*armstorage.Account
+		pager := client.NewListPager(nil)
+		for pager.More() {
+			if err := checkMaxResults(ctx, len(accounts)); err != nil {
+				return accounts, err
+			}
+			page, err := pager.NextPage(ctx)
+			if err != nil {
+				return accounts, apiError(err)
+			}
+			accounts = append(accounts, page.Value...)
+		}
+		return accounts, nil
+	})
+
+	var resources []cloudy.Resource
+	for _, account := range accounts {
+		if !inLocation(account.Location, location) {
+			continue
+		}
+
+		attributes := baseAttributes(str(account.ID))
+		attributes["kind"] = str(account.Kind)
+		if account.SKU != nil {
+			attributes["sku"] = str(account.SKU.Name)
+		}
+		var state string
+		if props := account.Properties; props != nil {
+			state = str(props.StatusOfPrimary)
+			attributes["provisioning_state"] = str(props.ProvisioningState)
+			attributes["access_tier"] = str(props.AccessTier)
+			attributes["https_only"] = boolString(props.EnableHTTPSTrafficOnly)
+			attributes["minimum_tls_version"] = str(props.MinimumTLSVersion)
+			attributes["allow_blob_public_access"] = boolString(props.AllowBlobPublicAccess)
+			attributes["public_network_access"] = str(props.PublicNetworkAccess)
+			attributes["hns_enabled"] = boolString(props.IsHnsEnabled)
+			attributes["created"] = timeString(props.CreationTime)
+		}
+
+		resources = append(resources, cloudy.Resource{
+			ID:         str(account.ID),
+			Name:       str(account.Name),
+			Type:       "Azure Storage Account",
+			State:      state,
+			Region:     location,
+			Tags:       tags(account.Tags),
+			Attributes: attributes,
+		})
+	}
+
+	return resources, err
+}
@@ -0,0 +1,112 @@
+package azure
+
+import (
+	"context"
+	"strings"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/to"
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/compute/armcompute/v6"
+	"github.com/alwindoss/cloudy/pkg/cloudy"
+	"github.com/alwindoss/cloudy/pkg/cloudy/internal/listings"
+)
+
+func listVMs(ctx context.Context, p *Provider, subscription, location string) ([]cloudy.Resource, error) {
+	client, err := armcompute.NewVirtualMachinesClient(subscription, p.cred, p.options)
+	if err != nil {
+		return nil, err
+	}
+
+	var resources []cloudy.Resource
+	pager := client.NewListByLocationPager(location, nil)
+	for pager.More() {
+		if err := checkMaxResults(ctx, len(resources)); err != nil {
+			return resources, err
+		}
+		page, err := pager.NextPage(ctx)
+		if err != nil {
+			return resources, apiError(err)
+		}
+
+		for _, vm := range page.Value {
+			attributes := baseAttributes(str(vm.ID))
+			if props := vm.Properties; props != nil {
+				attributes["vm_id"] = str(props.VMID)
+				attributes["provisioning_state"] = str(props.ProvisioningState)
+				attributes["created"] = timeString(props.TimeCreated)
+				if props.HardwareProfile != nil {
+					attributes["vm_size"] = str(props.HardwareProfile.VMSize)
+				}
+				if props.StorageProfile != nil && props.StorageProfile.OSDisk != nil {
+					attributes["os_type"] = str(props.StorageProfile.OSDisk.OSType)
+				}
+				if props.Priority != nil {
+					attributes["priority"] = str(props.Priority)
+				}
+			}
+			zones := make([]string, 0, len(vm.Zones))
+			for _, zone := range vm.Zones {
+				zones = append(zones, str(zone))
+			}
+			if len(zones) > 0 {
+				attributes["zones"] = strings.Join(zones, ",")
+			}
+
+			resources = append(resources, cloudy.Resource{
+				ID:         str(vm.ID),
+				Name:       str(vm.Name),
+				Type:       "Azure VM",
+				Region:     location,
+				Tags:       tags(vm.Tags),
+				Attributes: attributes,
+			})
+		}
+	}
+
+	// Listing by location leaves out power states, which only come with
+	// a listing of the whole subscription
+	states, err := listings.Shared(ctx, p.listings, "vm-states/"+subscription, func(ctx context.Context) ([]*armcompute.VirtualMachine, error) {
+		return listVMStates(ctx, client)
+	})
+	if err != nil {
+		return resources, err
+	}
+	powerStates := make(map[string]string, len(states))
+	for _, vm := range states {
+		powerStates[strings.ToLower(str(vm.ID))] = powerState(vm)
+	}
+	for i := range resources {
+		resources[i].State = powerStates[strings.ToLower(resources[i].ID)]
+	}
+
+	return resources, nil
+}
+
+func listVMStates(ctx context.Context, client *armcompute.VirtualMachinesClient) ([]*armcompute.VirtualMachine, error) {
+	var vms []*armcompute.VirtualMachine
+	pager := client.NewListAllPager(&armcompute.VirtualMachinesClientListAllOptions{StatusOnly: to.Ptr("true")})
+	for pager.More() {
+		if err := checkMaxResults(ctx, len(vms)); err != nil {
+			return vms, err
+		}
+		page, err := pager.NextPage(ctx)
+		if err != nil {
+			return vms, apiError(err)
+		}
+		vms = append(vms, page.Value...)
+	}
+	return vms, nil
+}
+
+// powerState returns a VM's power state, like running or deallocated,
+// from the PowerState/ status of its instance view.
+func powerState(vm *armcompute.VirtualMachine) string {
+	if vm.Properties == nil || vm.Properties.InstanceView == nil {
+		return ""
+	}
+	for _, status := range vm.Properties.InstanceView.Statuses {
+		if state, ok := strings.CutPrefix(str(status.Code), "PowerState/"); ok {
+			return state
+		}
+	}
+	return ""
+}
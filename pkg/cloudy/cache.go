@@ -0,0 +1,189 @@
+package cloudy
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ResultCache keeps what each lister listed for a while, so repeated scans
+// of the same account and region within its TTL don't call AWS again.
+// Scanners sharing a cache share its results. Only complete listings of
+// every state are cached; a scan filtering by state is served from them,
+// since callers filter the results anyway.
+//
+// Identical listings that run at the same time, such as when several
+// clients ask for the same regions at once, are collapsed into one call to
+// AWS whose result every caller gets.
+type ResultCache struct {
+	ttl time.Duration
+
+	mu      sync.Mutex
+	entries map[cacheKey]cacheEntry
+
+	flightsMu sync.Mutex
+	flights   map[flightKey]*flight
+}
+
+type cacheKey struct {
+	account, region, service string
+}
+
+type cacheEntry struct {
+	resources []Resource
+	expires   time.Time
+}
+
+// NewResultCache returns a cache keeping results for ttl.
+func NewResultCache(ttl time.Duration) *ResultCache {
+	return &ResultCache{
+		ttl:     ttl,
+		entries: make(map[cacheKey]cacheEntry),
+		flights: make(map[flightKey]*flight),
+	}
+}
+
+func (c *ResultCache) get(key cacheKey) ([]Resource, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry, ok := c.entries[key]
+	if !ok || time.Now().After(entry.expires) {
+		return nil, false
+	}
+	return entry.resources, true
+}
+
+// put stores resources for key until the TTL is up, or until if that is
+// later, dropping expired entries on the way so regions and accounts that
+// are no longer scanned don't pile up.
+func (c *ResultCache) put(key cacheKey, resources []Resource, until time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := time.Now()
+	for k, entry := range c.entries {
+		if now.After(entry.expires) {
+			delete(c.entries, k)
+		}
+	}
+	expires := now.Add(c.ttl)
+	if until.After(expires) {
+		expires = until
+	}
+	c.entries[key] = cacheEntry{resources: resources, expires: expires}
+}
+
+// flightKey identifies a listing in flight. Unlike cached results, one
+// filtered by state can only be shared with listings of the same states.
+type flightKey struct {
+	cacheKey
+	states string
+}
+
+// flight is a listing shared by every caller waiting for it. It runs on
+// its own context, so one caller giving up or running out of time doesn't
+// fail the others; it is only cancelled once every caller has given up.
+type flight struct {
+	done      chan struct{}
+	resources []Resource
+	err       error
+
+	waiters int
+	cancel  context.CancelFunc
+}
+
+// share returns the result of list, joining a listing of the same key and
+// states already in flight rather than starting another. A new listing
+// keeps ctx's values but is bounded by timeout rather than ctx's deadline.
+// share returns early with ctx's error if ctx is done first.
+func (c *ResultCache) share(ctx context.Context, key cacheKey, states []string, timeout time.Duration, list func(context.Context) ([]Resource, error)) ([]Resource, error) {
+	fk := flightKey{cacheKey: key, states: strings.Join(states, "\x00")}
+
+	c.flightsMu.Lock()
+	f, ok := c.flights[fk]
+	if !ok {
+		flightCtx, cancel := withTimeout(context.WithoutCancel(ctx), timeout)
+		f = &flight{done: make(chan struct{}), cancel: cancel}
+		c.flights[fk] = f
+
+		go func() {
+			defer cancel()
+			f.resources, f.err = list(flightCtx)
+			c.land(fk, f)
+			close(f.done)
+		}()
+	}
+	f.waiters++
+	c.flightsMu.Unlock()
+
+	select {
+	case <-f.done:
+		c.leave(fk, f)
+		return f.resources, f.err
+	case <-ctx.Done():
+		c.leave(fk, f)
+		return nil, ctx.Err()
+	}
+}
+
+// land forgets f once it has finished, so later listings start afresh.
+func (c *ResultCache) land(fk flightKey, f *flight) {
+	c.flightsMu.Lock()
+	defer c.flightsMu.Unlock()
+	if c.flights[fk] == f {
+		delete(c.flights, fk)
+	}
+}
+
+// leave drops a caller from f, cancelling f if it was the last one.
+func (c *ResultCache) leave(fk flightKey, f *flight) {
+	c.flightsMu.Lock()
+	defer c.flightsMu.Unlock()
+	f.waiters--
+	if f.waiters == 0 {
+		f.cancel()
+		if c.flights[fk] == f {
+			delete(c.flights, fk)
+		}
+	}
+}
+
+type refreshKey struct{}
+
+// WithRefresh returns a context whose scans list every service from AWS
+// instead of using cached results, caching what they list in their place.
+func WithRefresh(ctx context.Context) context.Context {
+	return context.WithValue(ctx, refreshKey{}, true)
+}
+
+func refreshing(ctx context.Context) bool {
+	refresh, _ := ctx.Value(refreshKey{}).(bool)
+	return refresh
+}
+
+type cacheUntilKey struct{}
+
+// WithCacheUntil returns a context whose scans keep what they list cached
+// until at, if that is later than the cache's TTL would, so results a
+// scheduled scan lists can be served until the next one replaces them.
+func WithCacheUntil(ctx context.Context, at time.Time) context.Context {
+	return context.WithValue(ctx, cacheUntilKey{}, at)
+}
+
+func cacheUntil(ctx context.Context) time.Time {
+	at, _ := ctx.Value(cacheUntilKey{}).(time.Time)
+	return at
+}
+
+// cacheAccount returns the ID of the account the Scanner's credentials
+// belong to, which scopes its cached results, or "" if the Scanner has no
+// cache, doesn't scan AWS or the account can't be told, in which case
+// nothing is cached.
+func (s *Scanner) cacheAccount(ctx context.Context) string {
+	if s.cache == nil {
+		return ""
+	}
+	return s.Account(ctx).ID
+}
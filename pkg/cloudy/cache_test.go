@@ -0,0 +1,279 @@
+package cloudy
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestResultCacheExpires(t *testing.T) {
+	c := NewResultCache(time.Minute)
+	live := cacheKey{"123456789012", "us-east-1", "ec2"}
+	stale := cacheKey{"123456789012", "eu-west-1", "ec2"}
+
+	if _, ok := c.get(live); ok {
+		t.Fatal("get on an empty cache hit")
+	}
+	c.put(live, []Resource{{ID: "i-1"}}, time.Time{})
+	if resources, ok := c.get(live); !ok || len(resources) != 1 {
+		t.Errorf("get = %v, %v; want the stored listing", resources, ok)
+	}
+
+	c.entries[stale] = cacheEntry{resources: []Resource{{ID: "i-2"}}, expires: time.Now().Add(-time.Second)}
+	if _, ok := c.get(stale); ok {
+		t.Error("get returned an expired listing")
+	}
+
+	// Storing anything drops what has expired
+	c.put(live, nil, time.Time{})
+	if _, ok := c.entries[stale]; ok {
+		t.Error("expired entry kept after put")
+	}
+	if _, ok := c.get(live); !ok {
+		t.Error("an empty listing isn't cached")
+	}
+}
+
+func TestResultCacheKeepsUntil(t *testing.T) {
+	c := NewResultCache(time.Minute)
+	key := cacheKey{"123456789012", "us-east-1", "ec2"}
+
+	until := time.Now().Add(time.Hour)
+	c.put(key, nil, until)
+	if expires := c.entries[key].expires; !expires.Equal(until) {
+		t.Errorf("kept until %s, want %s", expires, until)
+	}
+
+	// The TTL still applies when until is sooner
+	c.put(key, nil, time.Now().Add(time.Second))
+	if expires := c.entries[key].expires; time.Until(expires) < 59*time.Second {
+		t.Errorf("kept for %s, want the 1m TTL", time.Until(expires))
+	}
+}
+
+func TestRefreshing(t *testing.T) {
+	if refreshing(context.Background()) {
+		t.Error("refreshing without WithRefresh")
+	}
+	if !refreshing(WithRefresh(context.Background())) {
+		t.Error("not refreshing with WithRefresh")
+	}
+}
+
+// blockingList is a listing that counts its calls and blocks until
+// released or its context is done.
+type blockingList struct {
+	calls    atomic.Int32
+	release  chan struct{}
+	canceled chan struct{}
+}
+
+func newBlockingList() *blockingList {
+	return &blockingList{release: make(chan struct{}), canceled: make(chan struct{}, 16)}
+}
+
+func (l *blockingList) list(ctx context.Context) ([]Resource, error) {
+	l.calls.Add(1)
+	select {
+	case <-l.release:
+		return []Resource{{ID: "i-1"}}, nil
+	case <-ctx.Done():
+		l.canceled <- struct{}{}
+		return nil, ctx.Err()
+	}
+}
+
+// waitForWaiters waits until the flight for fk has n callers waiting on
+// it.
+func waitForWaiters(t *testing.T, c *ResultCache, fk flightKey, n int) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		c.flightsMu.Lock()
+		f := c.flights[fk]
+		waiters := 0
+		if f != nil {
+			waiters = f.waiters
+		}
+		c.flightsMu.Unlock()
+		if waiters == n {
+			return
+		}
+		time.Sleep(time.Millisecond)
+	}
+	t.Fatalf("flight never had %d waiters", n)
+}
+
+// flightCount returns how many listings are in flight.
+func flightCount(c *ResultCache) int {
+	c.flightsMu.Lock()
+	defer c.flightsMu.Unlock()
+	return len(c.flights)
+}
+
+type shareResult struct {
+	resources []Resource
+	err       error
+}
+
+func TestShareCollapsesConcurrentListings(t *testing.T) {
+	c := NewResultCache(time.Minute)
+	key := cacheKey{"123456789012", "us-east-1", "ec2"}
+	l := newBlockingList()
+
+	const callers = 5
+	results := make(chan shareResult, callers)
+	for range callers {
+		go func() {
+			resources, err := c.share(context.Background(), key, nil, time.Minute, l.list)
+			results <- shareResult{resources, err}
+		}()
+	}
+	waitForWaiters(t, c, flightKey{cacheKey: key}, callers)
+	close(l.release)
+
+	for range callers {
+		result := <-results
+		if result.err != nil || len(result.resources) != 1 {
+			t.Errorf("share = %v, %v; want the shared listing", result.resources, result.err)
+		}
+	}
+	if n := l.calls.Load(); n != 1 {
+		t.Errorf("list called %d times, want 1", n)
+	}
+	if n := flightCount(c); n != 0 {
+		t.Errorf("%d flights left after landing, want 0", n)
+	}
+}
+
+func TestShareKeepsStatesApart(t *testing.T) {
+	c := NewResultCache(time.Minute)
+	key := cacheKey{"123456789012", "us-east-1", "ec2"}
+	l := newBlockingList()
+
+	var wg sync.WaitGroup
+	for _, states := range [][]string{nil, {"running"}, {"running", "stopped"}, {"running"}} {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			c.share(context.Background(), key, states, time.Minute, l.list)
+		}()
+	}
+	waitForWaiters(t, c, flightKey{cacheKey: key, states: "running"}, 2)
+	waitForWaiters(t, c, flightKey{cacheKey: key}, 1)
+	waitForWaiters(t, c, flightKey{cacheKey: key, states: "running\x00stopped"}, 1)
+	close(l.release)
+	wg.Wait()
+
+	if n := l.calls.Load(); n != 3 {
+		t.Errorf("list called %d times, want once per set of states", n)
+	}
+}
+
+func TestShareOneCallerGivingUp(t *testing.T) {
+	c := NewResultCache(time.Minute)
+	key := cacheKey{"123456789012", "us-east-1", "ec2"}
+	fk := flightKey{cacheKey: key}
+	l := newBlockingList()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	quitter := make(chan error, 1)
+	go func() {
+		_, err := c.share(ctx, key, nil, time.Minute, l.list)
+		quitter <- err
+	}()
+	stayer := make(chan shareResult, 1)
+	go func() {
+		resources, err := c.share(context.Background(), key, nil, time.Minute, l.list)
+		stayer <- shareResult{resources, err}
+	}()
+	waitForWaiters(t, c, fk, 2)
+
+	cancel()
+	if err := <-quitter; !errors.Is(err, context.Canceled) {
+		t.Errorf("cancelled caller got %v, want context.Canceled", err)
+	}
+	waitForWaiters(t, c, fk, 1)
+	select {
+	case <-l.canceled:
+		t.Fatal("listing cancelled while a caller still waits on it")
+	default:
+	}
+
+	close(l.release)
+	if result := <-stayer; result.err != nil || len(result.resources) != 1 {
+		t.Errorf("remaining caller got %v, %v; want the listing", result.resources, result.err)
+	}
+}
+
+func TestShareEveryCallerGivingUp(t *testing.T) {
+	c := NewResultCache(time.Minute)
+	key := cacheKey{"123456789012", "us-east-1", "ec2"}
+	fk := flightKey{cacheKey: key}
+	l := newBlockingList()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	errs := make(chan error, 2)
+	for range 2 {
+		go func() {
+			_, err := c.share(ctx, key, nil, time.Minute, l.list)
+			errs <- err
+		}()
+	}
+	waitForWaiters(t, c, fk, 2)
+	cancel()
+	for range 2 {
+		if err := <-errs; !errors.Is(err, context.Canceled) {
+			t.Errorf("share = %v, want context.Canceled", err)
+		}
+	}
+
+	select {
+	case <-l.canceled:
+	case <-time.After(5 * time.Second):
+		t.Fatal("listing not cancelled once every caller gave up")
+	}
+	if n := flightCount(c); n != 0 {
+		t.Errorf("%d flights left after every caller gave up, want 0", n)
+	}
+
+	// A later caller starts a listing of its own rather than joining the
+	// cancelled one
+	close(l.release)
+	resources, err := c.share(context.Background(), key, nil, time.Minute, l.list)
+	if err != nil || len(resources) != 1 {
+		t.Errorf("share after cancellation = %v, %v; want a new listing", resources, err)
+	}
+	if n := l.calls.Load(); n != 2 {
+		t.Errorf("list called %d times, want 2", n)
+	}
+}
+
+func TestShareBoundsListingByTimeout(t *testing.T) {
+	c := NewResultCache(time.Minute)
+	key := cacheKey{"123456789012", "us-east-1", "ec2"}
+
+	type valueKey struct{}
+	ctx, cancel := context.WithTimeout(context.WithValue(context.Background(), valueKey{}, "kept"), time.Hour)
+	defer cancel()
+
+	var deadline time.Time
+	var value any
+	_, err := c.share(ctx, key, nil, time.Second, func(ctx context.Context) ([]Resource, error) {
+		deadline, _ = ctx.Deadline()
+		value = ctx.Value(valueKey{})
+		return nil, nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if remaining := time.Until(deadline); remaining > time.Second {
+		t.Errorf("listing deadline %s away, want the 1s timeout rather than the caller's", remaining)
+	}
+	if value != "kept" {
+		t.Errorf("listing context value = %v, want the caller's", value)
+	}
+}
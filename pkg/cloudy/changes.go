@@ -0,0 +1,155 @@
+package cloudy
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudtrail"
+	cloudtrailtypes "github.com/aws/aws-sdk-go-v2/service/cloudtrail/types"
+)
+
+// cloudTrailLag is how long CloudTrail can take to make an event visible
+// to LookupEvents. Changes are looked up from this long before a baseline
+// was listed, so none made while it was being listed are missed.
+const cloudTrailLag = 15 * time.Minute
+
+// maxChangeEvents is how many CloudTrail events ChangedTypes looks through
+// before giving up with ErrTooManyChanges. LookupEvents allows two calls a
+// second, so this is about ten seconds of paging.
+const maxChangeEvents = 1000
+
+// ErrTooManyChanges is returned by ChangedTypes when a region has more
+// recent changes than are worth looking through; listing it in full is
+// quicker.
+var ErrTooManyChanges = errors.New("too many changes to look through")
+
+// Baseline is a region's earlier full listing, of every resource type,
+// and when it was taken.
+type Baseline struct {
+	Resources []Resource
+	ListedAt  time.Time
+}
+
+// ChangedTypes returns the resource types listed in region whose service
+// CloudTrail recorded write API calls to, such as a RunInstances for EC2
+// instances, from shortly before since. Changes are tracked per service,
+// so any write call to a service marks all of its listers' types as
+// changed. It fails with ErrTooManyChanges if there are more than a
+// thousand calls to look through.
+func (s *Scanner) ChangedTypes(ctx context.Context, region string, since time.Time) ([]string, error) {
+	sources := make(map[string][]string)
+	for _, lister := range append(s.provider.Listers(), s.listers...) {
+		if !runsIn(lister, region) {
+			continue
+		}
+		for _, source := range eventSources(lister) {
+			sources[source] = append(sources[source], lister.Types()...)
+		}
+	}
+
+	client := Client(withClients(ctx, s.clients), s.RegionConfig(region), cloudtrail.NewFromConfig)
+	paginator := cloudtrail.NewLookupEventsPaginator(client, &cloudtrail.LookupEventsInput{
+		LookupAttributes: []cloudtrailtypes.LookupAttribute{{
+			AttributeKey:   cloudtrailtypes.LookupAttributeKeyReadOnly,
+			AttributeValue: aws.String("false"),
+		}},
+		StartTime:  aws.Time(since.Add(-cloudTrailLag)),
+		MaxResults: aws.Int32(50),
+	})
+
+	changed := make(map[string]bool)
+	events := 0
+	for paginator.HasMorePages() {
+		if events >= maxChangeEvents {
+			return nil, ErrTooManyChanges
+		}
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return nil, err
+		}
+		events += len(page.Events)
+		for _, event := range page.Events {
+			for _, resourceType := range sources[aws_string_value(event.EventSource)] {
+				changed[resourceType] = true
+			}
+		}
+	}
+
+	resourceTypes := make([]string, 0, len(changed))
+	for resourceType := range changed {
+		resourceTypes = append(resourceTypes, resourceType)
+	}
+	sort.Strings(resourceTypes)
+	return resourceTypes, nil
+}
+
+// eventSources returns the CloudTrail event sources of the services
+// lister calls, such as ec2.amazonaws.com, from its IAM actions. Cloud
+// Control reads, which go through CloudFormation, are left out.
+func eventSources(lister ServiceLister) []string {
+	var sources []string
+	seen := make(map[string]bool)
+	for _, action := range lister.IAMActions() {
+		service, _, _ := strings.Cut(action, ":")
+		if service == "cloudformation" || seen[service] {
+			continue
+		}
+		seen[service] = true
+		sources = append(sources, service+".amazonaws.com")
+	}
+	return sources
+}
+
+// StreamIncremental is a StreamRegions that brings baselines up to date
+// instead of listing every service again: only the types ChangedTypes
+// reports for a region are listed, bypassing any cache, and replace that
+// type's resources in the baseline. Regions without a baseline, or with
+// too many changes to look through, are listed in full. If CloudTrail
+// can't be queried the region is listed in full too, and the CloudTrail
+// error is reported with it.
+//
+// Each region's result is, like a baseline, a full listing of every type.
+// Baselines are left unchanged.
+// CloudTrail records S3 bucket changes in the bucket's region, so buckets
+// created outside the scanned regions only show up on the next full scan.
+func (s *Scanner) StreamIncremental(ctx context.Context, regions []string, baselines map[string]Baseline) <-chan RegionResources {
+	return s.stream(ctx, regions, func(ctx context.Context, region string) ([]Resource, error) {
+		ctx, cancel := withTimeout(ctx, s.timeouts.Region)
+		defer cancel()
+
+		baseline, ok := baselines[region]
+		if !ok {
+			return s.ListResourcesInRegion(ctx, region, nil, nil)
+		}
+
+		changed, err := s.ChangedTypes(ctx, region, baseline.ListedAt)
+		if err != nil {
+			resources, listErr := s.ListResourcesInRegion(ctx, region, nil, nil)
+			if errors.Is(err, ErrTooManyChanges) {
+				return resources, listErr
+			}
+			return resources, errors.Join(newServiceError("CloudTrail events", region, s.credentialsError(err)), listErr)
+		}
+		if len(changed) == 0 {
+			// Callers go on to sort and trim the result
+			return append([]Resource(nil), baseline.Resources...), nil
+		}
+
+		listed, err := s.ListResourcesInRegion(WithRefresh(ctx), region, changed, nil)
+		relisted := make(map[string]bool, len(changed))
+		for _, resourceType := range changed {
+			relisted[resourceType] = true
+		}
+		resources := make([]Resource, 0, len(baseline.Resources)+len(listed))
+		for _, resource := range baseline.Resources {
+			if !relisted[resource.Type] {
+				resources = append(resources, resource)
+			}
+		}
+		return append(resources, listed...), err
+	})
+}
@@ -0,0 +1,147 @@
+package cloudy
+
+import (
+	"context"
+	"path"
+	"reflect"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	awshttp "github.com/aws/aws-sdk-go-v2/aws/transport/http"
+)
+
+// EndpointResolver returns the endpoint to send a region's requests to,
+// such as a LocalStack URL, or "" for the service's default. It applies to
+// every service without an endpoint of its own; see
+// Scanner.SetServiceEndpoints.
+type EndpointResolver func(region string) string
+
+// ClientFactory hands listers the aws.Config for a region. Each one is a
+// copy of the base config, so the loaded credentials, retryer and HTTP
+// client carry over to every region. It also keeps the service clients
+// listers build with Client, so they are reused from scan to scan.
+type ClientFactory struct {
+	base             aws.Config
+	resolveEndpoint  EndpointResolver
+	serviceEndpoints map[string]string
+
+	mu      sync.Mutex
+	clients map[clientKey]any
+}
+
+// clientKey identifies a service client by the type of client and the
+// region and endpoint it sends requests to.
+type clientKey struct {
+	client   reflect.Type
+	region   string
+	endpoint string
+}
+
+// NewClientFactory returns a ClientFactory copying base. resolveEndpoint
+// may be nil. Unless base sets a retryer or retry mode, calls use adaptive
+// retry, throttled attempts are counted for RegionResources.Throttles, and
+// calls keep to their account's rate in the Scanner's WorkerPool.
+func NewClientFactory(base aws.Config, resolveEndpoint EndpointResolver) *ClientFactory {
+	base = base.Copy()
+	if base.Retryer == nil && base.RetryMode == "" {
+		base.Retryer = newAdaptiveRetryer
+	}
+	base.APIOptions = append(base.APIOptions, addThrottleCounting, addRateLimit)
+	return &ClientFactory{base: base, resolveEndpoint: resolveEndpoint}
+}
+
+// SetCallTimeout bounds each API call made with the factory's configs to
+// d. A base config with its own kind of HTTP client keeps it unchanged.
+func (f *ClientFactory) SetCallTimeout(d time.Duration) {
+	switch client := f.base.HTTPClient.(type) {
+	case nil:
+		f.base.HTTPClient = awshttp.NewBuildableClient().WithTimeout(d)
+	case *awshttp.BuildableClient:
+		f.base.HTTPClient = client.WithTimeout(d)
+	}
+	// Clients already built still have the old HTTP client
+	f.mu.Lock()
+	f.clients = nil
+	f.mu.Unlock()
+}
+
+// Config returns the config for clients in region.
+func (f *ClientFactory) Config(region string) aws.Config {
+	cfg := f.base.Copy()
+	cfg.Region = region
+	if f.resolveEndpoint != nil {
+		if endpoint := f.resolveEndpoint(region); endpoint != "" {
+			cfg.BaseEndpoint = aws.String(endpoint)
+		}
+	}
+	return cfg
+}
+
+type clientsKey struct{}
+
+func withClients(ctx context.Context, f *ClientFactory) context.Context {
+	return context.WithValue(ctx, clientsKey{}, f)
+}
+
+// Client returns the service client newClient builds from cfg, such as
+// Client(ctx, cfg, ec2.NewFromConfig). During a scan, the client built for
+// cfg's region and endpoint is kept by the Scanner and returned again
+// rather than built anew, so listers should call Client instead of the
+// service's NewFromConfig. cfg must be the config the lister was given, or
+// a copy with another region. S3 clients sent to an endpoint other than
+// the default address buckets by path, since LocalStack and VPC interface
+// endpoints don't serve them as subdomains.
+func Client[C, O any](ctx context.Context, cfg aws.Config, newClient func(aws.Config, ...func(O)) C) C {
+	f, ok := ctx.Value(clientsKey{}).(*ClientFactory)
+	if !ok {
+		return newClient(cfg, clientOption[O](""))
+	}
+
+	key := clientKey{client: reflect.TypeFor[C](), region: cfg.Region, endpoint: aws.ToString(cfg.BaseEndpoint)}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if client, ok := f.clients[key]; ok {
+		return client.(C)
+	}
+	client := newClient(cfg, clientOption[O](f.serviceEndpoints[serviceName[O]()]))
+	if f.clients == nil {
+		f.clients = make(map[clientKey]any)
+	}
+	f.clients[key] = client
+	return client
+}
+
+// serviceName returns the package name of the service O, a service's
+// *Options, belongs to, like "s3".
+func serviceName[O any]() string {
+	t := reflect.TypeFor[O]()
+	if t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	return path.Base(t.PkgPath())
+}
+
+// clientOption sets the endpoint of the client it is passed the options
+// of, unless endpoint is "", and has S3 clients with an endpoint address
+// buckets by path. The SDK's options have no common interface, so their
+// fields are set by name.
+func clientOption[O any](endpoint string) func(O) {
+	return func(o O) {
+		options := reflect.ValueOf(o)
+		if options.Kind() != reflect.Pointer || options.Elem().Kind() != reflect.Struct {
+			return
+		}
+		options = options.Elem()
+		baseEndpoint := options.FieldByName("BaseEndpoint")
+		if !baseEndpoint.IsValid() || baseEndpoint.Type() != reflect.TypeFor[*string]() {
+			return
+		}
+		if endpoint != "" {
+			baseEndpoint.Set(reflect.ValueOf(aws.String(endpoint)))
+		}
+		if pathStyle := options.FieldByName("UsePathStyle"); !baseEndpoint.IsNil() && pathStyle.Kind() == reflect.Bool {
+			pathStyle.SetBool(true)
+		}
+	}
+}
@@ -0,0 +1,191 @@
+// Package cloudflare is Cloudy's Cloudflare provider. It lists zones, DNS
+// records, Workers, R2 buckets and Pages projects in one account as
+// cloudy.Resources, so a Scanner from cloudy.NewProviderScanner scans
+// Cloudflare the way it scans AWS.
+package cloudflare
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/alwindoss/cloudy/pkg/cloudy"
+	"github.com/alwindoss/cloudy/pkg/cloudy/internal/listings"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/smithy-go"
+	"github.com/cloudflare/cloudflare-go"
+)
+
+// ProviderName names the Cloudflare provider in requests and on its
+// resources.
+const ProviderName = "cloudflare"
+
+// GlobalRegion is the one region Cloudflare has: its resources run on
+// the whole network, so they are all listed here.
+const GlobalRegion = "global"
+
+// listingTTL is how long the account's zone listing is shared. The zone
+// and DNS record listers both need the zones, so a scan lists them once.
+const listingTTL = 30 * time.Second
+
+// pageSize is the most DNS records or Pages projects asked for in a page.
+const pageSize = 100
+
+// Provider is a Cloudflare account.
+type Provider struct {
+	api     *cloudflare.API
+	account string
+
+	listings *listings.Cache
+}
+
+// NewProvider returns the Cloudflare provider for account, listed with
+// api, e.g. from cloudflare.NewWithAPIToken.
+func NewProvider(api *cloudflare.API, account string) *Provider {
+	return &Provider{api: api, account: account, listings: &listings.Cache{TTL: listingTTL}}
+}
+
+func (p *Provider) Name() string { return ProviderName }
+
+// Regions returns GlobalRegion, the only region.
+func (p *Provider) Regions(ctx context.Context) ([]string, error) {
+	return []string{GlobalRegion}, nil
+}
+
+// Listers returns a lister for each supported service.
+func (p *Provider) Listers() []cloudy.ServiceLister {
+	return []cloudy.ServiceLister{
+		lister{p: p, name: "Cloudflare zones", types: []string{"Cloudflare Zone"}, permissions: []string{"Zone Read"}, list: listZones},
+		lister{p: p, name: "Cloudflare DNS", types: []string{"Cloudflare DNS Record"}, permissions: []string{"Zone Read", "DNS Read"}, list: listDNSRecords},
+		lister{p: p, name: "Workers", types: []string{"Cloudflare Worker"}, permissions: []string{"Workers Scripts Read"}, list: listWorkers},
+		lister{p: p, name: "R2", types: []string{"R2 Bucket"}, permissions: []string{"Workers R2 Storage Read"}, list: listR2Buckets},
+		lister{p: p, name: "Pages", types: []string{"Pages Project"}, permissions: []string{"Cloudflare Pages Read"}, list: listPagesProjects},
+	}
+}
+
+// listFunc lists a service's resources in the account.
+type listFunc func(ctx context.Context, p *Provider) ([]cloudy.Resource, error)
+
+// lister is a cloudy.ServiceLister for a Cloudflare service. It only
+// lists in GlobalRegion; other regions have nothing in them.
+type lister struct {
+	p           *Provider
+	name        string
+	types       []string
+	permissions []string
+	list        listFunc
+}
+
+func (l lister) Name() string    { return l.name }
+func (l lister) Types() []string { return l.types }
+func (l lister) Global() bool    { return false }
+
+// IAMActions returns the API token permissions the service needs, as the
+// dashboard names them.
+func (l lister) IAMActions() []string { return l.permissions }
+
+func (l lister) List(ctx context.Context, cfg aws.Config, _ []string) ([]cloudy.Resource, error) {
+	if cfg.Region != GlobalRegion {
+		return nil, nil
+	}
+	resources, err := l.list(ctx, l.p)
+	return resources, apiError(err)
+}
+
+// zones lists the account's zones, once for the listers of a scan.
+func (p *Provider) zones(ctx context.Context) ([]cloudflare.Zone, error) {
+	return listings.Shared(ctx, p.listings, "zones", func(ctx context.Context) ([]cloudflare.Zone, error) {
+		// ListZonesContext fetches every page itself
+		response, err := p.api.ListZonesContext(ctx, cloudflare.WithZoneFilters("", p.account, ""))
+		if err != nil {
+			return nil, err
+		}
+		return capped(ctx, response.Result)
+	})
+}
+
+// capped cuts items, listed without paging, down to the cap of the scan
+// ctx belongs to, and fails if there were more.
+func capped[T any](ctx context.Context, items []T) ([]T, error) {
+	if limit := cloudy.MaxResults(ctx); len(items) > limit {
+		return items[:limit], checkMaxResults(ctx, limit)
+	}
+	return items, nil
+}
+
+// checkMaxResults fails once n items have been listed, at the cap of the
+// scan ctx belongs to.
+func checkMaxResults(ctx context.Context, n int) error {
+	if limit := cloudy.MaxResults(ctx); n >= limit {
+		return fmt.Errorf("%w: stopped after %d", cloudy.ErrMaxResults, limit)
+	}
+	return nil
+}
+
+// responseError gives a Cloudflare error's code and status to cloudy's
+// ServiceErrors, which read them as they read an AWS error's.
+type responseError struct {
+	err *cloudflare.Error
+}
+
+// ErrorCode returns the error's first Cloudflare code, like 10000, or
+// else its HTTP status, like NotFound.
+func (e responseError) ErrorCode() string {
+	if len(e.err.Errors) > 0 && e.err.Errors[0].Code != 0 {
+		return fmt.Sprintf("%d", e.err.Errors[0].Code)
+	}
+	return strings.ReplaceAll(http.StatusText(e.err.StatusCode), " ", "")
+}
+
+func (e responseError) ErrorMessage() string {
+	if len(e.err.Errors) > 0 {
+		return e.err.Errors[0].Message
+	}
+	return ""
+}
+
+func (e responseError) Error() string       { return e.err.Error() }
+func (e responseError) HTTPStatusCode() int { return e.err.StatusCode }
+func (e responseError) Unwrap() error       { return e.err }
+
+func (e responseError) ErrorFault() smithy.ErrorFault {
+	if e.err.StatusCode >= 500 {
+		return smithy.FaultServer
+	}
+	return smithy.FaultClient
+}
+
+// apiError wraps err in a responseError if it is a Cloudflare error
+// response.
+func apiError(err error) error {
+	var apiErr *cloudflare.Error
+	if errors.As(err, &apiErr) {
+		return responseError{apiErr}
+	}
+	return err
+}
+
+// baseAttributes returns the attributes every resource carries.
+func (p *Provider) baseAttributes() map[string]string {
+	return map[string]string{"account_id": p.account}
+}
+
+// tags returns Cloudflare's name:value tags as cloudy tags.
+func tags(list []string) map[string]string {
+	converted := make(map[string]string, len(list))
+	for _, tag := range list {
+		key, value, _ := strings.Cut(tag, ":")
+		converted[key] = value
+	}
+	return converted
+}
+
+func timeString(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.String()
+}
@@ -0,0 +1,63 @@
+package cloudflare
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/alwindoss/cloudy/pkg/cloudy"
+	"github.com/cloudflare/cloudflare-go"
+)
+
+func listDNSRecords(ctx context.Context, p *Provider) ([]cloudy.Resource, error) {
+	zones, err := p.zones(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var resources []cloudy.Resource
+	for _, zone := range zones {
+		// With a page set, ListDNSRecords fetches just that page
+		params := cloudflare.ListDNSRecordsParams{ResultInfo: cloudflare.ResultInfo{Page: 1, PerPage: pageSize}}
+		for {
+			if err := checkMaxResults(ctx, len(resources)); err != nil {
+				return resources, err
+			}
+			records, info, err := p.api.ListDNSRecords(ctx, cloudflare.ZoneIdentifier(zone.ID), params)
+			if err != nil {
+				return resources, err
+			}
+
+			for _, record := range records {
+				attributes := p.baseAttributes()
+				attributes["zone_id"] = zone.ID
+				attributes["zone"] = zone.Name
+				attributes["record_type"] = record.Type
+				attributes["content"] = record.Content
+				attributes["ttl"] = fmt.Sprintf("%d", record.TTL)
+				attributes["created"] = timeString(record.CreatedOn)
+				if record.Proxied != nil {
+					attributes["proxied"] = fmt.Sprintf("%t", *record.Proxied)
+				}
+				if record.Comment != "" {
+					attributes["comment"] = record.Comment
+				}
+
+				resources = append(resources, cloudy.Resource{
+					ID:         "cloudflare:dns_record:" + record.ID,
+					Name:       record.Name,
+					Type:       "Cloudflare DNS Record",
+					Region:     GlobalRegion,
+					Tags:       tags(record.Tags),
+					Attributes: attributes,
+				})
+			}
+
+			if info == nil || !info.HasMorePages() {
+				break
+			}
+			params.Page++
+		}
+	}
+
+	return resources, nil
+}
@@ -0,0 +1,53 @@
+package cloudflare
+
+import (
+	"context"
+	"strings"
+
+	"github.com/alwindoss/cloudy/pkg/cloudy"
+	"github.com/cloudflare/cloudflare-go"
+)
+
+func listPagesProjects(ctx context.Context, p *Provider) ([]cloudy.Resource, error) {
+	var resources []cloudy.Resource
+	params := cloudflare.ListPagesProjectsParams{PaginationOptions: cloudflare.PaginationOptions{Page: 1, PerPage: pageSize}}
+	for {
+		if err := checkMaxResults(ctx, len(resources)); err != nil {
+			return resources, err
+		}
+		projects, info, err := p.api.ListPagesProjects(ctx, cloudflare.AccountIdentifier(p.account), params)
+		if err != nil {
+			return resources, err
+		}
+
+		for _, project := range projects {
+			attributes := p.baseAttributes()
+			attributes["subdomain"] = project.SubDomain
+			attributes["domains"] = strings.Join(project.Domains, ",")
+			attributes["production_branch"] = project.ProductionBranch
+			if project.Source != nil {
+				attributes["source"] = project.Source.Type
+				if config := project.Source.Config; config != nil {
+					attributes["repository"] = config.Owner + "/" + config.RepoName
+				}
+			}
+			if project.CreatedOn != nil {
+				attributes["created"] = timeString(*project.CreatedOn)
+			}
+
+			resources = append(resources, cloudy.Resource{
+				ID:         "cloudflare:pages:" + project.ID,
+				Name:       project.Name,
+				Type:       "Pages Project",
+				Region:     GlobalRegion,
+				Tags:       make(map[string]string),
+				Attributes: attributes,
+			})
+		}
+
+		if !info.HasMorePages() {
+			return resources, nil
+		}
+		params.Page++
+	}
+}
@@ -0,0 +1,42 @@
+package cloudflare
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/alwindoss/cloudy/pkg/cloudy"
+	"github.com/cloudflare/cloudflare-go"
+)
+
+// r2PageSize is the most buckets R2 lists in a page. The client doesn't
+// return the cursor to the next page, but an account has at most this
+// many buckets unless its limit was raised.
+const r2PageSize = 1000
+
+func listR2Buckets(ctx context.Context, p *Provider) ([]cloudy.Resource, error) {
+	buckets, err := p.api.ListR2Buckets(ctx, cloudflare.AccountIdentifier(p.account), cloudflare.ListR2BucketsParams{PerPage: r2PageSize})
+	if err != nil {
+		return nil, err
+	}
+	buckets, err = capped(ctx, buckets)
+
+	var resources []cloudy.Resource
+	for _, bucket := range buckets {
+		attributes := p.baseAttributes()
+		attributes["location"] = bucket.Location
+		if bucket.CreationDate != nil {
+			attributes["created"] = timeString(*bucket.CreationDate)
+		}
+
+		resources = append(resources, cloudy.Resource{
+			ID:         fmt.Sprintf("cloudflare:r2:%s/%s", p.account, bucket.Name),
+			Name:       bucket.Name,
+			Type:       "R2 Bucket",
+			Region:     GlobalRegion,
+			Tags:       make(map[string]string),
+			Attributes: attributes,
+		})
+	}
+
+	return resources, err
+}
@@ -0,0 +1,42 @@
+package cloudflare
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/alwindoss/cloudy/pkg/cloudy"
+	"github.com/cloudflare/cloudflare-go"
+)
+
+func listWorkers(ctx context.Context, p *Provider) ([]cloudy.Resource, error) {
+	response, _, err := p.api.ListWorkers(ctx, cloudflare.AccountIdentifier(p.account), cloudflare.ListWorkersParams{})
+	if err != nil {
+		return nil, err
+	}
+	workers, err := capped(ctx, response.WorkerList)
+
+	var resources []cloudy.Resource
+	for _, worker := range workers {
+		attributes := p.baseAttributes()
+		attributes["size_bytes"] = fmt.Sprintf("%d", worker.Size)
+		attributes["created"] = timeString(worker.CreatedOn)
+		attributes["modified"] = timeString(worker.ModifiedOn)
+		if worker.Logpush != nil {
+			attributes["logpush"] = fmt.Sprintf("%t", *worker.Logpush)
+		}
+		if worker.LastDeployedFrom != nil {
+			attributes["deployed_from"] = *worker.LastDeployedFrom
+		}
+
+		resources = append(resources, cloudy.Resource{
+			ID:         fmt.Sprintf("cloudflare:worker:%s/%s", p.account, worker.ID),
+			Name:       worker.ID,
+			Type:       "Cloudflare Worker",
+			Region:     GlobalRegion,
+			Tags:       make(map[string]string),
+			Attributes: attributes,
+		})
+	}
+
+	return resources, err
+}
@@ -0,0 +1,35 @@
+package cloudflare
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/alwindoss/cloudy/pkg/cloudy"
+)
+
+func listZones(ctx context.Context, p *Provider) ([]cloudy.Resource, error) {
+	zones, err := p.zones(ctx)
+
+	var resources []cloudy.Resource
+	for _, zone := range zones {
+		attributes := p.baseAttributes()
+		attributes["type"] = zone.Type
+		attributes["plan"] = zone.Plan.Name
+		attributes["paused"] = fmt.Sprintf("%t", zone.Paused)
+		attributes["name_servers"] = strings.Join(zone.NameServers, ",")
+		attributes["created"] = timeString(zone.CreatedOn)
+
+		resources = append(resources, cloudy.Resource{
+			ID:         "cloudflare:zone:" + zone.ID,
+			Name:       zone.Name,
+			Type:       "Cloudflare Zone",
+			State:      zone.Status,
+			Region:     GlobalRegion,
+			Tags:       make(map[string]string),
+			Attributes: attributes,
+		})
+	}
+
+	return resources, err
+}
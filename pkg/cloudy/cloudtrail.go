@@ -0,0 +1,84 @@
+package cloudy
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cloudtrail"
+)
+
+func init() {
+	Register(lister{
+		name:       "CloudTrail trails",
+		types:      []string{"CloudTrail Trail"},
+		iamActions: []string{"cloudtrail:DescribeTrails", "cloudtrail:GetTrailStatus"},
+		list:       withoutStates(listCloudTrailTrails),
+	})
+}
+
+func listCloudTrailTrails(ctx context.Context, cfg aws.Config) ([]Resource, error) {
+	client := Client(ctx, cfg, cloudtrail.NewFromConfig)
+
+	// Multi-region trails also show up as shadow copies in every other
+	// region; only list each trail in its home region.
+	result, err := client.DescribeTrails(ctx, &cloudtrail.DescribeTrailsInput{
+		IncludeShadowTrails: aws.Bool(false),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	resources := make([]Resource, len(result.TrailList))
+	for i, trail := range result.TrailList {
+		attributes := map[string]string{
+			"home_region":                   aws_string_value(trail.HomeRegion),
+			"multi_region":                  fmt.Sprintf("%t", aws_bool_value(trail.IsMultiRegionTrail)),
+			"organization_trail":            fmt.Sprintf("%t", aws_bool_value(trail.IsOrganizationTrail)),
+			"include_global_service_events": fmt.Sprintf("%t", aws_bool_value(trail.IncludeGlobalServiceEvents)),
+			"log_file_validation":           fmt.Sprintf("%t", aws_bool_value(trail.LogFileValidationEnabled)),
+			"s3_bucket":                     aws_string_value(trail.S3BucketName),
+			"s3_key_prefix":                 aws_string_value(trail.S3KeyPrefix),
+		}
+		if trail.KmsKeyId != nil {
+			attributes["kms_key_id"] = *trail.KmsKeyId
+		}
+		if trail.CloudWatchLogsLogGroupArn != nil {
+			attributes["cloudwatch_logs_log_group"] = *trail.CloudWatchLogsLogGroupArn
+		}
+
+		resources[i] = Resource{
+			ID:         aws_string_value(trail.TrailARN),
+			Name:       aws_string_value(trail.Name),
+			Type:       "CloudTrail Trail",
+			Region:     cfg.Region,
+			Attributes: attributes,
+		}
+	}
+
+	// Whether a trail is actually logging needs a per-trail status call. A
+	// trail whose status can't be read is still listed, with the failure
+	// recorded on it.
+	forEachBounded(len(resources), describeConcurrency, func(i int) {
+		status, err := client.GetTrailStatus(ctx, &cloudtrail.GetTrailStatusInput{
+			Name: aws.String(resources[i].ID),
+		})
+		if err != nil {
+			resources[i].Attributes["status_error"] = err.Error()
+			return
+		}
+
+		logging := aws_bool_value(status.IsLogging)
+		resources[i].State = "STOPPED"
+		if logging {
+			resources[i].State = "LOGGING"
+		}
+		resources[i].Attributes["logging"] = fmt.Sprintf("%t", logging)
+		resources[i].Attributes["latest_delivery"] = aws_time_string(status.LatestDeliveryTime)
+		if status.LatestDeliveryError != nil {
+			resources[i].Attributes["latest_delivery_error"] = *status.LatestDeliveryError
+		}
+	})
+
+	return resources, nil
+}
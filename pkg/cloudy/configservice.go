@@ -0,0 +1,192 @@
+package cloudy
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/configservice"
+	"github.com/aws/aws-sdk-go-v2/service/configservice/types"
+)
+
+func init() {
+	Register(lister{
+		name:  "AWS Config",
+		types: []string{"Config Recorder", "Config Rule"},
+		iamActions: []string{
+			"config:DescribeConfigurationRecorders",
+			"config:DescribeConfigurationRecorderStatus",
+			"config:DescribeConfigRules",
+			"config:DescribeComplianceByConfigRule",
+		},
+		list: withoutStates(listConfigResources),
+	})
+}
+
+// listConfigResources lists AWS Config recorders and rules. The two are
+// independent, so a failure in one still returns what the other found.
+func listConfigResources(ctx context.Context, cfg aws.Config) ([]Resource, error) {
+	client := Client(ctx, cfg, configservice.NewFromConfig)
+
+	var errs []error
+
+	resources, err := listConfigRecorders(ctx, client, cfg.Region)
+	if err != nil {
+		errs = append(errs, fmt.Errorf("configuration recorders: %w", err))
+	}
+
+	ruleResources, err := listConfigRules(ctx, client, cfg.Region)
+	if err != nil {
+		errs = append(errs, fmt.Errorf("config rules: %w", err))
+	}
+	resources = append(resources, ruleResources...)
+
+	return resources, errors.Join(errs...)
+}
+
+func listConfigRecorders(ctx context.Context, client *configservice.Client, region string) ([]Resource, error) {
+	result, err := client.DescribeConfigurationRecorders(ctx, &configservice.DescribeConfigurationRecordersInput{})
+	if err != nil {
+		return nil, err
+	}
+
+	if len(result.ConfigurationRecorders) == 0 {
+		return []Resource{}, nil
+	}
+
+	statuses := make(map[string]types.ConfigurationRecorderStatus)
+	statusResult, statusErr := client.DescribeConfigurationRecorderStatus(ctx, &configservice.DescribeConfigurationRecorderStatusInput{})
+	if statusErr == nil {
+		for _, status := range statusResult.ConfigurationRecordersStatus {
+			statuses[aws_string_value(status.Name)] = status
+		}
+	}
+
+	var resources []Resource
+	for _, recorder := range result.ConfigurationRecorders {
+		name := aws_string_value(recorder.Name)
+		attributes := map[string]string{
+			"role_arn":              aws_string_value(recorder.RoleARN),
+			"includes_global_types": "false",
+		}
+
+		if group := recorder.RecordingGroup; group != nil {
+			if group.AllSupported {
+				attributes["recorded_resources"] = "all"
+			} else {
+				resourceTypes := make([]string, 0, len(group.ResourceTypes))
+				for _, resourceType := range group.ResourceTypes {
+					resourceTypes = append(resourceTypes, string(resourceType))
+				}
+				attributes["recorded_resources"] = strings.Join(resourceTypes, ",")
+			}
+			if group.ExclusionByResourceTypes != nil {
+				attributes["excluded_resource_type_count"] = fmt.Sprintf("%d", len(group.ExclusionByResourceTypes.ResourceTypes))
+			}
+			attributes["includes_global_types"] = fmt.Sprintf("%t", group.IncludeGlobalResourceTypes)
+		}
+
+		state := ""
+		if statusErr != nil {
+			attributes["status_error"] = statusErr.Error()
+		} else if status, ok := statuses[name]; ok {
+			state = string(status.LastStatus)
+			attributes["recording"] = fmt.Sprintf("%t", status.Recording)
+			attributes["last_start_time"] = aws_time_string(status.LastStartTime)
+			if status.LastErrorCode != nil {
+				attributes["last_error_code"] = *status.LastErrorCode
+			}
+		}
+
+		id := aws_string_value(recorder.Arn)
+		if id == "" {
+			id = name
+		}
+
+		resources = append(resources, Resource{
+			ID:         id,
+			Name:       name,
+			Type:       "Config Recorder",
+			State:      state,
+			Region:     region,
+			Attributes: attributes,
+		})
+	}
+
+	return resources, nil
+}
+
+func listConfigRules(ctx context.Context, client *configservice.Client, region string) ([]Resource, error) {
+	var rules []types.ConfigRule
+	paginator := configservice.NewDescribeConfigRulesPaginator(client, &configservice.DescribeConfigRulesInput{})
+	for paginator.HasMorePages() {
+		if err := checkMaxResults(ctx, len(rules)); err != nil {
+			return nil, err
+		}
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return nil, err
+		}
+		rules = append(rules, page.ConfigRules...)
+	}
+
+	// Compliance is looked up for all rules at once; if it fails the rules
+	// are still listed, each with the failure recorded on it.
+	compliance := make(map[string]*types.Compliance)
+	var complianceErr error
+	compliancePaginator := configservice.NewDescribeComplianceByConfigRulePaginator(client, &configservice.DescribeComplianceByConfigRuleInput{})
+	for compliancePaginator.HasMorePages() {
+		page, err := compliancePaginator.NextPage(ctx)
+		if err != nil {
+			complianceErr = err
+			break
+		}
+		for _, ruleCompliance := range page.ComplianceByConfigRules {
+			compliance[aws_string_value(ruleCompliance.ConfigRuleName)] = ruleCompliance.Compliance
+		}
+	}
+
+	var resources []Resource
+	for _, rule := range rules {
+		name := aws_string_value(rule.ConfigRuleName)
+		attributes := map[string]string{
+			"description": aws_string_value(rule.Description),
+		}
+
+		if rule.Source != nil {
+			attributes["owner"] = string(rule.Source.Owner)
+			attributes["source_identifier"] = aws_string_value(rule.Source.SourceIdentifier)
+		}
+		if rule.CreatedBy != nil {
+			attributes["created_by"] = *rule.CreatedBy
+		}
+
+		if complianceErr != nil {
+			attributes["compliance_error"] = complianceErr.Error()
+		} else if ruleCompliance := compliance[name]; ruleCompliance != nil {
+			attributes["compliance"] = string(ruleCompliance.ComplianceType)
+			if count := ruleCompliance.ComplianceContributorCount; count != nil {
+				noncompliant := fmt.Sprintf("%d", count.CappedCount)
+				if count.CapExceeded {
+					noncompliant += "+"
+				}
+				attributes["noncompliant_resources"] = noncompliant
+			}
+		} else {
+			attributes["compliance"] = string(types.ComplianceTypeInsufficientData)
+		}
+
+		resources = append(resources, Resource{
+			ID:         aws_string_value(rule.ConfigRuleArn),
+			Name:       name,
+			Type:       "Config Rule",
+			State:      string(rule.ConfigRuleState),
+			Region:     region,
+			Attributes: attributes,
+		})
+	}
+
+	return resources, nil
+}
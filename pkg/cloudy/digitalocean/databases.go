@@ -0,0 +1,45 @@
+package digitalocean
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/alwindoss/cloudy/pkg/cloudy"
+	"github.com/alwindoss/cloudy/pkg/cloudy/internal/listings"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/digitalocean/godo"
+)
+
+func listDatabases(ctx context.Context, p *Provider, cfg aws.Config) ([]cloudy.Resource, error) {
+	databases, err := listings.Shared(ctx, p.listings, "databases", func(ctx context.Context) ([]godo.Database, error) {
+		return listAll(ctx, p.client.Databases.List)
+	})
+
+	var resources []cloudy.Resource
+	for _, database := range databases {
+		if database.RegionSlug != cfg.Region {
+			continue
+		}
+
+		resources = append(resources, cloudy.Resource{
+			ID:     "do:dbaas:" + database.ID,
+			Name:   database.Name,
+			Type:   "DigitalOcean Database",
+			State:  database.Status,
+			Region: cfg.Region,
+			Tags:   tags(database.Tags),
+			Attributes: map[string]string{
+				"engine":      database.EngineSlug,
+				"version":     database.VersionSlug,
+				"size":        database.SizeSlug,
+				"nodes":       fmt.Sprintf("%d", database.NumNodes),
+				"storage_mib": fmt.Sprintf("%d", database.StorageSizeMib),
+				"vpc_id":      database.PrivateNetworkUUID,
+				"project_id":  database.ProjectID,
+				"created":     timeString(database.CreatedAt),
+			},
+		})
+	}
+
+	return resources, err
+}
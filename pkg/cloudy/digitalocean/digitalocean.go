@@ -0,0 +1,203 @@
+// Package digitalocean is Cloudy's DigitalOcean provider. It lists
+// Droplets, Kubernetes clusters, managed databases, Spaces and load
+// balancers in one account, region by region, as cloudy.Resources, so a
+// Scanner from cloudy.NewProviderScanner scans DigitalOcean the way it
+// scans AWS.
+package digitalocean
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/alwindoss/cloudy/pkg/cloudy"
+	"github.com/alwindoss/cloudy/pkg/cloudy/internal/listings"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/smithy-go"
+	"github.com/digitalocean/godo"
+)
+
+// ProviderName names the DigitalOcean provider in requests and on its
+// resources.
+const ProviderName = "digitalocean"
+
+// listingTTL is how long an account-wide listing is shared. Droplets,
+// Kubernetes clusters, databases and load balancers can only be listed
+// for the whole account, so the regions of a scan, which all ask for
+// them at about the same time, take theirs out of one listing instead of
+// each listing the account again.
+const listingTTL = 30 * time.Second
+
+// Provider is a DigitalOcean account.
+type Provider struct {
+	client *godo.Client
+	spaces aws.CredentialsProvider
+
+	listings *listings.Cache
+}
+
+// NewProvider returns the DigitalOcean provider for the account client
+// has a token for, e.g. from godo.NewFromToken. Spaces, which only take
+// S3 credentials, are listed with spaces, a Spaces access key; if it is
+// nil they aren't listed.
+func NewProvider(client *godo.Client, spaces aws.CredentialsProvider) *Provider {
+	return &Provider{client: client, spaces: spaces, listings: &listings.Cache{TTL: listingTTL}}
+}
+
+func (p *Provider) Name() string { return ProviderName }
+
+// Regions returns the available regions, like nyc3.
+func (p *Provider) Regions(ctx context.Context) ([]string, error) {
+	available, err := listAll(ctx, p.client.Regions.List)
+	if err != nil {
+		return nil, err
+	}
+
+	var regions []string
+	for _, region := range available {
+		if region.Available {
+			regions = append(regions, region.Slug)
+		}
+	}
+	sort.Strings(regions)
+	return regions, nil
+}
+
+// Listers returns a lister for each supported service. Spaces are left
+// out without a Spaces access key.
+func (p *Provider) Listers() []cloudy.ServiceLister {
+	listers := []cloudy.ServiceLister{
+		lister{p: p, name: "Droplets", types: []string{"Droplet"}, scopes: []string{"droplet:read"}, list: listDroplets},
+		lister{p: p, name: "DigitalOcean Kubernetes clusters", types: []string{"DOKS Cluster"}, scopes: []string{"kubernetes:read"}, list: listClusters},
+		lister{p: p, name: "DigitalOcean managed databases", types: []string{"DigitalOcean Database"}, scopes: []string{"database:read"}, list: listDatabases},
+		lister{p: p, name: "DigitalOcean load balancers", types: []string{"DigitalOcean Load Balancer"}, scopes: []string{"load_balancer:read"}, list: listLoadBalancers},
+	}
+	if p.spaces != nil {
+		listers = append(listers, lister{p: p, name: "Spaces", types: []string{"Space"}, list: listSpaces})
+	}
+	return listers
+}
+
+// listFunc lists a service's resources in one region. cfg is the config
+// the lister was given.
+type listFunc func(ctx context.Context, p *Provider, cfg aws.Config) ([]cloudy.Resource, error)
+
+// lister is a cloudy.ServiceLister for a DigitalOcean service. List is
+// passed the region to list as the config's region.
+type lister struct {
+	p      *Provider
+	name   string
+	types  []string
+	scopes []string
+	list   listFunc
+}
+
+func (l lister) Name() string    { return l.name }
+func (l lister) Types() []string { return l.types }
+func (l lister) Global() bool    { return false }
+
+// IAMActions are the API token scopes List needs. Spaces need a Spaces
+// access key instead.
+func (l lister) IAMActions() []string { return l.scopes }
+
+func (l lister) List(ctx context.Context, cfg aws.Config, _ []string) ([]cloudy.Resource, error) {
+	return l.list(ctx, l.p, cfg)
+}
+
+// listAll pages through list to the end, up to the cap of the scan ctx
+// belongs to.
+func listAll[T any](ctx context.Context, list func(context.Context, *godo.ListOptions) ([]T, *godo.Response, error)) ([]T, error) {
+	var items []T
+	opt := &godo.ListOptions{PerPage: 200}
+	for {
+		if err := checkMaxResults(ctx, len(items)); err != nil {
+			return items, err
+		}
+		page, resp, err := list(ctx, opt)
+		if err != nil {
+			return items, apiError(err)
+		}
+		items = append(items, page...)
+
+		if resp == nil || resp.Links == nil || resp.Links.IsLastPage() {
+			return items, nil
+		}
+		current, err := resp.Links.CurrentPage()
+		if err != nil {
+			return items, err
+		}
+		opt.Page = current + 1
+	}
+}
+
+// checkMaxResults fails once n items have been listed, at the cap of the
+// scan ctx belongs to.
+func checkMaxResults(ctx context.Context, n int) error {
+	if limit := cloudy.MaxResults(ctx); n >= limit {
+		return fmt.Errorf("%w: stopped after %d", cloudy.ErrMaxResults, limit)
+	}
+	return nil
+}
+
+// responseError gives a DigitalOcean error's status to cloudy's
+// ServiceErrors, which read it as they read an AWS error's.
+type responseError struct {
+	err *godo.ErrorResponse
+}
+
+func (e responseError) statusCode() int {
+	if e.err.Response == nil {
+		return 0
+	}
+	return e.err.Response.StatusCode
+}
+
+// ErrorCode returns the error's HTTP status, like NotFound or
+// TooManyRequests.
+func (e responseError) ErrorCode() string {
+	return strings.ReplaceAll(http.StatusText(e.statusCode()), " ", "")
+}
+
+func (e responseError) Error() string        { return e.err.Error() }
+func (e responseError) ErrorMessage() string { return e.err.Message }
+func (e responseError) HTTPStatusCode() int  { return e.statusCode() }
+func (e responseError) Unwrap() error        { return e.err }
+
+func (e responseError) ErrorFault() smithy.ErrorFault {
+	if e.statusCode() >= 500 {
+		return smithy.FaultServer
+	}
+	return smithy.FaultClient
+}
+
+// apiError wraps err in a responseError if it is a DigitalOcean error
+// response.
+func apiError(err error) error {
+	var respErr *godo.ErrorResponse
+	if errors.As(err, &respErr) {
+		return responseError{respErr}
+	}
+	return err
+}
+
+// tags turns DigitalOcean's tags into key/value tags: key:value tags are
+// split at the first colon and others have an empty value.
+func tags(list []string) map[string]string {
+	converted := make(map[string]string, len(list))
+	for _, tag := range list {
+		key, value, _ := strings.Cut(tag, ":")
+		converted[key] = value
+	}
+	return converted
+}
+
+func timeString(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.String()
+}
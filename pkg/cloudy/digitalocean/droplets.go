@@ -0,0 +1,57 @@
+package digitalocean
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/alwindoss/cloudy/pkg/cloudy"
+	"github.com/alwindoss/cloudy/pkg/cloudy/internal/listings"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/digitalocean/godo"
+)
+
+func listDroplets(ctx context.Context, p *Provider, cfg aws.Config) ([]cloudy.Resource, error) {
+	droplets, err := listings.Shared(ctx, p.listings, "droplets", func(ctx context.Context) ([]godo.Droplet, error) {
+		return listAll(ctx, p.client.Droplets.List)
+	})
+
+	var resources []cloudy.Resource
+	for _, droplet := range droplets {
+		if droplet.Region == nil || droplet.Region.Slug != cfg.Region {
+			continue
+		}
+
+		attributes := map[string]string{
+			"size":      droplet.SizeSlug,
+			"vcpus":     fmt.Sprintf("%d", droplet.Vcpus),
+			"memory_mb": fmt.Sprintf("%d", droplet.Memory),
+			"disk_gb":   fmt.Sprintf("%d", droplet.Disk),
+			"vpc_id":    droplet.VPCUUID,
+			"created":   droplet.Created,
+		}
+		if droplet.Image != nil {
+			attributes["image"] = droplet.Image.Slug
+			if attributes["image"] == "" {
+				attributes["image"] = droplet.Image.Name
+			}
+		}
+		if ip, err := droplet.PrivateIPv4(); err == nil {
+			attributes["private_ip"] = ip
+		}
+		if ip, err := droplet.PublicIPv4(); err == nil {
+			attributes["public_ip"] = ip
+		}
+
+		resources = append(resources, cloudy.Resource{
+			ID:         fmt.Sprintf("do:droplet:%d", droplet.ID),
+			Name:       droplet.Name,
+			Type:       "Droplet",
+			State:      droplet.Status,
+			Region:     cfg.Region,
+			Tags:       tags(droplet.Tags),
+			Attributes: attributes,
+		})
+	}
+
+	return resources, err
+}
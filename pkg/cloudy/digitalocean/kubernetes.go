@@ -0,0 +1,54 @@
+package digitalocean
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/alwindoss/cloudy/pkg/cloudy"
+	"github.com/alwindoss/cloudy/pkg/cloudy/internal/listings"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/digitalocean/godo"
+)
+
+func listClusters(ctx context.Context, p *Provider, cfg aws.Config) ([]cloudy.Resource, error) {
+	clusters, err := listings.Shared(ctx, p.listings, "kubernetes", func(ctx context.Context) ([]*godo.KubernetesCluster, error) {
+		return listAll(ctx, p.client.Kubernetes.List)
+	})
+
+	var resources []cloudy.Resource
+	for _, cluster := range clusters {
+		if cluster.RegionSlug != cfg.Region {
+			continue
+		}
+
+		nodes := 0
+		for _, pool := range cluster.NodePools {
+			nodes += pool.Count
+		}
+		var state string
+		if cluster.Status != nil {
+			state = string(cluster.Status.State)
+		}
+
+		resources = append(resources, cloudy.Resource{
+			ID:     "do:kubernetes:" + cluster.ID,
+			Name:   cluster.Name,
+			Type:   "DOKS Cluster",
+			State:  state,
+			Region: cfg.Region,
+			Tags:   tags(cluster.Tags),
+			Attributes: map[string]string{
+				"kubernetes_version": cluster.VersionSlug,
+				"endpoint":           cluster.Endpoint,
+				"node_pools":         fmt.Sprintf("%d", len(cluster.NodePools)),
+				"nodes":              fmt.Sprintf("%d", nodes),
+				"ha":                 fmt.Sprintf("%t", cluster.HA),
+				"auto_upgrade":       fmt.Sprintf("%t", cluster.AutoUpgrade),
+				"vpc_id":             cluster.VPCUUID,
+				"created":            timeString(cluster.CreatedAt),
+			},
+		})
+	}
+
+	return resources, err
+}
@@ -0,0 +1,51 @@
+package digitalocean
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/alwindoss/cloudy/pkg/cloudy"
+	"github.com/alwindoss/cloudy/pkg/cloudy/internal/listings"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/digitalocean/godo"
+)
+
+func listLoadBalancers(ctx context.Context, p *Provider, cfg aws.Config) ([]cloudy.Resource, error) {
+	balancers, err := listings.Shared(ctx, p.listings, "load-balancers", func(ctx context.Context) ([]godo.LoadBalancer, error) {
+		return listAll(ctx, p.client.LoadBalancers.List)
+	})
+
+	var resources []cloudy.Resource
+	for _, balancer := range balancers {
+		// Global load balancers have no region
+		if balancer.Region == nil || balancer.Region.Slug != cfg.Region {
+			continue
+		}
+
+		size := balancer.SizeSlug
+		if size == "" && balancer.SizeUnit > 0 {
+			size = fmt.Sprintf("%d units", balancer.SizeUnit)
+		}
+
+		resources = append(resources, cloudy.Resource{
+			ID:     "do:loadbalancer:" + balancer.ID,
+			Name:   balancer.Name,
+			Type:   "DigitalOcean Load Balancer",
+			State:  balancer.Status,
+			Region: cfg.Region,
+			Tags:   tags(balancer.Tags),
+			Attributes: map[string]string{
+				"ip":               balancer.IP,
+				"size":             size,
+				"type":             balancer.Type,
+				"droplets":         fmt.Sprintf("%d", len(balancer.DropletIDs)),
+				"forwarding_rules": fmt.Sprintf("%d", len(balancer.ForwardingRules)),
+				"vpc_id":           balancer.VPCUUID,
+				"project_id":       balancer.ProjectID,
+				"created":          balancer.Created,
+			},
+		})
+	}
+
+	return resources, err
+}
@@ -0,0 +1,70 @@
+package digitalocean
+
+import (
+	"context"
+
+	"github.com/alwindoss/cloudy/pkg/cloudy"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+)
+
+// spacesRegions are the regions Spaces is offered in.
+var spacesRegions = map[string]bool{
+	"nyc3": true, "sfo2": true, "sfo3": true, "ams3": true, "fra1": true,
+	"lon1": true, "sgp1": true, "syd1": true, "blr1": true, "tor1": true,
+	"atl1": true,
+}
+
+func listSpaces(ctx context.Context, p *Provider, cfg aws.Config) ([]cloudy.Resource, error) {
+	if !spacesRegions[cfg.Region] {
+		return nil, nil
+	}
+
+	// Spaces speak the S3 API at an endpoint per region, which lists that
+	// region's Spaces
+	region := cfg.Region
+	cfg = cfg.Copy()
+	cfg.Credentials = p.spaces
+	cfg.BaseEndpoint = aws.String("https://" + region + ".digitaloceanspaces.com")
+	client := cloudy.Client(ctx, cfg, s3.NewFromConfig)
+	asSpaces := func(o *s3.Options) {
+		// Spaces sign requests for us-east-1 whatever the region
+		o.Region = "us-east-1"
+	}
+
+	var resources []cloudy.Resource
+	paginator := s3.NewListBucketsPaginator(client, &s3.ListBucketsInput{})
+	for paginator.HasMorePages() {
+		if err := checkMaxResults(ctx, len(resources)); err != nil {
+			return resources, err
+		}
+		page, err := paginator.NextPage(ctx, asSpaces)
+		if err != nil {
+			return resources, err
+		}
+
+		for _, bucket := range page.Buckets {
+			if bucket.BucketRegion != nil && *bucket.BucketRegion != region {
+				continue
+			}
+			created := ""
+			if bucket.CreationDate != nil {
+				created = bucket.CreationDate.String()
+			}
+
+			resources = append(resources, cloudy.Resource{
+				ID:     "do:space:" + aws.ToString(bucket.Name),
+				Name:   aws.ToString(bucket.Name),
+				Type:   "Space",
+				Region: region,
+				Tags:   map[string]string{},
+				Attributes: map[string]string{
+					"endpoint": "https://" + aws.ToString(bucket.Name) + "." + region + ".digitaloceanspaces.com",
+					"created":  created,
+				},
+			})
+		}
+	}
+
+	return resources, nil
+}
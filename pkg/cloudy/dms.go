@@ -0,0 +1,226 @@
+package cloudy
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	dms "github.com/aws/aws-sdk-go-v2/service/databasemigrationservice"
+	"github.com/aws/aws-sdk-go-v2/service/databasemigrationservice/types"
+)
+
+func init() {
+	Register(lister{
+		name:  "DMS replication instances and tasks",
+		types: []string{"DMS Replication Instance", "DMS Replication Task"},
+		iamActions: []string{
+			"dms:DescribeReplicationInstances",
+			"dms:DescribeReplicationTasks",
+			"dms:DescribeEndpoints",
+			"dms:ListTagsForResource",
+		},
+		list: withoutStates(listDMSResources),
+	})
+}
+
+// dmsTagBatch is how many ARNs one ListTagsForResource call is asked about.
+const dmsTagBatch = 20
+
+// listDMSResources lists DMS replication instances and tasks. The two are
+// independent, so a failure in one still returns what the other found.
+func listDMSResources(ctx context.Context, cfg aws.Config) ([]Resource, error) {
+	client := Client(ctx, cfg, dms.NewFromConfig)
+
+	var errs []error
+
+	resources, err := listDMSReplicationInstances(ctx, client, cfg.Region)
+	if err != nil {
+		errs = append(errs, fmt.Errorf("replication instances: %w", err))
+	}
+
+	taskResources, err := listDMSReplicationTasks(ctx, client, cfg.Region)
+	if err != nil {
+		errs = append(errs, fmt.Errorf("replication tasks: %w", err))
+	}
+	resources = append(resources, taskResources...)
+
+	tagDMSResources(ctx, client, resources)
+
+	return resources, errors.Join(errs...)
+}
+
+func listDMSReplicationInstances(ctx context.Context, client *dms.Client, region string) ([]Resource, error) {
+	var instances []types.ReplicationInstance
+	paginator := dms.NewDescribeReplicationInstancesPaginator(client, &dms.DescribeReplicationInstancesInput{})
+	for paginator.HasMorePages() {
+		if err := checkMaxResults(ctx, len(instances)); err != nil {
+			return nil, err
+		}
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return nil, err
+		}
+		instances = append(instances, page.ReplicationInstances...)
+	}
+
+	resources := make([]Resource, len(instances))
+	for i, instance := range instances {
+		resources[i] = Resource{
+			ID:     aws_string_value(instance.ReplicationInstanceArn),
+			Name:   aws_string_value(instance.ReplicationInstanceIdentifier),
+			Type:   "DMS Replication Instance",
+			State:  aws_string_value(instance.ReplicationInstanceStatus),
+			Region: region,
+			Attributes: map[string]string{
+				"instance_class":      aws_string_value(instance.ReplicationInstanceClass),
+				"allocated_storage":   fmt.Sprintf("%d", instance.AllocatedStorage),
+				"multi_az":            fmt.Sprintf("%t", instance.MultiAZ),
+				"engine_version":      aws_string_value(instance.EngineVersion),
+				"availability_zone":   aws_string_value(instance.AvailabilityZone),
+				"publicly_accessible": fmt.Sprintf("%t", instance.PubliclyAccessible),
+				"created":             aws_time_string(instance.InstanceCreateTime),
+				"age_days":            aws_age_days(instance.InstanceCreateTime),
+			},
+		}
+	}
+
+	return resources, nil
+}
+
+// dmsEndpoint is what a replication task reports about its source and
+// target endpoints.
+type dmsEndpoint struct {
+	identifier string
+	engine     string
+	server     string
+}
+
+func listDMSReplicationTasks(ctx context.Context, client *dms.Client, region string) ([]Resource, error) {
+	var tasks []types.ReplicationTask
+	paginator := dms.NewDescribeReplicationTasksPaginator(client, &dms.DescribeReplicationTasksInput{
+		// Task settings are a large JSON document we don't report
+		WithoutSettings: aws.Bool(true),
+	})
+	for paginator.HasMorePages() {
+		if err := checkMaxResults(ctx, len(tasks)); err != nil {
+			return nil, err
+		}
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return nil, err
+		}
+		tasks = append(tasks, page.ReplicationTasks...)
+	}
+
+	if len(tasks) == 0 {
+		return []Resource{}, nil
+	}
+
+	// Tasks only carry endpoint ARNs, so the endpoints are fetched in one
+	// pass rather than per task.
+	endpoints := make(map[string]dmsEndpoint)
+	var endpointsErr error
+	endpointPaginator := dms.NewDescribeEndpointsPaginator(client, &dms.DescribeEndpointsInput{})
+	for endpointPaginator.HasMorePages() {
+		page, err := endpointPaginator.NextPage(ctx)
+		if err != nil {
+			endpointsErr = err
+			break
+		}
+		for _, endpoint := range page.Endpoints {
+			endpoints[aws_string_value(endpoint.EndpointArn)] = dmsEndpoint{
+				identifier: aws_string_value(endpoint.EndpointIdentifier),
+				engine:     aws_string_value(endpoint.EngineName),
+				server:     aws_string_value(endpoint.ServerName),
+			}
+		}
+	}
+
+	resources := make([]Resource, len(tasks))
+	for i, task := range tasks {
+		attributes := map[string]string{
+			"migration_type":           string(task.MigrationType),
+			"replication_instance_arn": aws_string_value(task.ReplicationInstanceArn),
+			"source_endpoint_arn":      aws_string_value(task.SourceEndpointArn),
+			"target_endpoint_arn":      aws_string_value(task.TargetEndpointArn),
+			"created":                  aws_time_string(task.ReplicationTaskCreationDate),
+			"started":                  aws_time_string(task.ReplicationTaskStartDate),
+		}
+		if reason := aws_string_value(task.StopReason); reason != "" {
+			attributes["stop_reason"] = reason
+		}
+		if message := aws_string_value(task.LastFailureMessage); message != "" {
+			attributes["last_failure_message"] = message
+		}
+		if stats := task.ReplicationTaskStats; stats != nil {
+			attributes["full_load_progress_percent"] = fmt.Sprintf("%d", stats.FullLoadProgressPercent)
+			attributes["tables_loaded"] = fmt.Sprintf("%d", stats.TablesLoaded)
+			attributes["tables_errored"] = fmt.Sprintf("%d", stats.TablesErrored)
+		}
+
+		if endpointsErr != nil {
+			attributes["endpoints_error"] = endpointsErr.Error()
+		} else {
+			for prefix, arn := range map[string]*string{"source": task.SourceEndpointArn, "target": task.TargetEndpointArn} {
+				if endpoint, ok := endpoints[aws_string_value(arn)]; ok {
+					attributes[prefix+"_endpoint"] = endpoint.identifier
+					attributes[prefix+"_engine"] = endpoint.engine
+					attributes[prefix+"_server"] = endpoint.server
+				}
+			}
+		}
+
+		resources[i] = Resource{
+			ID:         aws_string_value(task.ReplicationTaskArn),
+			Name:       aws_string_value(task.ReplicationTaskIdentifier),
+			Type:       "DMS Replication Task",
+			State:      aws_string_value(task.Status),
+			Region:     region,
+			Attributes: attributes,
+		}
+	}
+
+	return resources, nil
+}
+
+// tagDMSResources sets the tags of resources, which DMS only returns
+// through ListTagsForResource. A batch that can't be tagged is recorded on
+// its resources, which are still listed.
+func tagDMSResources(ctx context.Context, client *dms.Client, resources []Resource) {
+	for start := 0; start < len(resources); start += dmsTagBatch {
+		batch := resources[start:min(start+dmsTagBatch, len(resources))]
+
+		arns := make([]string, len(batch))
+		for i, resource := range batch {
+			arns[i] = resource.ID
+		}
+
+		result, err := client.ListTagsForResource(ctx, &dms.ListTagsForResourceInput{ResourceArnList: arns})
+		if err != nil {
+			for i := range batch {
+				batch[i].Attributes["tags_error"] = err.Error()
+			}
+			continue
+		}
+
+		tags := make(map[string]map[string]string)
+		for _, tag := range result.TagList {
+			if tag.Key == nil || tag.Value == nil {
+				continue
+			}
+			arn := aws_string_value(tag.ResourceArn)
+			if tags[arn] == nil {
+				tags[arn] = make(map[string]string)
+			}
+			tags[arn][*tag.Key] = *tag.Value
+		}
+		for i := range batch {
+			if batchTags, ok := tags[batch[i].ID]; ok {
+				batch[i].Tags = batchTags
+			} else {
+				batch[i].Tags = map[string]string{}
+			}
+		}
+	}
+}
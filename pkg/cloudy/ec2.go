@@ -0,0 +1,155 @@
+package cloudy
+
+import (
+	"context"
+	"strconv"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	ec2types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
+)
+
+func init() {
+	Register(lister{
+		name:       "EBS volumes",
+		types:      []string{"EBS Volume"},
+		iamActions: []string{"ec2:DescribeVolumes"},
+		list:       listEBSVolumes,
+	})
+	Register(lister{
+		name:       "NAT gateways",
+		types:      []string{"NAT Gateway"},
+		iamActions: []string{"ec2:DescribeNatGateways"},
+		list:       listNATGateways,
+	})
+}
+
+func listEBSVolumes(ctx context.Context, cfg aws.Config, states []string) ([]Resource, error) {
+	client := Client(ctx, cfg, ec2.NewFromConfig)
+
+	input := &ec2.DescribeVolumesInput{}
+	if len(states) > 0 {
+		input.Filters = []ec2types.Filter{{Name: aws.String("status"), Values: lowerStates(states)}}
+	}
+
+	var resources []Resource
+	paginator := ec2.NewDescribeVolumesPaginator(client, input)
+	for paginator.HasMorePages() {
+		if err := checkMaxResults(ctx, len(resources)); err != nil {
+			return resources, err
+		}
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return resources, err
+		}
+
+		for _, volume := range page.Volumes {
+			tags, name := ec2Tags(volume.Tags)
+			attributes := map[string]string{
+				"volume_type":       string(volume.VolumeType),
+				"size_gb":           strconv.Itoa(int(aws_int32_value(volume.Size))),
+				"availability_zone": aws_string_value(volume.AvailabilityZone),
+				"encrypted":         strconv.FormatBool(aws_bool_value(volume.Encrypted)),
+				"created":           aws_time_string(volume.CreateTime),
+			}
+			if volume.Iops != nil {
+				attributes["iops"] = strconv.Itoa(int(*volume.Iops))
+			}
+			if volume.Throughput != nil {
+				attributes["throughput"] = strconv.Itoa(int(*volume.Throughput))
+			}
+			if volume.KmsKeyId != nil {
+				attributes["kms_key_id"] = *volume.KmsKeyId
+			}
+			// Multi-Attach volumes report the first of their instances
+			if len(volume.Attachments) > 0 {
+				attributes["instance_id"] = aws_string_value(volume.Attachments[0].InstanceId)
+				attributes["attachments"] = strconv.Itoa(len(volume.Attachments))
+			}
+
+			resources = append(resources, Resource{
+				ID:         aws_string_value(volume.VolumeId),
+				Name:       name,
+				Type:       "EBS Volume",
+				State:      string(volume.State),
+				Region:     cfg.Region,
+				Tags:       tags,
+				Attributes: attributes,
+			})
+		}
+	}
+
+	return resources, nil
+}
+
+func listNATGateways(ctx context.Context, cfg aws.Config, states []string) ([]Resource, error) {
+	client := Client(ctx, cfg, ec2.NewFromConfig)
+
+	input := &ec2.DescribeNatGatewaysInput{}
+	if len(states) > 0 {
+		input.Filter = []ec2types.Filter{{Name: aws.String("state"), Values: lowerStates(states)}}
+	}
+
+	var resources []Resource
+	paginator := ec2.NewDescribeNatGatewaysPaginator(client, input)
+	for paginator.HasMorePages() {
+		if err := checkMaxResults(ctx, len(resources)); err != nil {
+			return resources, err
+		}
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return resources, err
+		}
+
+		for _, gateway := range page.NatGateways {
+			tags, name := ec2Tags(gateway.Tags)
+			attributes := map[string]string{
+				"connectivity_type": string(gateway.ConnectivityType),
+				"vpc_id":            aws_string_value(gateway.VpcId),
+				"subnet_id":         aws_string_value(gateway.SubnetId),
+				"created":           aws_time_string(gateway.CreateTime),
+			}
+			for _, address := range gateway.NatGatewayAddresses {
+				if address.PublicIp != nil && attributes["public_ip"] == "" {
+					attributes["public_ip"] = *address.PublicIp
+				}
+				if address.PrivateIp != nil && attributes["private_ip"] == "" {
+					attributes["private_ip"] = *address.PrivateIp
+				}
+			}
+
+			resources = append(resources, Resource{
+				ID:         aws_string_value(gateway.NatGatewayId),
+				Name:       name,
+				Type:       "NAT Gateway",
+				State:      string(gateway.State),
+				Region:     cfg.Region,
+				Tags:       tags,
+				Attributes: attributes,
+			})
+		}
+	}
+
+	return resources, nil
+}
+
+// ec2Tags returns EC2 tags as a map, and the Name tag's value.
+func ec2Tags(ec2Tags []ec2types.Tag) (map[string]string, string) {
+	tags := make(map[string]string, len(ec2Tags))
+	for _, tag := range ec2Tags {
+		if tag.Key != nil && tag.Value != nil {
+			tags[*tag.Key] = *tag.Value
+		}
+	}
+	return tags, tags["Name"]
+}
+
+// lowerStates lowercases states, as EC2 filters take them.
+func lowerStates(states []string) []string {
+	lowered := make([]string, len(states))
+	for i, state := range states {
+		lowered[i] = strings.ToLower(state)
+	}
+	return lowered
+}
@@ -0,0 +1,174 @@
+package cloudy
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/emr"
+	"github.com/aws/aws-sdk-go-v2/service/emr/types"
+)
+
+func init() {
+	Register(lister{
+		name:  "EMR clusters",
+		types: []string{"EMR Cluster"},
+		iamActions: []string{
+			"elasticmapreduce:ListClusters",
+			"elasticmapreduce:DescribeCluster",
+			"elasticmapreduce:ListInstanceGroups",
+			"elasticmapreduce:ListInstanceFleets",
+			"elasticmapreduce:ListInstances",
+		},
+		list: withoutStates(listEMRClusters),
+	})
+}
+
+func listEMRClusters(ctx context.Context, cfg aws.Config) ([]Resource, error) {
+	client := Client(ctx, cfg, emr.NewFromConfig)
+
+	// Terminated clusters stay listable for two months; only live ones
+	// matter here
+	var summaries []types.ClusterSummary
+	paginator := emr.NewListClustersPaginator(client, &emr.ListClustersInput{
+		ClusterStates: []types.ClusterState{
+			types.ClusterStateStarting,
+			types.ClusterStateBootstrapping,
+			types.ClusterStateRunning,
+			types.ClusterStateWaiting,
+			types.ClusterStateTerminating,
+		},
+	})
+	for paginator.HasMorePages() {
+		if err := checkMaxResults(ctx, len(summaries)); err != nil {
+			return nil, err
+		}
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return nil, err
+		}
+		summaries = append(summaries, page.Clusters...)
+	}
+
+	resources := make([]Resource, len(summaries))
+	for i, summary := range summaries {
+		attributes := map[string]string{
+			"normalized_instance_hours": fmt.Sprintf("%d", aws_int32_value(summary.NormalizedInstanceHours)),
+		}
+
+		state := ""
+		if status := summary.Status; status != nil {
+			state = string(status.State)
+			if status.Timeline != nil {
+				attributes["created"] = aws_time_string(status.Timeline.CreationDateTime)
+				attributes["age_days"] = aws_age_days(status.Timeline.CreationDateTime)
+			}
+		}
+
+		resources[i] = Resource{
+			ID:         aws_string_value(summary.ClusterArn),
+			Name:       aws_string_value(summary.Name),
+			Type:       "EMR Cluster",
+			State:      state,
+			Region:     cfg.Region,
+			Attributes: attributes,
+		}
+	}
+
+	// Release label and instance counts need per-cluster calls. A cluster
+	// that can't be described is still listed, with the failure recorded on it.
+	forEachBounded(len(summaries), describeConcurrency, func(i int) {
+		if err := describeEMRCluster(ctx, client, summaries[i].Id, &resources[i]); err != nil {
+			resources[i].Attributes["error"] = err.Error()
+		}
+	})
+
+	return resources, nil
+}
+
+func describeEMRCluster(ctx context.Context, client *emr.Client, clusterID *string, resource *Resource) error {
+	result, err := client.DescribeCluster(ctx, &emr.DescribeClusterInput{ClusterId: clusterID})
+	if err != nil {
+		return fmt.Errorf("describe cluster: %w", err)
+	}
+	cluster := result.Cluster
+
+	var applications []string
+	for _, application := range cluster.Applications {
+		applications = append(applications, aws_string_value(application.Name))
+	}
+
+	resource.Attributes["release_label"] = aws_string_value(cluster.ReleaseLabel)
+	resource.Attributes["applications"] = strings.Join(applications, ",")
+	resource.Attributes["instance_collection_type"] = string(cluster.InstanceCollectionType)
+	resource.Attributes["auto_terminate"] = fmt.Sprintf("%t", aws_bool_value(cluster.AutoTerminate))
+	resource.Attributes["termination_protected"] = fmt.Sprintf("%t", aws_bool_value(cluster.TerminationProtected))
+
+	tags := make(map[string]string)
+	for _, tag := range cluster.Tags {
+		if tag.Key != nil && tag.Value != nil {
+			tags[*tag.Key] = *tag.Value
+		}
+	}
+	resource.Tags = tags
+
+	// Running instances per node type (MASTER, CORE, TASK). Fleets only
+	// report provisioned capacity in weighted units, so their instances are
+	// counted with ListInstances and the units are recorded alongside.
+	counts := make(map[string]int32)
+	if cluster.InstanceCollectionType == types.InstanceCollectionTypeInstanceFleet {
+		fleetTypes := make(map[string]string)
+		capacity := make(map[string]int32)
+		paginator := emr.NewListInstanceFleetsPaginator(client, &emr.ListInstanceFleetsInput{ClusterId: clusterID})
+		for paginator.HasMorePages() {
+			page, err := paginator.NextPage(ctx)
+			if err != nil {
+				return fmt.Errorf("list instance fleets: %w", err)
+			}
+			for _, fleet := range page.InstanceFleets {
+				fleetTypes[aws_string_value(fleet.Id)] = string(fleet.InstanceFleetType)
+				capacity[string(fleet.InstanceFleetType)] += aws_int32_value(fleet.ProvisionedOnDemandCapacity) + aws_int32_value(fleet.ProvisionedSpotCapacity)
+			}
+		}
+		for fleetType, units := range capacity {
+			resource.Attributes[strings.ToLower(fleetType)+"_capacity_units"] = fmt.Sprintf("%d", units)
+		}
+
+		instances := emr.NewListInstancesPaginator(client, &emr.ListInstancesInput{
+			ClusterId:      clusterID,
+			InstanceStates: []types.InstanceState{types.InstanceStateRunning},
+		})
+		for instances.HasMorePages() {
+			page, err := instances.NextPage(ctx)
+			if err != nil {
+				return fmt.Errorf("list instances: %w", err)
+			}
+			for _, instance := range page.Instances {
+				if fleetType, ok := fleetTypes[aws_string_value(instance.InstanceFleetId)]; ok {
+					counts[fleetType]++
+				}
+			}
+		}
+	} else {
+		paginator := emr.NewListInstanceGroupsPaginator(client, &emr.ListInstanceGroupsInput{ClusterId: clusterID})
+		for paginator.HasMorePages() {
+			page, err := paginator.NextPage(ctx)
+			if err != nil {
+				return fmt.Errorf("list instance groups: %w", err)
+			}
+			for _, group := range page.InstanceGroups {
+				counts[string(group.InstanceGroupType)] += aws_int32_value(group.RunningInstanceCount)
+			}
+		}
+	}
+
+	var total int32
+	for nodeType, count := range counts {
+		resource.Attributes[strings.ToLower(nodeType)+"_instances"] = fmt.Sprintf("%d", count)
+		total += count
+	}
+	resource.Attributes["instance_count"] = fmt.Sprintf("%d", total)
+
+	return nil
+}
@@ -0,0 +1,107 @@
+package cloudy
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/aws/retry"
+)
+
+// ServiceError is one service's failure to list a region, so callers know
+// which slice of the inventory is incomplete. Whatever the service listed
+// before it failed is still returned, as are other services' resources.
+type ServiceError struct {
+	Service string `json:"service" yaml:"service"`
+	Region  string `json:"region" yaml:"region"`
+	// Code is the AWS error code, like AccessDeniedException, or one of
+	// Timeout, Canceled, MaxResultsReached and SSOSessionExpired.
+	Code string `json:"code,omitempty" yaml:"code,omitempty"`
+	// Retryable reports whether the failure looks transient, so listing
+	// again may succeed.
+	Retryable bool   `json:"retryable" yaml:"retryable"`
+	Message   string `json:"message" yaml:"message"`
+
+	Err error `json:"-" yaml:"-"`
+}
+
+func newServiceError(service, region string, err error) *ServiceError {
+	return &ServiceError{
+		Service:   service,
+		Region:    region,
+		Code:      errorCode(err),
+		Retryable: retryableError(err),
+		Message:   err.Error(),
+		Err:       err,
+	}
+}
+
+func (e *ServiceError) Error() string {
+	if e.Service == "" {
+		return e.Message
+	}
+	return fmt.Sprintf("%s in %s: %s", e.Service, e.Region, e.Message)
+}
+
+func (e *ServiceError) Unwrap() error {
+	return e.Err
+}
+
+// ServiceErrors returns the ServiceErrors joined in err, as returned by
+// ListResourcesInRegion.
+func ServiceErrors(err error) []ServiceError {
+	if err == nil {
+		return nil
+	}
+	// Look into joined errors before errors.As, which stops at the first
+	if joined, ok := err.(interface{ Unwrap() []error }); ok {
+		var serviceErrs []ServiceError
+		for _, err := range joined.Unwrap() {
+			serviceErrs = append(serviceErrs, ServiceErrors(err)...)
+		}
+		return serviceErrs
+	}
+	var serviceErr *ServiceError
+	if errors.As(err, &serviceErr) {
+		return []ServiceError{*serviceErr}
+	}
+	return []ServiceError{{Code: errorCode(err), Retryable: retryableError(err), Message: err.Error(), Err: err}}
+}
+
+// errorSummary joins serviceErrs' messages into one line.
+func errorSummary(serviceErrs []ServiceError) string {
+	messages := make([]string, len(serviceErrs))
+	for i := range serviceErrs {
+		messages[i] = serviceErrs[i].Error()
+	}
+	return strings.Join(messages, "; ")
+}
+
+var isRetryable = retry.IsErrorRetryables(retry.DefaultRetryables)
+
+func errorCode(err error) string {
+	var timeout interface{ Timeout() bool }
+	switch {
+	case errors.Is(err, ErrMaxResults):
+		return "MaxResultsReached"
+	case errors.Is(err, ErrSSOSessionExpired):
+		return "SSOSessionExpired"
+	case errors.Is(err, context.DeadlineExceeded), errors.As(err, &timeout) && timeout.Timeout():
+		return "Timeout"
+	case errors.Is(err, context.Canceled):
+		return "Canceled"
+	}
+	return aws_error_code(err)
+}
+
+func retryableError(err error) bool {
+	switch errorCode(err) {
+	case "MaxResultsReached", "SSOSessionExpired":
+		return false
+	case "Timeout", "Canceled":
+		return true
+	}
+	return isRetryable.IsErrorRetryable(err) == aws.TrueTernary
+}
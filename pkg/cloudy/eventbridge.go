@@ -0,0 +1,204 @@
+package cloudy
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/eventbridge"
+	"github.com/aws/aws-sdk-go-v2/service/eventbridge/types"
+	"github.com/aws/aws-sdk-go-v2/service/scheduler"
+	schedulertypes "github.com/aws/aws-sdk-go-v2/service/scheduler/types"
+)
+
+func init() {
+	Register(lister{
+		name:       "EventBridge buses and rules",
+		types:      []string{"EventBridge Event Bus", "EventBridge Rule"},
+		iamActions: []string{"events:ListEventBuses", "events:ListRules", "events:ListTargetsByRule"},
+		list:       withoutStates(listEventBridgeResources),
+	})
+	Register(lister{
+		name:       "EventBridge schedules",
+		types:      []string{"EventBridge Schedule"},
+		iamActions: []string{"scheduler:ListSchedules", "scheduler:GetSchedule"},
+		list:       withoutStates(listEventBridgeSchedules),
+	})
+}
+
+func listEventBridgeResources(ctx context.Context, cfg aws.Config) ([]Resource, error) {
+	client := Client(ctx, cfg, eventbridge.NewFromConfig)
+
+	// The EventBridge SDK has no paginators, so follow NextToken by hand
+	var buses []types.EventBus
+	input := &eventbridge.ListEventBusesInput{}
+	for {
+		if err := checkMaxResults(ctx, len(buses)); err != nil {
+			return nil, err
+		}
+		result, err := client.ListEventBuses(ctx, input)
+		if err != nil {
+			return nil, err
+		}
+		buses = append(buses, result.EventBuses...)
+		if result.NextToken == nil {
+			break
+		}
+		input.NextToken = result.NextToken
+	}
+
+	var resources []Resource
+	for _, bus := range buses {
+		busName := aws_string_value(bus.Name)
+		attributes := map[string]string{
+			"description": aws_string_value(bus.Description),
+			"custom":      fmt.Sprintf("%t", busName != "default"),
+			"created":     aws_time_string(bus.CreationTime),
+		}
+
+		// Rules that couldn't be listed are reported on the bus itself so
+		// the other buses and their rules are still returned.
+		ruleResources, err := listEventBridgeRules(ctx, client, cfg.Region, busName)
+		if err != nil {
+			attributes["rules_error"] = err.Error()
+		}
+
+		resources = append(resources, Resource{
+			ID:         aws_string_value(bus.Arn),
+			Name:       busName,
+			Type:       "EventBridge Event Bus",
+			Region:     cfg.Region,
+			Attributes: attributes,
+		})
+		resources = append(resources, ruleResources...)
+	}
+
+	return resources, nil
+}
+
+func listEventBridgeRules(ctx context.Context, client *eventbridge.Client, region, busName string) ([]Resource, error) {
+	var resources []Resource
+
+	input := &eventbridge.ListRulesInput{EventBusName: aws.String(busName)}
+	for {
+		if err := checkMaxResults(ctx, len(resources)); err != nil {
+			return resources, err
+		}
+		result, err := client.ListRules(ctx, input)
+		if err != nil {
+			return resources, err
+		}
+
+		for _, rule := range result.Rules {
+			attributes := map[string]string{
+				"event_bus":   busName,
+				"description": aws_string_value(rule.Description),
+			}
+
+			if targetCount, err := countEventBridgeTargets(ctx, client, busName, rule.Name); err != nil {
+				attributes["target_count_error"] = err.Error()
+			} else {
+				attributes["target_count"] = fmt.Sprintf("%d", targetCount)
+			}
+
+			if rule.ScheduleExpression != nil {
+				attributes["rule_type"] = "schedule"
+				attributes["schedule_expression"] = *rule.ScheduleExpression
+			} else {
+				attributes["rule_type"] = "pattern"
+				attributes["event_pattern"] = aws_string_value(rule.EventPattern)
+			}
+			if rule.ManagedBy != nil {
+				attributes["managed_by"] = *rule.ManagedBy
+			}
+
+			resources = append(resources, Resource{
+				ID:         aws_string_value(rule.Arn),
+				Name:       aws_string_value(rule.Name),
+				Type:       "EventBridge Rule",
+				State:      string(rule.State),
+				Region:     region,
+				Attributes: attributes,
+			})
+		}
+
+		if result.NextToken == nil {
+			return resources, nil
+		}
+		input.NextToken = result.NextToken
+	}
+}
+
+func countEventBridgeTargets(ctx context.Context, client *eventbridge.Client, busName string, ruleName *string) (int, error) {
+	count := 0
+	input := &eventbridge.ListTargetsByRuleInput{
+		Rule:         ruleName,
+		EventBusName: aws.String(busName),
+	}
+	for {
+		result, err := client.ListTargetsByRule(ctx, input)
+		if err != nil {
+			return count, err
+		}
+		count += len(result.Targets)
+		if result.NextToken == nil {
+			return count, nil
+		}
+		input.NextToken = result.NextToken
+	}
+}
+
+func listEventBridgeSchedules(ctx context.Context, cfg aws.Config) ([]Resource, error) {
+	client := Client(ctx, cfg, scheduler.NewFromConfig)
+
+	var summaries []schedulertypes.ScheduleSummary
+	paginator := scheduler.NewListSchedulesPaginator(client, &scheduler.ListSchedulesInput{})
+	for paginator.HasMorePages() {
+		if err := checkMaxResults(ctx, len(summaries)); err != nil {
+			return nil, err
+		}
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return nil, err
+		}
+		summaries = append(summaries, page.Schedules...)
+	}
+
+	resources := make([]Resource, len(summaries))
+	for i, summary := range summaries {
+		attributes := map[string]string{
+			"group": aws_string_value(summary.GroupName),
+		}
+		if summary.Target != nil {
+			attributes["target_arn"] = aws_string_value(summary.Target.Arn)
+		}
+		resources[i] = Resource{
+			ID:         aws_string_value(summary.Arn),
+			Name:       aws_string_value(summary.Name),
+			Type:       "EventBridge Schedule",
+			State:      string(summary.State),
+			Region:     cfg.Region,
+			Attributes: attributes,
+		}
+	}
+
+	// The expression a schedule runs on needs a GetSchedule per schedule.
+	// A schedule that can't be read is still listed, with the failure
+	// recorded on it.
+	forEachBounded(len(summaries), describeConcurrency, func(i int) {
+		schedule, err := client.GetSchedule(ctx, &scheduler.GetScheduleInput{
+			Name:      summaries[i].Name,
+			GroupName: summaries[i].GroupName,
+		})
+		if err != nil {
+			resources[i].Attributes["error"] = err.Error()
+			return
+		}
+		resources[i].Attributes["schedule_expression"] = aws_string_value(schedule.ScheduleExpression)
+		if timezone := aws_string_value(schedule.ScheduleExpressionTimezone); timezone != "" {
+			resources[i].Attributes["schedule_timezone"] = timezone
+		}
+	})
+
+	return resources, nil
+}
@@ -0,0 +1,145 @@
+package cloudy
+
+import (
+	"context"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/resourceexplorer2"
+	explorertypes "github.com/aws/aws-sdk-go-v2/service/resourceexplorer2/types"
+)
+
+// explorerTypes maps Resource Explorer resource types to the resource
+// types the listers produce.
+var explorerTypes = map[string]string{
+	"ec2:instance":             "EC2 Instance",
+	"ec2:volume":               "EBS Volume",
+	"ec2:natgateway":           "NAT Gateway",
+	"s3:bucket":                "S3 Bucket",
+	"rds:db":                   "RDS Instance",
+	"rds:snapshot":             "RDS Snapshot",
+	"rds:cluster-snapshot":     "RDS Cluster Snapshot",
+	"lambda:function":          "Lambda Function",
+	"ecs:cluster":              "ECS Cluster",
+	"iam:user":                 "IAM User",
+	"apprunner:service":        "App Runner Service",
+	"cloudtrail:trail":         "CloudTrail Trail",
+	"elasticmapreduce:cluster": "EMR Cluster",
+	"events:event-bus":         "EventBridge Event Bus",
+	"events:rule":              "EventBridge Rule",
+	"guardduty:detector":       "GuardDuty Detector",
+	"workspaces:workspace":     "WorkSpace",
+}
+
+// explorerGlobalRegion is the region Resource Explorer reports global
+// resources, like IAM users, in.
+const explorerGlobalRegion = "global"
+
+// SetExplorer makes ScanExplorer and StreamExplorer query the Resource
+// Explorer index in region, through view, or the region's default view if
+// view is "". To see every region, region must hold the account's
+// aggregator index. By default the Scanner's own region is used. It must
+// be called before the Scanner is used.
+func (s *Scanner) SetExplorer(region, view string) {
+	s.explorerRegion = region
+	s.explorerView = view
+}
+
+// explorerIndex is AWS Resource Explorer, as used by explorer scans.
+func (s *Scanner) explorerIndex() index {
+	indexRegion := s.explorerRegion
+	if indexRegion == "" {
+		indexRegion = s.cfg.Region
+	}
+
+	return index{
+		name:  "Resource Explorer",
+		types: explorerTypes,
+		list: func(ctx context.Context, region string, indexTypes []string) ([]Resource, error) {
+			client := Client(ctx, s.RegionConfig(indexRegion), resourceexplorer2.NewFromConfig)
+			return listExplorerResources(ctx, client, s.explorerView, region, indexTypes)
+		},
+	}
+}
+
+// StreamExplorer is a StreamRegions built on AWS Resource Explorer, for
+// accounts that have it turned on: each region takes one paginated query
+// of an index Resource Explorer keeps up to date, instead of a call per
+// service.
+// Resources are returned as by StreamFast, untagged ones included, and
+// global resources are returned under their partition's global region as
+// in a regular scan.
+//
+// Resource Explorer only knows the resources in the view queried and can
+// lag changes by a few minutes. See SetExplorer for the index used.
+func (s *Scanner) StreamExplorer(ctx context.Context, regions, resourceTypes []string) <-chan RegionResources {
+	return s.explorerIndex().stream(ctx, s, regions, resourceTypes)
+}
+
+// ScanExplorer is the Scan form of StreamExplorer.
+func (s *Scanner) ScanExplorer(ctx context.Context, regions, resourceTypes []string) []RegionResources {
+	var regionData []RegionResources
+	for rd := range s.StreamExplorer(ctx, regions, resourceTypes) {
+		regionData = append(regionData, rd)
+	}
+	return regionData
+}
+
+func listExplorerResources(ctx context.Context, client *resourceexplorer2.Client, view, region string, resourceTypes []string) ([]Resource, error) {
+	// Filters with the same prefix match any of them; different prefixes
+	// must all match
+	filters := []string{"region:" + region}
+	if isGlobalRegion(region) {
+		filters = append(filters, "region:"+explorerGlobalRegion)
+	}
+	for _, resourceType := range resourceTypes {
+		filters = append(filters, "resourcetype:"+resourceType)
+	}
+
+	input := &resourceexplorer2.ListResourcesInput{
+		Filters: &explorertypes.SearchFilter{FilterString: aws.String(strings.Join(filters, " "))},
+	}
+	if view != "" {
+		input.ViewArn = aws.String(view)
+	}
+
+	var resources []Resource
+	paginator := resourceexplorer2.NewListResourcesPaginator(client, input)
+	for paginator.HasMorePages() {
+		if err := checkMaxResults(ctx, len(resources)); err != nil {
+			return resources, err
+		}
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return resources, err
+		}
+
+		for _, found := range page.Resources {
+			resources = append(resources, indexedResource(explorerTypes, aws_string_value(found.Arn), aws_string_value(found.ResourceType), region, explorerTags(found)))
+		}
+	}
+
+	return resources, nil
+}
+
+// explorerTags decodes a resource's tags property, which holds a list of
+// key-value pairs.
+func explorerTags(resource explorertypes.Resource) map[string]string {
+	tags := make(map[string]string)
+	for _, property := range resource.Properties {
+		if aws_string_value(property.Name) != "tags" || property.Data == nil {
+			continue
+		}
+		var pairs []struct {
+			Key   string
+			Value string
+		}
+		if err := property.Data.UnmarshalSmithyDocument(&pairs); err != nil {
+			continue
+		}
+		for _, pair := range pairs {
+			tags[pair.Key] = pair.Value
+		}
+	}
+	return tags
+}
@@ -0,0 +1,93 @@
+package cloudy
+
+import (
+	"context"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/resourcegroupstaggingapi"
+)
+
+// taggedTypes maps Tagging API resource type filters to the resource
+// types the listers produce.
+var taggedTypes = map[string]string{
+	"ec2:instance":             "EC2 Instance",
+	"ec2:volume":               "EBS Volume",
+	"ec2:natgateway":           "NAT Gateway",
+	"s3":                       "S3 Bucket",
+	"rds:db":                   "RDS Instance",
+	"rds:snapshot":             "RDS Snapshot",
+	"rds:cluster-snapshot":     "RDS Cluster Snapshot",
+	"lambda:function":          "Lambda Function",
+	"ecs:cluster":              "ECS Cluster",
+	"apprunner:service":        "App Runner Service",
+	"cloudtrail:trail":         "CloudTrail Trail",
+	"config:config-rule":       "Config Rule",
+	"elasticmapreduce:cluster": "EMR Cluster",
+	"events:event-bus":         "EventBridge Event Bus",
+	"events:rule":              "EventBridge Rule",
+	"guardduty:detector":       "GuardDuty Detector",
+	"workspaces:workspace":     "WorkSpace",
+}
+
+// taggingIndex is the Resource Groups Tagging API, as used by fast scans.
+func (s *Scanner) taggingIndex() index {
+	return index{
+		name:  "Resource Groups Tagging API",
+		types: taggedTypes,
+		list: func(ctx context.Context, region string, indexTypes []string) ([]Resource, error) {
+			return listTaggedResources(ctx, s.RegionConfig(region), indexTypes)
+		},
+	}
+}
+
+// StreamFast is a quicker StreamRegions built on the Resource Groups
+// Tagging API, which returns most services' resources, with their tags, in
+// a few calls per region. Its resources only carry an ID, which is always
+// the ARN, a name (the Name tag, or the last part of the ARN), a type, a
+// region and tags; they have no state or attributes. Resources of a type
+// no lister produces are typed after their ARN, like "dynamodb:table".
+//
+// The Tagging API only returns resources that have, or once had, tags. If
+// resourceTypes includes types it can't list, their listers run as in a
+// regular scan, so they come with state and attributes.
+func (s *Scanner) StreamFast(ctx context.Context, regions, resourceTypes []string) <-chan RegionResources {
+	return s.taggingIndex().stream(ctx, s, regions, resourceTypes)
+}
+
+// ScanFast is the Scan form of StreamFast.
+func (s *Scanner) ScanFast(ctx context.Context, regions, resourceTypes []string) []RegionResources {
+	var regionData []RegionResources
+	for rd := range s.StreamFast(ctx, regions, resourceTypes) {
+		regionData = append(regionData, rd)
+	}
+	return regionData
+}
+
+func listTaggedResources(ctx context.Context, cfg aws.Config, filters []string) ([]Resource, error) {
+	client := Client(ctx, cfg, resourcegroupstaggingapi.NewFromConfig)
+
+	var resources []Resource
+	paginator := resourcegroupstaggingapi.NewGetResourcesPaginator(client, &resourcegroupstaggingapi.GetResourcesInput{
+		ResourceTypeFilters: filters,
+		ResourcesPerPage:    aws.Int32(100),
+	})
+	for paginator.HasMorePages() {
+		if err := checkMaxResults(ctx, len(resources)); err != nil {
+			return resources, err
+		}
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return resources, err
+		}
+
+		for _, mapping := range page.ResourceTagMappingList {
+			tags := make(map[string]string)
+			for _, tag := range mapping.Tags {
+				tags[aws_string_value(tag.Key)] = aws_string_value(tag.Value)
+			}
+			resources = append(resources, indexedResource(taggedTypes, aws_string_value(mapping.ResourceARN), "", cfg.Region, tags))
+		}
+	}
+
+	return resources, nil
+}
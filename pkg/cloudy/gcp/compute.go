@@ -0,0 +1,66 @@
+package gcp
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/alwindoss/cloudy/pkg/cloudy"
+	"github.com/alwindoss/cloudy/pkg/cloudy/internal/listings"
+	compute "google.golang.org/api/compute/v1"
+)
+
+func listInstances(ctx context.Context, p *Provider, project, region string) ([]cloudy.Resource, error) {
+	// Instances are zonal, so the whole project is listed once and split
+	// by the region of each instance's zone
+	instances, err := listings.Shared(ctx, p.listings, "instances/"+project, func(ctx context.Context) ([]*compute.Instance, error) {
+		var instances []*compute.Instance
+		err := p.compute.Instances.AggregatedList(project).ReturnPartialSuccess(true).Pages(ctx, func(page *compute.InstanceAggregatedList) error {
+			for _, scope := range page.Items {
+				instances = append(instances, scope.Instances...)
+			}
+			return checkMaxResults(ctx, len(instances))
+		})
+		return instances, apiError(err)
+	})
+
+	var resources []cloudy.Resource
+	for _, instance := range instances {
+		zone := lastSegment(instance.Zone)
+		if locationRegion(zone) != region {
+			continue
+		}
+
+		attributes := baseAttributes(project)
+		attributes["zone"] = zone
+		attributes["instance_id"] = fmt.Sprintf("%d", instance.Id)
+		attributes["machine_type"] = lastSegment(instance.MachineType)
+		attributes["cpu_platform"] = instance.CpuPlatform
+		attributes["created"] = instance.CreationTimestamp
+		if instance.Scheduling != nil && instance.Scheduling.ProvisioningModel != "" {
+			attributes["provisioning_model"] = instance.Scheduling.ProvisioningModel
+		}
+		for _, nic := range instance.NetworkInterfaces {
+			if attributes["private_ip"] == "" {
+				attributes["private_ip"] = nic.NetworkIP
+			}
+			for _, access := range nic.AccessConfigs {
+				if attributes["public_ip"] == "" && access.NatIP != "" {
+					attributes["public_ip"] = access.NatIP
+				}
+			}
+		}
+
+		resources = append(resources, cloudy.Resource{
+			ID:         fmt.Sprintf("//compute.googleapis.com/projects/%s/zones/%s/instances/%s", project, zone, instance.Name),
+			Name:       instance.Name,
+			Type:       "GCE Instance",
+			State:      strings.ToLower(instance.Status),
+			Region:     region,
+			Tags:       labels(instance.Labels),
+			Attributes: attributes,
+		})
+	}
+
+	return resources, err
+}
@@ -0,0 +1,56 @@
+package gcp
+
+import (
+	"context"
+	"strings"
+
+	"github.com/alwindoss/cloudy/pkg/cloudy"
+	"github.com/alwindoss/cloudy/pkg/cloudy/internal/listings"
+	cloudfunctions "google.golang.org/api/cloudfunctions/v2"
+)
+
+func listFunctions(ctx context.Context, p *Provider, project, region string) ([]cloudy.Resource, error) {
+	// Functions of every region, 1st and 2nd gen, come in one listing
+	functions, err := listings.Shared(ctx, p.listings, "functions/"+project, func(ctx context.Context) ([]*cloudfunctions.Function, error) {
+		var functions []*cloudfunctions.Function
+		err := p.functions.Projects.Locations.Functions.List("projects/"+project+"/locations/-").Pages(ctx, func(page *cloudfunctions.ListFunctionsResponse) error {
+			functions = append(functions, page.Functions...)
+			return checkMaxResults(ctx, len(functions))
+		})
+		return functions, apiError(err)
+	})
+
+	var resources []cloudy.Resource
+	for _, function := range functions {
+		// Names are projects/<project>/locations/<region>/functions/<name>
+		parts := strings.Split(function.Name, "/")
+		if len(parts) != 6 || parts[3] != region {
+			continue
+		}
+
+		attributes := baseAttributes(project)
+		attributes["environment"] = function.Environment
+		attributes["url"] = function.Url
+		attributes["updated"] = function.UpdateTime
+		if build := function.BuildConfig; build != nil {
+			attributes["runtime"] = build.Runtime
+			attributes["entry_point"] = build.EntryPoint
+		}
+		if service := function.ServiceConfig; service != nil {
+			attributes["memory"] = service.AvailableMemory
+			attributes["service_account"] = service.ServiceAccountEmail
+		}
+
+		resources = append(resources, cloudy.Resource{
+			ID:         "//cloudfunctions.googleapis.com/" + function.Name,
+			Name:       parts[5],
+			Type:       "Cloud Function",
+			State:      function.State,
+			Region:     region,
+			Tags:       labels(function.Labels),
+			Attributes: attributes,
+		})
+	}
+
+	return resources, err
+}
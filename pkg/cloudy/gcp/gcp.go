@@ -0,0 +1,218 @@
+// Package gcp is Cloudy's GCP provider. It lists Compute Engine instances,
+// Cloud Storage buckets, GKE clusters, Cloud SQL instances and Cloud
+// Functions in one or more projects, region by region, as
+// cloudy.Resources, so a Scanner from cloudy.NewProviderScanner scans GCP
+// the way it scans AWS.
+package gcp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/alwindoss/cloudy/pkg/cloudy"
+	"github.com/alwindoss/cloudy/pkg/cloudy/internal/listings"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/smithy-go"
+	cloudfunctions "google.golang.org/api/cloudfunctions/v2"
+	compute "google.golang.org/api/compute/v1"
+	container "google.golang.org/api/container/v1"
+	"google.golang.org/api/googleapi"
+	"google.golang.org/api/option"
+	sqladmin "google.golang.org/api/sqladmin/v1"
+	storage "google.golang.org/api/storage/v1"
+)
+
+// ProviderName names the GCP provider in requests and on its resources.
+const ProviderName = "gcp"
+
+// listingTTL is how long a project-wide listing is shared. Instances,
+// buckets, clusters, Cloud SQL instances and functions are listed for a
+// whole project at once, so the regions of a scan, which all ask for them
+// at about the same time, take theirs out of one listing instead of each
+// listing the project again.
+const listingTTL = 30 * time.Second
+
+// Provider is GCP, scanned with one set of credentials across projects.
+type Provider struct {
+	projects []string
+
+	compute   *compute.Service
+	storage   *storage.Service
+	container *container.Service
+	sql       *sqladmin.Service
+	functions *cloudfunctions.Service
+
+	listings *listings.Cache
+}
+
+// NewProvider returns the GCP provider for projects. Without options it
+// authenticates with Application Default Credentials; options may give
+// other credentials or endpoints.
+func NewProvider(ctx context.Context, projects []string, opts ...option.ClientOption) (*Provider, error) {
+	p := &Provider{projects: projects, listings: &listings.Cache{TTL: listingTTL}}
+
+	var err error
+	if p.compute, err = compute.NewService(ctx, opts...); err != nil {
+		return nil, fmt.Errorf("compute: %w", err)
+	}
+	if p.storage, err = storage.NewService(ctx, opts...); err != nil {
+		return nil, fmt.Errorf("storage: %w", err)
+	}
+	if p.container, err = container.NewService(ctx, opts...); err != nil {
+		return nil, fmt.Errorf("container: %w", err)
+	}
+	if p.sql, err = sqladmin.NewService(ctx, opts...); err != nil {
+		return nil, fmt.Errorf("sqladmin: %w", err)
+	}
+	if p.functions, err = cloudfunctions.NewService(ctx, opts...); err != nil {
+		return nil, fmt.Errorf("cloudfunctions: %w", err)
+	}
+	return p, nil
+}
+
+func (p *Provider) Name() string { return ProviderName }
+
+// Regions returns the Compute Engine regions, like us-central1, that are
+// up in any of the projects.
+func (p *Provider) Regions(ctx context.Context) ([]string, error) {
+	seen := make(map[string]bool)
+	var regions []string
+	for _, project := range p.projects {
+		err := p.compute.Regions.List(project).Pages(ctx, func(page *compute.RegionList) error {
+			for _, region := range page.Items {
+				if region.Status == "UP" && !seen[region.Name] {
+					seen[region.Name] = true
+					regions = append(regions, region.Name)
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, apiError(err)
+		}
+	}
+	sort.Strings(regions)
+	return regions, nil
+}
+
+// Listers returns a lister for each supported service.
+func (p *Provider) Listers() []cloudy.ServiceLister {
+	return []cloudy.ServiceLister{
+		lister{p: p, name: "Compute Engine instances", types: []string{"GCE Instance"}, permissions: []string{"compute.instances.list"}, list: listInstances},
+		lister{p: p, name: "Cloud Storage buckets", types: []string{"GCS Bucket"}, permissions: []string{"storage.buckets.list"}, list: listBuckets},
+		lister{p: p, name: "GKE clusters", types: []string{"GKE Cluster"}, permissions: []string{"container.clusters.list"}, list: listClusters},
+		lister{p: p, name: "Cloud SQL instances", types: []string{"Cloud SQL Instance"}, permissions: []string{"cloudsql.instances.list"}, list: listSQLInstances},
+		lister{p: p, name: "Cloud Functions", types: []string{"Cloud Function"}, permissions: []string{"cloudfunctions.functions.list"}, list: listFunctions},
+	}
+}
+
+// listFunc lists a service's resources in one project and region.
+type listFunc func(ctx context.Context, p *Provider, project, region string) ([]cloudy.Resource, error)
+
+// lister is a cloudy.ServiceLister for a GCP service. List is passed the
+// region to list as the config's region, and lists it in every project.
+type lister struct {
+	p           *Provider
+	name        string
+	types       []string
+	permissions []string
+	list        listFunc
+}
+
+func (l lister) Name() string    { return l.name }
+func (l lister) Types() []string { return l.types }
+func (l lister) Global() bool    { return false }
+
+// IAMActions are the GCP IAM permissions List needs, all of which the
+// basic Viewer role has.
+func (l lister) IAMActions() []string { return l.permissions }
+
+func (l lister) List(ctx context.Context, cfg aws.Config, _ []string) ([]cloudy.Resource, error) {
+	var resources []cloudy.Resource
+	var errs []error
+	for _, project := range l.p.projects {
+		listed, err := l.list(ctx, l.p, project, cfg.Region)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("project %s: %w", project, err))
+		}
+		resources = append(resources, listed...)
+	}
+	return resources, errors.Join(errs...)
+}
+
+// checkMaxResults fails once n items have been listed, at the cap of the
+// scan ctx belongs to.
+func checkMaxResults(ctx context.Context, n int) error {
+	if limit := cloudy.MaxResults(ctx); n >= limit {
+		return fmt.Errorf("%w: stopped after %d", cloudy.ErrMaxResults, limit)
+	}
+	return nil
+}
+
+// responseError gives a GCP error's reason and status to cloudy's
+// ServiceErrors, which read them as they read an AWS error's.
+type responseError struct {
+	err *googleapi.Error
+}
+
+// ErrorCode returns the error's first reason, like rateLimitExceeded, or
+// else its HTTP status, like NotFound.
+func (e responseError) ErrorCode() string {
+	if len(e.err.Errors) > 0 && e.err.Errors[0].Reason != "" {
+		return e.err.Errors[0].Reason
+	}
+	return strings.ReplaceAll(http.StatusText(e.err.Code), " ", "")
+}
+
+func (e responseError) Error() string        { return e.err.Error() }
+func (e responseError) ErrorMessage() string { return e.err.Message }
+func (e responseError) HTTPStatusCode() int  { return e.err.Code }
+func (e responseError) Unwrap() error        { return e.err }
+
+func (e responseError) ErrorFault() smithy.ErrorFault {
+	if e.err.Code >= 500 {
+		return smithy.FaultServer
+	}
+	return smithy.FaultClient
+}
+
+// apiError wraps err in a responseError if it is a GCP error response.
+func apiError(err error) error {
+	var apiErr *googleapi.Error
+	if errors.As(err, &apiErr) {
+		return responseError{apiErr}
+	}
+	return err
+}
+
+// baseAttributes returns the attributes every resource carries.
+func baseAttributes(project string) map[string]string {
+	return map[string]string{"project_id": project}
+}
+
+// locationRegion returns the region of a location: the location itself
+// if it is a region, like us-central1, or the region of a zone, like
+// us-central1-a.
+func locationRegion(location string) string {
+	if strings.Count(location, "-") > 1 {
+		return location[:strings.LastIndex(location, "-")]
+	}
+	return location
+}
+
+// lastSegment returns the name at the end of a resource name or URL.
+func lastSegment(name string) string {
+	return name[strings.LastIndex(name, "/")+1:]
+}
+
+func labels(labels map[string]string) map[string]string {
+	if labels == nil {
+		return make(map[string]string)
+	}
+	return labels
+}
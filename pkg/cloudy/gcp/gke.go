@@ -0,0 +1,52 @@
+package gcp
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/alwindoss/cloudy/pkg/cloudy"
+	"github.com/alwindoss/cloudy/pkg/cloudy/internal/listings"
+	container "google.golang.org/api/container/v1"
+)
+
+func listClusters(ctx context.Context, p *Provider, project, region string) ([]cloudy.Resource, error) {
+	// Regional and zonal clusters of every location come in one listing
+	clusters, err := listings.Shared(ctx, p.listings, "clusters/"+project, func(ctx context.Context) ([]*container.Cluster, error) {
+		page, err := p.container.Projects.Locations.Clusters.List("projects/" + project + "/locations/-").Context(ctx).Do()
+		if err != nil {
+			return nil, apiError(err)
+		}
+		return page.Clusters, nil
+	})
+
+	var resources []cloudy.Resource
+	for _, cluster := range clusters {
+		if locationRegion(cluster.Location) != region {
+			continue
+		}
+
+		attributes := baseAttributes(project)
+		attributes["location"] = cluster.Location
+		attributes["kubernetes_version"] = cluster.CurrentMasterVersion
+		attributes["endpoint"] = cluster.Endpoint
+		attributes["node_pools"] = fmt.Sprintf("%d", len(cluster.NodePools))
+		attributes["nodes"] = fmt.Sprintf("%d", cluster.CurrentNodeCount)
+		attributes["autopilot"] = fmt.Sprintf("%t", cluster.Autopilot != nil && cluster.Autopilot.Enabled)
+		attributes["created"] = cluster.CreateTime
+		if cluster.ReleaseChannel != nil {
+			attributes["release_channel"] = cluster.ReleaseChannel.Channel
+		}
+
+		resources = append(resources, cloudy.Resource{
+			ID:         fmt.Sprintf("//container.googleapis.com/projects/%s/locations/%s/clusters/%s", project, cluster.Location, cluster.Name),
+			Name:       cluster.Name,
+			Type:       "GKE Cluster",
+			State:      cluster.Status,
+			Region:     region,
+			Tags:       labels(cluster.ResourceLabels),
+			Attributes: attributes,
+		})
+	}
+
+	return resources, err
+}
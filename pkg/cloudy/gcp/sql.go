@@ -0,0 +1,54 @@
+package gcp
+
+import (
+	"context"
+
+	"github.com/alwindoss/cloudy/pkg/cloudy"
+	"github.com/alwindoss/cloudy/pkg/cloudy/internal/listings"
+	sqladmin "google.golang.org/api/sqladmin/v1"
+)
+
+func listSQLInstances(ctx context.Context, p *Provider, project, region string) ([]cloudy.Resource, error) {
+	// Cloud SQL instances can only be listed for the whole project
+	instances, err := listings.Shared(ctx, p.listings, "sql-instances/"+project, func(ctx context.Context) ([]*sqladmin.DatabaseInstance, error) {
+		var instances []*sqladmin.DatabaseInstance
+		err := p.sql.Instances.List(project).Pages(ctx, func(page *sqladmin.InstancesListResponse) error {
+			instances = append(instances, page.Items...)
+			return checkMaxResults(ctx, len(instances))
+		})
+		return instances, apiError(err)
+	})
+
+	var resources []cloudy.Resource
+	for _, instance := range instances {
+		if instance.Region != region {
+			continue
+		}
+
+		attributes := baseAttributes(project)
+		attributes["database_version"] = instance.DatabaseVersion
+		attributes["instance_type"] = instance.InstanceType
+		attributes["connection_name"] = instance.ConnectionName
+		attributes["zone"] = instance.GceZone
+		attributes["created"] = instance.CreateTime
+		tags := make(map[string]string)
+		if settings := instance.Settings; settings != nil {
+			attributes["tier"] = settings.Tier
+			attributes["availability_type"] = settings.AvailabilityType
+			attributes["edition"] = settings.Edition
+			tags = labels(settings.UserLabels)
+		}
+
+		resources = append(resources, cloudy.Resource{
+			ID:         "//cloudsql.googleapis.com/projects/" + project + "/instances/" + instance.Name,
+			Name:       instance.Name,
+			Type:       "Cloud SQL Instance",
+			State:      instance.State,
+			Region:     region,
+			Tags:       tags,
+			Attributes: attributes,
+		})
+	}
+
+	return resources, err
+}
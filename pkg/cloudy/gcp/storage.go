@@ -0,0 +1,58 @@
+package gcp
+
+import (
+	"context"
+	"strings"
+
+	"github.com/alwindoss/cloudy/pkg/cloudy"
+	"github.com/alwindoss/cloudy/pkg/cloudy/internal/listings"
+	storage "google.golang.org/api/storage/v1"
+)
+
+func listBuckets(ctx context.Context, p *Provider, project, region string) ([]cloudy.Resource, error) {
+	// Buckets can only be listed for the whole project
+	buckets, err := listings.Shared(ctx, p.listings, "buckets/"+project, func(ctx context.Context) ([]*storage.Bucket, error) {
+		var buckets []*storage.Bucket
+		err := p.storage.Buckets.List(project).Pages(ctx, func(page *storage.Buckets) error {
+			buckets = append(buckets, page.Items...)
+			return checkMaxResults(ctx, len(buckets))
+		})
+		return buckets, apiError(err)
+	})
+
+	var resources []cloudy.Resource
+	for _, bucket := range buckets {
+		// Multi- and dual-region buckets, in locations like US or NAM4,
+		// are listed under that location rather than a region
+		if strings.ToLower(bucket.Location) != region {
+			continue
+		}
+
+		attributes := baseAttributes(project)
+		attributes["location_type"] = bucket.LocationType
+		attributes["storage_class"] = bucket.StorageClass
+		attributes["created"] = bucket.TimeCreated
+		attributes["versioning"] = "false"
+		if bucket.Versioning != nil && bucket.Versioning.Enabled {
+			attributes["versioning"] = "true"
+		}
+		if iam := bucket.IamConfiguration; iam != nil {
+			attributes["public_access_prevention"] = iam.PublicAccessPrevention
+			attributes["uniform_access"] = "false"
+			if iam.UniformBucketLevelAccess != nil && iam.UniformBucketLevelAccess.Enabled {
+				attributes["uniform_access"] = "true"
+			}
+		}
+
+		resources = append(resources, cloudy.Resource{
+			ID:         "//storage.googleapis.com/projects/_/buckets/" + bucket.Name,
+			Name:       bucket.Name,
+			Type:       "GCS Bucket",
+			Region:     region,
+			Tags:       labels(bucket.Labels),
+			Attributes: attributes,
+		})
+	}
+
+	return resources, err
+}
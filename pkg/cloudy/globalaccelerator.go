@@ -0,0 +1,130 @@
+package cloudy
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/globalaccelerator"
+	"github.com/aws/aws-sdk-go-v2/service/globalaccelerator/types"
+)
+
+func init() {
+	Register(lister{
+		name:       "Global accelerators",
+		types:      []string{"Global Accelerator", "Global Accelerator Listener"},
+		global:     true,
+		iamActions: []string{"globalaccelerator:ListAccelerators", "globalaccelerator:ListListeners"},
+		partitions: []string{PartitionAWS},
+		list:       withoutStates(listGlobalAccelerators),
+	})
+}
+
+// Global Accelerator is a global service whose API is only served from
+// us-west-2, whatever region the scan is running in.
+const globalAcceleratorRegion = "us-west-2"
+
+func listGlobalAccelerators(ctx context.Context, cfg aws.Config) ([]Resource, error) {
+	gaCfg := cfg.Copy()
+	gaCfg.Region = globalAcceleratorRegion
+	client := Client(ctx, gaCfg, globalaccelerator.NewFromConfig)
+
+	var accelerators []types.Accelerator
+	paginator := globalaccelerator.NewListAcceleratorsPaginator(client, &globalaccelerator.ListAcceleratorsInput{})
+	for paginator.HasMorePages() {
+		if err := checkMaxResults(ctx, len(accelerators)); err != nil {
+			return nil, err
+		}
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return nil, err
+		}
+		accelerators = append(accelerators, page.Accelerators...)
+	}
+
+	var resources []Resource
+	for _, accelerator := range accelerators {
+		var ips []string
+		for _, ipSet := range accelerator.IpSets {
+			ips = append(ips, ipSet.IpAddresses...)
+		}
+
+		enabled := aws_bool_value(accelerator.Enabled)
+		state := "DISABLED"
+		if enabled {
+			state = "ENABLED"
+		}
+
+		attributes := map[string]string{
+			"dns_name":            aws_string_value(accelerator.DnsName),
+			"dual_stack_dns_name": aws_string_value(accelerator.DualStackDnsName),
+			"enabled":             fmt.Sprintf("%t", enabled),
+			"ip_address_type":     string(accelerator.IpAddressType),
+			"ip_addresses":        strings.Join(ips, ","),
+		}
+
+		// A listener failure is reported on its accelerator so the other
+		// accelerators and their listeners are still returned.
+		listenerResources, listenerErr := listGlobalAcceleratorListeners(ctx, client, accelerator)
+		if listenerErr != nil {
+			attributes["listeners_error"] = listenerErr.Error()
+		}
+
+		resources = append(resources, Resource{
+			ID:         aws_string_value(accelerator.AcceleratorArn),
+			Name:       aws_string_value(accelerator.Name),
+			Type:       "Global Accelerator",
+			State:      state,
+			Region:     "global", // Global Accelerator is global
+			Attributes: attributes,
+		})
+		resources = append(resources, listenerResources...)
+	}
+
+	return resources, nil
+}
+
+func listGlobalAcceleratorListeners(ctx context.Context, client *globalaccelerator.Client, accelerator types.Accelerator) ([]Resource, error) {
+	var listeners []types.Listener
+	paginator := globalaccelerator.NewListListenersPaginator(client, &globalaccelerator.ListListenersInput{
+		AcceleratorArn: accelerator.AcceleratorArn,
+	})
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return nil, err
+		}
+		listeners = append(listeners, page.Listeners...)
+	}
+
+	var resources []Resource
+	for _, listener := range listeners {
+		var ports []string
+		for _, portRange := range listener.PortRanges {
+			from, to := aws_int32_value(portRange.FromPort), aws_int32_value(portRange.ToPort)
+			if from == to {
+				ports = append(ports, fmt.Sprintf("%d", from))
+			} else {
+				ports = append(ports, fmt.Sprintf("%d-%d", from, to))
+			}
+		}
+
+		resources = append(resources, Resource{
+			ID:     aws_string_value(listener.ListenerArn),
+			Name:   aws_string_value(accelerator.Name) + " " + string(listener.Protocol) + " " + strings.Join(ports, ","),
+			Type:   "Global Accelerator Listener",
+			Region: "global",
+			Attributes: map[string]string{
+				"accelerator_arn": aws_string_value(accelerator.AcceleratorArn),
+				"dns_name":        aws_string_value(accelerator.DnsName),
+				"enabled":         fmt.Sprintf("%t", aws_bool_value(accelerator.Enabled)),
+				"protocol":        string(listener.Protocol),
+				"port_ranges":     strings.Join(ports, ","),
+				"client_affinity": string(listener.ClientAffinity),
+			},
+		})
+	}
+
+	return resources, nil
+}
@@ -0,0 +1,115 @@
+package cloudy
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/guardduty"
+	"github.com/aws/aws-sdk-go-v2/service/guardduty/types"
+)
+
+func init() {
+	Register(lister{
+		name:       "GuardDuty detectors",
+		types:      []string{"GuardDuty Detector"},
+		iamActions: []string{"guardduty:ListDetectors", "guardduty:GetDetector", "guardduty:GetFindingsStatistics"},
+		list:       withoutStates(listGuardDutyDetectors),
+	})
+}
+
+func listGuardDutyDetectors(ctx context.Context, cfg aws.Config) ([]Resource, error) {
+	client := Client(ctx, cfg, guardduty.NewFromConfig)
+
+	var detectorIDs []string
+	paginator := guardduty.NewListDetectorsPaginator(client, &guardduty.ListDetectorsInput{})
+	for paginator.HasMorePages() {
+		if err := checkMaxResults(ctx, len(detectorIDs)); err != nil {
+			return nil, err
+		}
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return nil, err
+		}
+		detectorIDs = append(detectorIDs, page.DetectorIds...)
+	}
+
+	// A region has at most one detector, so these lookups stay sequential
+	var resources []Resource
+	for _, detectorID := range detectorIDs {
+		resource := Resource{
+			ID:         detectorID,
+			Name:       detectorID,
+			Type:       "GuardDuty Detector",
+			Region:     cfg.Region,
+			Attributes: map[string]string{},
+		}
+
+		detector, err := client.GetDetector(ctx, &guardduty.GetDetectorInput{DetectorId: aws.String(detectorID)})
+		if err != nil {
+			resource.Attributes["error"] = err.Error()
+		} else {
+			resource.State = string(detector.Status)
+			resource.Tags = detector.Tags
+			resource.Attributes["finding_publishing_frequency"] = string(detector.FindingPublishingFrequency)
+			resource.Attributes["created"] = aws_string_value(detector.CreatedAt)
+			resource.Attributes["updated"] = aws_string_value(detector.UpdatedAt)
+		}
+
+		if err := countGuardDutyFindings(ctx, client, detectorID, resource.Attributes); err != nil {
+			resource.Attributes["findings_error"] = err.Error()
+		}
+
+		resources = append(resources, resource)
+	}
+
+	return resources, nil
+}
+
+// countGuardDutyFindings records the detector's current (non-archived)
+// findings grouped into GuardDuty's severity bands.
+func countGuardDutyFindings(ctx context.Context, client *guardduty.Client, detectorID string, attributes map[string]string) error {
+	result, err := client.GetFindingsStatistics(ctx, &guardduty.GetFindingsStatisticsInput{
+		DetectorId:            aws.String(detectorID),
+		FindingStatisticTypes: []types.FindingStatisticType{types.FindingStatisticTypeCountBySeverity},
+		FindingCriteria: &types.FindingCriteria{
+			Criterion: map[string]types.Condition{
+				"service.archived": {Equals: []string{"false"}},
+			},
+		},
+	})
+	if err != nil {
+		return err
+	}
+
+	counts := map[string]int32{"low": 0, "medium": 0, "high": 0, "critical": 0}
+	if result.FindingStatistics != nil {
+		// Keys are the severity score, e.g. "8.0"
+		for score, count := range result.FindingStatistics.CountBySeverity {
+			severity, err := strconv.ParseFloat(score, 64)
+			if err != nil {
+				continue
+			}
+			switch {
+			case severity >= 9:
+				counts["critical"] += count
+			case severity >= 7:
+				counts["high"] += count
+			case severity >= 4:
+				counts["medium"] += count
+			default:
+				counts["low"] += count
+			}
+		}
+	}
+
+	var total int32
+	for band, count := range counts {
+		attributes["findings_"+band] = fmt.Sprintf("%d", count)
+		total += count
+	}
+	attributes["findings_total"] = fmt.Sprintf("%d", total)
+
+	return nil
+}
@@ -0,0 +1,77 @@
+package cloudy
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/aws/smithy-go"
+)
+
+// describeConcurrency bounds the per-resource describe calls a single lister
+// makes in parallel (e.g. per-function or per-bucket lookups).
+const describeConcurrency = 8
+
+// forEachBounded calls fn for every index in [0, n), running at most limit
+// calls at once, and returns when all of them have finished.
+func forEachBounded(n, limit int, fn func(i int)) {
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, limit)
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(i int) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			fn(i)
+		}(i)
+	}
+	wg.Wait()
+}
+
+// Helper functions
+func aws_string_value(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
+
+func aws_int32_value(i *int32) int32 {
+	if i == nil {
+		return 0
+	}
+	return *i
+}
+
+func aws_bool_value(b *bool) bool {
+	if b == nil {
+		return false
+	}
+	return *b
+}
+
+// aws_error_code returns the AWS API error code carried by err, or "" when
+// err is nil or isn't an API error.
+func aws_error_code(err error) string {
+	var apiErr smithy.APIError
+	if errors.As(err, &apiErr) {
+		return apiErr.ErrorCode()
+	}
+	return ""
+}
+
+func aws_time_string(t *time.Time) string {
+	if t == nil {
+		return ""
+	}
+	return t.String()
+}
+
+func aws_age_days(t *time.Time) string {
+	if t == nil {
+		return ""
+	}
+	return fmt.Sprintf("%d", int(time.Since(*t).Hours()/24))
+}
@@ -0,0 +1,191 @@
+// Package hetzner is Cloudy's Hetzner Cloud provider. It lists servers,
+// volumes, load balancers and networks in one project, location by
+// location, as cloudy.Resources, so a Scanner from
+// cloudy.NewProviderScanner scans Hetzner Cloud the way it scans AWS.
+package hetzner
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/alwindoss/cloudy/pkg/cloudy"
+	"github.com/alwindoss/cloudy/pkg/cloudy/internal/listings"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/smithy-go"
+	"github.com/hetznercloud/hcloud-go/v2/hcloud"
+)
+
+// ProviderName names the Hetzner Cloud provider in requests and on its
+// resources.
+const ProviderName = "hetzner"
+
+// listingTTL is how long a project-wide listing is shared. Hetzner Cloud
+// lists every resource of a kind for the whole project, so the locations
+// of a scan, which all ask for them at about the same time, take theirs
+// out of one listing instead of each listing the project again.
+const listingTTL = 30 * time.Second
+
+// Provider is a Hetzner Cloud project.
+type Provider struct {
+	client *hcloud.Client
+
+	listings *listings.Cache
+}
+
+// NewProvider returns the Hetzner Cloud provider for the project client
+// has a token for, e.g. from hcloud.NewClient(hcloud.WithToken(token)).
+func NewProvider(client *hcloud.Client) *Provider {
+	return &Provider{client: client, listings: &listings.Cache{TTL: listingTTL}}
+}
+
+func (p *Provider) Name() string { return ProviderName }
+
+// Regions returns the locations, like fsn1, and the network zones, like
+// eu-central, that networks are listed under.
+func (p *Provider) Regions(ctx context.Context) ([]string, error) {
+	locations, err := listAll(ctx, func(ctx context.Context, opts hcloud.ListOpts) ([]*hcloud.Location, *hcloud.Response, error) {
+		return p.client.Location.List(ctx, hcloud.LocationListOpts{ListOpts: opts})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	seen := make(map[string]bool)
+	var regions []string
+	for _, location := range locations {
+		for _, name := range []string{location.Name, string(location.NetworkZone)} {
+			if name != "" && !seen[name] {
+				seen[name] = true
+				regions = append(regions, name)
+			}
+		}
+	}
+	sort.Strings(regions)
+	return regions, nil
+}
+
+// Listers returns a lister for each supported service.
+func (p *Provider) Listers() []cloudy.ServiceLister {
+	return []cloudy.ServiceLister{
+		lister{p: p, name: "Hetzner servers", types: []string{"Hetzner Server"}, list: listServers},
+		lister{p: p, name: "Hetzner volumes", types: []string{"Hetzner Volume"}, list: listVolumes},
+		lister{p: p, name: "Hetzner load balancers", types: []string{"Hetzner Load Balancer"}, list: listLoadBalancers},
+		lister{p: p, name: "Hetzner networks", types: []string{"Hetzner Network"}, list: listNetworks},
+	}
+}
+
+// listFunc lists a service's resources in one location or network zone.
+type listFunc func(ctx context.Context, p *Provider, region string) ([]cloudy.Resource, error)
+
+// lister is a cloudy.ServiceLister for a Hetzner Cloud service. List is
+// passed the location or network zone to list as the config's region.
+type lister struct {
+	p     *Provider
+	name  string
+	types []string
+	list  listFunc
+}
+
+func (l lister) Name() string    { return l.name }
+func (l lister) Types() []string { return l.types }
+func (l lister) Global() bool    { return false }
+
+// IAMActions is empty: Hetzner Cloud tokens aren't scoped by service, and
+// a read-only token can list everything.
+func (l lister) IAMActions() []string { return nil }
+
+func (l lister) List(ctx context.Context, cfg aws.Config, _ []string) ([]cloudy.Resource, error) {
+	return l.list(ctx, l.p, cfg.Region)
+}
+
+// listAll pages through list to the end, up to the cap of the scan ctx
+// belongs to.
+func listAll[T any](ctx context.Context, list func(context.Context, hcloud.ListOpts) ([]T, *hcloud.Response, error)) ([]T, error) {
+	var items []T
+	opts := hcloud.ListOpts{Page: 1, PerPage: 50}
+	for {
+		if err := checkMaxResults(ctx, len(items)); err != nil {
+			return items, err
+		}
+		page, resp, err := list(ctx, opts)
+		if err != nil {
+			return items, apiError(err)
+		}
+		items = append(items, page...)
+
+		if resp == nil || resp.Meta.Pagination == nil || resp.Meta.Pagination.NextPage == 0 {
+			return items, nil
+		}
+		opts.Page = resp.Meta.Pagination.NextPage
+	}
+}
+
+// shared lists every item of a kind through list, once for all the
+// locations of a scan.
+func shared[T any](ctx context.Context, p *Provider, kind string, list func(context.Context, hcloud.ListOpts) ([]T, *hcloud.Response, error)) ([]T, error) {
+	return listings.Shared(ctx, p.listings, kind, func(ctx context.Context) ([]T, error) {
+		return listAll(ctx, list)
+	})
+}
+
+// checkMaxResults fails once n items have been listed, at the cap of the
+// scan ctx belongs to.
+func checkMaxResults(ctx context.Context, n int) error {
+	if limit := cloudy.MaxResults(ctx); n >= limit {
+		return fmt.Errorf("%w: stopped after %d", cloudy.ErrMaxResults, limit)
+	}
+	return nil
+}
+
+// responseError gives a Hetzner Cloud error's code and status to cloudy's
+// ServiceErrors, which read them as they read an AWS error's.
+type responseError struct {
+	err hcloud.Error
+}
+
+func (e responseError) statusCode() int {
+	if resp := e.err.Response(); resp != nil && resp.Response != nil {
+		return resp.StatusCode
+	}
+	return 0
+}
+
+func (e responseError) Error() string        { return e.err.Error() }
+func (e responseError) ErrorCode() string    { return string(e.err.Code) }
+func (e responseError) ErrorMessage() string { return e.err.Message }
+func (e responseError) HTTPStatusCode() int  { return e.statusCode() }
+func (e responseError) Unwrap() error        { return e.err }
+
+func (e responseError) ErrorFault() smithy.ErrorFault {
+	if e.statusCode() >= 500 {
+		return smithy.FaultServer
+	}
+	return smithy.FaultClient
+}
+
+// apiError wraps err in a responseError if it is a Hetzner Cloud error
+// response.
+func apiError(err error) error {
+	var apiErr hcloud.Error
+	if errors.As(err, &apiErr) {
+		return responseError{apiErr}
+	}
+	return err
+}
+
+func labels(labels map[string]string) map[string]string {
+	if labels == nil {
+		return make(map[string]string)
+	}
+	return labels
+}
+
+func timeString(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.String()
+}
@@ -0,0 +1,46 @@
+package hetzner
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/alwindoss/cloudy/pkg/cloudy"
+	"github.com/hetznercloud/hcloud-go/v2/hcloud"
+)
+
+func listLoadBalancers(ctx context.Context, p *Provider, region string) ([]cloudy.Resource, error) {
+	balancers, err := shared(ctx, p, "load-balancers", func(ctx context.Context, opts hcloud.ListOpts) ([]*hcloud.LoadBalancer, *hcloud.Response, error) {
+		return p.client.LoadBalancer.List(ctx, hcloud.LoadBalancerListOpts{ListOpts: opts})
+	})
+
+	var resources []cloudy.Resource
+	for _, balancer := range balancers {
+		if balancer.Location == nil || balancer.Location.Name != region {
+			continue
+		}
+
+		attributes := map[string]string{
+			"algorithm": string(balancer.Algorithm.Type),
+			"services":  fmt.Sprintf("%d", len(balancer.Services)),
+			"targets":   fmt.Sprintf("%d", len(balancer.Targets)),
+			"created":   timeString(balancer.Created),
+		}
+		if balancer.LoadBalancerType != nil {
+			attributes["load_balancer_type"] = balancer.LoadBalancerType.Name
+		}
+		if ip := balancer.PublicNet.IPv4.IP; balancer.PublicNet.Enabled && ip != nil {
+			attributes["public_ip"] = ip.String()
+		}
+
+		resources = append(resources, cloudy.Resource{
+			ID:         fmt.Sprintf("hetzner:load-balancer:%d", balancer.ID),
+			Name:       balancer.Name,
+			Type:       "Hetzner Load Balancer",
+			Region:     region,
+			Tags:       labels(balancer.Labels),
+			Attributes: attributes,
+		})
+	}
+
+	return resources, err
+}
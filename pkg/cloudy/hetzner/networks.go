@@ -0,0 +1,50 @@
+package hetzner
+
+import (
+	"context"
+	"fmt"
+	"slices"
+
+	"github.com/alwindoss/cloudy/pkg/cloudy"
+	"github.com/hetznercloud/hcloud-go/v2/hcloud"
+)
+
+func listNetworks(ctx context.Context, p *Provider, region string) ([]cloudy.Resource, error) {
+	networks, err := shared(ctx, p, "networks", func(ctx context.Context, opts hcloud.ListOpts) ([]*hcloud.Network, *hcloud.Response, error) {
+		return p.client.Network.List(ctx, hcloud.NetworkListOpts{ListOpts: opts})
+	})
+
+	var resources []cloudy.Resource
+	for _, network := range networks {
+		// Networks aren't in a location; they are listed under the network
+		// zones their subnets are in
+		var zones []string
+		for _, subnet := range network.Subnets {
+			zones = append(zones, string(subnet.NetworkZone))
+		}
+		if !slices.Contains(zones, region) {
+			continue
+		}
+
+		attributes := map[string]string{
+			"subnets":        fmt.Sprintf("%d", len(network.Subnets)),
+			"servers":        fmt.Sprintf("%d", len(network.Servers)),
+			"load_balancers": fmt.Sprintf("%d", len(network.LoadBalancers)),
+			"created":        timeString(network.Created),
+		}
+		if network.IPRange != nil {
+			attributes["ip_range"] = network.IPRange.String()
+		}
+
+		resources = append(resources, cloudy.Resource{
+			ID:         fmt.Sprintf("hetzner:network:%d", network.ID),
+			Name:       network.Name,
+			Type:       "Hetzner Network",
+			Region:     region,
+			Tags:       labels(network.Labels),
+			Attributes: attributes,
+		})
+	}
+
+	return resources, err
+}
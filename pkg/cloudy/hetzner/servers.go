@@ -0,0 +1,54 @@
+package hetzner
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/alwindoss/cloudy/pkg/cloudy"
+	"github.com/hetznercloud/hcloud-go/v2/hcloud"
+)
+
+func listServers(ctx context.Context, p *Provider, region string) ([]cloudy.Resource, error) {
+	servers, err := shared(ctx, p, "servers", func(ctx context.Context, opts hcloud.ListOpts) ([]*hcloud.Server, *hcloud.Response, error) {
+		return p.client.Server.List(ctx, hcloud.ServerListOpts{ListOpts: opts})
+	})
+
+	var resources []cloudy.Resource
+	for _, server := range servers {
+		if server.Datacenter == nil || server.Datacenter.Location == nil || server.Datacenter.Location.Name != region {
+			continue
+		}
+
+		attributes := map[string]string{
+			"datacenter": server.Datacenter.Name,
+			"created":    timeString(server.Created),
+		}
+		if serverType := server.ServerType; serverType != nil {
+			attributes["server_type"] = serverType.Name
+			attributes["cores"] = fmt.Sprintf("%d", serverType.Cores)
+			attributes["memory_gb"] = fmt.Sprintf("%g", serverType.Memory)
+			attributes["disk_gb"] = fmt.Sprintf("%d", serverType.Disk)
+		}
+		if server.Image != nil {
+			attributes["image"] = server.Image.Name
+		}
+		if ip := server.PublicNet.IPv4.IP; ip != nil {
+			attributes["public_ip"] = ip.String()
+		}
+		if len(server.PrivateNet) > 0 && server.PrivateNet[0].IP != nil {
+			attributes["private_ip"] = server.PrivateNet[0].IP.String()
+		}
+
+		resources = append(resources, cloudy.Resource{
+			ID:         fmt.Sprintf("hetzner:server:%d", server.ID),
+			Name:       server.Name,
+			Type:       "Hetzner Server",
+			State:      string(server.Status),
+			Region:     region,
+			Tags:       labels(server.Labels),
+			Attributes: attributes,
+		})
+	}
+
+	return resources, err
+}
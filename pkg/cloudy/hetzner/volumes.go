@@ -0,0 +1,46 @@
+package hetzner
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/alwindoss/cloudy/pkg/cloudy"
+	"github.com/hetznercloud/hcloud-go/v2/hcloud"
+)
+
+func listVolumes(ctx context.Context, p *Provider, region string) ([]cloudy.Resource, error) {
+	volumes, err := shared(ctx, p, "volumes", func(ctx context.Context, opts hcloud.ListOpts) ([]*hcloud.Volume, *hcloud.Response, error) {
+		return p.client.Volume.List(ctx, hcloud.VolumeListOpts{ListOpts: opts})
+	})
+
+	var resources []cloudy.Resource
+	for _, volume := range volumes {
+		if volume.Location == nil || volume.Location.Name != region {
+			continue
+		}
+
+		attributes := map[string]string{
+			"size_gb":      fmt.Sprintf("%d", volume.Size),
+			"linux_device": volume.LinuxDevice,
+			"created":      timeString(volume.Created),
+		}
+		if volume.Format != nil {
+			attributes["format"] = *volume.Format
+		}
+		if volume.Server != nil {
+			attributes["server_id"] = fmt.Sprintf("%d", volume.Server.ID)
+		}
+
+		resources = append(resources, cloudy.Resource{
+			ID:         fmt.Sprintf("hetzner:volume:%d", volume.ID),
+			Name:       volume.Name,
+			Type:       "Hetzner Volume",
+			State:      string(volume.Status),
+			Region:     region,
+			Tags:       labels(volume.Labels),
+			Attributes: attributes,
+		})
+	}
+
+	return resources, err
+}
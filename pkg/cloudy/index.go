@@ -0,0 +1,112 @@
+package cloudy
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws/arn"
+)
+
+// An index is an AWS service that lists many services' resources at once,
+// like the Resource Groups Tagging API or Resource Explorer. Its resources
+// carry only an ID (the ARN), a name, a type, a region and tags.
+type index struct {
+	// name identifies the index in errors, the WorkerPool and the cache.
+	name string
+	// types maps the index's resource types, e.g. "ec2:instance", to the
+	// resource types the listers produce. Types missing here either aren't
+	// indexed or can't be told apart by ARN, like Aurora and Neptune
+	// clusters; those are listed with their lister instead.
+	types map[string]string
+	// list lists region's resources of the given index types, or of every
+	// type if there are none.
+	list func(ctx context.Context, region string, indexTypes []string) ([]Resource, error)
+}
+
+// stream lists every region from the index. If resourceTypes includes
+// types the index doesn't have, their listers run as in a regular scan.
+func (idx index) stream(ctx context.Context, s *Scanner, regions, resourceTypes []string) <-chan RegionResources {
+	var indexTypes, unindexed []string
+	for _, resourceType := range resourceTypes {
+		if indexType, ok := idx.indexType(resourceType); ok {
+			indexTypes = append(indexTypes, indexType)
+		} else {
+			unindexed = append(unindexed, resourceType)
+		}
+	}
+	sort.Strings(indexTypes)
+
+	return s.stream(ctx, regions, func(ctx context.Context, region string) ([]Resource, error) {
+		ctx, cancel := withTimeout(ctx, s.timeouts.Region)
+		defer cancel()
+		ctx = withClients(ctx, s.clients)
+		if s.maxResults > 0 {
+			ctx = withMaxResults(ctx, s.maxResults)
+		}
+
+		var resources []Resource
+		var errs []error
+		if len(resourceTypes) == 0 || len(indexTypes) > 0 {
+			// Listings of different types are cached apart
+			service := idx.name
+			if len(indexTypes) > 0 {
+				service += " (" + strings.Join(indexTypes, ", ") + ")"
+			}
+			indexed, err := s.runLister(ctx, s.cacheAccount(ctx), region, service, nil, func(ctx context.Context) ([]Resource, error) {
+				indexed, err := idx.list(ctx, region, indexTypes)
+				s.label(ctx, indexed, region)
+				return indexed, err
+			})
+			if err != nil {
+				errs = append(errs, newServiceError(idx.name, region, s.credentialsError(err)))
+			}
+			resources = indexed
+		}
+		if len(unindexed) > 0 {
+			listed, err := s.ListResourcesInRegion(ctx, region, unindexed, nil)
+			if err != nil {
+				errs = append(errs, err)
+			}
+			resources = append(resources, listed...)
+		}
+
+		return resources, errors.Join(errs...)
+	})
+}
+
+// indexType returns the index's type for resourceType.
+func (idx index) indexType(resourceType string) (string, bool) {
+	for indexType, typ := range idx.types {
+		if typ == resourceType {
+			return indexType, true
+		}
+	}
+	return "", false
+}
+
+// indexedResource builds a Resource from an indexed ARN, typing it with
+// types. indexType may be "" to derive it from the ARN. Types no lister
+// produces are kept as the index names them, like "dynamodb:table".
+func indexedResource(types map[string]string, id, indexType, region string, tags map[string]string) Resource {
+	resource := Resource{ID: id, Type: indexType, Region: region, Tags: tags}
+	if parsed, err := arn.Parse(id); err == nil {
+		// The resource type leads the resource part, e.g. instance/i-0abc
+		// or db:orders; S3 bucket ARNs are just the name
+		if resource.Type == "" {
+			resource.Type = parsed.Service
+			if i := strings.IndexAny(parsed.Resource, "/:"); i >= 0 {
+				resource.Type += ":" + parsed.Resource[:i]
+			}
+		}
+		resource.Name = parsed.Resource[strings.LastIndexAny(parsed.Resource, "/:")+1:]
+	}
+	if typ, ok := types[resource.Type]; ok {
+		resource.Type = typ
+	}
+	if name := tags["Name"]; name != "" {
+		resource.Name = name
+	}
+	return resource
+}
@@ -0,0 +1,65 @@
+// Package listings shares the listings of a provider's whole account,
+// project or subscription between the regions of a scan, for providers
+// whose services can't be listed region by region.
+package listings
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Cache shares listings by key for TTL. A listing that failed isn't
+// shared past the callers already waiting for it. The zero Cache shares
+// listings only while they run.
+type Cache struct {
+	TTL time.Duration
+
+	mu      sync.Mutex
+	entries map[string]*listing
+}
+
+type listing struct {
+	done  chan struct{}
+	items any
+	err   error
+	at    time.Time
+}
+
+func (l *listing) reusable(ttl time.Duration) bool {
+	select {
+	case <-l.done:
+		return l.err == nil && time.Since(l.at) < ttl
+	default:
+		return true
+	}
+}
+
+// Shared returns the listing named key, running list for it unless it is
+// already running or was listed within the cache's TTL.
+func Shared[T any](ctx context.Context, c *Cache, key string, list func(ctx context.Context) ([]T, error)) ([]T, error) {
+	c.mu.Lock()
+	entry, ok := c.entries[key]
+	if !ok || !entry.reusable(c.TTL) {
+		entry = &listing{done: make(chan struct{})}
+		if c.entries == nil {
+			c.entries = make(map[string]*listing)
+		}
+		c.entries[key] = entry
+		c.mu.Unlock()
+
+		items, err := list(ctx)
+		entry.items, entry.err, entry.at = items, err, time.Now()
+		close(entry.done)
+	} else {
+		c.mu.Unlock()
+	}
+
+	select {
+	case <-entry.done:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+	items, _ := entry.items.([]T)
+	return items, entry.err
+}
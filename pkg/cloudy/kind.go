@@ -0,0 +1,196 @@
+package cloudy
+
+import (
+	"sort"
+	"strings"
+)
+
+// Kinds are a Resource's place in a taxonomy shared by every provider, so
+// "all compute" can be found without knowing each provider's type names.
+// A kind is a category and what the resource is within it, separated by a
+// dot.
+const (
+	KindComputeInstance = "compute.instance"
+	KindComputeDesktop  = "compute.desktop"
+
+	KindContainerCluster   = "container.cluster"
+	KindContainerNamespace = "container.namespace"
+	KindContainerService   = "container.service"
+	KindContainerWorkload  = "container.workload"
+
+	KindStorageBucket = "storage.bucket"
+	KindStorageVolume = "storage.volume"
+
+	KindDBInstance = "db.instance"
+	KindDBCluster  = "db.cluster"
+	KindDBSnapshot = "db.snapshot"
+
+	KindServerlessFunction        = "serverless.function"
+	KindServerlessFunctionVersion = "serverless.function_version"
+	KindServerlessLayer           = "serverless.layer"
+	KindServerlessTrigger         = "serverless.trigger"
+
+	KindNetworkNetwork      = "network.network"
+	KindNetworkLoadBalancer = "network.load_balancer"
+	KindNetworkListener     = "network.listener"
+	KindNetworkService      = "network.service"
+	KindNetworkIngress      = "network.ingress"
+	KindNetworkGateway      = "network.gateway"
+
+	KindDNSZone   = "dns.zone"
+	KindDNSRecord = "dns.record"
+
+	KindEventsBus      = "events.bus"
+	KindEventsRule     = "events.rule"
+	KindEventsSchedule = "events.schedule"
+
+	KindWebApp    = "web.app"
+	KindWebBranch = "web.branch"
+
+	KindIdentityUser = "identity.user"
+
+	KindSecurityDetector = "security.detector"
+
+	KindAuditTrail    = "audit.trail"
+	KindAuditRecorder = "audit.recorder"
+	KindAuditRule     = "audit.rule"
+
+	KindAnalyticsCluster = "analytics.cluster"
+
+	KindMigrationInstance = "migration.instance"
+	KindMigrationTask     = "migration.task"
+)
+
+// kinds are the kinds of the resource types Cloudy's listers produce,
+// whichever provider they belong to.
+var kinds = map[string]string{
+	"EC2 Instance":       KindComputeInstance,
+	"Azure VM":           KindComputeInstance,
+	"GCE Instance":       KindComputeInstance,
+	"Droplet":            KindComputeInstance,
+	"OCI Instance":       KindComputeInstance,
+	"Hetzner Server":     KindComputeInstance,
+	"Linode":             KindComputeInstance,
+	"OpenStack Instance": KindComputeInstance,
+	"WorkSpace":          KindComputeDesktop,
+
+	"ECS Cluster":           KindContainerCluster,
+	"AKS Cluster":           KindContainerCluster,
+	"GKE Cluster":           KindContainerCluster,
+	"DOKS Cluster":          KindContainerCluster,
+	"OKE Cluster":           KindContainerCluster,
+	"LKE Cluster":           KindContainerCluster,
+	"Kubernetes Namespace":  KindContainerNamespace,
+	"App Runner Service":    KindContainerService,
+	"Kubernetes Deployment": KindContainerWorkload,
+
+	"S3 Bucket":                        KindStorageBucket,
+	"Azure Storage Account":            KindStorageBucket,
+	"GCS Bucket":                       KindStorageBucket,
+	"Space":                            KindStorageBucket,
+	"OCI Bucket":                       KindStorageBucket,
+	"Linode Object Storage Bucket":     KindStorageBucket,
+	"R2 Bucket":                        KindStorageBucket,
+	"Swift Container":                  KindStorageBucket,
+	"EBS Volume":                       KindStorageVolume,
+	"Hetzner Volume":                   KindStorageVolume,
+	"Cinder Volume":                    KindStorageVolume,
+	"Kubernetes PersistentVolumeClaim": KindStorageVolume,
+
+	"RDS Instance":          KindDBInstance,
+	"Azure SQL Database":    KindDBInstance,
+	"Cloud SQL Instance":    KindDBInstance,
+	"Autonomous Database":   KindDBInstance,
+	"Aurora Cluster":        KindDBCluster,
+	"Neptune Cluster":       KindDBCluster,
+	"DocumentDB Cluster":    KindDBCluster,
+	"DigitalOcean Database": KindDBCluster,
+	"RDS Snapshot":          KindDBSnapshot,
+	"RDS Cluster Snapshot":  KindDBSnapshot,
+
+	"Lambda Function":             KindServerlessFunction,
+	"Azure Function App":          KindServerlessFunction,
+	"Cloud Function":              KindServerlessFunction,
+	"Cloudflare Worker":           KindServerlessFunction,
+	"Lambda Version":              KindServerlessFunctionVersion,
+	"Lambda Alias":                KindServerlessFunctionVersion,
+	"Lambda Layer":                KindServerlessLayer,
+	"Lambda Event Source Mapping": KindServerlessTrigger,
+
+	"Hetzner Network":             KindNetworkNetwork,
+	"Neutron Network":             KindNetworkNetwork,
+	"Global Accelerator":          KindNetworkLoadBalancer,
+	"DigitalOcean Load Balancer":  KindNetworkLoadBalancer,
+	"Hetzner Load Balancer":       KindNetworkLoadBalancer,
+	"NodeBalancer":                KindNetworkLoadBalancer,
+	"Global Accelerator Listener": KindNetworkListener,
+	"Kubernetes Service":          KindNetworkService,
+	"Kubernetes Ingress":          KindNetworkIngress,
+	"NAT Gateway":                 KindNetworkGateway,
+
+	"Cloudflare Zone":       KindDNSZone,
+	"Cloudflare DNS Record": KindDNSRecord,
+
+	"EventBridge Event Bus": KindEventsBus,
+	"EventBridge Rule":      KindEventsRule,
+	"EventBridge Schedule":  KindEventsSchedule,
+
+	"Amplify App":    KindWebApp,
+	"Pages Project":  KindWebApp,
+	"Amplify Branch": KindWebBranch,
+
+	"IAM User": KindIdentityUser,
+
+	"GuardDuty Detector": KindSecurityDetector,
+
+	"CloudTrail Trail": KindAuditTrail,
+	"Config Recorder":  KindAuditRecorder,
+	"Config Rule":      KindAuditRule,
+
+	"EMR Cluster": KindAnalyticsCluster,
+
+	"DMS Replication Instance": KindMigrationInstance,
+	"DMS Replication Task":     KindMigrationTask,
+}
+
+// KindOf returns the kind of resources of resourceType, or "" for a type
+// outside the taxonomy, such as one only an index names.
+func KindOf(resourceType string) string {
+	return kinds[resourceType]
+}
+
+// Kinds returns every kind, sorted.
+func Kinds() []string {
+	seen := make(map[string]bool)
+	var all []string
+	for _, kind := range kinds {
+		if !seen[kind] {
+			seen[kind] = true
+			all = append(all, kind)
+		}
+	}
+	sort.Strings(all)
+	return all
+}
+
+// MatchesKind reports whether kind is want, or in want's category when
+// want is a category alone, e.g. "compute".
+func MatchesKind(kind, want string) bool {
+	return kind == want || strings.HasPrefix(kind, want+".")
+}
+
+// KindTypes returns the resource types of the kinds, or categories, in
+// want, sorted.
+func KindTypes(want ...string) []string {
+	var types []string
+	for resourceType, kind := range kinds {
+		for _, w := range want {
+			if MatchesKind(kind, w) {
+				types = append(types, resourceType)
+				break
+			}
+		}
+	}
+	sort.Strings(types)
+	return types
+}
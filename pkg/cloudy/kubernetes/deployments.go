@@ -0,0 +1,52 @@
+package kubernetes
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/alwindoss/cloudy/pkg/cloudy"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+func listDeployments(ctx context.Context, client kubernetes.Interface, cluster string) ([]cloudy.Resource, error) {
+	deployments, err := listAll(ctx, func(ctx context.Context, opts metav1.ListOptions) ([]appsv1.Deployment, metav1.ListMeta, error) {
+		list, err := client.AppsV1().Deployments(metav1.NamespaceAll).List(ctx, opts)
+		if err != nil {
+			return nil, metav1.ListMeta{}, err
+		}
+		return list.Items, list.ListMeta, nil
+	})
+
+	var resources []cloudy.Resource
+	for _, deployment := range deployments {
+		r := resource(cluster, "deployment", "Kubernetes Deployment", deployment.ObjectMeta)
+
+		// The state is whether the Available condition holds: enough
+		// replicas are ready
+		r.State = "Unavailable"
+		for _, condition := range deployment.Status.Conditions {
+			if condition.Type == appsv1.DeploymentAvailable && condition.Status == corev1.ConditionTrue {
+				r.State = "Available"
+			}
+		}
+
+		if deployment.Spec.Replicas != nil {
+			r.Attributes["replicas"] = fmt.Sprintf("%d", *deployment.Spec.Replicas)
+		}
+		r.Attributes["ready_replicas"] = fmt.Sprintf("%d", deployment.Status.ReadyReplicas)
+		r.Attributes["available_replicas"] = fmt.Sprintf("%d", deployment.Status.AvailableReplicas)
+		r.Attributes["strategy"] = string(deployment.Spec.Strategy.Type)
+		var images []string
+		for _, container := range deployment.Spec.Template.Spec.Containers {
+			images = append(images, container.Image)
+		}
+		r.Attributes["images"] = join(images)
+
+		resources = append(resources, r)
+	}
+
+	return resources, err
+}
@@ -0,0 +1,54 @@
+package kubernetes
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/alwindoss/cloudy/pkg/cloudy"
+	networkingv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+func listIngresses(ctx context.Context, client kubernetes.Interface, cluster string) ([]cloudy.Resource, error) {
+	ingresses, err := listAll(ctx, func(ctx context.Context, opts metav1.ListOptions) ([]networkingv1.Ingress, metav1.ListMeta, error) {
+		list, err := client.NetworkingV1().Ingresses(metav1.NamespaceAll).List(ctx, opts)
+		if err != nil {
+			return nil, metav1.ListMeta{}, err
+		}
+		return list.Items, list.ListMeta, nil
+	})
+
+	var resources []cloudy.Resource
+	for _, ingress := range ingresses {
+		r := resource(cluster, "ingress", "Kubernetes Ingress", ingress.ObjectMeta)
+		if ingress.Spec.IngressClassName != nil {
+			r.Attributes["ingress_class"] = *ingress.Spec.IngressClassName
+		}
+
+		var hosts []string
+		for _, rule := range ingress.Spec.Rules {
+			if rule.Host != "" {
+				hosts = append(hosts, rule.Host)
+			}
+		}
+		r.Attributes["hosts"] = join(hosts)
+		r.Attributes["tls"] = fmt.Sprintf("%t", len(ingress.Spec.TLS) > 0)
+
+		var addresses []string
+		for _, point := range ingress.Status.LoadBalancer.Ingress {
+			if point.Hostname != "" {
+				addresses = append(addresses, point.Hostname)
+			} else if point.IP != "" {
+				addresses = append(addresses, point.IP)
+			}
+		}
+		if len(addresses) > 0 {
+			r.Attributes["address"] = join(addresses)
+		}
+
+		resources = append(resources, r)
+	}
+
+	return resources, err
+}
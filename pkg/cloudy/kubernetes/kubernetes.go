@@ -0,0 +1,234 @@
+// Package kubernetes is Cloudy's Kubernetes provider. It lists
+// namespaces, deployments, services, ingresses and persistent volume
+// claims in the clusters of kubeconfig contexts as cloudy.Resources, so a
+// Scanner from cloudy.NewProviderScanner scans clusters the way it scans
+// AWS regions: each context is a region.
+package kubernetes
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/alwindoss/cloudy/pkg/cloudy"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/smithy-go"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/clientcmd"
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
+)
+
+// ProviderName names the Kubernetes provider in requests and on its
+// resources.
+const ProviderName = "kubernetes"
+
+// pageSize is the most objects asked for in a page.
+const pageSize = 500
+
+// Provider is the clusters of one or more kubeconfig contexts.
+type Provider struct {
+	config   clientcmdapi.Config
+	contexts []string
+
+	mu      sync.Mutex
+	clients map[string]kubernetes.Interface
+}
+
+// NewProvider returns the Kubernetes provider for contexts of config, e.g.
+// from clientcmd.NewDefaultClientConfigLoadingRules().Load(). Without
+// contexts it scans every context in config.
+func NewProvider(config *clientcmdapi.Config, contexts []string) (*Provider, error) {
+	if len(contexts) == 0 {
+		for name := range config.Contexts {
+			contexts = append(contexts, name)
+		}
+		sort.Strings(contexts)
+	}
+	for _, name := range contexts {
+		if _, ok := config.Contexts[name]; !ok {
+			return nil, fmt.Errorf("kubeconfig has no context %q", name)
+		}
+	}
+	return &Provider{config: *config, contexts: contexts}, nil
+}
+
+func (p *Provider) Name() string { return ProviderName }
+
+// Regions returns the contexts the provider scans, which stand in for
+// regions.
+func (p *Provider) Regions(ctx context.Context) ([]string, error) {
+	return p.contexts, nil
+}
+
+// Listers returns a lister for each supported kind.
+func (p *Provider) Listers() []cloudy.ServiceLister {
+	return []cloudy.ServiceLister{
+		lister{p: p, name: "Kubernetes namespaces", types: []string{"Kubernetes Namespace"}, verbs: []string{"list namespaces"}, list: listNamespaces},
+		lister{p: p, name: "Kubernetes deployments", types: []string{"Kubernetes Deployment"}, verbs: []string{"list deployments.apps"}, list: listDeployments},
+		lister{p: p, name: "Kubernetes services", types: []string{"Kubernetes Service"}, verbs: []string{"list services"}, list: listServices},
+		lister{p: p, name: "Kubernetes ingresses", types: []string{"Kubernetes Ingress"}, verbs: []string{"list ingresses.networking.k8s.io"}, list: listIngresses},
+		lister{p: p, name: "Kubernetes persistent volume claims", types: []string{"Kubernetes PersistentVolumeClaim"}, verbs: []string{"list persistentvolumeclaims"}, list: listVolumeClaims},
+	}
+}
+
+// listFunc lists a kind's objects in every namespace of one context's
+// cluster.
+type listFunc func(ctx context.Context, client kubernetes.Interface, cluster string) ([]cloudy.Resource, error)
+
+// lister is a cloudy.ServiceLister for a Kubernetes kind. List is passed
+// the context to list as the config's region.
+type lister struct {
+	p     *Provider
+	name  string
+	types []string
+	verbs []string
+	list  listFunc
+}
+
+func (l lister) Name() string    { return l.name }
+func (l lister) Types() []string { return l.types }
+func (l lister) Global() bool    { return false }
+
+// IAMActions returns the RBAC verbs and resources, cluster-wide, List
+// needs.
+func (l lister) IAMActions() []string { return l.verbs }
+
+func (l lister) List(ctx context.Context, cfg aws.Config, _ []string) ([]cloudy.Resource, error) {
+	client, err := l.p.clusterClient(cfg.Region)
+	if err != nil {
+		return nil, err
+	}
+	return l.list(ctx, client, cfg.Region)
+}
+
+// clusterClient returns the client for the cluster of context name,
+// building it the first time the context is listed.
+func (p *Provider) clusterClient(name string) (kubernetes.Interface, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if client, ok := p.clients[name]; ok {
+		return client, nil
+	}
+
+	if _, ok := p.config.Contexts[name]; !ok {
+		return nil, fmt.Errorf("kubeconfig has no context %q", name)
+	}
+	restConfig, err := clientcmd.NewNonInteractiveClientConfig(p.config, name, &clientcmd.ConfigOverrides{}, nil).ClientConfig()
+	if err != nil {
+		return nil, err
+	}
+	client, err := kubernetes.NewForConfig(restConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	if p.clients == nil {
+		p.clients = make(map[string]kubernetes.Interface)
+	}
+	p.clients[name] = client
+	return client, nil
+}
+
+// listAll pages through list to the end, up to the cap of the scan ctx
+// belongs to.
+func listAll[T any](ctx context.Context, list func(context.Context, metav1.ListOptions) ([]T, metav1.ListMeta, error)) ([]T, error) {
+	var items []T
+	opts := metav1.ListOptions{Limit: pageSize}
+	for {
+		if err := checkMaxResults(ctx, len(items)); err != nil {
+			return items, err
+		}
+		page, meta, err := list(ctx, opts)
+		if err != nil {
+			return items, apiError(err)
+		}
+		items = append(items, page...)
+
+		if meta.Continue == "" {
+			return items, nil
+		}
+		opts.Continue = meta.Continue
+	}
+}
+
+// checkMaxResults fails once n items have been listed, at the cap of the
+// scan ctx belongs to.
+func checkMaxResults(ctx context.Context, n int) error {
+	if limit := cloudy.MaxResults(ctx); n >= limit {
+		return fmt.Errorf("%w: stopped after %d", cloudy.ErrMaxResults, limit)
+	}
+	return nil
+}
+
+// responseError gives a Kubernetes API status's reason and code to
+// cloudy's ServiceErrors, which read them as they read an AWS error's.
+type responseError struct {
+	err    error
+	status metav1.Status
+}
+
+func (e responseError) Error() string        { return e.err.Error() }
+func (e responseError) ErrorCode() string    { return string(e.status.Reason) }
+func (e responseError) ErrorMessage() string { return e.status.Message }
+func (e responseError) HTTPStatusCode() int  { return int(e.status.Code) }
+func (e responseError) Unwrap() error        { return e.err }
+
+func (e responseError) ErrorFault() smithy.ErrorFault {
+	if e.status.Code >= 500 {
+		return smithy.FaultServer
+	}
+	return smithy.FaultClient
+}
+
+// apiError wraps err in a responseError if it is a Kubernetes API status.
+func apiError(err error) error {
+	var status apierrors.APIStatus
+	if errors.As(err, &status) {
+		return responseError{err: err, status: status.Status()}
+	}
+	return err
+}
+
+// resource returns the resource for an object of kind in cluster, with
+// the ID, name, labels and attributes every object has.
+func resource(cluster, kind, resourceType string, meta metav1.ObjectMeta) cloudy.Resource {
+	id := fmt.Sprintf("k8s:%s:%s:%s", cluster, kind, meta.Name)
+	attributes := map[string]string{
+		"uid":     string(meta.UID),
+		"created": timeString(meta.CreationTimestamp),
+	}
+	if meta.Namespace != "" {
+		id = fmt.Sprintf("k8s:%s:%s:%s/%s", cluster, kind, meta.Namespace, meta.Name)
+		attributes["namespace"] = meta.Namespace
+	}
+
+	tags := make(map[string]string, len(meta.Labels))
+	for key, value := range meta.Labels {
+		tags[key] = value
+	}
+
+	return cloudy.Resource{
+		ID:         id,
+		Name:       meta.Name,
+		Type:       resourceType,
+		Region:     cluster,
+		Tags:       tags,
+		Attributes: attributes,
+	}
+}
+
+func timeString(t metav1.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.Time.String()
+}
+
+func join(values []string) string {
+	return strings.Join(values, ",")
+}
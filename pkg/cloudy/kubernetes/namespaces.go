@@ -0,0 +1,29 @@
+package kubernetes
+
+import (
+	"context"
+
+	"github.com/alwindoss/cloudy/pkg/cloudy"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+func listNamespaces(ctx context.Context, client kubernetes.Interface, cluster string) ([]cloudy.Resource, error) {
+	namespaces, err := listAll(ctx, func(ctx context.Context, opts metav1.ListOptions) ([]corev1.Namespace, metav1.ListMeta, error) {
+		list, err := client.CoreV1().Namespaces().List(ctx, opts)
+		if err != nil {
+			return nil, metav1.ListMeta{}, err
+		}
+		return list.Items, list.ListMeta, nil
+	})
+
+	var resources []cloudy.Resource
+	for _, namespace := range namespaces {
+		r := resource(cluster, "namespace", "Kubernetes Namespace", namespace.ObjectMeta)
+		r.State = string(namespace.Status.Phase)
+		resources = append(resources, r)
+	}
+
+	return resources, err
+}
@@ -0,0 +1,55 @@
+package kubernetes
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/alwindoss/cloudy/pkg/cloudy"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+func listServices(ctx context.Context, client kubernetes.Interface, cluster string) ([]cloudy.Resource, error) {
+	services, err := listAll(ctx, func(ctx context.Context, opts metav1.ListOptions) ([]corev1.Service, metav1.ListMeta, error) {
+		list, err := client.CoreV1().Services(metav1.NamespaceAll).List(ctx, opts)
+		if err != nil {
+			return nil, metav1.ListMeta{}, err
+		}
+		return list.Items, list.ListMeta, nil
+	})
+
+	var resources []cloudy.Resource
+	for _, service := range services {
+		r := resource(cluster, "service", "Kubernetes Service", service.ObjectMeta)
+		r.Attributes["service_type"] = string(service.Spec.Type)
+		r.Attributes["cluster_ip"] = service.Spec.ClusterIP
+
+		var ports []string
+		for _, port := range service.Spec.Ports {
+			ports = append(ports, fmt.Sprintf("%d/%s", port.Port, port.Protocol))
+		}
+		r.Attributes["ports"] = join(ports)
+		if external := loadBalancerAddresses(service.Status.LoadBalancer.Ingress); len(external) > 0 {
+			r.Attributes["external_address"] = join(external)
+		}
+
+		resources = append(resources, r)
+	}
+
+	return resources, err
+}
+
+// loadBalancerAddresses returns the hostnames or IPs a load balancer is
+// reached at.
+func loadBalancerAddresses(ingress []corev1.LoadBalancerIngress) []string {
+	var addresses []string
+	for _, point := range ingress {
+		if point.Hostname != "" {
+			addresses = append(addresses, point.Hostname)
+		} else if point.IP != "" {
+			addresses = append(addresses, point.IP)
+		}
+	}
+	return addresses
+}
@@ -0,0 +1,45 @@
+package kubernetes
+
+import (
+	"context"
+
+	"github.com/alwindoss/cloudy/pkg/cloudy"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+func listVolumeClaims(ctx context.Context, client kubernetes.Interface, cluster string) ([]cloudy.Resource, error) {
+	claims, err := listAll(ctx, func(ctx context.Context, opts metav1.ListOptions) ([]corev1.PersistentVolumeClaim, metav1.ListMeta, error) {
+		list, err := client.CoreV1().PersistentVolumeClaims(metav1.NamespaceAll).List(ctx, opts)
+		if err != nil {
+			return nil, metav1.ListMeta{}, err
+		}
+		return list.Items, list.ListMeta, nil
+	})
+
+	var resources []cloudy.Resource
+	for _, claim := range claims {
+		r := resource(cluster, "persistentvolumeclaim", "Kubernetes PersistentVolumeClaim", claim.ObjectMeta)
+		r.State = string(claim.Status.Phase)
+		r.Attributes["volume_name"] = claim.Spec.VolumeName
+		if claim.Spec.StorageClassName != nil {
+			r.Attributes["storage_class"] = *claim.Spec.StorageClassName
+		}
+		if capacity, ok := claim.Status.Capacity[corev1.ResourceStorage]; ok {
+			r.Attributes["capacity"] = capacity.String()
+		} else if request, ok := claim.Spec.Resources.Requests[corev1.ResourceStorage]; ok {
+			r.Attributes["capacity"] = request.String()
+		}
+
+		var modes []string
+		for _, mode := range claim.Spec.AccessModes {
+			modes = append(modes, string(mode))
+		}
+		r.Attributes["access_modes"] = join(modes)
+
+		resources = append(resources, r)
+	}
+
+	return resources, err
+}
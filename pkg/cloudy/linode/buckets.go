@@ -0,0 +1,44 @@
+package linode
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/alwindoss/cloudy/pkg/cloudy"
+)
+
+func listBuckets(ctx context.Context, p *Provider, region string) ([]cloudy.Resource, error) {
+	buckets, err := shared(ctx, p, "buckets", p.client.ListObjectStorageBuckets)
+
+	var resources []cloudy.Resource
+	for _, bucket := range buckets {
+		// Buckets made before Object Storage regions only name their
+		// cluster, like us-east-1, which is in the region us-east
+		bucketRegion := bucket.Region
+		if bucketRegion == "" {
+			bucketRegion = bucket.Cluster[:max(strings.LastIndex(bucket.Cluster, "-"), 0)]
+		}
+		if bucketRegion != region {
+			continue
+		}
+
+		resources = append(resources, cloudy.Resource{
+			ID:     fmt.Sprintf("linode:bucket:%s/%s", bucket.Cluster, bucket.Label),
+			Name:   bucket.Label,
+			Type:   "Linode Object Storage Bucket",
+			Region: region,
+			Tags:   make(map[string]string),
+			Attributes: map[string]string{
+				"cluster":       bucket.Cluster,
+				"hostname":      bucket.Hostname,
+				"endpoint_type": string(bucket.EndpointType),
+				"objects":       fmt.Sprintf("%d", bucket.Objects),
+				"size_bytes":    fmt.Sprintf("%d", bucket.Size),
+				"created":       timeString(bucket.Created),
+			},
+		})
+	}
+
+	return resources, err
+}
@@ -0,0 +1,58 @@
+package linode
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/alwindoss/cloudy/pkg/cloudy"
+)
+
+func listInstances(ctx context.Context, p *Provider, region string) ([]cloudy.Resource, error) {
+	instances, err := shared(ctx, p, "instances", p.client.ListInstances)
+
+	var resources []cloudy.Resource
+	for _, instance := range instances {
+		if instance.Region != region {
+			continue
+		}
+
+		attributes := map[string]string{
+			"type":       instance.Type,
+			"image":      instance.Image,
+			"hypervisor": instance.Hypervisor,
+			"created":    timeString(instance.Created),
+		}
+		if specs := instance.Specs; specs != nil {
+			attributes["vcpus"] = fmt.Sprintf("%d", specs.VCPUs)
+			attributes["memory_mb"] = fmt.Sprintf("%d", specs.Memory)
+			attributes["disk_mb"] = fmt.Sprintf("%d", specs.Disk)
+		}
+		for _, ip := range instance.IPv4 {
+			if ip == nil {
+				continue
+			}
+			key := "public_ip"
+			if ip.IsPrivate() {
+				key = "private_ip"
+			}
+			if _, ok := attributes[key]; !ok {
+				attributes[key] = ip.String()
+			}
+		}
+		if instance.LKEClusterID != 0 {
+			attributes["lke_cluster_id"] = fmt.Sprintf("%d", instance.LKEClusterID)
+		}
+
+		resources = append(resources, cloudy.Resource{
+			ID:         fmt.Sprintf("linode:instance:%d", instance.ID),
+			Name:       instance.Label,
+			Type:       "Linode",
+			State:      string(instance.Status),
+			Region:     region,
+			Tags:       tags(instance.Tags),
+			Attributes: attributes,
+		})
+	}
+
+	return resources, err
+}
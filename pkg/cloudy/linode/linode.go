@@ -0,0 +1,201 @@
+// Package linode is Cloudy's Linode (Akamai Cloud) provider. It lists
+// Linodes, LKE clusters, NodeBalancers and Object Storage buckets in one
+// account, region by region, as cloudy.Resources, so a Scanner from
+// cloudy.NewProviderScanner scans Linode the way it scans AWS.
+package linode
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/alwindoss/cloudy/pkg/cloudy"
+	"github.com/alwindoss/cloudy/pkg/cloudy/internal/listings"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/smithy-go"
+	"github.com/linode/linodego"
+)
+
+// ProviderName names the Linode provider in requests and on its
+// resources.
+const ProviderName = "linode"
+
+// listingTTL is how long an account-wide listing is shared. Linode lists
+// every resource of a kind for the whole account, so the regions of a
+// scan, which all ask for them at about the same time, take theirs out of
+// one listing instead of each listing the account again.
+const listingTTL = 30 * time.Second
+
+// pageSize is the most items the Linode API returns in a page.
+const pageSize = 500
+
+// Provider is a Linode account.
+type Provider struct {
+	client *linodego.Client
+
+	listings *listings.Cache
+}
+
+// NewProvider returns the Linode provider for the account client has a
+// token for, e.g. from linodego.NewClient(nil) and SetToken.
+func NewProvider(client *linodego.Client) *Provider {
+	return &Provider{client: client, listings: &listings.Cache{TTL: listingTTL}}
+}
+
+func (p *Provider) Name() string { return ProviderName }
+
+// Regions returns the regions that are up, like us-east.
+func (p *Provider) Regions(ctx context.Context) ([]string, error) {
+	regions, err := listAll(ctx, p.client.ListRegions)
+	if err != nil {
+		return nil, err
+	}
+
+	var names []string
+	for _, region := range regions {
+		if region.Status == "ok" {
+			names = append(names, region.ID)
+		}
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// Listers returns a lister for each supported service.
+func (p *Provider) Listers() []cloudy.ServiceLister {
+	return []cloudy.ServiceLister{
+		lister{p: p, name: "Linodes", types: []string{"Linode"}, actions: []string{"linodes:read_only"}, list: listInstances},
+		lister{p: p, name: "LKE", types: []string{"LKE Cluster"}, actions: []string{"lke:read_only"}, list: listLKEClusters},
+		lister{p: p, name: "NodeBalancers", types: []string{"NodeBalancer"}, actions: []string{"nodebalancers:read_only"}, list: listNodeBalancers},
+		lister{p: p, name: "Linode Object Storage", types: []string{"Linode Object Storage Bucket"}, actions: []string{"object_storage:read_only"}, list: listBuckets},
+	}
+}
+
+// listFunc lists a service's resources in one region.
+type listFunc func(ctx context.Context, p *Provider, region string) ([]cloudy.Resource, error)
+
+// lister is a cloudy.ServiceLister for a Linode service. List is passed
+// the region to list as the config's region.
+type lister struct {
+	p       *Provider
+	name    string
+	types   []string
+	actions []string
+	list    listFunc
+}
+
+func (l lister) Name() string    { return l.name }
+func (l lister) Types() []string { return l.types }
+func (l lister) Global() bool    { return false }
+
+// IAMActions returns the OAuth scopes a personal access token needs to
+// list the service.
+func (l lister) IAMActions() []string { return l.actions }
+
+func (l lister) List(ctx context.Context, cfg aws.Config, _ []string) ([]cloudy.Resource, error) {
+	return l.list(ctx, l.p, cfg.Region)
+}
+
+// listAll pages through list to the end, up to the cap of the scan ctx
+// belongs to.
+func listAll[T any](ctx context.Context, list func(context.Context, *linodego.ListOptions) ([]T, error)) ([]T, error) {
+	var items []T
+	opts := &linodego.ListOptions{PageOptions: &linodego.PageOptions{Page: 1}, PageSize: pageSize}
+	for {
+		if err := checkMaxResults(ctx, len(items)); err != nil {
+			return items, err
+		}
+		// With a page set, list fetches just that page and sets Pages
+		page, err := list(ctx, opts)
+		if err != nil {
+			return items, apiError(err)
+		}
+		items = append(items, page...)
+
+		if opts.Page >= opts.Pages {
+			return items, nil
+		}
+		opts.Page++
+	}
+}
+
+// shared lists every item of a kind through list, once for all the
+// regions of a scan.
+func shared[T any](ctx context.Context, p *Provider, kind string, list func(context.Context, *linodego.ListOptions) ([]T, error)) ([]T, error) {
+	return listings.Shared(ctx, p.listings, kind, func(ctx context.Context) ([]T, error) {
+		return listAll(ctx, list)
+	})
+}
+
+// checkMaxResults fails once n items have been listed, at the cap of the
+// scan ctx belongs to.
+func checkMaxResults(ctx context.Context, n int) error {
+	if limit := cloudy.MaxResults(ctx); n >= limit {
+		return fmt.Errorf("%w: stopped after %d", cloudy.ErrMaxResults, limit)
+	}
+	return nil
+}
+
+// responseError gives a Linode error's status to cloudy's ServiceErrors,
+// which read it as they read an AWS error's.
+type responseError struct {
+	err *linodego.Error
+}
+
+// ErrorCode returns the error's HTTP status, like NotFound: Linode errors
+// carry no code of their own.
+func (e responseError) ErrorCode() string {
+	return strings.ReplaceAll(http.StatusText(e.err.Code), " ", "")
+}
+
+func (e responseError) Error() string        { return e.err.Error() }
+func (e responseError) ErrorMessage() string { return e.err.Message }
+func (e responseError) HTTPStatusCode() int  { return e.err.Code }
+func (e responseError) Unwrap() error        { return e.err }
+
+func (e responseError) ErrorFault() smithy.ErrorFault {
+	if e.err.Code >= 500 {
+		return smithy.FaultServer
+	}
+	return smithy.FaultClient
+}
+
+// apiError wraps err in a responseError if it is a Linode error response.
+// linodego also reports transport errors as Errors, with a code below any
+// HTTP status; those are left alone.
+func apiError(err error) error {
+	var apiErr *linodego.Error
+	if !errors.As(err, &apiErr) {
+		var value linodego.Error
+		if !errors.As(err, &value) {
+			return err
+		}
+		apiErr = &value
+	}
+	if apiErr.Code < 100 {
+		return err
+	}
+	return responseError{apiErr}
+}
+
+// tags returns Linode's tags as cloudy tags, splitting key:value tags at
+// the first colon.
+func tags(list []string) map[string]string {
+	converted := make(map[string]string, len(list))
+	for _, tag := range list {
+		key, value, _ := strings.Cut(tag, ":")
+		converted[key] = value
+	}
+	return converted
+}
+
+func timeString(t *time.Time) string {
+	if t == nil {
+		return ""
+	}
+	return t.String()
+}
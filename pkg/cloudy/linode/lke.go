@@ -0,0 +1,36 @@
+package linode
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/alwindoss/cloudy/pkg/cloudy"
+)
+
+func listLKEClusters(ctx context.Context, p *Provider, region string) ([]cloudy.Resource, error) {
+	clusters, err := shared(ctx, p, "lke-clusters", p.client.ListLKEClusters)
+
+	var resources []cloudy.Resource
+	for _, cluster := range clusters {
+		if cluster.Region != region {
+			continue
+		}
+
+		resources = append(resources, cloudy.Resource{
+			ID:     fmt.Sprintf("linode:lke:%d", cluster.ID),
+			Name:   cluster.Label,
+			Type:   "LKE Cluster",
+			State:  string(cluster.Status),
+			Region: region,
+			Tags:   tags(cluster.Tags),
+			Attributes: map[string]string{
+				"k8s_version":       cluster.K8sVersion,
+				"tier":              cluster.Tier,
+				"high_availability": fmt.Sprintf("%t", cluster.ControlPlane.HighAvailability),
+				"created":           timeString(cluster.Created),
+			},
+		})
+	}
+
+	return resources, err
+}
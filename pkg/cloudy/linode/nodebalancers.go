@@ -0,0 +1,45 @@
+package linode
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/alwindoss/cloudy/pkg/cloudy"
+)
+
+func listNodeBalancers(ctx context.Context, p *Provider, region string) ([]cloudy.Resource, error) {
+	balancers, err := shared(ctx, p, "nodebalancers", p.client.ListNodeBalancers)
+
+	var resources []cloudy.Resource
+	for _, balancer := range balancers {
+		if balancer.Region != region {
+			continue
+		}
+
+		attributes := map[string]string{
+			"type":    string(balancer.Type),
+			"created": timeString(balancer.Created),
+		}
+		for key, value := range map[string]*string{"hostname": balancer.Hostname, "public_ip": balancer.IPv4, "ipv6": balancer.IPv6} {
+			if value != nil {
+				attributes[key] = *value
+			}
+		}
+
+		var name string
+		if balancer.Label != nil {
+			name = *balancer.Label
+		}
+
+		resources = append(resources, cloudy.Resource{
+			ID:         fmt.Sprintf("linode:nodebalancer:%d", balancer.ID),
+			Name:       name,
+			Type:       "NodeBalancer",
+			Region:     region,
+			Tags:       tags(balancer.Tags),
+			Attributes: attributes,
+		})
+	}
+
+	return resources, err
+}
@@ -0,0 +1,776 @@
+package cloudy
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	ec2types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	"github.com/aws/aws-sdk-go-v2/service/ecs"
+	ecstypes "github.com/aws/aws-sdk-go-v2/service/ecs/types"
+	"github.com/aws/aws-sdk-go-v2/service/iam"
+	"github.com/aws/aws-sdk-go-v2/service/lambda"
+	lambdatypes "github.com/aws/aws-sdk-go-v2/service/lambda/types"
+	"github.com/aws/aws-sdk-go-v2/service/rds"
+	rdstypes "github.com/aws/aws-sdk-go-v2/service/rds/types"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	s3types "github.com/aws/aws-sdk-go-v2/service/s3/types"
+)
+
+func init() {
+	Register(lister{
+		name:       "EC2 instances",
+		types:      []string{"EC2 Instance"},
+		iamActions: []string{"ec2:DescribeInstances"},
+		list:       listEC2Instances,
+	})
+	Register(lister{
+		name:   "S3 buckets",
+		types:  []string{"S3 Bucket"},
+		global: true,
+		iamActions: []string{
+			"s3:ListBuckets",
+			"s3:GetBucketLocation",
+			"s3:GetBucketVersioning",
+			"s3:GetEncryptionConfiguration",
+			"s3:GetBucketPublicAccessBlock",
+			"s3:GetLifecycleConfiguration",
+		},
+		list: withoutStates(listS3Buckets),
+	})
+	Register(lister{
+		name:       "RDS instances",
+		types:      []string{"RDS Instance"},
+		iamActions: []string{"rds:DescribeDBInstances"},
+		list:       withoutStates(listRDSInstances),
+	})
+	Register(lister{
+		name:       "Aurora clusters",
+		types:      []string{"Aurora Cluster"},
+		iamActions: []string{"rds:DescribeDBClusters"},
+		list:       withoutStates(listAuroraClusters),
+	})
+	Register(lister{
+		name:       "RDS snapshots",
+		types:      []string{"RDS Snapshot", "RDS Cluster Snapshot"},
+		iamActions: []string{"rds:DescribeDBSnapshots", "rds:DescribeDBClusterSnapshots"},
+		list:       withoutStates(listRDSSnapshots),
+	})
+	Register(lister{
+		name:       "Lambda functions",
+		types:      []string{"Lambda Function", "Lambda Version", "Lambda Alias"},
+		iamActions: []string{"lambda:ListFunctions", "lambda:ListVersionsByFunction", "lambda:ListAliases"},
+		list:       withoutStates(listLambdaFunctions),
+	})
+	Register(lister{
+		name:       "Lambda layers",
+		types:      []string{"Lambda Layer"},
+		iamActions: []string{"lambda:ListLayers"},
+		list:       withoutStates(listLambdaLayers),
+	})
+	Register(lister{
+		name:       "Lambda event source mappings",
+		types:      []string{"Lambda Event Source Mapping"},
+		iamActions: []string{"lambda:ListEventSourceMappings"},
+		list:       withoutStates(listLambdaEventSourceMappings),
+	})
+	Register(lister{
+		name:       "ECS clusters",
+		types:      []string{"ECS Cluster"},
+		iamActions: []string{"ecs:ListClusters", "ecs:DescribeClusters"},
+		list:       withoutStates(listECSClusters),
+	})
+	Register(lister{
+		name:       "IAM users",
+		types:      []string{"IAM User"},
+		global:     true,
+		iamActions: []string{"iam:ListUsers"},
+		list:       withoutStates(listIAMUsers),
+	})
+}
+
+func listEC2Instances(ctx context.Context, cfg aws.Config, states []string) ([]Resource, error) {
+	client := Client(ctx, cfg, ec2.NewFromConfig)
+
+	input := &ec2.DescribeInstancesInput{}
+	if len(states) > 0 {
+		stateNames := make([]string, len(states))
+		for i, state := range states {
+			stateNames[i] = strings.ToLower(state)
+		}
+		input.Filters = []ec2types.Filter{{Name: aws.String("instance-state-name"), Values: stateNames}}
+	}
+
+	paginator := ec2.NewDescribeInstancesPaginator(client, input)
+
+	var resources []Resource
+	for paginator.HasMorePages() {
+		if err := checkMaxResults(ctx, len(resources)); err != nil {
+			return resources, err
+		}
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return resources, err
+		}
+
+		for _, reservation := range page.Reservations {
+			for _, instance := range reservation.Instances {
+				tags := make(map[string]string)
+				name := ""
+				for _, tag := range instance.Tags {
+					if tag.Key != nil && tag.Value != nil {
+						tags[*tag.Key] = *tag.Value
+						if *tag.Key == "Name" {
+							name = *tag.Value
+						}
+					}
+				}
+
+				attributes := map[string]string{
+					"instance_type": string(instance.InstanceType),
+					"vpc_id":        aws_string_value(instance.VpcId),
+					"subnet_id":     aws_string_value(instance.SubnetId),
+				}
+
+				if instance.PublicIpAddress != nil {
+					attributes["public_ip"] = *instance.PublicIpAddress
+				}
+				if instance.PrivateIpAddress != nil {
+					attributes["private_ip"] = *instance.PrivateIpAddress
+				}
+
+				resources = append(resources, Resource{
+					ID:         aws_string_value(instance.InstanceId),
+					Name:       name,
+					Type:       "EC2 Instance",
+					State:      string(instance.State.Name),
+					Region:     cfg.Region,
+					Tags:       tags,
+					Attributes: attributes,
+				})
+			}
+		}
+	}
+
+	return resources, nil
+}
+
+func listS3Buckets(ctx context.Context, cfg aws.Config) ([]Resource, error) {
+	client := Client(ctx, cfg, s3.NewFromConfig)
+
+	// Buckets listed before a failure are still described and returned
+	var buckets []s3types.Bucket
+	var listErr error
+	paginator := s3.NewListBucketsPaginator(client, &s3.ListBucketsInput{})
+	for paginator.HasMorePages() {
+		if listErr = checkMaxResults(ctx, len(buckets)); listErr != nil {
+			break
+		}
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			listErr = err
+			break
+		}
+		buckets = append(buckets, page.Buckets...)
+	}
+
+	// Each bucket needs several configuration lookups, so inspect buckets
+	// in parallel rather than one after another.
+	resources := make([]Resource, len(buckets))
+	forEachBounded(len(buckets), describeConcurrency, func(i int) {
+		bucket := buckets[i]
+		attributes := describeS3Bucket(ctx, client, aws_string_value(bucket.Name))
+		attributes["created"] = bucket.CreationDate.String()
+
+		resources[i] = Resource{
+			ID:         aws_string_value(bucket.Name),
+			Name:       aws_string_value(bucket.Name),
+			Type:       "S3 Bucket",
+			Region:     "global", // S3 buckets are global but shown in the partition's global region
+			Attributes: attributes,
+		}
+	})
+
+	return resources, listErr
+}
+
+// describeS3Bucket collects the audit-relevant configuration of a bucket:
+// its home region, versioning, default encryption, public access block and
+// lifecycle rules. Configuration that simply isn't set is reported as such.
+// A lookup that fails (e.g. denied by a bucket policy) is recorded under its
+// own "<check>_error" key without skipping the other checks; only a failed
+// location lookup stops early, since the rest must go to the bucket's region.
+func describeS3Bucket(ctx context.Context, client *s3.Client, bucket string) map[string]string {
+	attributes := make(map[string]string)
+
+	location, err := client.GetBucketLocation(ctx, &s3.GetBucketLocationInput{Bucket: aws.String(bucket)})
+	if err != nil {
+		attributes["bucket_region_error"] = err.Error()
+		return attributes
+	}
+
+	bucketRegion := string(location.LocationConstraint)
+	switch bucketRegion {
+	case "":
+		// us-east-1, or its counterpart in the other partitions
+		bucketRegion = GlobalRegion(Partition(client.Options().Region))
+	case "EU":
+		bucketRegion = "eu-west-1"
+	}
+	attributes["bucket_region"] = bucketRegion
+
+	// Bucket configuration must be read from the bucket's own region
+	inBucketRegion := func(o *s3.Options) {
+		o.Region = bucketRegion
+	}
+
+	versioning, err := client.GetBucketVersioning(ctx, &s3.GetBucketVersioningInput{Bucket: aws.String(bucket)}, inBucketRegion)
+	if err != nil {
+		attributes["versioning_error"] = err.Error()
+	} else {
+		attributes["versioning"] = string(versioning.Status)
+		if attributes["versioning"] == "" {
+			attributes["versioning"] = "Disabled"
+		}
+	}
+
+	encryption, err := client.GetBucketEncryption(ctx, &s3.GetBucketEncryptionInput{Bucket: aws.String(bucket)}, inBucketRegion)
+	switch {
+	case aws_error_code(err) == "ServerSideEncryptionConfigurationNotFoundError":
+		attributes["encryption"] = "none"
+	case err != nil:
+		attributes["encryption_error"] = err.Error()
+	case encryption.ServerSideEncryptionConfiguration != nil:
+		for _, rule := range encryption.ServerSideEncryptionConfiguration.Rules {
+			if rule.ApplyServerSideEncryptionByDefault == nil {
+				continue
+			}
+			attributes["encryption"] = string(rule.ApplyServerSideEncryptionByDefault.SSEAlgorithm)
+			if keyID := rule.ApplyServerSideEncryptionByDefault.KMSMasterKeyID; keyID != nil {
+				attributes["encryption_kms_key"] = *keyID
+			}
+		}
+	}
+
+	publicAccess, err := client.GetPublicAccessBlock(ctx, &s3.GetPublicAccessBlockInput{Bucket: aws.String(bucket)}, inBucketRegion)
+	switch {
+	case aws_error_code(err) == "NoSuchPublicAccessBlockConfiguration":
+		attributes["public_access_block"] = "none"
+	case err != nil:
+		attributes["public_access_block_error"] = err.Error()
+	case publicAccess.PublicAccessBlockConfiguration != nil:
+		block := publicAccess.PublicAccessBlockConfiguration
+		attributes["block_public_acls"] = fmt.Sprintf("%t", aws_bool_value(block.BlockPublicAcls))
+		attributes["ignore_public_acls"] = fmt.Sprintf("%t", aws_bool_value(block.IgnorePublicAcls))
+		attributes["block_public_policy"] = fmt.Sprintf("%t", aws_bool_value(block.BlockPublicPolicy))
+		attributes["restrict_public_buckets"] = fmt.Sprintf("%t", aws_bool_value(block.RestrictPublicBuckets))
+	}
+
+	lifecycle, err := client.GetBucketLifecycleConfiguration(ctx, &s3.GetBucketLifecycleConfigurationInput{Bucket: aws.String(bucket)}, inBucketRegion)
+	switch {
+	case aws_error_code(err) == "NoSuchLifecycleConfiguration":
+		attributes["lifecycle_rule_count"] = "0"
+	case err != nil:
+		attributes["lifecycle_error"] = err.Error()
+	default:
+		attributes["lifecycle_rule_count"] = fmt.Sprintf("%d", len(lifecycle.Rules))
+	}
+
+	return attributes
+}
+
+func listRDSInstances(ctx context.Context, cfg aws.Config) ([]Resource, error) {
+	client := Client(ctx, cfg, rds.NewFromConfig)
+	paginator := rds.NewDescribeDBInstancesPaginator(client, &rds.DescribeDBInstancesInput{})
+
+	var resources []Resource
+	for paginator.HasMorePages() {
+		if err := checkMaxResults(ctx, len(resources)); err != nil {
+			return resources, err
+		}
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return resources, err
+		}
+
+		for _, instance := range page.DBInstances {
+			attributes := map[string]string{
+				"engine":         aws_string_value(instance.Engine),
+				"engine_version": aws_string_value(instance.EngineVersion),
+				"instance_class": aws_string_value(instance.DBInstanceClass),
+				"multi_az":       fmt.Sprintf("%t", aws_bool_value(instance.MultiAZ)),
+			}
+
+			if instance.Endpoint != nil {
+				attributes["endpoint"] = aws_string_value(instance.Endpoint.Address)
+				if instance.Endpoint.Port != nil {
+					attributes["port"] = fmt.Sprintf("%d", *instance.Endpoint.Port)
+				}
+			}
+
+			resources = append(resources, Resource{
+				ID:         aws_string_value(instance.DBInstanceIdentifier),
+				Name:       aws_string_value(instance.DBInstanceIdentifier),
+				Type:       "RDS Instance",
+				State:      aws_string_value(instance.DBInstanceStatus),
+				Region:     cfg.Region,
+				Attributes: attributes,
+			})
+		}
+	}
+
+	return resources, nil
+}
+
+func listAuroraClusters(ctx context.Context, cfg aws.Config) ([]Resource, error) {
+	client := Client(ctx, cfg, rds.NewFromConfig)
+	paginator := rds.NewDescribeDBClustersPaginator(client, &rds.DescribeDBClustersInput{
+		Filters: []rdstypes.Filter{
+			{
+				Name:   aws.String("engine"),
+				Values: []string{"aurora", "aurora-mysql", "aurora-postgresql"},
+			},
+		},
+	})
+
+	var resources []Resource
+	for paginator.HasMorePages() {
+		if err := checkMaxResults(ctx, len(resources)); err != nil {
+			return resources, err
+		}
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return resources, err
+		}
+
+		for _, cluster := range page.DBClusters {
+			var members []string
+			writer := ""
+			for _, member := range cluster.DBClusterMembers {
+				members = append(members, aws_string_value(member.DBInstanceIdentifier))
+				if member.IsClusterWriter != nil && *member.IsClusterWriter {
+					writer = aws_string_value(member.DBInstanceIdentifier)
+				}
+			}
+
+			attributes := map[string]string{
+				"engine":          aws_string_value(cluster.Engine),
+				"engine_version":  aws_string_value(cluster.EngineVersion),
+				"engine_mode":     aws_string_value(cluster.EngineMode),
+				"endpoint":        aws_string_value(cluster.Endpoint),
+				"reader_endpoint": aws_string_value(cluster.ReaderEndpoint),
+				"members":         strings.Join(members, ","),
+				"member_count":    fmt.Sprintf("%d", len(members)),
+				"writer":          writer,
+			}
+
+			tags := make(map[string]string)
+			for _, tag := range cluster.TagList {
+				if tag.Key != nil && tag.Value != nil {
+					tags[*tag.Key] = *tag.Value
+				}
+			}
+
+			resources = append(resources, Resource{
+				ID:         aws_string_value(cluster.DBClusterArn),
+				Name:       aws_string_value(cluster.DBClusterIdentifier),
+				Type:       "Aurora Cluster",
+				State:      aws_string_value(cluster.Status),
+				Region:     cfg.Region,
+				Tags:       tags,
+				Attributes: attributes,
+			})
+		}
+	}
+
+	return resources, nil
+}
+
+// listRDSSnapshots lists instance and cluster snapshots. The two lookups are
+// independent, so a failure in one still returns what the other found.
+func listRDSSnapshots(ctx context.Context, cfg aws.Config) ([]Resource, error) {
+	client := Client(ctx, cfg, rds.NewFromConfig)
+
+	var resources []Resource
+	var errs []error
+
+	instancePaginator := rds.NewDescribeDBSnapshotsPaginator(client, &rds.DescribeDBSnapshotsInput{})
+	for instancePaginator.HasMorePages() {
+		if err := checkMaxResults(ctx, len(resources)); err != nil {
+			errs = append(errs, fmt.Errorf("DB snapshots: %w", err))
+			break
+		}
+		page, err := instancePaginator.NextPage(ctx)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("DB snapshots: %w", err))
+			break
+		}
+
+		for _, snapshot := range page.DBSnapshots {
+			resources = append(resources, Resource{
+				ID:     aws_string_value(snapshot.DBSnapshotArn),
+				Name:   aws_string_value(snapshot.DBSnapshotIdentifier),
+				Type:   "RDS Snapshot",
+				State:  aws_string_value(snapshot.Status),
+				Region: cfg.Region,
+				Attributes: map[string]string{
+					"source":               aws_string_value(snapshot.DBInstanceIdentifier),
+					"snapshot_type":        aws_string_value(snapshot.SnapshotType),
+					"engine":               aws_string_value(snapshot.Engine),
+					"allocated_storage_gb": fmt.Sprintf("%d", aws_int32_value(snapshot.AllocatedStorage)),
+					"created":              aws_time_string(snapshot.SnapshotCreateTime),
+					"age_days":             aws_age_days(snapshot.SnapshotCreateTime),
+				},
+			})
+		}
+	}
+
+	clusterPaginator := rds.NewDescribeDBClusterSnapshotsPaginator(client, &rds.DescribeDBClusterSnapshotsInput{})
+	for clusterPaginator.HasMorePages() {
+		if err := checkMaxResults(ctx, len(resources)); err != nil {
+			errs = append(errs, fmt.Errorf("DB cluster snapshots: %w", err))
+			break
+		}
+		page, err := clusterPaginator.NextPage(ctx)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("DB cluster snapshots: %w", err))
+			break
+		}
+
+		for _, snapshot := range page.DBClusterSnapshots {
+			resources = append(resources, Resource{
+				ID:     aws_string_value(snapshot.DBClusterSnapshotArn),
+				Name:   aws_string_value(snapshot.DBClusterSnapshotIdentifier),
+				Type:   "RDS Cluster Snapshot",
+				State:  aws_string_value(snapshot.Status),
+				Region: cfg.Region,
+				Attributes: map[string]string{
+					"source":               aws_string_value(snapshot.DBClusterIdentifier),
+					"snapshot_type":        aws_string_value(snapshot.SnapshotType),
+					"engine":               aws_string_value(snapshot.Engine),
+					"allocated_storage_gb": fmt.Sprintf("%d", aws_int32_value(snapshot.AllocatedStorage)),
+					"created":              aws_time_string(snapshot.SnapshotCreateTime),
+					"age_days":             aws_age_days(snapshot.SnapshotCreateTime),
+				},
+			})
+		}
+	}
+
+	return resources, errors.Join(errs...)
+}
+
+func listLambdaFunctions(ctx context.Context, cfg aws.Config) ([]Resource, error) {
+	client := Client(ctx, cfg, lambda.NewFromConfig)
+
+	// Functions listed before a failure are still returned
+	var configurations []lambdatypes.FunctionConfiguration
+	var listErr error
+	paginator := lambda.NewListFunctionsPaginator(client, &lambda.ListFunctionsInput{})
+	for paginator.HasMorePages() {
+		if listErr = checkMaxResults(ctx, len(configurations)); listErr != nil {
+			break
+		}
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			listErr = err
+			break
+		}
+		configurations = append(configurations, page.Functions...)
+	}
+
+	functions := make([]Resource, len(configurations))
+	for i, function := range configurations {
+		attributes := map[string]string{
+			"runtime":     string(function.Runtime),
+			"handler":     aws_string_value(function.Handler),
+			"memory_size": fmt.Sprintf("%d", aws_int32_value(function.MemorySize)),
+			"timeout":     fmt.Sprintf("%d", aws_int32_value(function.Timeout)),
+		}
+
+		functions[i] = Resource{
+			ID:         aws_string_value(function.FunctionArn),
+			Name:       aws_string_value(function.FunctionName),
+			Type:       "Lambda Function",
+			State:      string(function.State),
+			Region:     cfg.Region,
+			Attributes: attributes,
+		}
+	}
+
+	// Versions and aliases are per-function calls. A function whose versions
+	// can't be read (e.g. missing lambda:ListVersionsByFunction) is still
+	// listed, with the failure recorded on it.
+	versions := make([][]Resource, len(functions))
+	forEachBounded(len(functions), describeConcurrency, func(i int) {
+		versionResources, err := listLambdaVersionsAndAliases(ctx, client, cfg.Region, functions[i].Name)
+		if err != nil {
+			functions[i].Attributes["error"] = err.Error()
+		}
+		versions[i] = versionResources
+	})
+
+	var resources []Resource
+	for i := range functions {
+		resources = append(resources, functions[i])
+		resources = append(resources, versions[i]...)
+	}
+
+	return resources, listErr
+}
+
+func listLambdaVersionsAndAliases(ctx context.Context, client *lambda.Client, region, functionName string) ([]Resource, error) {
+	var resources []Resource
+
+	versionPaginator := lambda.NewListVersionsByFunctionPaginator(client, &lambda.ListVersionsByFunctionInput{
+		FunctionName: aws.String(functionName),
+	})
+	for versionPaginator.HasMorePages() {
+		if err := checkMaxResults(ctx, len(resources)); err != nil {
+			return resources, fmt.Errorf("list versions: %w", err)
+		}
+		page, err := versionPaginator.NextPage(ctx)
+		if err != nil {
+			return resources, fmt.Errorf("list versions: %w", err)
+		}
+
+		for _, version := range page.Versions {
+			// $LATEST is the unpublished function itself, already listed
+			if aws_string_value(version.Version) == "$LATEST" {
+				continue
+			}
+
+			resources = append(resources, Resource{
+				ID:     aws_string_value(version.FunctionArn),
+				Name:   functionName + ":" + aws_string_value(version.Version),
+				Type:   "Lambda Version",
+				State:  string(version.State),
+				Region: region,
+				Attributes: map[string]string{
+					"function_name": functionName,
+					"version":       aws_string_value(version.Version),
+					"runtime":       string(version.Runtime),
+					"description":   aws_string_value(version.Description),
+					"last_modified": aws_string_value(version.LastModified),
+				},
+			})
+		}
+	}
+
+	aliasPaginator := lambda.NewListAliasesPaginator(client, &lambda.ListAliasesInput{
+		FunctionName: aws.String(functionName),
+	})
+	for aliasPaginator.HasMorePages() {
+		if err := checkMaxResults(ctx, len(resources)); err != nil {
+			return resources, fmt.Errorf("list aliases: %w", err)
+		}
+		page, err := aliasPaginator.NextPage(ctx)
+		if err != nil {
+			return resources, fmt.Errorf("list aliases: %w", err)
+		}
+
+		for _, alias := range page.Aliases {
+			attributes := map[string]string{
+				"function_name":    functionName,
+				"function_version": aws_string_value(alias.FunctionVersion),
+				"description":      aws_string_value(alias.Description),
+			}
+
+			if alias.RoutingConfig != nil {
+				for version, weight := range alias.RoutingConfig.AdditionalVersionWeights {
+					attributes["routing_weight_"+version] = fmt.Sprintf("%g", weight)
+				}
+			}
+
+			resources = append(resources, Resource{
+				ID:         aws_string_value(alias.AliasArn),
+				Name:       aws_string_value(alias.Name),
+				Type:       "Lambda Alias",
+				Region:     region,
+				Attributes: attributes,
+			})
+		}
+	}
+
+	return resources, nil
+}
+
+func listLambdaLayers(ctx context.Context, cfg aws.Config) ([]Resource, error) {
+	client := Client(ctx, cfg, lambda.NewFromConfig)
+	paginator := lambda.NewListLayersPaginator(client, &lambda.ListLayersInput{})
+
+	var resources []Resource
+	for paginator.HasMorePages() {
+		if err := checkMaxResults(ctx, len(resources)); err != nil {
+			return resources, err
+		}
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return resources, err
+		}
+
+		for _, layer := range page.Layers {
+			attributes := map[string]string{}
+
+			if latest := layer.LatestMatchingVersion; latest != nil {
+				runtimes := make([]string, 0, len(latest.CompatibleRuntimes))
+				for _, runtime := range latest.CompatibleRuntimes {
+					runtimes = append(runtimes, string(runtime))
+				}
+
+				attributes["latest_version"] = fmt.Sprintf("%d", latest.Version)
+				attributes["latest_version_arn"] = aws_string_value(latest.LayerVersionArn)
+				attributes["compatible_runtimes"] = strings.Join(runtimes, ",")
+				attributes["description"] = aws_string_value(latest.Description)
+				attributes["created"] = aws_string_value(latest.CreatedDate)
+			}
+
+			resources = append(resources, Resource{
+				ID:         aws_string_value(layer.LayerArn),
+				Name:       aws_string_value(layer.LayerName),
+				Type:       "Lambda Layer",
+				Region:     cfg.Region,
+				Attributes: attributes,
+			})
+		}
+	}
+
+	return resources, nil
+}
+
+func listLambdaEventSourceMappings(ctx context.Context, cfg aws.Config) ([]Resource, error) {
+	client := Client(ctx, cfg, lambda.NewFromConfig)
+	paginator := lambda.NewListEventSourceMappingsPaginator(client, &lambda.ListEventSourceMappingsInput{})
+
+	var resources []Resource
+	for paginator.HasMorePages() {
+		if err := checkMaxResults(ctx, len(resources)); err != nil {
+			return resources, err
+		}
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return resources, err
+		}
+
+		for _, mapping := range page.EventSourceMappings {
+			eventSourceArn := aws_string_value(mapping.EventSourceArn)
+			attributes := map[string]string{
+				"function_arn":     aws_string_value(mapping.FunctionArn),
+				"event_source_arn": eventSourceArn,
+				"batch_size":       fmt.Sprintf("%d", aws_int32_value(mapping.BatchSize)),
+			}
+
+			// arn:partition:service:region:account:resource, the service
+			// being sqs, kinesis or dynamodb, say
+			if parts := strings.SplitN(eventSourceArn, ":", 4); len(parts) > 2 {
+				attributes["event_source_type"] = parts[2]
+			}
+			if mapping.LastModified != nil {
+				attributes["last_modified"] = mapping.LastModified.String()
+			}
+
+			resources = append(resources, Resource{
+				ID:         aws_string_value(mapping.UUID),
+				Name:       aws_string_value(mapping.UUID),
+				Type:       "Lambda Event Source Mapping",
+				State:      aws_string_value(mapping.State),
+				Region:     cfg.Region,
+				Attributes: attributes,
+			})
+		}
+	}
+
+	return resources, nil
+}
+
+// ecsDescribeBatch is the most clusters DescribeClusters accepts at once.
+const ecsDescribeBatch = 100
+
+func listECSClusters(ctx context.Context, cfg aws.Config) ([]Resource, error) {
+	client := Client(ctx, cfg, ecs.NewFromConfig)
+
+	var clusterArns []string
+	paginator := ecs.NewListClustersPaginator(client, &ecs.ListClustersInput{})
+	for paginator.HasMorePages() {
+		if err := checkMaxResults(ctx, len(clusterArns)); err != nil {
+			return nil, err
+		}
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return nil, err
+		}
+		clusterArns = append(clusterArns, page.ClusterArns...)
+	}
+
+	if len(clusterArns) == 0 {
+		return []Resource{}, nil
+	}
+
+	var clusters []ecstypes.Cluster
+	for start := 0; start < len(clusterArns); start += ecsDescribeBatch {
+		end := min(start+ecsDescribeBatch, len(clusterArns))
+		describeResult, err := client.DescribeClusters(ctx, &ecs.DescribeClustersInput{
+			Clusters: clusterArns[start:end],
+		})
+		if err != nil {
+			return nil, err
+		}
+		clusters = append(clusters, describeResult.Clusters...)
+	}
+
+	var resources []Resource
+	for _, cluster := range clusters {
+		attributes := map[string]string{
+			"active_services_count": fmt.Sprintf("%d", cluster.ActiveServicesCount),
+			"running_tasks_count":   fmt.Sprintf("%d", cluster.RunningTasksCount),
+			"pending_tasks_count":   fmt.Sprintf("%d", cluster.PendingTasksCount),
+		}
+
+		resources = append(resources, Resource{
+			ID:         aws_string_value(cluster.ClusterArn),
+			Name:       aws_string_value(cluster.ClusterName),
+			Type:       "ECS Cluster",
+			State:      aws_string_value(cluster.Status),
+			Region:     cfg.Region,
+			Attributes: attributes,
+		})
+	}
+
+	return resources, nil
+}
+
+func listIAMUsers(ctx context.Context, cfg aws.Config) ([]Resource, error) {
+	client := Client(ctx, cfg, iam.NewFromConfig)
+	paginator := iam.NewListUsersPaginator(client, &iam.ListUsersInput{})
+
+	var resources []Resource
+	for paginator.HasMorePages() {
+		if err := checkMaxResults(ctx, len(resources)); err != nil {
+			return resources, err
+		}
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return resources, err
+		}
+
+		for _, user := range page.Users {
+			attributes := map[string]string{
+				"path":    aws_string_value(user.Path),
+				"created": user.CreateDate.String(),
+				"user_id": aws_string_value(user.UserId),
+			}
+
+			resources = append(resources, Resource{
+				ID:         aws_string_value(user.Arn),
+				Name:       aws_string_value(user.UserName),
+				Type:       "IAM User",
+				Region:     "global", // IAM is global
+				Attributes: attributes,
+			})
+		}
+	}
+
+	return resources, nil
+}
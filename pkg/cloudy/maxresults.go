@@ -0,0 +1,40 @@
+package cloudy
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// DefaultMaxResults is how many items a lister pages through in one region
+// before it gives up, unless the Scanner is given another cap with
+// SetMaxResults. It only guards against runaway pagination.
+const DefaultMaxResults = 50000
+
+// ErrMaxResults is wrapped by the error of a lister that stopped paging at
+// the cap. The resources it returns along with it are incomplete.
+var ErrMaxResults = errors.New("max results reached")
+
+type maxResultsKey struct{}
+
+func withMaxResults(ctx context.Context, n int) context.Context {
+	return context.WithValue(ctx, maxResultsKey{}, n)
+}
+
+// MaxResults returns the cap listers should page up to during the scan ctx
+// belongs to.
+func MaxResults(ctx context.Context) int {
+	if n, ok := ctx.Value(maxResultsKey{}).(int); ok && n > 0 {
+		return n
+	}
+	return DefaultMaxResults
+}
+
+// checkMaxResults is called before fetching another page, with the number
+// of items listed so far, and fails once the cap is reached.
+func checkMaxResults(ctx context.Context, n int) error {
+	if limit := MaxResults(ctx); n >= limit {
+		return fmt.Errorf("%w: stopped after %d", ErrMaxResults, limit)
+	}
+	return nil
+}
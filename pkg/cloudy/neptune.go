@@ -0,0 +1,117 @@
+package cloudy
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/rds"
+	rdstypes "github.com/aws/aws-sdk-go-v2/service/rds/types"
+)
+
+func init() {
+	Register(lister{
+		name:       "Neptune and DocumentDB clusters",
+		types:      []string{"Neptune Cluster", "DocumentDB Cluster"},
+		iamActions: []string{"rds:DescribeDBClusters"},
+		list:       withoutStates(listNeptuneAndDocumentDBClusters),
+	})
+}
+
+// Neptune and DocumentDB clusters are managed through the RDS API, keyed by
+// engine name.
+var graphAndDocumentEngines = map[string]string{
+	"neptune": "Neptune Cluster",
+	"docdb":   "DocumentDB Cluster",
+}
+
+func listNeptuneAndDocumentDBClusters(ctx context.Context, cfg aws.Config) ([]Resource, error) {
+	client := Client(ctx, cfg, rds.NewFromConfig)
+
+	engines := make([]string, 0, len(graphAndDocumentEngines))
+	for engine := range graphAndDocumentEngines {
+		engines = append(engines, engine)
+	}
+	engineFilter := []rdstypes.Filter{{Name: aws.String("engine"), Values: engines}}
+
+	var clusters []rdstypes.DBCluster
+	clusterPaginator := rds.NewDescribeDBClustersPaginator(client, &rds.DescribeDBClustersInput{Filters: engineFilter})
+	for clusterPaginator.HasMorePages() {
+		if err := checkMaxResults(ctx, len(clusters)); err != nil {
+			return nil, err
+		}
+		page, err := clusterPaginator.NextPage(ctx)
+		if err != nil {
+			return nil, err
+		}
+		clusters = append(clusters, page.DBClusters...)
+	}
+
+	if len(clusters) == 0 {
+		return []Resource{}, nil
+	}
+
+	// Instance classes live on the member instances, so fetch them in one
+	// pass rather than per cluster.
+	instanceClasses := make(map[string]map[string]bool)
+	var instancesErr error
+	instancePaginator := rds.NewDescribeDBInstancesPaginator(client, &rds.DescribeDBInstancesInput{Filters: engineFilter})
+	for instancePaginator.HasMorePages() {
+		page, err := instancePaginator.NextPage(ctx)
+		if err != nil {
+			instancesErr = err
+			break
+		}
+		for _, instance := range page.DBInstances {
+			clusterID := aws_string_value(instance.DBClusterIdentifier)
+			if instanceClasses[clusterID] == nil {
+				instanceClasses[clusterID] = make(map[string]bool)
+			}
+			instanceClasses[clusterID][aws_string_value(instance.DBInstanceClass)] = true
+		}
+	}
+
+	var resources []Resource
+	for _, cluster := range clusters {
+		clusterID := aws_string_value(cluster.DBClusterIdentifier)
+		attributes := map[string]string{
+			"engine":          aws_string_value(cluster.Engine),
+			"engine_version":  aws_string_value(cluster.EngineVersion),
+			"endpoint":        aws_string_value(cluster.Endpoint),
+			"reader_endpoint": aws_string_value(cluster.ReaderEndpoint),
+			"member_count":    fmt.Sprintf("%d", len(cluster.DBClusterMembers)),
+		}
+
+		if instancesErr != nil {
+			attributes["instance_class_error"] = instancesErr.Error()
+		} else {
+			classes := make([]string, 0, len(instanceClasses[clusterID]))
+			for class := range instanceClasses[clusterID] {
+				classes = append(classes, class)
+			}
+			sort.Strings(classes)
+			attributes["instance_class"] = strings.Join(classes, ",")
+		}
+
+		tags := make(map[string]string)
+		for _, tag := range cluster.TagList {
+			if tag.Key != nil && tag.Value != nil {
+				tags[*tag.Key] = *tag.Value
+			}
+		}
+
+		resources = append(resources, Resource{
+			ID:         aws_string_value(cluster.DBClusterArn),
+			Name:       clusterID,
+			Type:       graphAndDocumentEngines[aws_string_value(cluster.Engine)],
+			State:      aws_string_value(cluster.Status),
+			Region:     cfg.Region,
+			Tags:       tags,
+			Attributes: attributes,
+		})
+	}
+
+	return resources, nil
+}
@@ -0,0 +1,56 @@
+package oci
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/alwindoss/cloudy/pkg/cloudy"
+	"github.com/oracle/oci-go-sdk/v65/common"
+	"github.com/oracle/oci-go-sdk/v65/core"
+)
+
+func listInstances(ctx context.Context, c *clients, compartment, region string) ([]cloudy.Resource, error) {
+	var resources []cloudy.Resource
+	req := core.ListInstancesRequest{CompartmentId: common.String(compartment)}
+	for {
+		if err := checkMaxResults(ctx, len(resources)); err != nil {
+			return resources, err
+		}
+		resp, err := c.compute.ListInstances(ctx, req)
+		if err != nil {
+			return resources, apiError(err)
+		}
+
+		for _, instance := range resp.Items {
+			attributes := baseAttributes(compartment)
+			attributes["shape"] = str(instance.Shape)
+			attributes["availability_domain"] = str(instance.AvailabilityDomain)
+			attributes["fault_domain"] = str(instance.FaultDomain)
+			attributes["image_id"] = str(instance.ImageId)
+			attributes["created"] = timeString(instance.TimeCreated)
+			if shape := instance.ShapeConfig; shape != nil {
+				if shape.Ocpus != nil {
+					attributes["ocpus"] = fmt.Sprintf("%g", *shape.Ocpus)
+				}
+				if shape.MemoryInGBs != nil {
+					attributes["memory_gb"] = fmt.Sprintf("%g", *shape.MemoryInGBs)
+				}
+			}
+
+			resources = append(resources, cloudy.Resource{
+				ID:         str(instance.Id),
+				Name:       str(instance.DisplayName),
+				Type:       "OCI Instance",
+				State:      string(instance.LifecycleState),
+				Region:     region,
+				Tags:       tags(instance.FreeformTags, instance.DefinedTags),
+				Attributes: attributes,
+			})
+		}
+
+		if resp.OpcNextPage == nil {
+			return resources, nil
+		}
+		req.Page = resp.OpcNextPage
+	}
+}
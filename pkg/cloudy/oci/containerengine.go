@@ -0,0 +1,52 @@
+package oci
+
+import (
+	"context"
+
+	"github.com/alwindoss/cloudy/pkg/cloudy"
+	"github.com/oracle/oci-go-sdk/v65/common"
+	"github.com/oracle/oci-go-sdk/v65/containerengine"
+)
+
+func listClusters(ctx context.Context, c *clients, compartment, region string) ([]cloudy.Resource, error) {
+	var resources []cloudy.Resource
+	req := containerengine.ListClustersRequest{CompartmentId: common.String(compartment)}
+	for {
+		if err := checkMaxResults(ctx, len(resources)); err != nil {
+			return resources, err
+		}
+		resp, err := c.containerEngine.ListClusters(ctx, req)
+		if err != nil {
+			return resources, apiError(err)
+		}
+
+		for _, cluster := range resp.Items {
+			attributes := baseAttributes(compartment)
+			attributes["kubernetes_version"] = str(cluster.KubernetesVersion)
+			attributes["cluster_type"] = string(cluster.Type)
+			attributes["vcn_id"] = str(cluster.VcnId)
+			if endpoints := cluster.Endpoints; endpoints != nil {
+				attributes["public_endpoint"] = str(endpoints.PublicEndpoint)
+				attributes["private_endpoint"] = str(endpoints.PrivateEndpoint)
+			}
+			if cluster.Metadata != nil {
+				attributes["created"] = timeString(cluster.Metadata.TimeCreated)
+			}
+
+			resources = append(resources, cloudy.Resource{
+				ID:         str(cluster.Id),
+				Name:       str(cluster.Name),
+				Type:       "OKE Cluster",
+				State:      string(cluster.LifecycleState),
+				Region:     region,
+				Tags:       tags(cluster.FreeformTags, cluster.DefinedTags),
+				Attributes: attributes,
+			})
+		}
+
+		if resp.OpcNextPage == nil {
+			return resources, nil
+		}
+		req.Page = resp.OpcNextPage
+	}
+}
@@ -0,0 +1,58 @@
+package oci
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/alwindoss/cloudy/pkg/cloudy"
+	"github.com/oracle/oci-go-sdk/v65/common"
+	"github.com/oracle/oci-go-sdk/v65/database"
+)
+
+func listAutonomousDatabases(ctx context.Context, c *clients, compartment, region string) ([]cloudy.Resource, error) {
+	var resources []cloudy.Resource
+	req := database.ListAutonomousDatabasesRequest{CompartmentId: common.String(compartment)}
+	for {
+		if err := checkMaxResults(ctx, len(resources)); err != nil {
+			return resources, err
+		}
+		resp, err := c.database.ListAutonomousDatabases(ctx, req)
+		if err != nil {
+			return resources, apiError(err)
+		}
+
+		for _, db := range resp.Items {
+			attributes := baseAttributes(compartment)
+			attributes["db_name"] = str(db.DbName)
+			attributes["workload"] = string(db.DbWorkload)
+			attributes["db_version"] = str(db.DbVersion)
+			attributes["license_model"] = string(db.LicenseModel)
+			attributes["created"] = timeString(db.TimeCreated)
+			if db.ComputeCount != nil {
+				attributes["compute_model"] = string(db.ComputeModel)
+				attributes["compute_count"] = fmt.Sprintf("%g", *db.ComputeCount)
+			}
+			if db.DataStorageSizeInTBs != nil {
+				attributes["storage_tb"] = fmt.Sprintf("%d", *db.DataStorageSizeInTBs)
+			}
+			if db.IsFreeTier != nil {
+				attributes["free_tier"] = fmt.Sprintf("%t", *db.IsFreeTier)
+			}
+
+			resources = append(resources, cloudy.Resource{
+				ID:         str(db.Id),
+				Name:       str(db.DisplayName),
+				Type:       "Autonomous Database",
+				State:      string(db.LifecycleState),
+				Region:     region,
+				Tags:       tags(db.FreeformTags, db.DefinedTags),
+				Attributes: attributes,
+			})
+		}
+
+		if resp.OpcNextPage == nil {
+			return resources, nil
+		}
+		req.Page = resp.OpcNextPage
+	}
+}
@@ -0,0 +1,55 @@
+package oci
+
+import (
+	"context"
+
+	"github.com/alwindoss/cloudy/pkg/cloudy"
+	"github.com/oracle/oci-go-sdk/v65/common"
+	"github.com/oracle/oci-go-sdk/v65/objectstorage"
+)
+
+func listBuckets(ctx context.Context, c *clients, compartment, region string) ([]cloudy.Resource, error) {
+	namespace, err := c.objectStorage.GetNamespace(ctx, objectstorage.GetNamespaceRequest{})
+	if err != nil {
+		return nil, apiError(err)
+	}
+
+	var resources []cloudy.Resource
+	req := objectstorage.ListBucketsRequest{
+		NamespaceName: namespace.Value,
+		CompartmentId: common.String(compartment),
+		Fields:        []objectstorage.ListBucketsFieldsEnum{objectstorage.ListBucketsFieldsTags},
+	}
+	for {
+		if err := checkMaxResults(ctx, len(resources)); err != nil {
+			return resources, err
+		}
+		resp, err := c.objectStorage.ListBuckets(ctx, req)
+		if err != nil {
+			return resources, apiError(err)
+		}
+
+		for _, bucket := range resp.Items {
+			attributes := baseAttributes(compartment)
+			attributes["namespace"] = str(bucket.Namespace)
+			attributes["created_by"] = str(bucket.CreatedBy)
+			attributes["created"] = timeString(bucket.TimeCreated)
+
+			resources = append(resources, cloudy.Resource{
+				// Bucket summaries have no OCID, so buckets are identified
+				// by their Object Storage path
+				ID:         "/n/" + str(bucket.Namespace) + "/b/" + str(bucket.Name),
+				Name:       str(bucket.Name),
+				Type:       "OCI Bucket",
+				Region:     region,
+				Tags:       tags(bucket.FreeformTags, bucket.DefinedTags),
+				Attributes: attributes,
+			})
+		}
+
+		if resp.OpcNextPage == nil {
+			return resources, nil
+		}
+		req.Page = resp.OpcNextPage
+	}
+}
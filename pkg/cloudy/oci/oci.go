@@ -0,0 +1,247 @@
+// Package oci is Cloudy's Oracle Cloud provider. It lists compute
+// instances, Object Storage buckets, Autonomous Databases and OKE clusters
+// in one or more compartments, region by region, as cloudy.Resources, so
+// a Scanner from cloudy.NewProviderScanner scans OCI the way it scans AWS.
+package oci
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/alwindoss/cloudy/pkg/cloudy"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/smithy-go"
+	"github.com/oracle/oci-go-sdk/v65/common"
+	"github.com/oracle/oci-go-sdk/v65/containerengine"
+	"github.com/oracle/oci-go-sdk/v65/core"
+	"github.com/oracle/oci-go-sdk/v65/database"
+	"github.com/oracle/oci-go-sdk/v65/identity"
+	"github.com/oracle/oci-go-sdk/v65/objectstorage"
+)
+
+// ProviderName names the OCI provider in requests and on its resources.
+const ProviderName = "oci"
+
+// Provider is an OCI tenancy, scanned in one or more of its compartments.
+type Provider struct {
+	config       common.ConfigurationProvider
+	compartments []string
+
+	mu      sync.Mutex
+	clients map[string]*clients
+}
+
+// clients are the service clients for one region.
+type clients struct {
+	compute         core.ComputeClient
+	objectStorage   objectstorage.ObjectStorageClient
+	database        database.DatabaseClient
+	containerEngine containerengine.ContainerEngineClient
+}
+
+// NewProvider returns the OCI provider for compartments, authenticating
+// with config, e.g. common.DefaultConfigProvider(). Without compartments
+// it scans the tenancy's root compartment. Compartments aren't scanned
+// recursively.
+func NewProvider(config common.ConfigurationProvider, compartments []string) (*Provider, error) {
+	if len(compartments) == 0 {
+		tenancy, err := config.TenancyOCID()
+		if err != nil {
+			return nil, err
+		}
+		compartments = []string{tenancy}
+	}
+	return &Provider{config: config, compartments: compartments}, nil
+}
+
+func (p *Provider) Name() string { return ProviderName }
+
+// Regions returns the regions, like us-ashburn-1, the tenancy is
+// subscribed to.
+func (p *Provider) Regions(ctx context.Context) ([]string, error) {
+	tenancy, err := p.config.TenancyOCID()
+	if err != nil {
+		return nil, err
+	}
+	client, err := identity.NewIdentityClientWithConfigurationProvider(p.config)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := client.ListRegionSubscriptions(ctx, identity.ListRegionSubscriptionsRequest{TenancyId: common.String(tenancy)})
+	if err != nil {
+		return nil, apiError(err)
+	}
+	var regions []string
+	for _, subscription := range resp.Items {
+		if subscription.Status == identity.RegionSubscriptionStatusReady {
+			regions = append(regions, str(subscription.RegionName))
+		}
+	}
+	sort.Strings(regions)
+	return regions, nil
+}
+
+// Listers returns a lister for each supported service.
+func (p *Provider) Listers() []cloudy.ServiceLister {
+	return []cloudy.ServiceLister{
+		lister{p: p, name: "OCI compute instances", types: []string{"OCI Instance"}, permissions: []string{"INSTANCE_INSPECT"}, list: listInstances},
+		lister{p: p, name: "OCI Object Storage buckets", types: []string{"OCI Bucket"}, permissions: []string{"OBJECTSTORAGE_NAMESPACE_READ", "BUCKET_INSPECT", "BUCKET_READ"}, list: listBuckets},
+		lister{p: p, name: "Autonomous Databases", types: []string{"Autonomous Database"}, permissions: []string{"AUTONOMOUS_DATABASE_INSPECT"}, list: listAutonomousDatabases},
+		lister{p: p, name: "OKE clusters", types: []string{"OKE Cluster"}, permissions: []string{"CLUSTER_INSPECT"}, list: listClusters},
+	}
+}
+
+// listFunc lists a service's resources in one compartment with a
+// region's clients.
+type listFunc func(ctx context.Context, c *clients, compartment, region string) ([]cloudy.Resource, error)
+
+// lister is a cloudy.ServiceLister for an OCI service. List is passed the
+// region to list as the config's region, and lists it in every
+// compartment.
+type lister struct {
+	p           *Provider
+	name        string
+	types       []string
+	permissions []string
+	list        listFunc
+}
+
+func (l lister) Name() string    { return l.name }
+func (l lister) Types() []string { return l.types }
+func (l lister) Global() bool    { return false }
+
+// IAMActions are the OCI IAM permissions List needs.
+func (l lister) IAMActions() []string { return l.permissions }
+
+func (l lister) List(ctx context.Context, cfg aws.Config, _ []string) ([]cloudy.Resource, error) {
+	c, err := l.p.regionClients(cfg.Region)
+	if err != nil {
+		return nil, err
+	}
+
+	var resources []cloudy.Resource
+	var errs []error
+	for _, compartment := range l.p.compartments {
+		listed, err := l.list(ctx, c, compartment, cfg.Region)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("compartment %s: %w", compartment, err))
+		}
+		resources = append(resources, listed...)
+	}
+	return resources, errors.Join(errs...)
+}
+
+// regionClients returns the service clients for region, building them the
+// first time region is listed.
+func (p *Provider) regionClients(region string) (*clients, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if c, ok := p.clients[region]; ok {
+		return c, nil
+	}
+
+	c := &clients{}
+	var err error
+	if c.compute, err = core.NewComputeClientWithConfigurationProvider(p.config); err != nil {
+		return nil, err
+	}
+	if c.objectStorage, err = objectstorage.NewObjectStorageClientWithConfigurationProvider(p.config); err != nil {
+		return nil, err
+	}
+	if c.database, err = database.NewDatabaseClientWithConfigurationProvider(p.config); err != nil {
+		return nil, err
+	}
+	if c.containerEngine, err = containerengine.NewContainerEngineClientWithConfigurationProvider(p.config); err != nil {
+		return nil, err
+	}
+	c.compute.SetRegion(region)
+	c.objectStorage.SetRegion(region)
+	c.database.SetRegion(region)
+	c.containerEngine.SetRegion(region)
+
+	if p.clients == nil {
+		p.clients = make(map[string]*clients)
+	}
+	p.clients[region] = c
+	return c, nil
+}
+
+// checkMaxResults fails once n items have been listed, at the cap of the
+// scan ctx belongs to.
+func checkMaxResults(ctx context.Context, n int) error {
+	if limit := cloudy.MaxResults(ctx); n >= limit {
+		return fmt.Errorf("%w: stopped after %d", cloudy.ErrMaxResults, limit)
+	}
+	return nil
+}
+
+// responseError gives an OCI error's code and status to cloudy's
+// ServiceErrors, which read them as they read an AWS error's.
+type responseError struct {
+	err     error
+	service common.ServiceError
+}
+
+// Error leaves out the SDK's troubleshooting tips, which run to several
+// lines.
+func (e responseError) Error() string {
+	return fmt.Sprintf("%s: %s (HTTP %d)", e.service.GetCode(), e.service.GetMessage(), e.service.GetHTTPStatusCode())
+}
+
+func (e responseError) ErrorCode() string    { return e.service.GetCode() }
+func (e responseError) ErrorMessage() string { return e.service.GetMessage() }
+func (e responseError) HTTPStatusCode() int  { return e.service.GetHTTPStatusCode() }
+func (e responseError) Unwrap() error        { return e.err }
+
+func (e responseError) ErrorFault() smithy.ErrorFault {
+	if e.service.GetHTTPStatusCode() >= 500 {
+		return smithy.FaultServer
+	}
+	return smithy.FaultClient
+}
+
+// apiError wraps err in a responseError if it is an OCI error response.
+func apiError(err error) error {
+	if service, ok := common.IsServiceError(err); ok {
+		return responseError{err: err, service: service}
+	}
+	return err
+}
+
+// baseAttributes returns the attributes every resource carries.
+func baseAttributes(compartment string) map[string]string {
+	return map[string]string{"compartment_id": compartment}
+}
+
+// tags merges a resource's free-form tags with its defined tags, which
+// are keyed namespace.key.
+func tags(freeform map[string]string, defined map[string]map[string]interface{}) map[string]string {
+	merged := make(map[string]string, len(freeform))
+	for key, value := range freeform {
+		merged[key] = value
+	}
+	for namespace, values := range defined {
+		for key, value := range values {
+			merged[namespace+"."+key] = fmt.Sprint(value)
+		}
+	}
+	return merged
+}
+
+func str(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
+
+func timeString(t *common.SDKTime) string {
+	if t == nil {
+		return ""
+	}
+	return t.String()
+}
@@ -0,0 +1,36 @@
+package openstack
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/alwindoss/cloudy/pkg/cloudy"
+	"github.com/gophercloud/gophercloud/v2/openstack"
+	"github.com/gophercloud/gophercloud/v2/openstack/objectstorage/v1/containers"
+)
+
+func listContainers(ctx context.Context, p *Provider, region string) ([]cloudy.Resource, error) {
+	client, err := p.serviceClient(region, openstack.NewObjectStorageV1)
+	if client == nil {
+		return nil, err
+	}
+	listed, err := listAll(ctx, containers.List(client, containers.ListOpts{Limit: pageSize}), containers.ExtractInfo)
+
+	var resources []cloudy.Resource
+	for _, container := range listed {
+		attributes := p.baseAttributes("")
+		attributes["objects"] = fmt.Sprintf("%d", container.Count)
+		attributes["size_bytes"] = fmt.Sprintf("%d", container.Bytes)
+
+		resources = append(resources, cloudy.Resource{
+			ID:         fmt.Sprintf("openstack:container:%s/%s", region, container.Name),
+			Name:       container.Name,
+			Type:       "Swift Container",
+			Region:     region,
+			Tags:       make(map[string]string),
+			Attributes: attributes,
+		})
+	}
+
+	return resources, err
+}
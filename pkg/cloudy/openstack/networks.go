@@ -0,0 +1,48 @@
+package openstack
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/alwindoss/cloudy/pkg/cloudy"
+	"github.com/gophercloud/gophercloud/v2/openstack"
+	"github.com/gophercloud/gophercloud/v2/openstack/networking/v2/networks"
+)
+
+func listNetworks(ctx context.Context, p *Provider, region string) ([]cloudy.Resource, error) {
+	client, err := p.serviceClient(region, openstack.NewNetworkV2)
+	if client == nil {
+		return nil, err
+	}
+	// Shared and external networks of other projects are listed too, with
+	// their own project_id
+	listed, err := listAll(ctx, networks.List(client, networks.ListOpts{Limit: pageSize}), networks.ExtractNetworks)
+
+	var resources []cloudy.Resource
+	for _, network := range listed {
+		attributes := p.baseAttributes(network.ProjectID)
+		attributes["shared"] = fmt.Sprintf("%t", network.Shared)
+		attributes["admin_state_up"] = fmt.Sprintf("%t", network.AdminStateUp)
+		attributes["subnets"] = fmt.Sprintf("%d", len(network.Subnets))
+		attributes["created"] = timeString(network.CreatedAt)
+
+		tags := make(map[string]string, len(network.Tags))
+		for _, tag := range network.Tags {
+			key, value, _ := strings.Cut(tag, "=")
+			tags[key] = value
+		}
+
+		resources = append(resources, cloudy.Resource{
+			ID:         "openstack:network:" + network.ID,
+			Name:       network.Name,
+			Type:       "Neutron Network",
+			State:      network.Status,
+			Region:     region,
+			Tags:       tags,
+			Attributes: attributes,
+		})
+	}
+
+	return resources, err
+}
@@ -0,0 +1,219 @@
+// Package openstack is Cloudy's OpenStack provider. It lists Nova
+// instances, Cinder volumes, Neutron networks and Swift containers in
+// one project, region by region, as cloudy.Resources, so a Scanner from
+// cloudy.NewProviderScanner scans a private cloud the way it scans AWS.
+package openstack
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/alwindoss/cloudy/pkg/cloudy"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/smithy-go"
+	"github.com/gophercloud/gophercloud/v2"
+	"github.com/gophercloud/gophercloud/v2/openstack"
+	"github.com/gophercloud/gophercloud/v2/openstack/identity/v3/regions"
+	"github.com/gophercloud/gophercloud/v2/openstack/identity/v3/tokens"
+	"github.com/gophercloud/gophercloud/v2/pagination"
+)
+
+// ProviderName names the OpenStack provider in requests and on its
+// resources.
+const ProviderName = "openstack"
+
+// pageSize is the most items asked for in a page.
+const pageSize = 1000
+
+// Provider is an OpenStack project.
+type Provider struct {
+	client  *gophercloud.ProviderClient
+	project string
+}
+
+// NewProvider returns the OpenStack provider for the project client is
+// authenticated to, e.g. from openstack.AuthenticatedClient with
+// openstack.AuthOptionsFromEnv().
+func NewProvider(client *gophercloud.ProviderClient) *Provider {
+	p := &Provider{client: client}
+	if result, ok := client.GetAuthResult().(tokens.CreateResult); ok {
+		if project, err := result.ExtractProject(); err == nil && project != nil {
+			p.project = project.ID
+		}
+	}
+	return p
+}
+
+func (p *Provider) Name() string { return ProviderName }
+
+// Regions returns the cloud's regions, like RegionOne, from Keystone.
+func (p *Provider) Regions(ctx context.Context) ([]string, error) {
+	identity, err := openstack.NewIdentityV3(p.client, gophercloud.EndpointOpts{})
+	if err != nil {
+		return nil, err
+	}
+	listed, err := listAll(ctx, regions.List(identity, nil), regions.ExtractRegions)
+	if err != nil {
+		return nil, err
+	}
+
+	names := make([]string, 0, len(listed))
+	for _, region := range listed {
+		names = append(names, region.ID)
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// Listers returns a lister for each supported service.
+func (p *Provider) Listers() []cloudy.ServiceLister {
+	return []cloudy.ServiceLister{
+		lister{p: p, name: "Nova", types: []string{"OpenStack Instance"}, policies: []string{"os_compute_api:servers:detail"}, list: listServers},
+		lister{p: p, name: "Cinder", types: []string{"Cinder Volume"}, policies: []string{"volume:get_all"}, list: listVolumes},
+		lister{p: p, name: "Neutron networks", types: []string{"Neutron Network"}, policies: []string{"get_network"}, list: listNetworks},
+		lister{p: p, name: "Swift", types: []string{"Swift Container"}, list: listContainers},
+	}
+}
+
+// listFunc lists a service's resources in one region.
+type listFunc func(ctx context.Context, p *Provider, region string) ([]cloudy.Resource, error)
+
+// lister is a cloudy.ServiceLister for an OpenStack service. List is
+// passed the region to list as the config's region.
+type lister struct {
+	p        *Provider
+	name     string
+	types    []string
+	policies []string
+	list     listFunc
+}
+
+func (l lister) Name() string    { return l.name }
+func (l lister) Types() []string { return l.types }
+func (l lister) Global() bool    { return false }
+
+// IAMActions returns the policy rules List is checked against. Swift has
+// none: a project member can list its containers.
+func (l lister) IAMActions() []string { return l.policies }
+
+func (l lister) List(ctx context.Context, cfg aws.Config, _ []string) ([]cloudy.Resource, error) {
+	return l.list(ctx, l.p, cfg.Region)
+}
+
+// serviceClient returns the client newClient builds for region, or nil if
+// the catalog has no endpoint for the service there, in which case the
+// region has nothing to list.
+func (p *Provider) serviceClient(region string, newClient func(*gophercloud.ProviderClient, gophercloud.EndpointOpts) (*gophercloud.ServiceClient, error)) (*gophercloud.ServiceClient, error) {
+	client, err := newClient(p.client, gophercloud.EndpointOpts{Region: region})
+	var notFound *gophercloud.ErrEndpointNotFound
+	if errors.As(err, &notFound) {
+		return nil, nil
+	}
+	return client, err
+}
+
+// listAll pages through pager to the end, up to the cap of the scan ctx
+// belongs to.
+func listAll[T any](ctx context.Context, pager pagination.Pager, extract func(pagination.Page) ([]T, error)) ([]T, error) {
+	var items []T
+	err := pager.EachPage(ctx, func(ctx context.Context, page pagination.Page) (bool, error) {
+		if err := checkMaxResults(ctx, len(items)); err != nil {
+			return false, err
+		}
+		extracted, err := extract(page)
+		if err != nil {
+			return false, err
+		}
+		items = append(items, extracted...)
+		return true, nil
+	})
+	return items, apiError(err)
+}
+
+// checkMaxResults fails once n items have been listed, at the cap of the
+// scan ctx belongs to.
+func checkMaxResults(ctx context.Context, n int) error {
+	if limit := cloudy.MaxResults(ctx); n >= limit {
+		return fmt.Errorf("%w: stopped after %d", cloudy.ErrMaxResults, limit)
+	}
+	return nil
+}
+
+// responseError gives an OpenStack error response's status to cloudy's
+// ServiceErrors, which read it as they read an AWS error's.
+type responseError struct {
+	err gophercloud.ErrUnexpectedResponseCode
+}
+
+// ErrorCode returns the error's HTTP status, like NotFound: the services
+// don't agree on a code of their own.
+func (e responseError) ErrorCode() string {
+	return strings.ReplaceAll(http.StatusText(e.err.Actual), " ", "")
+}
+
+// ErrorMessage returns the message in the error body, which every service
+// wraps in an object of its own, like {"itemNotFound": {"message": ...}}.
+func (e responseError) ErrorMessage() string {
+	var body map[string]struct {
+		Message string `json:"message"`
+	}
+	if json.Unmarshal(e.err.Body, &body) == nil {
+		for _, fault := range body {
+			if fault.Message != "" {
+				return fault.Message
+			}
+		}
+	}
+	return strings.TrimSpace(string(e.err.Body))
+}
+
+func (e responseError) Error() string       { return e.err.Error() }
+func (e responseError) HTTPStatusCode() int { return e.err.Actual }
+func (e responseError) Unwrap() error       { return e.err }
+
+func (e responseError) ErrorFault() smithy.ErrorFault {
+	if e.err.Actual >= 500 {
+		return smithy.FaultServer
+	}
+	return smithy.FaultClient
+}
+
+// apiError wraps err in a responseError if it is an OpenStack error
+// response.
+func apiError(err error) error {
+	var apiErr gophercloud.ErrUnexpectedResponseCode
+	if errors.As(err, &apiErr) {
+		return responseError{apiErr}
+	}
+	return err
+}
+
+// baseAttributes returns the attributes every resource carries: the
+// project it belongs to, or else the provider's.
+func (p *Provider) baseAttributes(project string) map[string]string {
+	if project == "" {
+		project = p.project
+	}
+	return map[string]string{"project_id": project}
+}
+
+// metadata returns a resource's metadata as tags.
+func metadata(metadata map[string]string) map[string]string {
+	if metadata == nil {
+		return make(map[string]string)
+	}
+	return metadata
+}
+
+func timeString(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.String()
+}
@@ -0,0 +1,70 @@
+package openstack
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/alwindoss/cloudy/pkg/cloudy"
+	"github.com/gophercloud/gophercloud/v2/openstack"
+	"github.com/gophercloud/gophercloud/v2/openstack/compute/v2/servers"
+)
+
+func listServers(ctx context.Context, p *Provider, region string) ([]cloudy.Resource, error) {
+	client, err := p.serviceClient(region, openstack.NewComputeV2)
+	if client == nil {
+		return nil, err
+	}
+	listed, err := listAll(ctx, servers.List(client, servers.ListOpts{Limit: pageSize}), servers.ExtractServers)
+
+	var resources []cloudy.Resource
+	for _, server := range listed {
+		attributes := p.baseAttributes(server.TenantID)
+		attributes["availability_zone"] = server.AvailabilityZone
+		attributes["key_name"] = server.KeyName
+		attributes["created"] = timeString(server.Created)
+		// Newer compute APIs only name the flavor
+		if flavor, ok := server.Flavor["original_name"]; ok {
+			attributes["flavor"] = fmt.Sprint(flavor)
+		} else if flavor, ok := server.Flavor["id"]; ok {
+			attributes["flavor"] = fmt.Sprint(flavor)
+		}
+		if image, ok := server.Image["id"]; ok {
+			attributes["image_id"] = fmt.Sprint(image)
+		}
+		if ips := addresses(server.Addresses); len(ips) > 0 {
+			attributes["addresses"] = strings.Join(ips, ",")
+		}
+
+		resources = append(resources, cloudy.Resource{
+			ID:         "openstack:server:" + server.ID,
+			Name:       server.Name,
+			Type:       "OpenStack Instance",
+			State:      server.Status,
+			Region:     region,
+			Tags:       metadata(server.Metadata),
+			Attributes: attributes,
+		})
+	}
+
+	return resources, err
+}
+
+// addresses returns the IPs of a server's addresses, which Nova gives by
+// network.
+func addresses(byNetwork map[string]any) []string {
+	var ips []string
+	for _, list := range byNetwork {
+		entries, _ := list.([]any)
+		for _, entry := range entries {
+			if address, ok := entry.(map[string]any); ok {
+				if ip, ok := address["addr"].(string); ok {
+					ips = append(ips, ip)
+				}
+			}
+		}
+	}
+	sort.Strings(ips)
+	return ips
+}
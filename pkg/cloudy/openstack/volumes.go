@@ -0,0 +1,49 @@
+package openstack
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/alwindoss/cloudy/pkg/cloudy"
+	"github.com/gophercloud/gophercloud/v2/openstack"
+	"github.com/gophercloud/gophercloud/v2/openstack/blockstorage/v3/volumes"
+)
+
+func listVolumes(ctx context.Context, p *Provider, region string) ([]cloudy.Resource, error) {
+	client, err := p.serviceClient(region, openstack.NewBlockStorageV3)
+	if client == nil {
+		return nil, err
+	}
+	listed, err := listAll(ctx, volumes.List(client, volumes.ListOpts{Limit: pageSize}), volumes.ExtractVolumes)
+
+	var resources []cloudy.Resource
+	for _, volume := range listed {
+		attributes := p.baseAttributes(volume.TenantID)
+		attributes["size_gb"] = fmt.Sprintf("%d", volume.Size)
+		attributes["volume_type"] = volume.VolumeType
+		attributes["availability_zone"] = volume.AvailabilityZone
+		attributes["bootable"] = volume.Bootable
+		attributes["encrypted"] = fmt.Sprintf("%t", volume.Encrypted)
+		attributes["created"] = timeString(volume.CreatedAt)
+		var servers []string
+		for _, attachment := range volume.Attachments {
+			servers = append(servers, attachment.ServerID)
+		}
+		if len(servers) > 0 {
+			attributes["attached_to"] = strings.Join(servers, ",")
+		}
+
+		resources = append(resources, cloudy.Resource{
+			ID:         "openstack:volume:" + volume.ID,
+			Name:       volume.Name,
+			Type:       "Cinder Volume",
+			State:      volume.Status,
+			Region:     region,
+			Tags:       metadata(volume.Metadata),
+			Attributes: attributes,
+		})
+	}
+
+	return resources, err
+}
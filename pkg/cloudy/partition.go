@@ -0,0 +1,71 @@
+package cloudy
+
+import (
+	"slices"
+	"strings"
+)
+
+// The AWS partitions cloudy scans. Each has its own regions, endpoints,
+// credentials and ARN prefix, e.g. arn:aws-us-gov:ec2:us-gov-west-1:...
+const (
+	PartitionAWS      = "aws"
+	PartitionGovCloud = "aws-us-gov"
+	PartitionChina    = "aws-cn"
+)
+
+// globalRegions are the regions each partition's global services are
+// listed in.
+var globalRegions = map[string]string{
+	PartitionAWS:      globalRegion,
+	PartitionGovCloud: "us-gov-west-1",
+	PartitionChina:    "cn-north-1",
+}
+
+// Partition returns the partition region is in, e.g. aws-us-gov for
+// us-gov-west-1.
+func Partition(region string) string {
+	switch {
+	case strings.HasPrefix(region, "us-gov-"):
+		return PartitionGovCloud
+	case strings.HasPrefix(region, "cn-"):
+		return PartitionChina
+	default:
+		return PartitionAWS
+	}
+}
+
+// GlobalRegion returns the region partition's global services, like IAM
+// and S3, are listed in.
+func GlobalRegion(partition string) string {
+	if region, ok := globalRegions[partition]; ok {
+		return region
+	}
+	return globalRegion
+}
+
+// isGlobalRegion reports whether region is where its partition's global
+// services are listed.
+func isGlobalRegion(region string) bool {
+	return region == GlobalRegion(Partition(region))
+}
+
+// A PartitionLister is a ServiceLister for a service that only some
+// partitions have. It is skipped in the regions of the others.
+type PartitionLister interface {
+	ServiceLister
+	// Partitions are the partitions the service is available in.
+	Partitions() []string
+}
+
+// runsIn reports whether lister should run in region: global listers only
+// run in their partition's global region, and partition listers only in
+// their partitions.
+func runsIn(lister ServiceLister, region string) bool {
+	if lister.Global() && !isGlobalRegion(region) {
+		return false
+	}
+	if pl, ok := lister.(PartitionLister); ok && pl.Partitions() != nil {
+		return slices.Contains(pl.Partitions(), Partition(region))
+	}
+	return true
+}
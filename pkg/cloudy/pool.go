@@ -0,0 +1,117 @@
+package cloudy
+
+import (
+	"context"
+	"math"
+	"sync"
+
+	"golang.org/x/time/rate"
+)
+
+// Concurrency bounds how many listers run at once, and how fast they call
+// AWS. A zero limit is unbounded.
+type Concurrency struct {
+	// Total bounds listers running across every account, region and scan
+	// sharing the pool.
+	Total int
+	// PerAccount bounds listers running for any one account, so scans of
+	// one large account leave slots for the others sharing the pool.
+	PerAccount int
+	// PerRegion bounds listers running in any one region of an account.
+	PerRegion int
+	// PerService bounds the regions of an account any one lister runs in
+	// at once.
+	PerService int
+	// AccountRate bounds the API calls per second made for any one
+	// account, retries included.
+	AccountRate float64
+}
+
+// DefaultConcurrency is used by Scanners not given a WorkerPool.
+var DefaultConcurrency = Concurrency{Total: 32, PerRegion: 8, PerService: 4}
+
+// WorkerPool runs listers within its Concurrency limits. Scanners sharing a
+// pool share its limits, so concurrent scans can't add up to more AWS calls
+// than it allows.
+type WorkerPool struct {
+	limits Concurrency
+	total  chan struct{}
+
+	mu       sync.Mutex
+	accounts map[string]*accountSlots
+}
+
+// accountSlots are one account's share of a WorkerPool.
+type accountSlots struct {
+	slots    chan struct{}
+	regions  map[string]chan struct{}
+	services map[string]chan struct{}
+	limiter  *rate.Limiter
+}
+
+// NewWorkerPool returns a pool enforcing limits.
+func NewWorkerPool(limits Concurrency) *WorkerPool {
+	return &WorkerPool{
+		limits:   limits,
+		total:    newSlots(limits.Total),
+		accounts: make(map[string]*accountSlots),
+	}
+}
+
+// run calls fn once slots for service and region in account, for account
+// and for the pool as a whole are free, or returns ctx's error if the scan
+// is cancelled first. Slots are always taken in that order, so runs never
+// deadlock waiting on each other. The AWS calls fn makes with the context
+// it is passed keep to account's rate.
+func (p *WorkerPool) run(ctx context.Context, account, region, service string, fn func(ctx context.Context)) error {
+	p.mu.Lock()
+	a, ok := p.accounts[account]
+	if !ok {
+		a = &accountSlots{
+			slots:    newSlots(p.limits.PerAccount),
+			regions:  make(map[string]chan struct{}),
+			services: make(map[string]chan struct{}),
+		}
+		if p.limits.AccountRate > 0 {
+			a.limiter = rate.NewLimiter(rate.Limit(p.limits.AccountRate), int(math.Max(1, math.Ceil(p.limits.AccountRate))))
+		}
+		p.accounts[account] = a
+	}
+	serviceSlots, ok := a.services[service]
+	if !ok {
+		serviceSlots = newSlots(p.limits.PerService)
+		a.services[service] = serviceSlots
+	}
+	regionSlots, ok := a.regions[region]
+	if !ok {
+		regionSlots = newSlots(p.limits.PerRegion)
+		a.regions[region] = regionSlots
+	}
+	p.mu.Unlock()
+
+	for _, slots := range []chan struct{}{serviceSlots, regionSlots, a.slots, p.total} {
+		if slots == nil {
+			continue
+		}
+		select {
+		case slots <- struct{}{}:
+			defer func() { <-slots }()
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+
+	if a.limiter != nil {
+		ctx = withRateLimiter(ctx, a.limiter)
+	}
+	fn(ctx)
+	return nil
+}
+
+// newSlots returns a semaphore with n slots, or nil for no limit.
+func newSlots(n int) chan struct{} {
+	if n <= 0 {
+		return nil
+	}
+	return make(chan struct{}, n)
+}
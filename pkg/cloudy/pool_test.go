@@ -0,0 +1,258 @@
+package cloudy
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+// poolRun is one lister run on a WorkerPool.
+type poolRun struct {
+	account, region, service string
+}
+
+// poolRuns are a run of every service in every region of two accounts.
+func poolRuns() []poolRun {
+	var runs []poolRun
+	for _, account := range []string{"111111111111", "222222222222"} {
+		for _, region := range []string{"us-east-1", "eu-west-1", "ap-south-1"} {
+			for _, service := range []string{"ec2", "s3", "rds"} {
+				runs = append(runs, poolRun{account, region, service})
+			}
+		}
+	}
+	return runs
+}
+
+// inFlight counts the runs in flight per group and the most ever seen at
+// once in any one group.
+type inFlight struct {
+	mu      sync.Mutex
+	running map[string]int
+	peak    int
+	total   int
+}
+
+func (f *inFlight) enter(group string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.running[group]++
+	f.total++
+	f.peak = max(f.peak, f.running[group])
+}
+
+func (f *inFlight) leave(group string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.running[group]--
+	f.total--
+}
+
+func (f *inFlight) current() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.total
+}
+
+func TestWorkerPoolLimits(t *testing.T) {
+	tests := []struct {
+		name   string
+		limits Concurrency
+		group  func(poolRun) string
+		// limit is the most runs of a group allowed at once, and running
+		// how many are in flight once the pool is saturated
+		limit, running int
+	}{
+		{
+			name:    "total",
+			limits:  Concurrency{Total: 4},
+			group:   func(poolRun) string { return "" },
+			limit:   4,
+			running: 4,
+		},
+		{
+			name:    "per account",
+			limits:  Concurrency{PerAccount: 2},
+			group:   func(r poolRun) string { return r.account },
+			limit:   2,
+			running: 4,
+		},
+		{
+			name:    "per region",
+			limits:  Concurrency{PerRegion: 1},
+			group:   func(r poolRun) string { return r.account + "/" + r.region },
+			limit:   1,
+			running: 6,
+		},
+		{
+			name:    "per service",
+			limits:  Concurrency{PerService: 2},
+			group:   func(r poolRun) string { return r.account + "/" + r.service },
+			limit:   2,
+			running: 12,
+		},
+		{
+			name:    "per account within the total",
+			limits:  Concurrency{Total: 3, PerAccount: 2},
+			group:   func(r poolRun) string { return r.account },
+			limit:   2,
+			running: 3,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := NewWorkerPool(tt.limits)
+			flight := &inFlight{running: make(map[string]int)}
+			release := make(chan struct{})
+
+			var wg sync.WaitGroup
+			for _, r := range poolRuns() {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					err := p.run(context.Background(), r.account, r.region, r.service, func(context.Context) {
+						flight.enter(tt.group(r))
+						defer flight.leave(tt.group(r))
+						<-release
+					})
+					if err != nil {
+						t.Errorf("run: %v", err)
+					}
+				}()
+			}
+
+			deadline := time.Now().Add(5 * time.Second)
+			for flight.current() < tt.running && time.Now().Before(deadline) {
+				time.Sleep(time.Millisecond)
+			}
+			// Give runs over the limit a chance to start if the pool let them
+			time.Sleep(20 * time.Millisecond)
+			if n := flight.current(); n != tt.running {
+				t.Errorf("%d runs in flight, want %d", n, tt.running)
+			}
+			close(release)
+			wg.Wait()
+
+			if flight.peak != tt.limit {
+				t.Errorf("peak runs in one group = %d, want %d", flight.peak, tt.limit)
+			}
+		})
+	}
+}
+
+func TestWorkerPoolAccountRate(t *testing.T) {
+	limiterOf := func(p *WorkerPool, account string) *rate.Limiter {
+		var limiter *rate.Limiter
+		p.run(context.Background(), account, "us-east-1", "ec2", func(ctx context.Context) {
+			limiter, _ = ctx.Value(rateLimiterKey{}).(*rate.Limiter)
+		})
+		return limiter
+	}
+
+	if limiter := limiterOf(NewWorkerPool(Concurrency{}), "111111111111"); limiter != nil {
+		t.Error("runs are rate limited without an AccountRate")
+	}
+
+	p := NewWorkerPool(Concurrency{AccountRate: 2.5})
+	first := limiterOf(p, "111111111111")
+	if first == nil {
+		t.Fatal("runs aren't rate limited with an AccountRate")
+	}
+	if first.Limit() != 2.5 || first.Burst() != 3 {
+		t.Errorf("limiter = %v/s with a burst of %d, want 2.5/s with a burst of 3", first.Limit(), first.Burst())
+	}
+	if again := limiterOf(p, "111111111111"); again != first {
+		t.Error("runs of one account don't share its rate")
+	}
+	if other := limiterOf(p, "222222222222"); other == first {
+		t.Error("runs of two accounts share one rate")
+	}
+}
+
+func TestWorkerPoolCancelledRunReleasesSlots(t *testing.T) {
+	p := NewWorkerPool(Concurrency{Total: 1, PerAccount: 2, PerRegion: 2, PerService: 2})
+
+	// Hold the pool's only slot
+	held := make(chan struct{})
+	release := make(chan struct{})
+	done := make(chan error, 1)
+	go func() {
+		done <- p.run(context.Background(), "111111111111", "us-east-1", "ec2", func(context.Context) {
+			close(held)
+			<-release
+		})
+	}()
+	<-held
+
+	// A run waiting for it takes its service, region and account slots,
+	// then gives up
+	ctx, cancel := context.WithCancel(context.Background())
+	waiting := make(chan error, 1)
+	go func() {
+		waiting <- p.run(ctx, "111111111111", "us-east-1", "s3", func(context.Context) {
+			t.Error("a cancelled run ran")
+		})
+	}()
+	a := poolAccount(t, p, "111111111111")
+	waitFor(t, "the waiting run to take its account slot", func() bool { return len(a.slots) == 2 })
+	cancel()
+	if err := <-waiting; !errors.Is(err, context.Canceled) {
+		t.Errorf("cancelled run = %v, want context.Canceled", err)
+	}
+
+	for name, slots := range map[string]chan struct{}{
+		"service": a.services["s3"],
+		"region":  a.regions["us-east-1"],
+		"account": a.slots,
+	} {
+		want := 1
+		if name == "service" {
+			want = 0
+		}
+		if len(slots) != want {
+			t.Errorf("%s slots taken after cancelling = %d, want %d", name, len(slots), want)
+		}
+	}
+
+	close(release)
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+	ran := false
+	if err := p.run(context.Background(), "111111111111", "us-east-1", "s3", func(context.Context) { ran = true }); err != nil || !ran {
+		t.Errorf("run after cancelling = %v, ran %v; want it to run", err, ran)
+	}
+	if len(p.total) != 0 || len(a.slots) != 0 {
+		t.Errorf("slots still taken once every run is done: %d total, %d account", len(p.total), len(a.slots))
+	}
+}
+
+// poolAccount returns account's slots in p once a run has created them.
+func poolAccount(t *testing.T, p *WorkerPool, account string) *accountSlots {
+	t.Helper()
+	var a *accountSlots
+	waitFor(t, fmt.Sprintf("slots for %s", account), func() bool {
+		p.mu.Lock()
+		defer p.mu.Unlock()
+		a = p.accounts[account]
+		return a != nil
+	})
+	return a
+}
+
+// waitFor polls cond until it holds, failing the test after five seconds.
+func waitFor(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %s", what)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
@@ -0,0 +1,114 @@
+package cloudy
+
+import (
+	"context"
+	"sort"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/aws/arn"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+)
+
+// ProviderAWS names the AWS provider, the one a Scanner built from an AWS
+// config scans.
+const ProviderAWS = "aws"
+
+// A Provider is a cloud a Scanner lists resources from: its regions and
+// the listers for its services. AWS is built in; see NewProviderScanner
+// for the others.
+type Provider interface {
+	// Name identifies the provider in requests and on its resources,
+	// e.g. "aws".
+	Name() string
+	// Regions returns the regions the account can scan.
+	Regions(ctx context.Context) ([]string, error)
+	// Listers returns the listers for the provider's services. The
+	// Scanner's own listers, from AddListers, run alongside them.
+	Listers() []ServiceLister
+}
+
+// awsProvider is AWS, listed with the registered listers.
+type awsProvider struct {
+	clients *ClientFactory
+}
+
+func (p awsProvider) Name() string             { return ProviderAWS }
+func (p awsProvider) Listers() []ServiceLister { return Listers() }
+
+// Regions returns the regions enabled for the account, in the partition
+// of the config's region: those that need no opt-in plus those it has
+// opted in to.
+func (p awsProvider) Regions(ctx context.Context) ([]string, error) {
+	region := p.clients.base.Region
+	if region == "" {
+		region = globalRegion
+	}
+
+	// Sent to the endpoint listers use, so a LocalStack or VPC endpoint
+	// is asked too. Without AllRegions, DescribeRegions only returns
+	// enabled regions
+	ctx = withClients(ctx, p.clients)
+	result, err := Client(ctx, p.clients.Config(region), ec2.NewFromConfig).DescribeRegions(ctx, &ec2.DescribeRegionsInput{})
+	if err != nil {
+		return nil, err
+	}
+
+	regions := make([]string, 0, len(result.Regions))
+	for _, region := range result.Regions {
+		regions = append(regions, aws.ToString(region.RegionName))
+	}
+	sort.Strings(regions)
+	return regions, nil
+}
+
+// NewProviderScanner returns a Scanner for a provider other than AWS. Its
+// listers are passed a config that only carries the region to list, and
+// bring their own credentials. Fast, explorer and incremental scans, and
+// the result cache, are only available for AWS.
+func NewProviderScanner(provider Provider) *Scanner {
+	s := NewScannerFromConfig(aws.Config{})
+	s.provider = provider
+	return s
+}
+
+// Provider returns the provider the Scanner lists.
+func (s *Scanner) Provider() Provider {
+	return s.provider
+}
+
+// isAWS reports whether the Scanner lists AWS, the only provider with
+// partitions, indexes, CloudTrail and an account to cache by.
+func (s *Scanner) isAWS() bool {
+	return s.provider.Name() == ProviderAWS
+}
+
+// label records the provider, and for AWS the partition, of resources
+// listed in region, and the kind and account of those whose lister left
+// them out. A resource's account is the one in its ARN, or the Scanner's
+// for resources without one, such as S3 buckets; see Scanner.Account and
+// AccountNames.
+func (s *Scanner) label(ctx context.Context, resources []Resource, region string) {
+	name := s.provider.Name()
+	partition := ""
+	var account Account
+	if s.isAWS() {
+		partition = Partition(region)
+		account = s.Account(ctx)
+	}
+	for i := range resources {
+		resources[i].Provider = name
+		resources[i].Partition = partition
+		if resources[i].Kind == "" {
+			resources[i].Kind = KindOf(resources[i].Type)
+		}
+		if resources[i].AccountID == "" {
+			resources[i].AccountID = account.ID
+			if parsed, err := arn.Parse(resources[i].ID); err == nil && parsed.AccountID != "" {
+				resources[i].AccountID = parsed.AccountID
+			}
+			if resources[i].AccountID != "" && s.isAWS() {
+				resources[i].AccountName = s.accountName(ctx, resources[i].AccountID)
+			}
+		}
+	}
+}
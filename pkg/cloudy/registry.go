@@ -0,0 +1,73 @@
+package cloudy
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+)
+
+// globalRegion is the only region of the aws partition global services
+// are listed in, so a multi-region scan doesn't return them once per
+// region. See GlobalRegion for the other partitions.
+const globalRegion = "us-east-1"
+
+var registry struct {
+	mu      sync.RWMutex
+	listers []ServiceLister
+}
+
+// Register adds a lister to every Scanner. The built-in listers register
+// themselves from init functions; other packages can do the same.
+// Register panics if a lister with the same name is already registered.
+func Register(l ServiceLister) {
+	registry.mu.Lock()
+	defer registry.mu.Unlock()
+
+	for _, registered := range registry.listers {
+		if registered.Name() == l.Name() {
+			panic(fmt.Sprintf("cloudy: lister %q registered twice", l.Name()))
+		}
+	}
+	registry.listers = append(registry.listers, l)
+}
+
+// Listers returns the registered listers in registration order.
+func Listers() []ServiceLister {
+	registry.mu.RLock()
+	defer registry.mu.RUnlock()
+	return append([]ServiceLister(nil), registry.listers...)
+}
+
+type listFunc func(ctx context.Context, cfg aws.Config, states []string) ([]Resource, error)
+
+// lister is a ServiceLister made of a list function and its metadata,
+// which is how the built-in listers register.
+type lister struct {
+	name       string
+	types      []string
+	global     bool
+	iamActions []string
+	// partitions limits the lister to those partitions; nil means all.
+	partitions []string
+	list       listFunc
+}
+
+func (l lister) Name() string         { return l.name }
+func (l lister) Types() []string      { return l.types }
+func (l lister) Global() bool         { return l.global }
+func (l lister) IAMActions() []string { return l.iamActions }
+func (l lister) Partitions() []string { return l.partitions }
+
+func (l lister) List(ctx context.Context, cfg aws.Config, states []string) ([]Resource, error) {
+	return l.list(ctx, cfg, states)
+}
+
+// withoutStates adapts a list function that can't filter by state; the
+// Scanner's callers filter its results instead.
+func withoutStates(list func(ctx context.Context, cfg aws.Config) ([]Resource, error)) listFunc {
+	return func(ctx context.Context, cfg aws.Config, _ []string) ([]Resource, error) {
+		return list(ctx, cfg)
+	}
+}
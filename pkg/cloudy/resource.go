@@ -0,0 +1,43 @@
+// Package cloudy is Cloudy's inventory engine. It lists cloud resources,
+// AWS's built in, region by region and normalizes them into Resources;
+// cmd/cloudy serves it over HTTP and gRPC, and other programs can embed it
+// directly.
+package cloudy
+
+// Resource is one inventoried resource. ID is the resource's ARN where it
+// has one, or its service-specific ID otherwise. Type is the provider's
+// name for what it is, e.g. EC2 Instance, and Kind the same across
+// providers, e.g. compute.instance; see KindOf. Provider is the cloud it
+// was listed from, e.g. aws, and Partition the AWS partition of its
+// region, e.g. aws-us-gov. AccountID is the account it belongs
+// to, for AWS the one in its ARN or else the Scanner's, and AccountName
+// that account's alias; see Scanner.Account.
+type Resource struct {
+	ID          string            `json:"id" yaml:"id"`
+	Name        string            `json:"name" yaml:"name"`
+	Type        string            `json:"type" yaml:"type"`
+	Kind        string            `json:"kind,omitempty" yaml:"kind,omitempty"`
+	State       string            `json:"state,omitempty" yaml:"state,omitempty"`
+	Region      string            `json:"region" yaml:"region"`
+	Provider    string            `json:"provider,omitempty" yaml:"provider,omitempty"`
+	Partition   string            `json:"partition,omitempty" yaml:"partition,omitempty"`
+	AccountID   string            `json:"account_id,omitempty" yaml:"account_id,omitempty"`
+	AccountName string            `json:"account_name,omitempty" yaml:"account_name,omitempty"`
+	Tags        map[string]string `json:"tags,omitempty" yaml:"tags,omitempty"`
+	Attributes  map[string]string `json:"attributes,omitempty" yaml:"attributes,omitempty"`
+}
+
+// RegionResources is the result of scanning one region. A region where
+// some services failed keeps whatever was listed along with Errors, one
+// per failed service, and Error, which sums them up in one line.
+// Throttles counts the AWS calls that were throttled and retried, a sign
+// the scan was slowed down by API rate limits. CostError is set when the
+// costs of some of the region's resources couldn't be estimated.
+type RegionResources struct {
+	Region    string         `json:"region" yaml:"region"`
+	Resources []Resource     `json:"resources" yaml:"resources"`
+	Error     string         `json:"error,omitempty" yaml:"error,omitempty"`
+	Errors    []ServiceError `json:"errors,omitempty" yaml:"errors,omitempty"`
+	Throttles int            `json:"throttles,omitempty" yaml:"throttles,omitempty"`
+	CostError string         `json:"cost_error,omitempty" yaml:"cost_error,omitempty"`
+}
@@ -0,0 +1,406 @@
+package cloudy
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/config"
+)
+
+// ServiceLister lists one service's resources in a region. Adding a
+// service takes a ServiceLister and a call to Register (or to a Scanner's
+// AddListers); the Scanner runs every lister it has for each region.
+type ServiceLister interface {
+	// Name identifies the lister in errors, e.g. "EC2 instances".
+	Name() string
+	// Types are the resource types the lister produces; it only runs
+	// when a scan asks for one of them, or for every type.
+	Types() []string
+	// Global reports whether the service's resources aren't regional.
+	// Global listers only run when their partition's global region,
+	// us-east-1 for the aws partition, is scanned; see GlobalRegion.
+	Global() bool
+	// IAMActions are the actions List needs to be allowed.
+	IAMActions() []string
+	// List returns the service's resources in cfg.Region. Listers that
+	// can't filter by state server-side may ignore states.
+	List(ctx context.Context, cfg aws.Config, states []string) ([]Resource, error)
+}
+
+// Scanner lists a Provider's resources across regions: AWS's, unless it
+// was created with NewProviderScanner.
+type Scanner struct {
+	cfg        aws.Config
+	provider   Provider
+	clients    *ClientFactory
+	listers    []ServiceLister
+	maxResults int
+	pool       *WorkerPool
+	timeouts   Timeouts
+	cache      *ResultCache
+
+	explorerRegion string
+	explorerView   string
+
+	accountOnce  sync.Once
+	accountID    string
+	accountNames *AccountNames
+}
+
+// credentialsExpiryWindow is how long before they expire temporary
+// credentials, like those of an IAM Identity Center (SSO) role, are
+// refreshed, so a scan's calls aren't made with credentials about to
+// expire.
+const credentialsExpiryWindow = 5 * time.Minute
+
+// LoadConfig loads the SDK's default configuration and credential chain,
+// as NewScanner does, refreshing temporary credentials ahead of expiry.
+// optFns are passed on to config.LoadDefaultConfig.
+func LoadConfig(ctx context.Context, optFns ...func(*config.LoadOptions) error) (aws.Config, error) {
+	optFns = append([]func(*config.LoadOptions) error{
+		config.WithCredentialsCacheOptions(func(o *aws.CredentialsCacheOptions) {
+			o.ExpiryWindow = credentialsExpiryWindow
+		}),
+	}, optFns...)
+	return config.LoadDefaultConfig(ctx, optFns...)
+}
+
+// NewScanner returns a Scanner using the SDK's default configuration and
+// credential chain; see LoadConfig. Profiles that sign in with IAM
+// Identity Center are supported, and their tokens refreshed, as by the
+// AWS CLI.
+func NewScanner(ctx context.Context) (*Scanner, error) {
+	cfg, err := LoadConfig(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("unable to load SDK config: %w", err)
+	}
+
+	return NewScannerFromConfig(cfg), nil
+}
+
+// NewScannerFromConfig returns a Scanner using cfg.
+func NewScannerFromConfig(cfg aws.Config) *Scanner {
+	clients := NewClientFactory(cfg, nil)
+	s := &Scanner{
+		cfg:      cfg,
+		provider: awsProvider{clients: clients},
+		clients:  clients,
+		pool:     NewWorkerPool(DefaultConcurrency),
+
+		accountNames: NewAccountNames(DefaultAccountNameTTL),
+	}
+	s.SetTimeouts(DefaultTimeouts)
+	return s
+}
+
+// Config returns the AWS configuration the Scanner was created with.
+func (s *Scanner) Config() aws.Config {
+	return s.cfg
+}
+
+// RegionConfig returns the config listers use in region: the Scanner's
+// config with the region, and endpoint if one is set, replaced.
+func (s *Scanner) RegionConfig(region string) aws.Config {
+	return s.clients.Config(region)
+}
+
+// SetEndpointResolver sends requests to the endpoints resolve returns. It
+// must be called before the Scanner is used.
+func (s *Scanner) SetEndpointResolver(resolve EndpointResolver) {
+	s.clients.resolveEndpoint = resolve
+}
+
+// SetServiceEndpoints sends each service's requests to its endpoint in
+// endpoints, in every region, rather than where the EndpointResolver or
+// config would. Services are keyed by their package name in the AWS SDK
+// for Go, like s3 or dynamodb. It must be called before the Scanner is
+// used.
+func (s *Scanner) SetServiceEndpoints(endpoints map[string]string) {
+	s.clients.serviceEndpoints = endpoints
+}
+
+// SetTimeouts replaces DefaultTimeouts. It must be called before the
+// Scanner is used.
+func (s *Scanner) SetTimeouts(timeouts Timeouts) {
+	s.timeouts = timeouts
+	s.clients.SetCallTimeout(timeouts.Call)
+}
+
+// SetMaxResults caps how many items each lister pages through in a
+// region; see DefaultMaxResults. It must be called before the Scanner is
+// used.
+func (s *Scanner) SetMaxResults(n int) {
+	s.maxResults = n
+}
+
+// SetCache serves listers' results from cache while they are fresh, and
+// stores new ones in it. It must be called before the Scanner is used.
+func (s *Scanner) SetCache(cache *ResultCache) {
+	s.cache = cache
+}
+
+// SetWorkerPool runs the Scanner's listers in pool, which may be shared
+// with other Scanners. It must be called before the Scanner is used.
+func (s *Scanner) SetWorkerPool(pool *WorkerPool) {
+	s.pool = pool
+}
+
+// AddListers adds listers to this Scanner only, on top of the registered
+// ones. It must be called before the Scanner is used.
+func (s *Scanner) AddListers(listers ...ServiceLister) {
+	s.listers = append(s.listers, listers...)
+}
+
+// Scan lists every region concurrently. Regions that failed keep their
+// partial results along with the error.
+func (s *Scanner) Scan(ctx context.Context, regions, resourceTypes, states []string) []RegionResources {
+	var regionData []RegionResources
+	for rd := range s.StreamRegions(ctx, regions, resourceTypes, states) {
+		regionData = append(regionData, rd)
+	}
+	return regionData
+}
+
+// StreamRegions lists every region concurrently and sends each region's
+// resources on the returned channel as soon as it finishes. The channel is
+// closed once every region is done.
+func (s *Scanner) StreamRegions(ctx context.Context, regions, resourceTypes, states []string) <-chan RegionResources {
+	return s.stream(ctx, regions, func(ctx context.Context, region string) ([]Resource, error) {
+		return s.ListResourcesInRegion(ctx, region, resourceTypes, states)
+	})
+}
+
+// ScanOptions selects what Stream lists. Types and States are as for
+// ListResourcesInRegion; empty means every type, or every state.
+type ScanOptions struct {
+	Regions []string
+	Types   []string
+	States  []string
+}
+
+// Stream lists every region in opts concurrently and sends resources on
+// the first channel as each service finishes listing a region, so very
+// large accounts can be processed without holding a whole scan in memory.
+// Each service that fails sends a *ServiceError on the second channel;
+// whatever it listed before failing is still sent. Both channels are
+// closed once every region is done. Stream waits for resources to be
+// received, so callers that stop reading early must cancel ctx.
+func (s *Scanner) Stream(ctx context.Context, opts ScanOptions) (<-chan Resource, <-chan error) {
+	ctx, cancel := withTimeout(ctx, s.timeouts.Scan)
+
+	resourceCh := make(chan Resource)
+	// Buffered so a service's error never waits on the resources being read
+	errCh := make(chan error, len(opts.Regions)*(len(Listers())+len(s.listers)))
+
+	// An expired SSO session would fail every service in every region
+	if err := s.CheckSSOSession(ctx); err != nil {
+		for _, region := range opts.Regions {
+			errCh <- newServiceError("", region, err)
+		}
+		cancel()
+		close(resourceCh)
+		close(errCh)
+		return resourceCh, errCh
+	}
+
+	var wg sync.WaitGroup
+	for _, region := range opts.Regions {
+		wg.Add(1)
+		go func(r string) {
+			defer wg.Done()
+			s.listRegion(ctx, r, opts.Types, opts.States, func(service string, listed []Resource, err error) {
+				if err != nil {
+					errCh <- newServiceError(service, r, err)
+				}
+				for _, resource := range listed {
+					select {
+					case resourceCh <- resource:
+					case <-ctx.Done():
+						return
+					}
+				}
+			})
+		}(region)
+	}
+
+	go func() {
+		wg.Wait()
+		cancel()
+		close(resourceCh)
+		close(errCh)
+	}()
+	return resourceCh, errCh
+}
+
+// stream runs listRegion for every region concurrently, within the scan
+// timeout, and sends each region's result as soon as it finishes.
+func (s *Scanner) stream(ctx context.Context, regions []string, listRegion func(ctx context.Context, region string) ([]Resource, error)) <-chan RegionResources {
+	ctx, cancel := withTimeout(ctx, s.timeouts.Scan)
+
+	var wg sync.WaitGroup
+	// Buffered so regions never block on a reader that has gone away
+	regionCh := make(chan RegionResources, len(regions))
+
+	// An expired SSO session would fail every service in every region
+	if err := s.CheckSSOSession(ctx); err != nil {
+		for _, region := range regions {
+			rd := RegionResources{Region: region, Errors: ServiceErrors(newServiceError("", region, err))}
+			rd.Error = errorSummary(rd.Errors)
+			regionCh <- rd
+		}
+		cancel()
+		close(regionCh)
+		return regionCh
+	}
+
+	for _, region := range regions {
+		wg.Add(1)
+		go func(r string) {
+			defer wg.Done()
+
+			var throttles atomic.Int64
+			resources, err := listRegion(withThrottleCounter(ctx, &throttles), r)
+			rd := RegionResources{Region: r, Resources: resources, Throttles: int(throttles.Load())}
+			if err != nil {
+				// Partial results are kept
+				rd.Errors = ServiceErrors(err)
+				rd.Error = errorSummary(rd.Errors)
+			}
+			regionCh <- rd
+		}(region)
+	}
+
+	go func() {
+		wg.Wait()
+		cancel()
+		close(regionCh)
+	}()
+	return regionCh
+}
+
+// ListResourcesInRegion lists the resources in region. If resourceTypes is
+// non-empty, only the listers producing at least one of those types run.
+// states is passed to the listers that can filter by state server-side;
+// callers still filter the results for the rest. Listers run within the
+// limits of the Scanner's WorkerPool, unless the Scanner has a cache with
+// their results. Scanners sharing a cache also share identical listings
+// that are running at the same time. The error joins a *ServiceError for
+// each lister that failed; see ServiceErrors.
+func (s *Scanner) ListResourcesInRegion(ctx context.Context, region string, resourceTypes, states []string) ([]Resource, error) {
+	var resources []Resource
+	var errs []error
+	var mu sync.Mutex
+
+	s.listRegion(ctx, region, resourceTypes, states, func(service string, listed []Resource, err error) {
+		mu.Lock()
+		defer mu.Unlock()
+		// Keep whatever was listed even if the lister failed part way
+		if err != nil {
+			errs = append(errs, newServiceError(service, region, err))
+		}
+		resources = append(resources, listed...)
+	})
+
+	return resources, errors.Join(errs...)
+}
+
+// listRegion runs the listers for region, as described for
+// ListResourcesInRegion, and calls listed with each one's result as soon
+// as it finishes. listed is called concurrently.
+func (s *Scanner) listRegion(ctx context.Context, region string, resourceTypes, states []string, listed func(service string, resources []Resource, err error)) {
+	ctx, cancel := withTimeout(ctx, s.timeouts.Region)
+	defer cancel()
+
+	var wg sync.WaitGroup
+
+	wanted := make(map[string]bool, len(resourceTypes))
+	for _, resourceType := range resourceTypes {
+		wanted[resourceType] = true
+	}
+	wants := func(produced ...string) bool {
+		if len(wanted) == 0 {
+			return true
+		}
+		for _, resourceType := range produced {
+			if wanted[resourceType] {
+				return true
+			}
+		}
+		return false
+	}
+
+	// Every lister shares the region's copy of the loaded config, and
+	// the clients built from it
+	regionCfg := s.RegionConfig(region)
+	ctx = withClients(ctx, s.clients)
+	if s.maxResults > 0 {
+		ctx = withMaxResults(ctx, s.maxResults)
+	}
+
+	account := s.cacheAccount(ctx)
+
+	for _, lister := range append(s.provider.Listers(), s.listers...) {
+		if !runsIn(lister, region) || !wants(lister.Types()...) {
+			continue
+		}
+		wg.Add(1)
+		go func(lister ServiceLister) {
+			defer wg.Done()
+			resources, err := s.runLister(ctx, account, region, lister.Name(), states, func(ctx context.Context) ([]Resource, error) {
+				listed, err := lister.List(ctx, regionCfg, states)
+				s.label(ctx, listed, region)
+				return listed, err
+			})
+			listed(lister.Name(), resources, s.credentialsError(err))
+		}(lister)
+	}
+
+	wg.Wait()
+}
+
+// poolAccount returns the account the Scanner's listers take their share
+// of its WorkerPool under: its AWS account, or its provider for other
+// providers and when the account can't be told.
+func (s *Scanner) poolAccount(ctx context.Context) string {
+	if account := s.Account(ctx).ID; account != "" {
+		return account
+	}
+	return s.provider.Name()
+}
+
+// runLister runs list for service in region within the limits of the
+// Scanner's WorkerPool for its account. With a cache and the account it
+// scopes, fresh cached results are returned instead, and a listing of the
+// same service already running is joined rather than repeated.
+func (s *Scanner) runLister(ctx context.Context, account, region, service string, states []string, list func(ctx context.Context) ([]Resource, error)) ([]Resource, error) {
+	key := cacheKey{account: account, region: region, service: service}
+	if account != "" && !refreshing(ctx) {
+		if cached, ok := s.cache.get(key); ok {
+			return cached, nil
+		}
+	}
+
+	run := func(ctx context.Context) ([]Resource, error) {
+		var listed []Resource
+		var err error
+		if poolErr := s.pool.run(ctx, s.poolAccount(ctx), region, service, func(ctx context.Context) {
+			listed, err = list(ctx)
+		}); poolErr != nil {
+			return listed, poolErr
+		}
+		if account != "" && err == nil && len(states) == 0 {
+			s.cache.put(key, listed, cacheUntil(ctx))
+		}
+		return listed, err
+	}
+
+	if account == "" {
+		return run(ctx)
+	}
+	return s.cache.share(ctx, key, states, s.timeouts.Region, run)
+}
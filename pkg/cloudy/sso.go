@@ -0,0 +1,105 @@
+package cloudy
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/credentials/ssocreds"
+	"github.com/aws/smithy-go"
+)
+
+// ErrSSOSessionExpired is wrapped by the error of a scan whose credentials
+// come from an IAM Identity Center (SSO) session that has expired, or
+// been signed out of, and can't be refreshed. Signing in again with
+// aws sso login lets the next scan through; the Scanner needn't be
+// recreated.
+var ErrSSOSessionExpired = errors.New("the AWS SSO session has expired or is invalid")
+
+// ssoSessionError replaces the SDK's error for an expired SSO session,
+// several wrapped calls deep, with one saying how to sign in again.
+type ssoSessionError struct {
+	profile string
+	err     error
+}
+
+func (e ssoSessionError) Error() string {
+	if e.profile == "" {
+		return ErrSSOSessionExpired.Error() + "; sign in again with aws sso login"
+	}
+	return ErrSSOSessionExpired.Error() + "; sign in again with aws sso login --profile " + e.profile
+}
+
+func (e ssoSessionError) Is(target error) bool { return target == ErrSSOSessionExpired }
+func (e ssoSessionError) Unwrap() error        { return e.err }
+
+// ssoErrorCodes are the errors the SSO and SSO OIDC services return for a
+// session that is over: its access token expired or was revoked, or its
+// refresh token can't be used any more.
+var ssoErrorCodes = map[string]bool{
+	"UnauthorizedException":       true,
+	"InvalidGrantException":       true,
+	"ExpiredTokenException":       true,
+	"InvalidClientException":      true,
+	"UnauthorizedClientException": true,
+}
+
+// isSSOSessionError reports whether err is the SDK failing to get
+// credentials from an SSO session that is over, rather than a call
+// failing.
+func isSSOSessionError(err error) bool {
+	if err == nil {
+		return false
+	}
+	for unwrapped := err; unwrapped != nil; unwrapped = errors.Unwrap(unwrapped) {
+		switch e := unwrapped.(type) {
+		case *ssocreds.InvalidTokenError:
+			return true
+		case *smithy.OperationError:
+			var apiErr smithy.APIError
+			if (e.ServiceID == "SSO" || e.ServiceID == "SSO OIDC") && errors.As(e, &apiErr) && ssoErrorCodes[apiErr.ErrorCode()] {
+				return true
+			}
+		}
+	}
+	// Without a cached token to refresh, the token provider's error is
+	// only a message
+	return strings.Contains(err.Error(), "cached SSO token")
+}
+
+// ssoProfile returns the shared config profile the Scanner's config was
+// loaded from, if it signs in with IAM Identity Center.
+func (s *Scanner) ssoProfile() (profile string, ok bool) {
+	for _, source := range s.cfg.ConfigSources {
+		if shared, isShared := source.(config.SharedConfig); isShared {
+			return shared.Profile, shared.SSOSession != nil || shared.SSOStartURL != ""
+		}
+	}
+	return "", false
+}
+
+// credentialsError returns err, or the error explaining it if it is an
+// expired SSO session's.
+func (s *Scanner) credentialsError(err error) error {
+	if err == nil || errors.Is(err, ErrSSOSessionExpired) || !isSSOSessionError(err) {
+		return err
+	}
+	profile, _ := s.ssoProfile()
+	return ssoSessionError{profile: profile, err: err}
+}
+
+// CheckSSOSession returns an error wrapping ErrSSOSessionExpired if the
+// Scanner signs in with IAM Identity Center and the session has expired
+// and can't be refreshed, and nil otherwise. Sessions configured with an
+// sso_session section are refreshed as they expire, until the session
+// itself ends. Scans check this first, rather than fail in every service.
+func (s *Scanner) CheckSSOSession(ctx context.Context) error {
+	if _, ok := s.ssoProfile(); !ok || s.cfg.Credentials == nil {
+		return nil
+	}
+	if _, err := s.cfg.Credentials.Retrieve(ctx); isSSOSessionError(err) {
+		return s.credentialsError(err)
+	}
+	return nil
+}
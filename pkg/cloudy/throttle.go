@@ -0,0 +1,76 @@
+package cloudy
+
+import (
+	"context"
+	"sync/atomic"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/aws/retry"
+	"github.com/aws/smithy-go/middleware"
+	"golang.org/x/time/rate"
+)
+
+// retryMaxAttempts is how many times a throttled call is tried before its
+// lister fails. It is higher than the SDK's default of 3 because adaptive
+// retry slows down rather than hammering the throttled API.
+const retryMaxAttempts = 10
+
+// newAdaptiveRetryer is the retryer of configs that don't set one. It
+// backs off exponentially with jitter on retryable errors and, after a
+// throttle, rate-limits the client's later calls until AWS accepts them
+// again.
+func newAdaptiveRetryer() aws.Retryer {
+	return retry.NewAdaptiveMode(func(o *retry.AdaptiveModeOptions) {
+		o.StandardOptions = append(o.StandardOptions, func(o *retry.StandardOptions) {
+			o.MaxAttempts = retryMaxAttempts
+		})
+	})
+}
+
+var isThrottle = retry.IsErrorThrottles(retry.DefaultThrottles)
+
+type throttlesKey struct{}
+
+// withThrottleCounter returns a context whose AWS calls add each throttled
+// attempt to n.
+func withThrottleCounter(ctx context.Context, n *atomic.Int64) context.Context {
+	return context.WithValue(ctx, throttlesKey{}, n)
+}
+
+// addThrottleCounting counts every throttled attempt of a call, retries
+// included, against the context's counter. It sits just inside the retry
+// middleware so it sees each attempt's error.
+func addThrottleCounting(stack *middleware.Stack) error {
+	return stack.Finalize.Insert(middleware.FinalizeMiddlewareFunc("CloudyThrottleCount",
+		func(ctx context.Context, in middleware.FinalizeInput, next middleware.FinalizeHandler) (middleware.FinalizeOutput, middleware.Metadata, error) {
+			out, metadata, err := next.HandleFinalize(ctx, in)
+			if err != nil && isThrottle.IsErrorThrottle(err) == aws.TrueTernary {
+				if n, ok := ctx.Value(throttlesKey{}).(*atomic.Int64); ok {
+					n.Add(1)
+				}
+			}
+			return out, metadata, err
+		}), "Retry", middleware.After)
+}
+
+type rateLimiterKey struct{}
+
+// withRateLimiter returns a context whose AWS calls wait for limiter.
+func withRateLimiter(ctx context.Context, limiter *rate.Limiter) context.Context {
+	return context.WithValue(ctx, rateLimiterKey{}, limiter)
+}
+
+// addRateLimit holds every attempt of a call, retries included, until the
+// context's rate limiter allows it. Like addThrottleCounting it sits just
+// inside the retry middleware.
+func addRateLimit(stack *middleware.Stack) error {
+	return stack.Finalize.Insert(middleware.FinalizeMiddlewareFunc("CloudyRateLimit",
+		func(ctx context.Context, in middleware.FinalizeInput, next middleware.FinalizeHandler) (middleware.FinalizeOutput, middleware.Metadata, error) {
+			if limiter, ok := ctx.Value(rateLimiterKey{}).(*rate.Limiter); ok {
+				if err := limiter.Wait(ctx); err != nil {
+					return middleware.FinalizeOutput{}, middleware.Metadata{}, err
+				}
+			}
+			return next.HandleFinalize(ctx, in)
+		}), "Retry", middleware.After)
+}
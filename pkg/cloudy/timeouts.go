@@ -0,0 +1,30 @@
+package cloudy
+
+import (
+	"context"
+	"time"
+)
+
+// Timeouts bound how long a scan waits on AWS, so one hung API call can't
+// stall it indefinitely. A zero timeout is unbounded.
+type Timeouts struct {
+	// Call bounds each AWS API call; retries get a fresh timeout.
+	Call time.Duration
+	// Region bounds listing one region, including time spent waiting for
+	// the WorkerPool.
+	Region time.Duration
+	// Scan bounds a whole scan.
+	Scan time.Duration
+}
+
+// DefaultTimeouts are used by Scanners not given others.
+var DefaultTimeouts = Timeouts{Call: 30 * time.Second, Region: 3 * time.Minute, Scan: 10 * time.Minute}
+
+// withTimeout is context.WithTimeout, except that a zero timeout adds no
+// deadline.
+func withTimeout(ctx context.Context, timeout time.Duration) (context.Context, context.CancelFunc) {
+	if timeout <= 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, timeout)
+}
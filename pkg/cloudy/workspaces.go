@@ -0,0 +1,144 @@
+package cloudy
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/workspaces"
+	"github.com/aws/aws-sdk-go-v2/service/workspaces/types"
+)
+
+func init() {
+	Register(lister{
+		name:  "WorkSpaces",
+		types: []string{"WorkSpace"},
+		iamActions: []string{
+			"workspaces:DescribeWorkspaces",
+			"workspaces:DescribeWorkspaceBundles",
+			"workspaces:DescribeTags",
+		},
+		list: withoutStates(listWorkSpaces),
+	})
+}
+
+// workspaceBundleBatch is the most bundle IDs DescribeWorkspaceBundles
+// accepts at once.
+const workspaceBundleBatch = 25
+
+func listWorkSpaces(ctx context.Context, cfg aws.Config) ([]Resource, error) {
+	client := Client(ctx, cfg, workspaces.NewFromConfig)
+
+	var desktops []types.Workspace
+	paginator := workspaces.NewDescribeWorkspacesPaginator(client, &workspaces.DescribeWorkspacesInput{})
+	for paginator.HasMorePages() {
+		if err := checkMaxResults(ctx, len(desktops)); err != nil {
+			return nil, err
+		}
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return nil, err
+		}
+		desktops = append(desktops, page.Workspaces...)
+	}
+
+	if len(desktops) == 0 {
+		return []Resource{}, nil
+	}
+
+	bundles, bundlesErr := describeWorkspaceBundles(ctx, client, desktops)
+
+	resources := make([]Resource, len(desktops))
+	for i, desktop := range desktops {
+		bundleID := aws_string_value(desktop.BundleId)
+		attributes := map[string]string{
+			"user_name":     aws_string_value(desktop.UserName),
+			"directory_id":  aws_string_value(desktop.DirectoryId),
+			"computer_name": aws_string_value(desktop.ComputerName),
+			"ip_address":    aws_string_value(desktop.IpAddress),
+			"bundle_id":     bundleID,
+		}
+		if properties := desktop.WorkspaceProperties; properties != nil {
+			attributes["running_mode"] = string(properties.RunningMode)
+			attributes["compute_type"] = string(properties.ComputeTypeName)
+			attributes["operating_system"] = string(properties.OperatingSystemName)
+			attributes["root_volume_gib"] = fmt.Sprintf("%d", aws_int32_value(properties.RootVolumeSizeGib))
+			attributes["user_volume_gib"] = fmt.Sprintf("%d", aws_int32_value(properties.UserVolumeSizeGib))
+			if properties.RunningMode == types.RunningModeAutoStop {
+				attributes["auto_stop_timeout_minutes"] = fmt.Sprintf("%d", aws_int32_value(properties.RunningModeAutoStopTimeoutInMinutes))
+			}
+		}
+		if bundlesErr != nil {
+			attributes["bundle_error"] = bundlesErr.Error()
+		} else if bundle, ok := bundles[bundleID]; ok {
+			attributes["bundle_name"] = aws_string_value(bundle.Name)
+			attributes["bundle_owner"] = aws_string_value(bundle.Owner)
+		}
+		if code := aws_string_value(desktop.ErrorCode); code != "" {
+			attributes["error_code"] = code
+		}
+
+		name := aws_string_value(desktop.WorkspaceName)
+		if name == "" {
+			name = aws_string_value(desktop.UserName)
+		}
+
+		resources[i] = Resource{
+			ID:         aws_string_value(desktop.WorkspaceId),
+			Name:       name,
+			Type:       "WorkSpace",
+			State:      string(desktop.State),
+			Region:     cfg.Region,
+			Attributes: attributes,
+		}
+	}
+
+	// Tags need a DescribeTags per WorkSpace. A WorkSpace whose tags can't
+	// be read is still listed, with the failure recorded on it.
+	forEachBounded(len(desktops), describeConcurrency, func(i int) {
+		result, err := client.DescribeTags(ctx, &workspaces.DescribeTagsInput{ResourceId: desktops[i].WorkspaceId})
+		if err != nil {
+			resources[i].Attributes["tags_error"] = err.Error()
+			return
+		}
+		tags := make(map[string]string)
+		for _, tag := range result.TagList {
+			if tag.Key != nil && tag.Value != nil {
+				tags[*tag.Key] = *tag.Value
+			}
+		}
+		resources[i].Tags = tags
+	})
+
+	return resources, nil
+}
+
+// describeWorkspaceBundles returns the bundles desktops were launched
+// from, by bundle ID.
+func describeWorkspaceBundles(ctx context.Context, client *workspaces.Client, desktops []types.Workspace) (map[string]types.WorkspaceBundle, error) {
+	seen := make(map[string]bool)
+	var ids []string
+	for _, desktop := range desktops {
+		if id := aws_string_value(desktop.BundleId); id != "" && !seen[id] {
+			seen[id] = true
+			ids = append(ids, id)
+		}
+	}
+
+	bundles := make(map[string]types.WorkspaceBundle)
+	for start := 0; start < len(ids); start += workspaceBundleBatch {
+		paginator := workspaces.NewDescribeWorkspaceBundlesPaginator(client, &workspaces.DescribeWorkspaceBundlesInput{
+			BundleIds: ids[start:min(start+workspaceBundleBatch, len(ids))],
+		})
+		for paginator.HasMorePages() {
+			page, err := paginator.NextPage(ctx)
+			if err != nil {
+				return nil, err
+			}
+			for _, bundle := range page.Bundles {
+				bundles[aws_string_value(bundle.BundleId)] = bundle
+			}
+		}
+	}
+	return bundles, nil
+}